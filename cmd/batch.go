@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dhabedank/prd-parser/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var batchOutDir string // Directory for the per-PRD outputs of batch
+
+// batchSingleFlags are parse flags for a single PRD: they name one input or
+// output file, need someone at the terminal for each PRD, or print a preview
+// instead of writing the output batch collects.
+var batchSingleFlags = []string{
+	"output-path", "from-json", "tasks-from-json", "retry-failed", "save-json", "doc-output", "interactive", "scan", "context-only",
+}
+
+// batchOutputExt is the file extension batch writes for each file output adapter.
+var batchOutputExt = map[string]string{
+	"json":         ".json",
+	"traceability": ".md",
+	"markdown":     ".md",
+	"csv":          ".csv",
+}
+
+// BatchCmd represents the batch command
+var BatchCmd = &cobra.Command{
+	Use:   "batch <prd-dir>",
+	Short: "Parse every PRD in a directory",
+	Long: `Parse every *.md file in a directory, writing one output per PRD to
+--output-dir (auth.md becomes auth.json). Each PRD goes through the same path
+as parse, with the same flags. A PRD that fails doesn't stop the batch; the
+run ends with a summary of which PRDs succeeded and which failed, and exits
+with the first failure's exit code.
+
+Example:
+  prd-parser batch ./prds/ --output json --output-dir ./out/`,
+	Args: usageArgs(cobra.ExactArgs(1)),
+	RunE: runBatch,
+}
+
+func init() {
+	// The parse flags are added to BatchCmd in parse.go, after they're defined
+	BatchCmd.Flags().StringVar(&batchOutDir, "output-dir", "", "Directory to write each PRD's output to (required)")
+}
+
+// batchResult is the outcome of parsing one PRD in a batch.
+type batchResult struct {
+	PRD    string
+	Output string
+	Err    error
+}
+
+func runBatch(cmd *cobra.Command, args []string) error {
+	if err := validateWorkDir(); err != nil {
+		return usageErrorf("%w", err)
+	}
+	if err := loadConfig(cmd); err != nil {
+		return usageErrorf("failed to load config: %w", err)
+	}
+	ext, ok := batchOutputExt[outputAdapter]
+	if !ok {
+		return usageErrorf("batch writes one file per PRD; use --output json, traceability, markdown, or csv, not %s", outputAdapter)
+	}
+	if batchOutDir == "" {
+		return usageErrorf("batch requires --output-dir")
+	}
+	for _, name := range batchSingleFlags {
+		if cmd.Flags().Changed(name) {
+			return usageErrorf("--%s applies to a single PRD; run parse for it instead of batch", name)
+		}
+	}
+
+	prds, err := filepath.Glob(filepath.Join(inWorkDir(args[0]), "*.md"))
+	if err != nil {
+		return usageErrorf("failed to list PRDs: %w", err)
+	}
+	if len(prds) == 0 {
+		return usageErrorf("no *.md files in %s", args[0])
+	}
+	if err := os.MkdirAll(inWorkDir(batchOutDir), 0755); err != nil {
+		return outputErrorf("failed to create output directory: %w", err)
+	}
+
+	results := make([]batchResult, 0, len(prds))
+	for i, prd := range prds {
+		name := filepath.Base(prd)
+		fmt.Printf("\n[%d/%d] %s\n", i+1, len(prds), name)
+
+		// runParse resolves both paths against --dir itself
+		outputPath = filepath.Join(batchOutDir, strings.TrimSuffix(name, ".md")+ext)
+		err := runParse(cmd, []string{filepath.Join(args[0], name)})
+		results = append(results, batchResult{PRD: name, Output: outputPath, Err: err})
+	}
+
+	return printBatchSummary(os.Stdout, results)
+}
+
+// printBatchSummary lists each PRD's output or error, and returns an error
+// with the first failure's exit code if any PRD failed.
+func printBatchSummary(w io.Writer, results []batchResult) error {
+	var firstErr error
+	failed := 0
+	fmt.Fprintf(w, "\nBatch summary:\n")
+	for _, r := range results {
+		if r.Err != nil {
+			if firstErr == nil {
+				firstErr = r.Err
+			}
+			failed++
+			fmt.Fprintf(w, "  %s %s: %v\n", tui.Sym.Warn, r.PRD, r.Err)
+			continue
+		}
+		fmt.Fprintf(w, "  %s %s -> %s\n", tui.Sym.Check, r.PRD, r.Output)
+	}
+	fmt.Fprintf(w, "%d of %d PRDs parsed, %d failed\n", len(results)-failed, len(results), failed)
+
+	if failed > 0 {
+		return withExitCode(ExitCode(firstErr), fmt.Errorf("%d of %d PRDs failed", failed, len(results)))
+	}
+	return nil
+}
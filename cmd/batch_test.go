@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// stubGenerator generates a one-epic plan named after the PRD's first line,
+// and fails for PRDs containing "unparseable".
+type stubGenerator struct{}
+
+func (stubGenerator) GenerateEpics(ctx context.Context, prd string, config core.ParseConfig) (*core.EpicsResponse, error) {
+	if strings.Contains(prd, "unparseable") {
+		return nil, errors.New("no valid JSON in Stage 1 response")
+	}
+	name := strings.TrimPrefix(strings.SplitN(prd, "\n", 2)[0], "# ")
+	return &core.EpicsResponse{
+		Project: core.ProjectContext{ProductName: name},
+		Epics:   []core.EpicSummary{{TempID: "1", Title: name + " setup", AcceptanceCriteria: []string{"Runs locally"}}},
+	}, nil
+}
+
+func (stubGenerator) GenerateTasks(ctx context.Context, epic core.Epic, project core.ProjectContext, config core.ParseConfig, prd string) ([]core.Task, error) {
+	return []core.Task{{TempID: epic.TempID + ".1", Title: "Scaffold"}}, nil
+}
+
+func (stubGenerator) GenerateSubtasks(ctx context.Context, task core.Task, epicCtx string, project core.ProjectContext, config core.ParseConfig, prd string) ([]core.Subtask, error) {
+	return []core.Subtask{{TempID: task.TempID + ".1", Title: "Init repo"}}, nil
+}
+
+func TestBatchParsesEveryPRD(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir) // no ~/.prd-parser.yaml
+	prds := filepath.Join(dir, "prds")
+	if err := os.Mkdir(prds, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"billing.md":  "# Billing\n\nInvoices for every customer.\n",
+		"search.md":   "# Search\n\nFind products by name.\n",
+		"notes.txt":   "# Not a PRD\n",
+		"unparsed.md": "# Broken\n\nunparseable\n",
+	} {
+		if err := os.WriteFile(filepath.Join(prds, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldGen, oldMulti, oldReview, oldProgress := newStageGenerator, multiStage, noReview, noProgress
+	oldOut, oldPath, oldDir := outputAdapter, outputPath, batchOutDir
+	t.Cleanup(func() {
+		newStageGenerator, multiStage, noReview, noProgress = oldGen, oldMulti, oldReview, oldProgress
+		outputAdapter, outputPath, batchOutDir = oldOut, oldPath, oldDir
+	})
+	newStageGenerator = func(string) (core.Generator, error) { return stubGenerator{}, nil }
+	multiStage, noReview, noProgress = true, true, true
+	outputAdapter, batchOutDir = "json", filepath.Join(dir, "out")
+
+	out, err := captureStdout(t, func() error { return runBatch(BatchCmd, []string{prds}) })
+	if ExitCode(err) != ExitGeneration || !strings.Contains(err.Error(), "1 of 3 PRDs failed") {
+		t.Errorf("runBatch() error = %v (exit %d), want the failed PRD reported with exit %d", err, ExitCode(err), ExitGeneration)
+	}
+
+	for name, product := range map[string]string{"billing.json": "Billing", "search.json": "Search"} {
+		data, err := os.ReadFile(filepath.Join(dir, "out", name))
+		if err != nil {
+			t.Errorf("batch should write %s: %v", name, err)
+			continue
+		}
+		var plan core.ParseResponse
+		if err := json.Unmarshal(data, &plan); err != nil || plan.Project.ProductName != product {
+			t.Errorf("%s: product %q (err %v), want %q", name, plan.Project.ProductName, err, product)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "unparsed.json")); err == nil {
+		t.Error("a PRD that failed to parse shouldn't get an output")
+	}
+	for _, want := range []string{"2 of 3 PRDs parsed, 1 failed", "unparsed.md: multi-stage parsing failed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestBatchRejectsPreviewFlags(t *testing.T) {
+	oldOut, oldDir := outputAdapter, batchOutDir
+	t.Cleanup(func() { outputAdapter, batchOutDir = oldOut, oldDir })
+	t.Setenv("HOME", t.TempDir()) // no ~/.prd-parser.yaml
+	outputAdapter, batchOutDir = "json", t.TempDir()
+
+	for _, name := range []string{"scan", "context-only"} {
+		flag := BatchCmd.Flags().Lookup(name)
+		if err := flag.Value.Set("true"); err != nil {
+			t.Fatal(err)
+		}
+		flag.Changed = true
+		err := runBatch(BatchCmd, []string{t.TempDir()})
+		flag.Changed = false
+		_ = flag.Value.Set("false")
+		if ExitCode(err) != ExitUsage || !strings.Contains(err.Error(), "--"+name+" applies to a single PRD") {
+			t.Errorf("--%s: error = %v (exit %d), want a usage error", name, err, ExitCode(err))
+		}
+	}
+}
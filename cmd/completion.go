@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dhabedank/prd-parser/internal/llm"
+	"github.com/dhabedank/prd-parser/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// outputAdapterHelp describes each of output.AdapterNames when completing --output.
+var outputAdapterHelp = map[string]string{
+	"beads":        "Create issues in beads with the bd CLI",
+	"json":         "Write the plan as JSON",
+	"traceability": "Write a requirements-traceability matrix",
+	"markdown":     "Write the plan as a Markdown outline",
+	"csv":          "Write one spreadsheet row per item",
+	"github":       "Create GitHub issues, optionally on a Projects board",
+}
+
+// llmProviderHelp describes each of llm.ProviderNames when completing --llm.
+var llmProviderHelp = map[string]string{
+	"auto":          "Claude CLI, then Codex CLI, then the Anthropic API",
+	"claude-cli":    "Claude Code CLI",
+	"codex-cli":     "OpenAI Codex CLI",
+	"anthropic-api": "Anthropic API (ANTHROPIC_API_KEY)",
+}
+
+// describedCompletions returns names as completions with their descriptions
+// from help.
+func describedCompletions(names []string, help map[string]string) []string {
+	completions := make([]string, len(names))
+	for i, name := range names {
+		completions[i] = name + "\t" + help[name]
+	}
+	return completions
+}
+
+// claudeAliases are the model aliases the Claude CLI resolves to its latest
+// model of each family.
+var claudeAliases = []string{llm.FamilyOpus, llm.FamilySonnet, llm.FamilyHaiku}
+
+// CompletionCmd represents the completion command
+var CompletionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate a shell completion script",
+	Long: `Generate a completion script for your shell. Besides commands and flags,
+it completes --model with the available model IDs, --output with the output
+adapters, and --llm with the LLM providers.
+
+  bash:       source <(prd-parser completion bash)
+  zsh:        prd-parser completion zsh > "${fpath[1]}/_prd-parser"
+  fish:       prd-parser completion fish > ~/.config/fish/completions/prd-parser.fish
+  powershell: prd-parser completion powershell | Out-String | Invoke-Expression`,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  usageArgs(cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs)),
+	DisableFlagsInUseLine: true,
+	RunE:                  runCompletion,
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	root := cmd.Root()
+	switch args[0] {
+	case "bash":
+		return root.GenBashCompletionV2(os.Stdout, true)
+	case "zsh":
+		return root.GenZshCompletion(os.Stdout)
+	case "fish":
+		return root.GenFishCompletion(os.Stdout, true)
+	default:
+		return root.GenPowerShellCompletionWithDesc(os.Stdout)
+	}
+}
+
+// IsCompletion reports whether the command line (without the program name)
+// is a completion run: the completion command, or the hidden request the
+// shell makes on each TAB. The shell reads their stdout, so notices such as
+// the first-run welcome and update check must not print.
+func IsCompletion(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	return false
+}
+
+// completeModels suggests the available model IDs, and the Claude CLI aliases
+// when Claude models are available.
+func completeModels(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var models []string
+	claude := false
+	for _, m := range llm.AllModels() {
+		models = append(models, fmt.Sprintf("%s\t%s", m.ID, m.Name))
+		claude = claude || m.Provider == "anthropic"
+	}
+	if claude {
+		for _, alias := range claudeAliases {
+			models = append(models, fmt.Sprintf("%s\tClaude CLI alias for the latest %s model", alias, alias))
+		}
+	}
+	return models, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeOutputAdapters suggests the --output adapter names.
+var completeOutputAdapters = cobra.FixedCompletions(describedCompletions(output.AdapterNames, outputAdapterHelp), cobra.ShellCompDirectiveNoFileComp)
+
+// completeLLMProviders suggests the --llm provider names.
+var completeLLMProviders = cobra.FixedCompletions(describedCompletions(llm.ProviderNames, llmProviderHelp), cobra.ShellCompDirectiveNoFileComp)
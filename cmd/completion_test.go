@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dhabedank/prd-parser/internal/llm"
+	"github.com/dhabedank/prd-parser/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// completedNames returns the names c completes for flag, failing t if any
+// completion lacks a description.
+func completedNames(t *testing.T, c *cobra.Command, flag string) []string {
+	t.Helper()
+	complete, ok := c.GetFlagCompletionFunc(flag)
+	if !ok {
+		t.Fatalf("%s --%s has no completion function", c.Name(), flag)
+	}
+	completions, directive := complete(c, nil, "")
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("%s --%s directive = %v, want no file completion", c.Name(), flag, directive)
+	}
+
+	var names []string
+	for _, completion := range completions {
+		name, description, _ := strings.Cut(completion, "\t")
+		if description == "" {
+			t.Errorf("%s --%s completion %q has no description", c.Name(), flag, name)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestOutputCompletionListsAdapters(t *testing.T) {
+	for _, c := range []*cobra.Command{ParseCmd, BatchCmd} {
+		if names := completedNames(t, c, "output"); !reflect.DeepEqual(names, output.AdapterNames) {
+			t.Errorf("%s --output completions = %v, want %v", c.Name(), names, output.AdapterNames)
+		}
+	}
+}
+
+func TestLLMCompletionListsProviders(t *testing.T) {
+	if names := completedNames(t, ParseCmd, "llm"); !reflect.DeepEqual(names, llm.ProviderNames) {
+		t.Errorf("--llm completions = %v, want %v", names, llm.ProviderNames)
+	}
+}
+
+func TestListedAdaptersAreAccepted(t *testing.T) {
+	t.Setenv("PATH", t.TempDir()) // no bd, claude, or codex
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	oldOut, oldProvider := outputAdapter, llmProvider
+	t.Cleanup(func() { outputAdapter, llmProvider = oldOut, oldProvider })
+
+	// Adapters may be unavailable here, but none is unknown
+	for _, name := range output.AdapterNames {
+		outputAdapter = name
+		if _, _, err := createOutputAdapter(nil, nil); err != nil && strings.Contains(err.Error(), "unknown output adapter") {
+			t.Errorf("createOutputAdapter() rejects listed adapter %s: %v", name, err)
+		}
+	}
+	for _, name := range llm.ProviderNames {
+		llmProvider = name
+		if _, err := createLLMAdapter(); err != nil && strings.Contains(err.Error(), "unknown LLM provider") {
+			t.Errorf("createLLMAdapter() rejects listed provider %s: %v", name, err)
+		}
+	}
+
+	outputAdapter = "jira"
+	if _, _, err := createOutputAdapter(nil, nil); err == nil || !strings.Contains(err.Error(), "use beads, json") {
+		t.Errorf("createOutputAdapter(jira) error = %v, want the adapter list", err)
+	}
+}
+
+func TestIsCompletion(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"completion", "zsh"}, true},
+		{[]string{cobra.ShellCompRequestCmd, "parse", "--output", ""}, true},
+		{[]string{"parse", "prd.md"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsCompletion(tt.args); got != tt.want {
+			t.Errorf("IsCompletion(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Process exit codes, so automation can tell failure classes apart.
+const (
+	ExitOK         = 0
+	ExitError      = 1 // Unclassified failure
+	ExitUsage      = 2 // Bad flags, arguments, config, or input files
+	ExitGeneration = 3 // LLM/generation failure
+	ExitOutput     = 4 // Output/creation failure
+	ExitValidation = 5 // Validation found gaps under --strict
+)
+
+// exitError carries the exit code an error should map to.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode tags err with an exit code. Returns nil for a nil err.
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return withExitCode(ExitUsage, fmt.Errorf(format, args...))
+}
+
+func generationErrorf(format string, args ...interface{}) error {
+	return withExitCode(ExitGeneration, fmt.Errorf(format, args...))
+}
+
+func outputErrorf(format string, args ...interface{}) error {
+	return withExitCode(ExitOutput, fmt.Errorf(format, args...))
+}
+
+func validationErrorf(format string, args ...interface{}) error {
+	return withExitCode(ExitValidation, fmt.Errorf(format, args...))
+}
+
+// UsageError marks err as a usage error (e.g., from cobra flag parsing).
+func UsageError(err error) error {
+	return withExitCode(ExitUsage, err)
+}
+
+// ExitCode returns the process exit code for an error returned by a command.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return ExitError
+}
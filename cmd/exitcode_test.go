@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"unclassified", errors.New("boom"), ExitError},
+		{"usage", usageErrorf("PRD file not found: %s", "prd.md"), ExitUsage},
+		{"generation", generationErrorf("multi-stage parsing failed: %w", errors.New("timeout")), ExitGeneration},
+		{"output", outputErrorf("creating items failed: %w", errors.New("bd missing")), ExitOutput},
+		{"validation", validationErrorf("validation found %d gaps (--strict)", 2), ExitValidation},
+		{"wrapped", fmt.Errorf("outer: %w", generationErrorf("inner")), ExitGeneration},
+		{"cobra args", usageArgs(cobra.ExactArgs(1))(&cobra.Command{}, nil), ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUsageArgsPassesValidArgs(t *testing.T) {
+	if err := usageArgs(cobra.ExactArgs(1))(&cobra.Command{}, []string{"prd.md"}); err != nil {
+		t.Errorf("usageArgs() error = %v, want nil", err)
+	}
+}
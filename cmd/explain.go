@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+	"github.com/spf13/cobra"
+)
+
+// ExplainCmd represents the explain command
+var ExplainCmd = &cobra.Command{
+	Use:   "explain <plan.json> <temp-id>",
+	Short: "Explain why an item in a plan exists",
+	Long: `Print the rationale for one epic, task, or subtask in a saved plan: its
+place in the hierarchy, context, the project goals it serves, what it depends
+on and what depends on it, and its testing requirements.
+
+Nothing is sent to an LLM; the explanation is read from the plan.
+
+Example:
+  prd-parser explain plan.json 2.3`,
+	Args: usageArgs(cobra.ExactArgs(2)),
+	RunE: runExplain,
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	if err := validateWorkDir(); err != nil {
+		return usageErrorf("%w", err)
+	}
+	data, err := os.ReadFile(inWorkDir(args[0]))
+	if err != nil {
+		return usageErrorf("failed to read plan: %w", err)
+	}
+	var plan core.ParseResponse
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return usageErrorf("failed to parse plan: %w", err)
+	}
+
+	explanation, err := core.ExplainItem(&plan, args[1])
+	if err != nil {
+		return usageErrorf("%w", err)
+	}
+	printExplanation(os.Stdout, explanation)
+	return nil
+}
+
+// printExplanation writes e as readable text.
+func printExplanation(w io.Writer, e *core.Explanation) {
+	fmt.Fprintf(w, "%s %s: %s\n", strings.ToUpper(e.Level[:1])+e.Level[1:], e.TempID, e.Title)
+	for _, parent := range e.Parents {
+		fmt.Fprintf(w, "  in %s %s: %s\n", parent.Level, parent.TempID(), parent.Title())
+	}
+	if e.Description != "" {
+		fmt.Fprintf(w, "\n%s\n", e.Description)
+	}
+
+	if e.Context != "" {
+		fmt.Fprintf(w, "\nContext:\n%s\n", indent(e.Context, "  "))
+	}
+	printList(w, "Project goals", e.Goals)
+
+	var source []string
+	if e.RequirementID != "" {
+		source = append(source, "Requirement: "+e.RequirementID)
+	}
+	if e.SourceHint != "" {
+		source = append(source, "Section: "+e.SourceHint)
+	}
+	printList(w, "From the PRD", source)
+
+	printList(w, "Depends on", itemLines(e.DependsOn))
+	printList(w, "Needed by", itemLines(e.Dependents))
+	printList(w, "Testing", testingLines(e.Testing))
+	if len(e.Labels) > 0 {
+		fmt.Fprintf(w, "\nLabels: %s\n", strings.Join(e.Labels, ", "))
+	}
+}
+
+// itemLines formats items as "<temp_id> <title> (<level>)".
+func itemLines(items []core.ItemRef) []string {
+	lines := make([]string, len(items))
+	for i, item := range items {
+		lines[i] = fmt.Sprintf("%s %s (%s)", item.TempID(), item.Title(), item.Level)
+	}
+	return lines
+}
+
+// testingLines lists the non-empty testing requirements.
+func testingLines(t core.TestingRequirements) []string {
+	var lines []string
+	for _, req := range []struct {
+		label string
+		value *core.FlexibleString
+	}{
+		{"Unit", t.UnitTests},
+		{"Integration", t.IntegrationTests},
+		{"Type", t.TypeTests},
+		{"E2E", t.E2ETests},
+	} {
+		if req.value != nil && *req.value != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", req.label, *req.value))
+		}
+	}
+	return lines
+}
+
+// indent prefixes every line of s with prefix.
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+	"github.com/dhabedank/prd-parser/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// LintCmd represents the lint command
+var LintCmd = &cobra.Command{
+	Use:   "lint <plan.json>",
+	Short: "Check a saved plan for structural problems",
+	Long: `Run every structural check on a saved plan and print the findings by check:
+temp_id gaps and ordering, dependency cycles and dangling dependencies,
+dependency levels, the foundation epic, verifiable acceptance criteria,
+decomposition granularity, estimate ranges, and orphan functionality.
+
+Nothing is sent to an LLM, so lint can gate plans in CI. It exits 5 if any
+error-level issue is found; warnings alone exit 0.
+
+Example:
+  prd-parser lint plan.json`,
+	Args: usageArgs(cobra.ExactArgs(1)),
+	RunE: runLint,
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	if err := validateWorkDir(); err != nil {
+		return usageErrorf("%w", err)
+	}
+	data, err := os.ReadFile(inWorkDir(args[0]))
+	if err != nil {
+		return usageErrorf("failed to read plan: %w", err)
+	}
+	var plan core.ParseResponse
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return usageErrorf("failed to parse plan: %w", err)
+	}
+
+	report := core.Lint(&plan)
+	printLintReport(os.Stdout, report)
+	if n := report.Errors(); n > 0 {
+		return validationErrorf("lint found %d error(s)", n)
+	}
+	return nil
+}
+
+// printLintReport writes the report grouped by check, in core.LintChecks order.
+func printLintReport(w io.Writer, report *core.LintReport) {
+	groups := report.ByCheck()
+	for _, check := range core.LintChecks {
+		issues := groups[check]
+		if len(issues) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s (%d):\n", check, len(issues))
+		for _, issue := range issues {
+			item := ""
+			if issue.ItemID != "" {
+				item = issue.ItemID + ": "
+			}
+			fmt.Fprintf(w, "  %s %-7s %s%s\n", tui.Sym.Warn, issue.Severity, item, issue.Message)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(report.Issues) == 0 {
+		fmt.Fprintf(w, "%s No issues found\n", tui.Sym.Check)
+		return
+	}
+	errs := report.Errors()
+	fmt.Fprintf(w, "%d error(s), %d warning(s)\n", errs, len(report.Issues)-errs)
+}
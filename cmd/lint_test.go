@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+func TestPrintLintReportGroupsByCheck(t *testing.T) {
+	report := &core.LintReport{Issues: []core.LintIssue{
+		{Check: core.LintGranularity, Severity: core.LintWarning, ItemID: "2.1", Message: "task has no subtasks"},
+		{Check: core.LintCycles, Severity: core.LintError, ItemID: "1", Message: "depends_on cycle through 1, 2"},
+		{Check: core.LintEstimates, Severity: core.LintWarning, Message: "only 1 of 5 items have estimates (20%)"},
+	}}
+
+	var buf bytes.Buffer
+	printLintReport(&buf, report)
+	got := buf.String()
+
+	// Groups follow core.LintChecks order, not the order issues were found
+	cycles, granularity := strings.Index(got, "cycles (1):"), strings.Index(got, "granularity (1):")
+	if cycles < 0 || granularity < 0 || cycles > granularity {
+		t.Errorf("report should list cycles before granularity:\n%s", got)
+	}
+	for _, want := range []string{
+		"error   1: depends_on cycle through 1, 2",
+		"warning 2.1: task has no subtasks",
+		"warning only 1 of 5 items have estimates (20%)",
+		"1 error(s), 2 warning(s)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report missing %q:\n%s", want, got)
+		}
+	}
+
+	buf.Reset()
+	printLintReport(&buf, &core.LintReport{})
+	if !strings.Contains(buf.String(), "No issues found") {
+		t.Errorf("empty report = %q, want No issues found", buf.String())
+	}
+}
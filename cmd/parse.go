@@ -2,44 +2,111 @@ package cmd
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
-	"github.com/spf13/cobra"
 	"github.com/dhabedank/prd-parser/internal/core"
 	"github.com/dhabedank/prd-parser/internal/llm"
 	"github.com/dhabedank/prd-parser/internal/output"
+	"github.com/dhabedank/prd-parser/internal/tui"
+	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
 
 var (
-	targetEpics      int
-	tasksPerEpic     int
-	subtasksPerTask  int
-	defaultPriority  string
-	testingLevel     string
-	llmProvider      string
-	llmModel         string
-	epicModel        string // Model for epic generation (Stage 1)
-	taskModel        string // Model for task generation (Stage 2)
-	subtaskModel     string // Model for subtasks in multi-stage (Stage 3)
-	outputAdapter    string
-	outputPath       string
-	dryRun           bool
-	fromJSON         string // Resume from checkpoint
-	saveJSON         string // Save checkpoint
-	configFile       string // Config file path
-	multiStage       bool   // Force multi-stage parsing
-	singleShot       bool   // Force single-shot parsing
-	validate         bool   // Run validation pass after generation
-	noReview         bool   // Disable automatic LLM review pass
-	interactiveMode  bool   // Enable human-in-the-loop mode
-	smartParseLines  int    // Threshold for smart parsing (lines)
-	fullContext      bool   // Pass PRD to all stages (not just Stage 1)
-	noProgress       bool   // Disable TUI progress display
+	targetEpics     int
+	tasksPerEpic    int
+	subtasksPerTask int
+	defaultPriority string
+	epicPriority    string // Priority for created epics ("default" uses defaultPriority)
+	testingLevel    string
+	llmProvider     string
+	llmModel        string
+	epicModel       string // Model for epic generation (Stage 1)
+	taskModel       string // Model for task generation (Stage 2)
+	subtaskModel    string // Model for subtasks in multi-stage (Stage 3)
+	outputAdapter   string
+	outputPath      string
+	teeOutput       bool // Also echo JSON adapter file output to stdout
+	gzipOutput      bool
+	jsonCase        string // Key style for JSON adapter output (snake/camel)
+	depsFormat      string // Top-level dependencies section in JSON adapter output (list/adjacency/nested)
+	docOutput       string // Also write a Markdown record of the created plan
+	githubRepo      string // Repository ("owner/name") for the GitHub adapter
+	githubProject   int    // Project (v2) number the GitHub adapter adds issues to
+	dryRun          bool
+	fromJSON        string // Resume from checkpoint
+	retryFailed     bool   // Resume the failed-creation checkpoint, creating only items missing from beads
+	checkIDs        bool   // Report plan items whose readable beads ID already exists, creating nothing
+	fillGaps        bool   // With --from-json, regenerate subtasks for tasks that have none
+	tasksFromJSON   string // Load epics and tasks from JSON and generate only their subtasks
+	saveJSON        string // Save checkpoint
+	configFile      string // Config file path
+	profileName     string // Config file profile layered over the base config
+	multiStage      bool   // Force multi-stage parsing
+	singleShot      bool   // Force single-shot parsing
+	validate        bool   // Run validation pass after generation
+	fixGaps         bool   // Ask the LLM to close the gaps validation finds (implies validate)
+	noReview        bool   // Disable automatic LLM review pass
+	reviewDiff      bool   // List the structural changes the review pass made
+	interactiveMode bool   // Enable human-in-the-loop mode
+	smartParseLines int    // Threshold for smart parsing (lines)
+	fullContext     bool   // Pass PRD to all stages (not just Stage 1)
+	summarize       bool   // Condense the PRD before Stage 1 (for PRDs beyond context limits)
+	epicCosts       bool   // Print what each epic's Stage 2 and 3 calls cost
+	sourceHints     bool   // Ask for a PRD source reference on each epic/task
+	requirementIDs  bool   // Ask for the PRD requirement ID each item implements
+	assumptions     bool   // Ask for assumptions and open questions the PRD leaves unresolved
+	noTesting       bool   // Leave testing requirements out of prompts and output
+	instructions    string // Extra per-run instructions appended to every prompt
+	glossaryFile    string // File of project term definitions added to every system prompt
+	glossary        string // Contents of glossaryFile, read in runParse
+	noProgress      bool   // Disable TUI progress display
+	scanOnly        bool   // Only run Stage 1 and print proposed epics
+	contextOnly     bool   // Only extract and print the project context
+	jsonSummary     bool   // Print the final summary as JSON
+	showReady       bool   // List items that can be started right away in the summary
+	quietLLM        bool   // Print only the final summary, hiding generation progress
+	useCLIContext   bool   // Let the Claude CLI read the repo (less isolation)
+	progressMode    string // Progress output mode while waiting on the LLM (auto/plain/none)
+	forceSingleCall bool   // One LLM call regardless of PRD size (API cost control)
+	maxTokens       int    // Response token limit per LLM call (0 = model default)
+	retryBudget     int    // Total LLM retries allowed across a multi-stage run (0 = no limit)
+	inheritLabels   bool   // Union parent domain/layer labels into children
+	inferDeps       bool   // Add task dependencies inferred from the plan's text
+	foundationDeps  bool   // Add the foundation epic to feature epics' depends_on (fails under --strict)
+	requireEstimate bool   // Run an LLM pass to fill in missing estimates
+	orderedSubtasks bool   // Generate subtasks in intra-epic dependency order, skipping dependents of failed tasks
+	fixedParallel   bool   // Keep Stage 2/3 concurrency constant instead of backing off on rate limits
+	strict          bool   // Fail (exit 5) instead of creating a plan with validation gaps
+	strictDeps      bool   // Fail (exit 5) instead of warning when depends_on names a missing temp_id
+	maxTasks        int    // Hard cap on tasks per epic (0 = no cap)
+	maxSubtasks     int    // Hard cap on subtasks per task (0 = no cap)
+	dropOverflow    bool   // Drop items past the caps instead of only warning
+	idScheme        string // Readable ID scheme for created items (ets/dotted/auto)
+	beadsBulk       bool   // Create beads issues with a single bd import
+	createStrategy  string // Beads creation order: phases or waves
+	estimateFormat  string // Format of bd create --estimate: minutes or hm
+	acceptanceFmt   string // How beads acceptance criteria are joined: bulleted, numbered, or lines
+	timingTop       int    // Slowest beads creations to list with the total creation time (0 = off)
+	epicStart       int    // Renumber epics to start here before output (0 keeps the plan's numbering)
+
+	beadsFields   map[string]string // Beads field mapping, e.g. acceptance=description
+	labelColors   map[string]string // Label color overrides by category or label, e.g. domain=0e8a16
+	appendLabels  []string          // Fixed labels added to every created item, e.g. q1-2026
+	contextLevels []string          // Levels whose descriptions get context blocks (default: all)
+
+	inferThreshold float64 // Minimum confidence for an inferred dependency to be applied
+	promptVersion  string  // Embedded prompt revision to generate with (v1, v2, ...)
 )
 
 // ParseCmd represents the parse command
@@ -54,7 +121,7 @@ The parser uses AI to analyze the PRD and create:
 - Subtasks (atomic actions within tasks)
 
 Each item includes context propagation and testing requirements.`,
-	Args: cobra.ExactArgs(1),
+	Args: usageArgs(cobra.ExactArgs(1)),
 	RunE: runParse,
 }
 
@@ -64,79 +131,306 @@ func init() {
 	ParseCmd.Flags().IntVarP(&tasksPerEpic, "tasks", "t", 5, "Target tasks per epic")
 	ParseCmd.Flags().IntVarP(&subtasksPerTask, "subtasks", "s", 4, "Target subtasks per task")
 	ParseCmd.Flags().StringVarP(&defaultPriority, "priority", "p", "medium", "Default priority (critical/high/medium/low)")
+	ParseCmd.Flags().StringVar(&epicPriority, "epic-priority", string(core.PriorityHigh), "Priority for created epics (critical/high/medium/low/very-low, or default to use --priority)")
 	ParseCmd.Flags().StringVar(&testingLevel, "testing", "comprehensive", "Testing level (minimal/standard/comprehensive)")
+	ParseCmd.Flags().StringVar(&instructions, "instructions", "", "Extra instructions for this run, appended to every prompt (e.g. \"we use pnpm, not npm\")")
+	ParseCmd.Flags().StringVar(&glossaryFile, "glossary", "", "File of project term definitions given to the LLM as authoritative (e.g. glossary.md)")
 
 	// LLM options
-	ParseCmd.Flags().StringVarP(&llmProvider, "llm", "l", "auto", "LLM provider (auto/claude-cli/codex-cli/anthropic-api)")
+	ParseCmd.Flags().StringVarP(&llmProvider, "llm", "l", "auto", "LLM provider ("+strings.Join(llm.ProviderNames, "/")+")")
 	ParseCmd.Flags().StringVarP(&llmModel, "model", "m", "", "Model to use (provider-specific)")
 	ParseCmd.Flags().StringVar(&epicModel, "epic-model", "", "Model for epic generation (Stage 1)")
 	ParseCmd.Flags().StringVar(&taskModel, "task-model", "", "Model for task generation (Stage 2)")
 	ParseCmd.Flags().StringVar(&subtaskModel, "subtask-model", "", "Model for subtask generation (Stage 3)")
+	ParseCmd.Flags().StringVar(&progressMode, "progress", llm.ProgressAuto, "Progress output while waiting on the LLM: auto (ticker on a TTY, plain otherwise), plain, none")
+	ParseCmd.Flags().BoolVar(&useCLIContext, "use-cli-context", false, "Let the Claude CLI read the repo with read-only tools (trades isolation for tech-stack grounding)")
 
 	// Parsing strategy (smart by default)
 	ParseCmd.Flags().BoolVar(&multiStage, "multi-stage", false, "Force multi-stage parsing")
 	ParseCmd.Flags().BoolVar(&singleShot, "single-shot", false, "Force single-shot parsing")
+	ParseCmd.Flags().BoolVar(&forceSingleCall, "force-single-call", false, "Always make one LLM call with a high token limit, even for large PRDs (cheaper on metered APIs)")
+	ParseCmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "Response token limit per LLM call (default: the model's own limit)")
+	ParseCmd.Flags().IntVar(&retryBudget, "retry-budget", 0, "Multi-stage: total retries allowed across all stages; when used up, stop and save a checkpoint (0 = no limit)")
 	ParseCmd.Flags().BoolVar(&validate, "validate", false, "Run validation pass to check for gaps after generation")
+	ParseCmd.Flags().BoolVar(&fixGaps, "fix-gaps", false, "Feed validation gaps back to the LLM and merge the tasks it adds to close them (implies --validate)")
+	ParseCmd.Flags().IntVar(&maxTasks, "max-tasks-per-epic", 0, "Hard cap on tasks per epic; exceeding it warns, or fails under --strict (0 = no cap)")
+	ParseCmd.Flags().IntVar(&maxSubtasks, "max-subtasks-per-task", 0, "Hard cap on subtasks per task; exceeding it warns, or fails under --strict (0 = no cap)")
+	ParseCmd.Flags().BoolVar(&dropOverflow, "drop-overflow", false, "Drop tasks/subtasks past the --max-* caps (keeping the first ones) instead of only warning")
+	ParseCmd.Flags().BoolVar(&strict, "strict", false, "Validate and exit with code 5 without creating anything if gaps are found")
+	ParseCmd.Flags().BoolVar(&strictDeps, "strict-deps", false, "Exit with code 5 without creating anything if a depends_on names a temp_id not in the plan")
 	ParseCmd.Flags().BoolVar(&noReview, "no-review", false, "Disable automatic LLM review pass (review is ON by default)")
+	ParseCmd.Flags().BoolVar(&reviewDiff, "review-diff", false, "List what the review pass changed: added/removed/retitled/moved items and dependencies")
 	ParseCmd.Flags().BoolVar(&interactiveMode, "interactive", false, "Enable human-in-the-loop mode (review at each stage)")
 	ParseCmd.Flags().IntVar(&smartParseLines, "smart-threshold", 300, "Line count threshold for auto multi-stage (0 to disable)")
 	ParseCmd.Flags().BoolVar(&fullContext, "full-context", true, "Pass PRD to all stages (default: true, use --full-context=false to disable)")
+	ParseCmd.Flags().BoolVar(&summarize, "summarize", false, "Condense the PRD before Stage 1; later stages get only the sections relevant to each epic (for very large PRDs)")
+	ParseCmd.Flags().BoolVar(&epicCosts, "epic-costs", false, "Print the cost of each epic's task and subtask calls in the summary (implies multi-stage)")
+	ParseCmd.Flags().BoolVar(&sourceHints, "source-hints", false, "Ask the LLM to note which PRD section each epic/task came from (source_hint)")
+	ParseCmd.Flags().BoolVar(&requirementIDs, "requirement-ids", false, "Ask the LLM to tag items with the numbered PRD requirement they implement (requirement_id; implied by --output traceability)")
+	ParseCmd.Flags().BoolVar(&noTesting, "no-testing", false, "Skip testing requirements on every item (smaller output, lower token cost)")
+	ParseCmd.Flags().BoolVar(&assumptions, "assumptions", false, "Ask the LLM to list assumptions it made and open questions about the PRD")
+	ParseCmd.Flags().BoolVar(&scanOnly, "scan", false, "Quick scan: only generate and print proposed epics (no tasks, nothing created)")
+	ParseCmd.Flags().BoolVar(&contextOnly, "context-only", false, "Only extract the project context (product, goals, tech stack, constraints) and print it as JSON; --save-json saves it")
 
 	// Output options
-	ParseCmd.Flags().StringVarP(&outputAdapter, "output", "o", "beads", "Output adapter (beads/json)")
-	ParseCmd.Flags().StringVar(&outputPath, "output-path", "", "Output path for JSON adapter")
+	ParseCmd.Flags().StringVarP(&outputAdapter, "output", "o", "beads", "Output adapter ("+strings.Join(output.AdapterNames, "/")+")")
+	ParseCmd.Flags().StringVar(&outputPath, "output-path", "", "Output path for the JSON, traceability, markdown, or csv adapter (.csv writes the traceability matrix as CSV; .tsv writes csv output tab-separated)")
+	ParseCmd.Flags().StringVar(&jsonCase, "json-case", output.JSONCaseSnake, "Key style for JSON adapter output: snake or camel")
+	ParseCmd.Flags().StringVar(&depsFormat, "dependencies-format", "", "Add a top-level dependencies section to JSON adapter output: list, adjacency, or nested")
+	ParseCmd.Flags().BoolVar(&teeOutput, "tee", false, "With --output json and --output-path, also echo the written output to stdout")
+	ParseCmd.Flags().BoolVar(&gzipOutput, "gzip", false, "Gzip the JSON adapter output (implied when --output-path ends in .gz)")
+	ParseCmd.Flags().StringVar(&githubRepo, "github-repo", "", "Repository (owner/name) the GitHub adapter creates issues in (token from GITHUB_TOKEN or GH_TOKEN)")
+	ParseCmd.Flags().IntVar(&githubProject, "github-project", 0, "Also add GitHub issues to this Project (v2) number under the repository owner, setting its Priority and Estimate fields")
+	ParseCmd.Flags().StringVar(&docOutput, "doc-output", "", "Also write a Markdown summary of the created plan (with assigned IDs) to this path")
 	ParseCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview without creating items")
+	ParseCmd.Flags().StringVar(&idScheme, "id-scheme", output.IDSchemeETS, "Readable ID scheme: ets (prefix-e1t1s1), dotted (prefix-1-1-1), auto (bd assigns)")
+	ParseCmd.Flags().IntVar(&epicStart, "epic-start", 0, "Number epics from N (e.g. 5 when adding to a project that has epics 1-4); dependencies are remapped")
+	ParseCmd.Flags().BoolVar(&beadsBulk, "beads-bulk", false, "Create all beads issues, parents, and dependencies with one bd import (falls back to per-issue bd create)")
+	ParseCmd.Flags().StringVar(&createStrategy, "create-strategy", output.CreateStrategyPhases, "Beads creation order: phases (epics, tasks, subtasks, then dependencies) or waves (dependency waves, each created concurrently)")
+	ParseCmd.Flags().StringVar(&estimateFormat, "estimate-format", output.EstimateFormatMinutes, "Format of beads estimates: minutes (150) or hm (2h30m), for bd versions that expect durations")
+	ParseCmd.Flags().StringVar(&acceptanceFmt, "acceptance-format", output.AcceptanceFormatBulleted, "Format of beads acceptance criteria: bulleted (- A), numbered (1. A), or lines (one per line, no marker)")
+	ParseCmd.Flags().IntVar(&timingTop, "timing", 0, "Print the total beads creation time and the N slowest item creations (--timing alone lists 10)")
+	ParseCmd.Flags().Lookup("timing").NoOptDefVal = "10"
+	ParseCmd.Flags().StringSliceVar(&contextLevels, "context-levels", nil, "Only add context blocks to these levels' descriptions: epic,task,subtask (default: all)")
+	ParseCmd.Flags().StringToStringVar(&beadsFields, "beads-field", nil, "Map plan fields to beads fields: acceptance|design=native|description|none|field:<name> (repeatable)")
+	ParseCmd.Flags().StringSliceVar(&appendLabels, "append-labels", nil, "Add these labels to every created item, e.g. q1-2026,team-payments")
+	ParseCmd.Flags().StringToStringVar(&labelColors, "label-color", nil, "Label color for adapters with colored labels, by category or label: layer|domain|skill|type|<label>=<hex> (repeatable)")
+	ParseCmd.Flags().BoolVar(&orderedSubtasks, "ordered-subtasks", false, "Multi-stage: generate subtasks in dependency order per epic; if a task fails, skip tasks that depend on it instead of aborting")
+	ParseCmd.Flags().BoolVar(&fixedParallel, "fixed-concurrency", false, "Multi-stage: keep Stage 2/3 concurrency constant instead of halving it on rate limits and ramping back up")
+	ParseCmd.Flags().BoolVar(&inheritLabels, "inherit-labels", false, "Copy epic/task domain and layer labels down to tasks and subtasks")
+	ParseCmd.Flags().BoolVar(&inferDeps, "infer-deps", false, "Add missing task dependencies inferred from titles and descriptions")
+	ParseCmd.Flags().BoolVar(&requireEstimate, "require-estimates", false, "Run a follow-up LLM pass that fills in only the missing estimates")
+	ParseCmd.Flags().BoolVar(&foundationDeps, "enforce-foundation-deps", false, "Make every feature epic depend on the foundation epic (under --strict, fail if one doesn't)")
+	ParseCmd.Flags().Float64Var(&inferThreshold, "infer-deps-threshold", core.DefaultInferDepsThreshold, "Minimum confidence (0-1) to apply an inferred dependency; weaker ones are only suggested")
+	ParseCmd.Flags().StringVar(&promptVersion, "prompt-version", core.CurrentPromptVersion, "Generate with an earlier revision of the embedded prompts, to reproduce older results: "+strings.Join(core.PromptVersions, ", "))
+	ParseCmd.Flags().BoolVar(&jsonSummary, "json-summary", false, "Print the final summary (stats, failures, warnings) as JSON")
+	ParseCmd.Flags().BoolVar(&showReady, "show-ready", false, "List items that can be started right away (no incomplete dependencies) in the summary")
+	ParseCmd.Flags().BoolVar(&quietLLM, "quiet-llm", false, "Hide stage progress and intermediate output; print only the final summary")
 
 	// Checkpoint/resume options
 	ParseCmd.Flags().StringVar(&fromJSON, "from-json", "", "Resume from saved JSON checkpoint (skip LLM)")
+	ParseCmd.Flags().BoolVar(&retryFailed, "retry-failed", false, "Reload the checkpoint saved when beads creation failed and create only the items not already in beads")
+	ParseCmd.Flags().BoolVar(&checkIDs, "check-ids", false, "Report plan items whose readable ID already exists in beads, and create nothing (exits 5 on collisions)")
+	ParseCmd.Flags().BoolVar(&fillGaps, "fill-gaps", false, "With --from-json, regenerate tasks only for epics that have none and subtasks only for tasks that have none")
+	ParseCmd.Flags().StringVar(&tasksFromJSON, "tasks-from-json", "", "Load epics and tasks from JSON and generate only their subtasks (Stage 3)")
 	ParseCmd.Flags().StringVar(&saveJSON, "save-json", "", "Save generated JSON to file (for resume)")
 
 	// Config file
 	ParseCmd.Flags().StringVar(&configFile, "config", "", "Config file (default: .prd-parser.yaml)")
+	ParseCmd.Flags().StringVar(&profileName, "profile", "", "Config file profile to layer over the base config, e.g. mobile")
 
 	// Progress display
 	ParseCmd.Flags().BoolVar(&noProgress, "no-progress", false, "Disable TUI progress display")
+
+	ParseCmd.MarkFlagsMutuallyExclusive("force-single-call", "multi-stage")
+	ParseCmd.MarkFlagsMutuallyExclusive("force-single-call", "interactive")
+	ParseCmd.MarkFlagsMutuallyExclusive("quiet-llm", "interactive")
+	ParseCmd.MarkFlagsMutuallyExclusive("strict", "fix-gaps")
+
+	_ = ParseCmd.RegisterFlagCompletionFunc("llm", completeLLMProviders)
+	_ = ParseCmd.RegisterFlagCompletionFunc("model", completeModels)
+	_ = ParseCmd.RegisterFlagCompletionFunc("output", completeOutputAdapters)
+
+	// batch runs parse on each PRD, so it takes the same flags
+	BatchCmd.Flags().AddFlagSet(ParseCmd.Flags())
 }
 
-func runParse(cmd *cobra.Command, args []string) error {
-	prdPath := args[0]
+// usageArgs marks positional argument errors as usage errors.
+func usageArgs(validate cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		return UsageError(validate(cmd, args))
+	}
+}
+
+// singleCallMaxTokens is the response limit for --force-single-call,
+// high enough for a full plan in one response.
+const singleCallMaxTokens = 64000
 
+// responseMaxTokens returns the response limit to request: --max-tokens if
+// set, else under --force-single-call singleCallMaxTokens capped at the --model
+// model's output limit, else 0 so the provider uses the model's default.
+func responseMaxTokens() int {
+	if maxTokens > 0 {
+		return maxTokens
+	}
+	if forceSingleCall {
+		if llmModel == "" {
+			return singleCallMaxTokens // Provider defaults allow it
+		}
+		return min(singleCallMaxTokens, llm.DefaultMaxTokensFor(llmModel))
+	}
+	return 0
+}
+
+func runParse(cmd *cobra.Command, args []string) error {
 	// Load config file (flags override config file values)
 	if err := loadConfig(cmd); err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return usageErrorf("failed to load config: %w", err)
+	}
+
+	// Relative paths are relative to --dir
+	if err := validateWorkDir(); err != nil {
+		return usageErrorf("%w", err)
+	}
+	if err := validateTempDir(); err != nil {
+		return usageErrorf("%w", err)
+	}
+	prdArg, prdPath := args[0], inWorkDir(args[0])
+	if tasksFromJSON != "" {
+		if fromJSON != "" {
+			return usageErrorf("use either --from-json or --tasks-from-json, not both")
+		}
+		fromJSON = tasksFromJSON
+	}
+	if retryFailed {
+		switch {
+		case fromJSON != "":
+			return usageErrorf("--retry-failed loads the failed-creation checkpoint; don't combine it with --from-json or --tasks-from-json")
+		case outputAdapter != "beads":
+			return usageErrorf("--retry-failed only works with --output beads")
+		case idScheme == output.IDSchemeAuto:
+			return usageErrorf("--retry-failed matches items by readable ID, so it can't be used with --id-scheme auto")
+		}
+		fromJSON = failureCheckpointPath(prdPath)
+		if _, err := os.Stat(fromJSON); err != nil {
+			return usageErrorf("no failed creation to retry: %s not found", fromJSON)
+		}
+	}
+	if checkIDs {
+		switch {
+		case outputAdapter != "beads":
+			return usageErrorf("--check-ids only works with --output beads")
+		case idScheme == output.IDSchemeAuto:
+			return usageErrorf("--check-ids checks readable IDs, so it can't be used with --id-scheme auto")
+		case retryFailed:
+			return usageErrorf("--retry-failed already skips items that exist; don't combine it with --check-ids")
+		}
 	}
+	switch {
+	case timingTop < 0:
+		return usageErrorf("--timing must be a positive number of items, got %d", timingTop)
+	case timingTop > 0 && outputAdapter != "beads":
+		return usageErrorf("--timing only works with --output beads")
+	}
+	fromJSON, saveJSON, docOutput, outputPath = inWorkDir(fromJSON), inWorkDir(saveJSON), inWorkDir(docOutput), inWorkDir(outputPath)
 
 	// Check PRD file exists (unless resuming from JSON)
 	if fromJSON == "" {
 		if _, err := os.Stat(prdPath); os.IsNotExist(err) {
-			return fmt.Errorf("PRD file not found: %s", prdPath)
+			return usageErrorf("PRD file not found: %s", prdPath)
 		}
 	}
 
+	if !llm.ValidProgressMode(progressMode) {
+		return usageErrorf("unknown progress mode: %s (use auto, plain, or none)", progressMode)
+	}
+	if !core.ValidPromptVersion(promptVersion) {
+		return usageErrorf("unknown prompt version: %s (use %s)", promptVersion, strings.Join(core.PromptVersions, ", "))
+	}
+	if maxTasks < 0 || maxSubtasks < 0 {
+		return usageErrorf("--max-tasks-per-epic and --max-subtasks-per-task can't be negative")
+	}
+	if epicStart < 0 {
+		return usageErrorf("--epic-start must be positive, got %d", epicStart)
+	}
+	if maxTokens < 0 {
+		return usageErrorf("--max-tokens can't be negative")
+	}
+	if retryBudget < 0 {
+		return usageErrorf("--retry-budget can't be negative")
+	}
+	if summarize && (singleShot || forceSingleCall || interactiveMode) {
+		return usageErrorf("--summarize requires multi-stage parsing (not --single-shot, --force-single-call, or --interactive)")
+	}
+	if epicCosts && (singleShot || forceSingleCall || fromJSON != "") {
+		return usageErrorf("--epic-costs needs multi-stage generation (not --single-shot, --force-single-call, or --from-json)")
+	}
+	if inferThreshold < 0 || inferThreshold > 1 {
+		return usageErrorf("--infer-deps-threshold must be between 0 and 1, got %g", inferThreshold)
+	}
+	if contextOnly && (scanOnly || fromJSON != "") {
+		return usageErrorf("--context-only reads the PRD; don't combine it with --scan, --from-json, or --retry-failed")
+	}
+	if fillGaps && fromJSON == "" {
+		return usageErrorf("--fill-gaps requires --from-json")
+	}
+	if fixGaps && fromJSON != "" {
+		return usageErrorf("--fix-gaps works on the plan a parse generates; a saved plan isn't validated, so check it with polish instead of --from-json")
+	}
+	if glossaryFile != "" {
+		data, err := os.ReadFile(inWorkDir(glossaryFile))
+		if err != nil {
+			return usageErrorf("failed to read glossary: %w", err)
+		}
+		if err := core.CheckPRDText(data); err != nil {
+			return usageErrorf("glossary %s is not usable: %w", glossaryFile, err)
+		}
+		glossary = string(data)
+	}
+
+	// Quick scan: Stage 1 only, nothing is created
+	if scanOnly {
+		return runScan(prdPath)
+	}
+	if contextOnly {
+		return runContextOnly(prdPath)
+	}
+
+	// Keep the real stdout for the summary; --quiet-llm hides everything before it
+	summaryOut, planOut := os.Stdout, os.Stdout
+	if gzipToStdout() {
+		// The compressed plan owns stdout, so progress and the summary go to stderr
+		summaryOut, os.Stdout = os.Stderr, os.Stderr
+		defer func() { os.Stdout = planOut }()
+	}
+	if quietLLM {
+		restore, err := silenceStdout()
+		if err != nil {
+			return fmt.Errorf("failed to silence output: %w", err)
+		}
+		defer restore()
+	}
+
+	// Collect non-fatal warnings for a consolidated report at the end
+	warnings := core.NewWarningCollector()
+
 	// Create output adapter
-	outAdapter, outConfig, err := createOutputAdapter()
+	outAdapter, outConfig, err := createOutputAdapter(warnings, planOut)
 	if err != nil {
-		return fmt.Errorf("failed to create output adapter: %w", err)
+		return usageErrorf("failed to create output adapter: %w", err)
 	}
 	fmt.Printf("Using output: %s\n", outAdapter.Name())
 
 	// Show beads status if using beads output
 	if outputAdapter == "beads" {
-		status := output.CheckBeadsStatus(".")
+		status := output.CheckBeadsStatus(workingDir())
 		if !status.CLIInstalled || !status.Initialized {
 			output.PrintBeadsStatus(status)
-			return fmt.Errorf("beads not ready - see above for setup instructions")
+			return outputErrorf("beads not ready - see above for setup instructions")
 		}
 		output.PrintBeadsStatus(status)
 	}
 
 	// Wrap the output adapter to satisfy core.OutputAdapter interface
 	wrappedOutput := &outputAdapterWrapper{
-		adapter: outAdapter,
-		config:  outConfig,
+		adapter:      outAdapter,
+		config:       outConfig,
+		docPath:      docOutput,
+		appendLabels: appendLabels,
+	}
+
+	// The traceability matrix flags PRD requirements that no item covers
+	if outputAdapter == "traceability" {
+		if prd, err := os.ReadFile(prdPath); err == nil {
+			wrappedOutput.config.Requirements = core.FindRequirementIDs(string(prd))
+		}
 	}
 
 	var parseResponse *core.ParseResponse
+	var costs *core.CostRecorder
+	if epicCosts {
+		costs = core.NewCostRecorder()
+	}
 
 	// Either resume from JSON checkpoint or generate new
 	if fromJSON != "" {
@@ -144,50 +438,56 @@ func runParse(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Resuming from checkpoint: %s\n", fromJSON)
 		data, err := os.ReadFile(fromJSON)
 		if err != nil {
-			return fmt.Errorf("failed to read checkpoint: %w", err)
+			return usageErrorf("failed to read checkpoint: %w", err)
 		}
 		parseResponse = &core.ParseResponse{}
 		if err := json.Unmarshal(data, parseResponse); err != nil {
-			return fmt.Errorf("failed to parse checkpoint JSON: %w", err)
+			return usageErrorf("failed to parse checkpoint JSON: %w", err)
+		}
+		if source := parseResponse.Metadata.SourcePRD; retryFailed && source != "" && source != absPath(prdPath) {
+			return usageErrorf("the failed-creation checkpoint %s is for %s, not %s", fromJSON, source, prdPath)
 		}
 		fmt.Printf("Loaded %d epics from checkpoint\n", len(parseResponse.Epics))
+
+		if fillGaps || tasksFromJSON != "" {
+			if err := generateCheckpointSubtasks(context.Background(), parseResponse, prdPath, tasksFromJSON != "", warnings); err != nil {
+				return err
+			}
+		}
 	} else {
 		// Read PRD content for smart parsing decision
-		prdContent, err := os.ReadFile(prdPath)
+		prdContent, err := readPRD(prdPath)
 		if err != nil {
-			return fmt.Errorf("failed to read PRD: %w", err)
+			return err
 		}
 
 		// Count lines for smart parsing
 		lineCount := len(strings.Split(string(prdContent), "\n"))
 
 		// Determine parsing strategy
-		useMultiStage := multiStage // Explicit flag takes precedence
-		if !multiStage && !singleShot && smartParseLines > 0 {
-			// Smart detection: use multi-stage for large PRDs
-			if lineCount > smartParseLines {
-				useMultiStage = true
-				fmt.Printf("PRD has %d lines (> %d threshold) - using multi-stage parsing\n", lineCount, smartParseLines)
-			} else {
-				fmt.Printf("PRD has %d lines - using single-shot parsing\n", lineCount)
-			}
-		} else if singleShot {
-			useMultiStage = false
-			fmt.Println("Forcing single-shot parsing")
-		} else if multiStage {
-			fmt.Println("Forcing multi-stage parsing")
+		useMultiStage, reason := chooseMultiStage(lineCount)
+		if reason != "" {
+			fmt.Println(reason)
+		}
+		if forceSingleCall && smartParseLines > 0 && lineCount > smartParseLines {
+			warnings.Add(core.WarnSingleCallLargePRD, "", "PRD has %d lines; a single call may truncate or drop detail (use multi-stage for robustness)", lineCount)
+		}
+
+		if shape := core.CheckPRDShape(string(prdContent)); shape.LooksLikeTaskList() {
+			msg := fmt.Sprintf("input looks like a task list, not a PRD (%d of %d lines are short list items); "+
+				"describe the product and its goals, or write the tasks as JSON and use --tasks-from-json", shape.ItemLines, shape.Lines)
+			// Printed before generation so the run can be stopped, and kept for the summary
+			fmt.Printf("%s %s\n", tui.Sym.Warn, msg)
+			warnings.Add(core.WarnTaskListInput, "", "%s", msg)
 		}
 
 		// Build config
-		priority := core.Priority(defaultPriority)
-		config := core.ParseConfig{
-			TargetEpics:      targetEpics,
-			TasksPerEpic:     tasksPerEpic,
-			SubtasksPerTask:  subtasksPerTask,
-			DefaultPriority:  priority,
-			TestingLevel:     testingLevel,
-			PropagateContext: true,
-			FullContext:      fullContext,
+		config := buildParseConfig()
+		config.Warnings = warnings
+		config.Costs = costs
+
+		if err := checkPromptSize(string(prdContent), config, useMultiStage || interactiveMode, warnings); err != nil {
+			return err
 		}
 
 		ctx := context.Background()
@@ -195,41 +495,37 @@ func runParse(cmd *cobra.Command, args []string) error {
 		if interactiveMode {
 			// Interactive mode - human-in-the-loop at each stage
 			fmt.Println("Interactive mode enabled - you'll review epics before task generation")
-			llmConfig := llm.Config{
-				Model:        llmModel,
-				EpicModel:    epicModel,
-				TaskModel:    taskModel,
-				SubtaskModel: subtaskModel,
-				PreferCLI:    true,
+			generator, err := newStageGenerator("interactive parsing")
+			if err != nil {
+				return err
 			}
-			generator := llm.NewMultiStageGenerator(llmConfig)
 			parser := core.NewInteractiveParser(generator, config)
 
 			parseResponse, err = parser.Parse(ctx, string(prdContent))
 			if err != nil {
-				return fmt.Errorf("interactive parsing failed: %w", err)
+				return generationErrorf("interactive parsing failed: %w", err)
 			}
 		} else if useMultiStage {
 			// Multi-stage parsing (parallel, more robust)
-			llmConfig := llm.Config{
-				Model:        llmModel,
-				EpicModel:    epicModel,
-				TaskModel:    taskModel,
-				SubtaskModel: subtaskModel,
-				PreferCLI:    true,
+			generator, err := newStageGenerator("multi-stage parsing")
+			if err != nil {
+				return err
 			}
-			generator := llm.NewMultiStageGenerator(llmConfig)
 			parser := core.NewMultiStageParser(generator, config)
 
 			parseResponse, err = parser.Parse(ctx, string(prdContent))
+			var budgetErr *core.RetryBudgetError
+			if errors.As(err, &budgetErr) {
+				return saveRetryBudgetCheckpoint(budgetErr, prdArg, prdPath)
+			}
 			if err != nil {
-				return fmt.Errorf("multi-stage parsing failed: %w", err)
+				return generationErrorf("multi-stage parsing failed: %w", err)
 			}
 		} else {
 			// Single-shot parsing (original behavior)
 			llmAdapter, err := createLLMAdapter()
 			if err != nil {
-				return fmt.Errorf("failed to create LLM adapter: %w", err)
+				return usageErrorf("failed to create LLM adapter: %w; %s", err, noLLMHint)
 			}
 			fmt.Printf("Using LLM: %s\n", llmAdapter.Name())
 
@@ -240,19 +536,19 @@ func runParse(cmd *cobra.Command, args []string) error {
 				Config:        &config,
 			})
 			if err != nil {
-				return fmt.Errorf("parsing failed: %w", err)
+				return generationErrorf("parsing failed: %w", err)
 			}
 			parseResponse = result.ParseResponse
 		}
 
 		// Save checkpoint if requested
 		if saveJSON != "" {
-			data, err := json.MarshalIndent(parseResponse, "", "  ")
+			data, err := marshalCheckpoint(parseResponse)
 			if err != nil {
 				return fmt.Errorf("failed to marshal JSON: %w", err)
 			}
-			if err := os.WriteFile(saveJSON, data, 0644); err != nil {
-				return fmt.Errorf("failed to save checkpoint: %w", err)
+			if err := writeCheckpoint(saveJSON, data); err != nil {
+				return outputErrorf("failed to save checkpoint: %w", err)
 			}
 			fmt.Printf("\nCheckpoint saved to: %s\n", saveJSON)
 			fmt.Println("To review and create:")
@@ -260,27 +556,47 @@ func runParse(cmd *cobra.Command, args []string) error {
 			fmt.Printf("  2. Run: prd-parser parse --from-json %s\n", saveJSON)
 		}
 
-		// Run validation if requested
-		if validate {
+		// Run validation if requested (--strict and --fix-gaps imply it)
+		if validate || strict || fixGaps {
 			fmt.Println("\nValidating plan for gaps...")
+			// Reported once, in the warnings summary
+			for _, v := range core.CheckConstraintViolations(parseResponse) {
+				warnings.Add(core.WarnConstraintViolation, v.ItemID, "mentions %q, which conflicts with constraint %q", v.Keyword, v.Constraint)
+			}
 			validationResult, err := runValidation(ctx, parseResponse, string(prdContent), llmModel)
 			if err != nil {
-				fmt.Printf("Warning: validation failed: %v\n", err)
+				if strict {
+					return generationErrorf("validation failed: %w", err)
+				}
+				warnings.Add(core.WarnValidationFailed, "", "validation failed: %v", err)
 			} else {
 				if validationResult.IsValid {
-					fmt.Println("✓ Plan validation passed - no gaps found")
+					fmt.Println(tui.Sym.Check + " Plan validation passed - no gaps found")
 				} else {
-					fmt.Println("⚠ Plan validation found gaps:")
+					fmt.Println(tui.Sym.Warn + " Plan validation found gaps:")
 					for _, gap := range validationResult.Gaps {
-						fmt.Printf("  • %s\n", gap)
+						fmt.Printf("  %s %s\n", tui.Sym.Bullet, gap)
+					}
+					gaps := validationResult.Gaps
+					if fixGaps {
+						if fixed, remaining := fixValidationGaps(ctx, parseResponse, string(prdContent), gaps, warnings); fixed != nil {
+							parseResponse, gaps = fixed, remaining
+						}
+					}
+					for _, gap := range gaps {
+						warnings.Add(core.WarnValidationGap, "", "%s", gap)
 					}
 				}
 				if len(validationResult.Warnings) > 0 {
 					fmt.Println("Warnings:")
 					for _, warning := range validationResult.Warnings {
-						fmt.Printf("  • %s\n", warning)
+						fmt.Printf("  %s %s\n", tui.Sym.Bullet, warning)
+						warnings.Add(core.WarnValidationNote, "", "%s", warning)
 					}
 				}
+				if strict && !validationResult.IsValid {
+					return validationErrorf("validation found %d gaps (--strict) - nothing was created", len(validationResult.Gaps))
+				}
 			}
 		}
 
@@ -290,58 +606,636 @@ func runParse(cmd *cobra.Command, args []string) error {
 			fmt.Println("\nReviewing structure...")
 			reviewResult, err := runReview(ctx, parseResponse, string(prdContent), llmModel)
 			if err != nil {
-				fmt.Printf("Warning: Review failed: %v\n", err)
+				warnings.Add(core.WarnReviewFailed, "", "review failed: %v", err)
 			} else if reviewResult.WasModified {
-				fmt.Printf("✓ Review fixed issues: %s\n", reviewResult.ReviewNotes)
+				fmt.Printf("%s Review fixed issues: %s\n", tui.Sym.Check, reviewResult.ReviewNotes)
+				if reviewDiff {
+					printReviewChanges(os.Stdout, reviewResult.Changes)
+				}
 				parseResponse = reviewResult.Response
 				// Update checkpoint if we saved one
 				if saveJSON != "" {
-					data, err := json.MarshalIndent(parseResponse, "", "  ")
+					data, err := marshalCheckpoint(parseResponse)
 					if err == nil {
-						_ = os.WriteFile(saveJSON, data, 0644)
+						_ = writeCheckpoint(saveJSON, data)
 						fmt.Printf("Updated checkpoint: %s\n", saveJSON)
 					}
 				}
 			} else {
-				fmt.Println("✓ Review passed - no changes needed")
+				fmt.Println(tui.Sym.Check + " Review passed - no changes needed")
 			}
 		}
 	}
 
+	if inheritLabels {
+		core.InheritLabels(parseResponse)
+	}
+	if err := enforceItemCaps(parseResponse, warnings); err != nil {
+		return err
+	}
+	if inferDeps {
+		applyInferredDeps(parseResponse, warnings)
+	}
+	if foundationDeps {
+		if err := enforceFoundationDeps(parseResponse); err != nil {
+			return err
+		}
+	}
+	if requireEstimate {
+		fillMissingEstimates(context.Background(), parseResponse, llmModel, warnings)
+	}
+	if epicStart > 0 {
+		if err := core.OffsetEpicIDs(parseResponse, epicStart); err != nil {
+			return usageErrorf("--epic-start: %w", err)
+		}
+	}
+
+	// Only structural problems block creation; incomplete decomposition is advisory
+	if err := parseResponse.ValidateStructure(); err != nil {
+		code := ExitGeneration
+		if fromJSON != "" {
+			code = ExitUsage
+		}
+		return withExitCode(code, fmt.Errorf("plan cannot be created: %w", err))
+	}
+	if err := parseResponse.ValidateComplete(); err != nil {
+		warnings.Add(core.WarnIncompletePlan, "", "%v", err)
+	}
+	if err := checkDependencies(parseResponse, warnings); err != nil {
+		return err
+	}
+	for _, issue := range core.CheckIDSequence(parseResponse) {
+		warnings.Add(core.WarnIDSequence, issue.ItemID, "%s", issue.Message)
+	}
+	for _, issue := range core.CheckDependencyLevels(parseResponse) {
+		warnings.Add(core.WarnDependencyLevel, issue.ItemID, "depends on %s - %s", issue.DependsOn, issue.Suggestion)
+	}
+	if coverage := core.CheckEstimateCoverage(parseResponse); coverage.Low() {
+		warnings.Add(core.WarnEstimateCoverage, "", "only %d of %d items have estimates (%.0f%%) - rollups will be incomplete; use --require-estimates to fill them",
+			coverage.Estimated, coverage.Items, coverage.Fraction()*100)
+	}
+	for _, orphan := range core.CheckOrphanFunctionality(parseResponse) {
+		warnings.Add(core.WarnOrphanFunctionality, orphan.EpicID, "%q has only backend/api/database tasks and no way to see it working - add a UI, CLI, or visible-output task", orphan.Title)
+	}
+
+	for _, gap := range output.CapabilityGaps(outAdapter, parseResponse) {
+		warnings.Add(core.WarnAdapterCapability, "", "%s", gap)
+	}
+
+	if checkIDs {
+		return checkIDCollisions(summaryOut, outAdapter, parseResponse)
+	}
+
 	// Auto-checkpoint before creation (allows recovery if creation fails)
-	autoCheckpoint := filepath.Join(os.TempDir(), "prd-parser-last.json")
-	if data, err := json.MarshalIndent(parseResponse, "", "  "); err == nil {
-		_ = os.WriteFile(autoCheckpoint, data, 0644)
+	autoCheckpoint := filepath.Join(tempDir(), "prd-parser-last.json")
+	if data, err := marshalCheckpoint(parseResponse); err == nil {
+		if err := writeCheckpoint(autoCheckpoint, data); err != nil {
+			warnings.Add(core.WarnCheckpointFailed, "", "failed to save %s: %v", autoCheckpoint, err)
+		}
+	}
+
+	if retryFailed {
+		if err := markExistingItems(outAdapter, parseResponse); err != nil {
+			return outputErrorf("--retry-failed: %w", err)
+		}
 	}
 
 	// Create items via output adapter
 	fmt.Println("\nCreating items...")
+	checkpointPath := failureCheckpointPath(prdPath)
 	createResult, err := wrappedOutput.CreateItems(parseResponse)
 	if err != nil {
 		// Auto-save checkpoint on failure for retry
-		checkpointPath := filepath.Join(os.TempDir(), "prd-parser-checkpoint.json")
-		data, _ := json.MarshalIndent(parseResponse, "", "  ")
-		_ = os.WriteFile(checkpointPath, data, 0644) // Best-effort, don't override original error
-		return fmt.Errorf("creating items failed: %w\n\nCheckpoint saved to: %s\nRetry with: prd-parser parse %s --from-json %s", err, checkpointPath, prdPath, checkpointPath)
+		_ = saveFailureCheckpoint(checkpointPath, parseResponse, prdPath) // Best-effort, don't override original error
+		return outputErrorf("creating items failed: %w\n\nCheckpoint saved to: %s\nRetry with: %s", err, checkpointPath, retryCommand(prdArg, checkpointPath))
+	}
+	if len(createResult.Failed) > 0 && outputAdapter == "beads" {
+		if err := saveFailureCheckpoint(checkpointPath, parseResponse, prdPath); err != nil {
+			warnings.Add(core.WarnCheckpointFailed, "", "%d items failed, but the checkpoint to retry them couldn't be saved: %v", len(createResult.Failed), err)
+		} else {
+			fmt.Printf("\n%d items failed; checkpoint saved to: %s\nRetry them with: %s\n", len(createResult.Failed), checkpointPath, retryCommand(prdArg, checkpointPath))
+		}
+	} else if retryFailed {
+		_ = os.Remove(checkpointPath) // Nothing left to retry
 	}
 
 	// Print summary
-	fmt.Println("\n--- Summary ---")
-	fmt.Printf("Epics: %d\n", createResult.Stats.Epics)
-	fmt.Printf("Tasks: %d\n", createResult.Stats.Tasks)
-	fmt.Printf("Subtasks: %d\n", createResult.Stats.Subtasks)
-	fmt.Printf("Dependencies: %d\n", createResult.Stats.Dependencies)
+	summary := buildParseSummary(parseResponse, createResult, warnings)
+	if timingTop > 0 && wrappedOutput.result != nil {
+		summary.Timing = buildCreationTiming(wrappedOutput.result, timingTop)
+	}
+	if costs != nil {
+		summary.EpicCosts = buildEpicCosts(parseResponse, costs.Costs())
+	}
+	if showReady {
+		var completed map[string]bool
+		if retryFailed {
+			completed = beadsCompleted(createResult.ExternalIDs) // Issues from the earlier run may be closed by now
+		}
+		summary.Ready = readyItems(parseResponse, createResult.ExternalIDs, completed)
+	}
+	return printSummary(summaryOut, summary, jsonSummary)
+}
+
+// failureCheckpointPath is where the plan is saved when creating it from
+// prdPath fails, and where --retry-failed looks for it. The name is keyed by
+// the PRD and working directory, so other PRDs in a batch and other projects
+// sharing the temp dir keep checkpoints of their own.
+func failureCheckpointPath(prdPath string) string {
+	return filepath.Join(tempDir(), "prd-parser-checkpoint-"+checkpointKey(prdPath)+".json")
+}
+
+// partialCheckpointPath is where a plan cut short by --retry-budget is saved
+// without --save-json. It's kept apart from the failure checkpoint, which
+// --retry-failed would otherwise load as if it were complete.
+func partialCheckpointPath(prdPath string) string {
+	return filepath.Join(tempDir(), "prd-parser-partial-"+checkpointKey(prdPath)+".json")
+}
+
+// checkpointKey is a short hash of the absolute working directory and PRD path.
+func checkpointKey(prdPath string) string {
+	sum := sha256.Sum256([]byte(absPath(workingDir()) + "\x00" + absPath(prdPath)))
+	return hex.EncodeToString(sum[:6])
+}
+
+// absPath returns path made absolute, or path itself if that fails.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// saveFailureCheckpoint writes response to path as a checkpoint recording
+// prdPath as its source, which --retry-failed checks before loading it.
+func saveFailureCheckpoint(path string, response *core.ParseResponse, prdPath string) error {
+	checkpoint := *response
+	checkpoint.Metadata.SourcePRD = absPath(prdPath)
+	data, err := marshalCheckpoint(&checkpoint)
+	if err != nil {
+		return err
+	}
+	return writeCheckpoint(path, data)
+}
+
+// retryCommand is the command that retries creation from the failure checkpoint
+// of prdArg (the PRD as given on the command line). It repeats --dir, and for
+// --retry-failed the flags readable IDs depend on, so the retry computes the
+// same IDs instead of creating duplicates.
+func retryCommand(prdArg, checkpointPath string) string {
+	command := "prd-parser parse " + prdArg
+	if workDir != "" {
+		command += " --dir " + workDir
+	}
+	if outputAdapter != "beads" || idScheme == output.IDSchemeAuto {
+		return command + " --from-json " + checkpointPath
+	}
+	command += " --retry-failed"
+	if idScheme != output.IDSchemeETS {
+		command += " --id-scheme " + idScheme
+	}
+	if epicStart > 0 {
+		command += fmt.Sprintf(" --epic-start %d", epicStart)
+	}
+	return command
+}
+
+// markExistingItems tells the beads adapter which plan items already exist, so
+// only the missing ones are created.
+func markExistingItems(adapter output.Adapter, response *core.ParseResponse) error {
+	beads, ok := adapter.(*output.BeadsAdapter)
+	if !ok {
+		return fmt.Errorf("only supported with the beads output")
+	}
+	ids, err := beads.ExistingIssueIDs()
+	if err != nil {
+		return err
+	}
+	total := 0
+	_ = core.WalkItems(response, func(core.ItemRef) error {
+		total++
+		return nil
+	})
+	existing := beads.MarkExisting(response, ids)
+	fmt.Printf("Retrying failed creation: %d of %d items already exist in beads; creating the other %d\n", existing, total, total-existing)
+	return nil
+}
+
+// checkIDCollisions reports the plan items whose readable ID is already a beads
+// issue (--check-ids) and creates nothing. Collisions exit 5.
+func checkIDCollisions(w io.Writer, adapter output.Adapter, response *core.ParseResponse) error {
+	beads, ok := adapter.(*output.BeadsAdapter)
+	if !ok {
+		return usageErrorf("--check-ids only works with --output beads")
+	}
+	ids, err := beads.ExistingIssueIDs()
+	if err != nil {
+		return outputErrorf("--check-ids: %w", err)
+	}
+	return reportIDCollisions(w, beads.IDCollisions(response, ids), response)
+}
+
+// reportIDCollisions prints collisions against the size of the plan.
+func reportIDCollisions(w io.Writer, collisions []output.IDCollision, response *core.ParseResponse) error {
+	total := 0
+	_ = core.WalkItems(response, func(core.ItemRef) error {
+		total++
+		return nil
+	})
+	if len(collisions) == 0 {
+		fmt.Fprintf(w, "%s None of the plan's %d IDs exist in beads\n", tui.Sym.Check, total)
+		return nil
+	}
+
+	fmt.Fprintf(w, "%s %d of the plan's %d IDs already exist in beads:\n", tui.Sym.Warn, len(collisions), total)
+	for _, c := range collisions {
+		fmt.Fprintf(w, "  %s %s  %s %s\n", tui.Sym.Bullet, c.ID, c.TempID, c.Title)
+	}
+	fmt.Fprintln(w, "Use --epic-start to number the new epics after the existing ones, or --id-scheme auto to let bd assign IDs")
+	return validationErrorf("--check-ids found %d ID collisions - nothing was created", len(collisions))
+}
+
+// saveRetryBudgetCheckpoint saves the partial plan of a run that ran out of
+// --retry-budget (to --save-json, or a temp file) and returns the generation
+// error explaining how to finish it. The command names the PRD as given on the
+// command line (prdArg), with --dir, as retryCommand does.
+func saveRetryBudgetCheckpoint(budgetErr *core.RetryBudgetError, prdArg, prdPath string) error {
+	checkpointPath := saveJSON
+	if checkpointPath == "" {
+		checkpointPath = partialCheckpointPath(prdPath)
+	}
+	data, err := marshalCheckpoint(budgetErr.Partial)
+	if err == nil {
+		err = writeCheckpoint(checkpointPath, data)
+	}
+	if err != nil {
+		return generationErrorf("multi-stage parsing failed: %w (checkpoint not saved: %v)", budgetErr, err)
+	}
+	command := "prd-parser parse " + prdArg
+	if workDir != "" {
+		command += " --dir " + workDir
+	}
+	return generationErrorf("multi-stage parsing stopped: %w\n\nPartial plan saved to: %s\nFinish it with: %s --from-json %s --fill-gaps",
+		budgetErr, checkpointPath, command, checkpointPath)
+}
+
+// enforceItemCaps applies --max-tasks-per-epic and --max-subtasks-per-task. Over
+// the caps, --strict fails; otherwise each violation is a warning and, with
+// --drop-overflow, the extra items are removed.
+func enforceItemCaps(response *core.ParseResponse, warnings *core.WarningCollector) error {
+	violations := core.CheckItemCaps(response, maxTasks, maxSubtasks)
+	if len(violations) == 0 {
+		return nil
+	}
+	if strict {
+		return validationErrorf("%d items exceed the task/subtask caps (--strict) - nothing was created: %s", len(violations), violations[0])
+	}
+	for _, v := range violations {
+		warnings.Add(core.WarnItemCap, v.ItemID, "has %d %ss (max %d)", v.Count, v.Level, v.Max)
+	}
+	if dropOverflow {
+		dropped := core.TrimToCaps(response, maxTasks, maxSubtasks)
+		warnings.Add(core.WarnItemCap, "", "dropped %d items over the caps: %s", len(dropped), strings.Join(dropped, ", "))
+	}
+	return nil
+}
+
+// enforceFoundationDeps makes every feature epic depend on the foundation
+// epic, as the generation prompts require; under --strict a missing
+// dependency fails the run instead. Plans without a foundation epic are left
+// alone.
+func enforceFoundationDeps(response *core.ParseResponse) error {
+	missing := core.MissingFoundationDeps(response)
+	if len(missing) == 0 {
+		return nil
+	}
+	foundation := response.Epics[0].TempID
+	if strict {
+		return validationErrorf("%d feature epics don't depend on foundation epic %s (--strict) - nothing was created: %s",
+			len(missing), foundation, strings.Join(missing, ", "))
+	}
+	added := core.AddFoundationDeps(response)
+	fmt.Printf("Added a dependency on foundation epic %s to %d epics: %s\n", foundation, len(added), strings.Join(added, ", "))
+	return nil
+}
+
+// checkDependencies fails under --strict-deps when depends_on entries name
+// temp_ids that aren't in the plan; otherwise each is a warning, and the
+// output adapter skips it.
+func checkDependencies(response *core.ParseResponse, warnings *core.WarningCollector) error {
+	if strictDeps {
+		if err := response.ValidateDependencies(); err != nil {
+			return validationErrorf("plan has dangling dependencies (--strict-deps) - nothing was created: %w", err)
+		}
+		return nil
+	}
+	for _, u := range response.UnresolvedDependencies() {
+		warnings.Add(core.WarnDanglingDependency, u.ItemID, "depends on %s, which is not in the plan - the dependency will be skipped", u.DependsOn)
+	}
+	return nil
+}
+
+// checkPromptSize warns, or fails under --strict, when a prompt that carries
+// the PRD likely exceeds its model's context window: the single-shot prompt,
+// or Stage 1 in multi-stage mode, plus with --full-context the Stage 2 and 3
+// prompts, which each carry the PRD up to that stage's limit. Those are sized
+// before generation, with an empty epic and task standing in for the largest.
+func checkPromptSize(prd string, config core.ParseConfig, multiStage bool, warnings *core.WarningCollector) error {
+	// The summary pre-pass chunks the PRD, so no single prompt holds all of it
+	if config.Summarize {
+		return nil
+	}
+	type promptCheck struct {
+		model, system, user, suggestion string
+	}
+	stageModel := func(model string) string {
+		if model != "" {
+			return model
+		}
+		return llmModel
+	}
+
+	checks := []promptCheck{{
+		model:      llmModel,
+		system:     core.SystemPromptFor(core.SystemPrompt, config),
+		user:       core.BuildUserPrompt(prd, config),
+		suggestion: "use --multi-stage, or split the PRD into smaller documents",
+	}}
+	if multiStage {
+		checks = []promptCheck{{
+			model:      stageModel(epicModel),
+			system:     core.SystemPromptFor(core.Stage1SystemPrompt, config),
+			user:       core.BuildStage1Prompt(prd, config),
+			suggestion: "Stage 1 sends the whole PRD; use --summarize, or split it into smaller documents",
+		}}
+		if config.FullContext {
+			checks = append(checks, promptCheck{
+				model:      stageModel(taskModel),
+				system:     core.SystemPromptFor(core.Stage2SystemPrompt, config),
+				user:       core.BuildStage2PromptWithPRD(core.Epic{}, core.ProjectContext{}, config, prd),
+				suggestion: "--full-context sends the PRD with every Stage 2 call; use --full-context=false, or use a larger --task-model",
+			}, promptCheck{
+				model:      stageModel(subtaskModel),
+				system:     core.SystemPromptFor(core.Stage3SystemPrompt, config),
+				user:       core.BuildStage3PromptWithPRD(core.Task{}, "", core.ProjectContext{}, config, prd),
+				suggestion: "--full-context sends the PRD with every Stage 3 call; use --full-context=false, or use a larger --subtask-model",
+			})
+		}
+	}
+
+	for _, check := range checks {
+		issue := core.CheckPromptSize(check.model, check.system, check.user, responseMaxTokens())
+		if issue == nil {
+			continue
+		}
+		if strict {
+			return validationErrorf("%s (--strict) - %s", issue, check.suggestion)
+		}
+		warnings.Add(core.WarnPromptTooLarge, "", "%s - %s", issue, check.suggestion)
+	}
+	return nil
+}
+
+// chooseMultiStage decides between multi-stage and single-shot parsing for a PRD
+// with lineCount lines, and returns a message explaining the choice.
+// Explicit flags take precedence over smart detection.
+func chooseMultiStage(lineCount int) (bool, string) {
+	switch {
+	case forceSingleCall:
+		return false, fmt.Sprintf("PRD has %d lines - forcing a single LLM call (--force-single-call)", lineCount)
+	case singleShot:
+		return false, "Forcing single-shot parsing"
+	case summarize:
+		return true, "Summarizing the PRD first - using multi-stage parsing (--summarize)"
+	case epicCosts:
+		return true, "Attributing cost to epics - using multi-stage parsing (--epic-costs)"
+	case multiStage:
+		return true, "Forcing multi-stage parsing"
+	case smartParseLines > 0 && lineCount > smartParseLines:
+		// Smart detection: use multi-stage for large PRDs
+		return true, fmt.Sprintf("PRD has %d lines (> %d threshold) - using multi-stage parsing", lineCount, smartParseLines)
+	case smartParseLines > 0:
+		return false, fmt.Sprintf("PRD has %d lines - using single-shot parsing", lineCount)
+	default:
+		return false, ""
+	}
+}
+
+// applyInferredDeps adds inferred task dependencies that meet
+// --infer-deps-threshold and reports the weaker ones: one warning per link in
+// the --json-summary, otherwise one warning with their count, since a large
+// plan can have dozens.
+func applyInferredDeps(response *core.ParseResponse, warnings *core.WarningCollector) {
+	applied, suggested := core.ApplyInferredDependencies(response, core.InferDependencies(response), inferThreshold)
+	if len(applied) > 0 {
+		fmt.Printf("Inferred %d dependencies:\n", len(applied))
+		for _, d := range applied {
+			fmt.Printf("  %s %s\n", tui.Sym.Bullet, d)
+		}
+	}
+	if len(suggested) == 0 {
+		return
+	}
+	if !jsonSummary {
+		warnings.Add(core.WarnInferredDependency, "", "%d inferred dependencies below confidence %.2f not applied (--json-summary lists them)",
+			len(suggested), inferThreshold)
+		return
+	}
+	for _, d := range suggested {
+		warnings.Add(core.WarnInferredDependency, d.ItemID, "may depend on %s (confidence %.2f, below %.2f; mentions %s) - not applied",
+			d.DependsOn, d.Confidence, inferThreshold, strings.Join(d.Matches, ", "))
+	}
+}
+
+// readPRD reads the PRD at path, rejecting binary and non-UTF-8 files with a
+// usage error before anything is sent to the LLM.
+func readPRD(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, usageErrorf("failed to read PRD: %w", err)
+	}
+	if err := core.CheckPRDText(data); err != nil {
+		return nil, usageErrorf("PRD %s is not usable: %w", path, err)
+	}
+	return data, nil
+}
+
+// runScan runs only Stage 1 (epic extraction) and prints the proposed epics.
+// Cheap PRD triage: no tasks, subtasks, review, or item creation.
+func runScan(prdPath string) error {
+	prdContent, err := readPRD(prdPath)
+	if err != nil {
+		return err
+	}
+	generator, err := newStageGenerator("scan")
+	if err != nil {
+		return err
+	}
+	parser := core.NewMultiStageParser(generator, buildParseConfig())
+
+	epicsResp, err := parser.Scan(context.Background(), string(prdContent))
+	if err != nil {
+		return generationErrorf("scan failed: %w", err)
+	}
+
+	fmt.Printf("\nScan complete: %d epics proposed for %s\n", len(epicsResp.Epics), epicsResp.Project.ProductName)
+	fmt.Println("Run without --scan to generate the full breakdown.")
+	return nil
+}
+
+// runContextOnly extracts just the project context from the PRD, without epics,
+// and prints it as {"project": ...} JSON, also writing it to --save-json if set.
+func runContextOnly(prdPath string) error {
+	prdContent, err := readPRD(prdPath)
+	if err != nil {
+		return err
+	}
+	generator, err := newStageGenerator("context extraction")
+	if err != nil {
+		return err
+	}
+	project, err := core.ExtractProjectContext(context.Background(), generator, string(prdContent), buildParseConfig())
+	if err != nil {
+		return generationErrorf("%w", err)
+	}
+	return writeProjectContext(os.Stdout, project, saveJSON)
+}
+
+// writeProjectContext prints project as {"project": ...} JSON to w and, if
+// path is set, saves the same JSON there.
+func writeProjectContext(w io.Writer, project *core.ProjectContext, path string) error {
+	data, err := json.MarshalIndent(struct {
+		Project *core.ProjectContext `json:"project"`
+	}{project}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal project context: %w", err)
+	}
+	fmt.Fprintln(w, string(data))
+	for _, issue := range core.CheckProjectContext(project) {
+		fmt.Fprintf(os.Stderr, "%s project context: %s\n", tui.Sym.Warn, issue)
+	}
+	if path == "" {
+		return nil
+	}
+	if err := writeCheckpoint(path, data); err != nil {
+		return outputErrorf("failed to save project context: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Project context saved to: %s\n", path)
+	return nil
+}
+
+// generateCheckpointSubtasks runs Stage 3 on a loaded checkpoint: for every
+// task when all is set (--tasks-from-json), otherwise only for tasks without
+// subtasks (--fill-gaps), after running Stage 2 for epics without tasks. With
+// --save-json the merged plan is written back out.
+func generateCheckpointSubtasks(ctx context.Context, response *core.ParseResponse, prdPath string, all bool, warnings *core.WarningCollector) error {
+	var emptyEpics []string
+	targets := core.TasksMissingSubtasks(response)
+	generate := core.FillSubtaskGaps
+	if !all {
+		emptyEpics = core.EpicsMissingTasks(response)
+	} else {
+		targets = nil
+		_ = core.WalkItems(response, func(item core.ItemRef) error {
+			if item.Level == core.LevelTask {
+				targets = append(targets, item.TempID())
+			}
+			return nil
+		})
+		generate = core.GenerateAllSubtasks
+	}
+	if len(emptyEpics) == 0 && len(targets) == 0 {
+		fmt.Println("No tasks need subtasks")
+		return nil
+	}
+	generator, err := newStageGenerator("subtask generation")
+	if err != nil {
+		return err
+	}
 
-	if len(createResult.Failed) > 0 {
-		fmt.Printf("\nFailed to create %d items:\n", len(createResult.Failed))
-		for _, f := range createResult.Failed {
-			fmt.Printf("  - %v: %s\n", f.Item, f.Error)
+	config := buildParseConfig()
+	config.Warnings = warnings
+
+	// The PRD is optional when resuming; it's only sent with --full-context
+	prd := ""
+	if config.FullContext {
+		if data, err := os.ReadFile(prdPath); err == nil {
+			prd = string(data)
 		}
 	}
 
+	filled := 0
+	if len(emptyEpics) > 0 {
+		fmt.Printf("Generating tasks for %d epics: %s\n", len(emptyEpics), strings.Join(emptyEpics, ", "))
+		epics := core.FillTaskGaps(ctx, generator, response, config, prd)
+		fmt.Printf("Filled %d of %d epics\n", len(epics), len(emptyEpics))
+		filled += len(epics)
+		targets = core.TasksMissingSubtasks(response)
+	}
+	if len(targets) > 0 {
+		fmt.Printf("Generating subtasks for %d tasks: %s\n", len(targets), strings.Join(targets, ", "))
+		tasks := generate(ctx, generator, response, config, prd)
+		fmt.Printf("Filled %d of %d tasks\n", len(tasks), len(targets))
+		filled += len(tasks)
+	}
+
+	if saveJSON != "" && filled > 0 {
+		data, err := marshalCheckpoint(response)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		if err := writeCheckpoint(saveJSON, data); err != nil {
+			return outputErrorf("failed to save checkpoint: %w", err)
+		}
+		fmt.Printf("Updated checkpoint: %s\n", saveJSON)
+	}
 	return nil
 }
 
+// buildParseConfig builds the core parse config from flags. Each call starts a
+// fresh --retry-budget.
+func buildParseConfig() core.ParseConfig {
+	config := core.ParseConfig{
+		TargetEpics:      targetEpics,
+		TasksPerEpic:     tasksPerEpic,
+		SubtasksPerTask:  subtasksPerTask,
+		DefaultPriority:  core.Priority(defaultPriority),
+		TestingLevel:     testingLevel,
+		PropagateContext: true,
+		FullContext:      fullContext,
+		Summarize:        summarize,
+		SourceHints:      sourceHints,
+		RequirementIDs:   requirementIDs || outputAdapter == "traceability",
+		Assumptions:      assumptions,
+		NoTesting:        noTesting,
+		OrderedSubtasks:  orderedSubtasks,
+		FixedConcurrency: fixedParallel,
+		Instructions:     instructions,
+		Glossary:         glossary,
+		PromptVersion:    promptVersion,
+		TempDir:          tempDir(),
+	}
+	if retryBudget > 0 {
+		config.RetryBudget = core.NewRetryBudget(retryBudget)
+	}
+	return config
+}
+
+// buildMultiStageLLMConfig builds the LLM config for multi-stage generation from flags.
+func buildMultiStageLLMConfig() llm.Config {
+	return llm.Config{
+		Model:         llmModel,
+		EpicModel:     epicModel,
+		TaskModel:     taskModel,
+		SubtaskModel:  subtaskModel,
+		PreferCLI:     true,
+		UseCLIContext: useCLIContext,
+		Progress:      progressMode,
+		MaxTokens:     responseMaxTokens(),
+		TempDir:       tempDir(),
+	}
+}
+
 // Config file structure
 type configFileData struct {
 	LLM             string `yaml:"llm"`
@@ -349,21 +1243,82 @@ type configFileData struct {
 	EpicModel       string `yaml:"epic_model"`
 	TaskModel       string `yaml:"task_model"`
 	SubtaskModel    string `yaml:"subtask_model"`
+	MaxTokens       int    `yaml:"max_tokens"`
 	Epics           int    `yaml:"epics"`
 	TasksPerEpic    int    `yaml:"tasks_per_epic"`
 	SubtasksPerTask int    `yaml:"subtasks_per_task"`
+	MaxTasks        int    `yaml:"max_tasks_per_epic"`
+	MaxSubtasks     int    `yaml:"max_subtasks_per_task"`
 	Priority        string `yaml:"priority"`
+	EpicPriority    string `yaml:"epic_priority"`
+	EstimateFormat  string `yaml:"estimate_format"`
+	AcceptanceFmt   string `yaml:"acceptance_format"`
+	PromptVersion   string `yaml:"prompt_version"`
 	Testing         string `yaml:"testing"`
 	Output          string `yaml:"output"`
+	Dir             string `yaml:"dir"`
+	Glossary        string `yaml:"glossary"`
+
+	BeadsFields   map[string]string `yaml:"beads_fields"`
+	LabelColors   map[string]string `yaml:"label_colors"`
+	AppendLabels  []string          `yaml:"append_labels"`
+	ContextLevels []string          `yaml:"context_levels"`
+
+	Profiles map[string]configProfile `yaml:"profiles"`
+}
+
+// configProfile is a named set of structure targets in the config file,
+// selected with --profile. Set values replace the base config's.
+type configProfile struct {
+	Epics           int `yaml:"epics"`
+	TasksPerEpic    int `yaml:"tasks_per_epic"`
+	SubtasksPerTask int `yaml:"subtasks_per_task"`
+	MaxTasks        int `yaml:"max_tasks_per_epic"`
+	MaxSubtasks     int `yaml:"max_subtasks_per_task"`
+}
+
+// applyProfile layers the named profile over cfg. An empty name leaves cfg as it is.
+func (cfg *configFileData) applyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	profile, ok := cfg.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(cfg.Profiles))
+		for n := range cfg.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return fmt.Errorf("unknown profile %q: the config file defines no profiles", name)
+		}
+		return fmt.Errorf("unknown profile %q (config file defines %s)", name, strings.Join(names, ", "))
+	}
+	if profile.Epics > 0 {
+		cfg.Epics = profile.Epics
+	}
+	if profile.TasksPerEpic > 0 {
+		cfg.TasksPerEpic = profile.TasksPerEpic
+	}
+	if profile.SubtasksPerTask > 0 {
+		cfg.SubtasksPerTask = profile.SubtasksPerTask
+	}
+	if profile.MaxTasks > 0 {
+		cfg.MaxTasks = profile.MaxTasks
+	}
+	if profile.MaxSubtasks > 0 {
+		cfg.MaxSubtasks = profile.MaxSubtasks
+	}
+	return nil
 }
 
 func loadConfig(cmd *cobra.Command) error {
 	// Find config file
 	configPath := configFile
 	if configPath == "" {
-		// Check .prd-parser.yaml in current dir
-		if _, err := os.Stat(".prd-parser.yaml"); err == nil {
-			configPath = ".prd-parser.yaml"
+		// Check .prd-parser.yaml in the working dir
+		if _, err := os.Stat(inWorkDir(".prd-parser.yaml")); err == nil {
+			configPath = inWorkDir(".prd-parser.yaml")
 		} else if home, err := os.UserHomeDir(); err == nil {
 			// Check ~/.prd-parser.yaml
 			homePath := filepath.Join(home, ".prd-parser.yaml")
@@ -374,6 +1329,10 @@ func loadConfig(cmd *cobra.Command) error {
 	}
 
 	if configPath == "" {
+		if profileName != "" {
+			return fmt.Errorf("--profile %s needs a config file that defines it", profileName)
+		}
+		applyEnvDefaults(cmd)
 		return nil // No config file, use defaults
 	}
 
@@ -386,8 +1345,11 @@ func loadConfig(cmd *cobra.Command) error {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return fmt.Errorf("failed to parse config file: %w", err)
 	}
+	if err := cfg.applyProfile(profileName); err != nil {
+		return err
+	}
 
-	fmt.Printf("Loaded config from: %s\n", configPath)
+	fmt.Fprintf(os.Stderr, "Loaded config from: %s\n", configPath) // Off stdout, which may carry a gzip stream
 
 	// Apply config values only if flags weren't explicitly set
 	if !cmd.Flags().Changed("llm") && cfg.LLM != "" {
@@ -405,6 +1367,9 @@ func loadConfig(cmd *cobra.Command) error {
 	if !cmd.Flags().Changed("subtask-model") && cfg.SubtaskModel != "" {
 		subtaskModel = cfg.SubtaskModel
 	}
+	if !cmd.Flags().Changed("max-tokens") && cfg.MaxTokens != 0 {
+		maxTokens = cfg.MaxTokens
+	}
 	if !cmd.Flags().Changed("epics") && cfg.Epics > 0 {
 		targetEpics = cfg.Epics
 	}
@@ -414,23 +1379,109 @@ func loadConfig(cmd *cobra.Command) error {
 	if !cmd.Flags().Changed("subtasks") && cfg.SubtasksPerTask > 0 {
 		subtasksPerTask = cfg.SubtasksPerTask
 	}
+	if !cmd.Flags().Changed("max-tasks-per-epic") && cfg.MaxTasks > 0 {
+		maxTasks = cfg.MaxTasks
+	}
+	if !cmd.Flags().Changed("max-subtasks-per-task") && cfg.MaxSubtasks > 0 {
+		maxSubtasks = cfg.MaxSubtasks
+	}
 	if !cmd.Flags().Changed("priority") && cfg.Priority != "" {
 		defaultPriority = cfg.Priority
 	}
+	if !cmd.Flags().Changed("epic-priority") && cfg.EpicPriority != "" {
+		epicPriority = cfg.EpicPriority
+	}
+	if !cmd.Flags().Changed("estimate-format") && cfg.EstimateFormat != "" {
+		estimateFormat = cfg.EstimateFormat
+	}
+	if !cmd.Flags().Changed("acceptance-format") && cfg.AcceptanceFmt != "" {
+		acceptanceFmt = cfg.AcceptanceFmt
+	}
+	if !cmd.Flags().Changed("prompt-version") && cfg.PromptVersion != "" {
+		promptVersion = cfg.PromptVersion
+	}
 	if !cmd.Flags().Changed("testing") && cfg.Testing != "" {
 		testingLevel = cfg.Testing
 	}
 	if !cmd.Flags().Changed("output") && cfg.Output != "" {
 		outputAdapter = cfg.Output
 	}
+	if !cmd.Flags().Changed("beads-field") && len(cfg.BeadsFields) > 0 {
+		beadsFields = cfg.BeadsFields
+	}
+	if !cmd.Flags().Changed("append-labels") && len(cfg.AppendLabels) > 0 {
+		appendLabels = cfg.AppendLabels
+	}
+	if !cmd.Flags().Changed("label-color") && len(cfg.LabelColors) > 0 {
+		labelColors = cfg.LabelColors
+	}
+	if !cmd.Flags().Changed("context-levels") && len(cfg.ContextLevels) > 0 {
+		contextLevels = cfg.ContextLevels
+	}
+	if !cmd.Flags().Changed("dir") && cfg.Dir != "" {
+		workDir = cfg.Dir
+	}
+	if !cmd.Flags().Changed("glossary") && cfg.Glossary != "" {
+		glossaryFile = cfg.Glossary
+	}
+
+	applyEnvDefaults(cmd)
+	return nil
+}
+
+// Environment variables that set the default provider and model.
+const (
+	envProvider = "PRD_PARSER_LLM"
+	envModel    = "PRD_PARSER_MODEL"
+)
+
+// applyEnvDefaults applies PRD_PARSER_LLM and PRD_PARSER_MODEL. They override the
+// config file but not explicit flags: flag > env > config file > built-in default.
+func applyEnvDefaults(cmd *cobra.Command) {
+	if v := os.Getenv(envProvider); v != "" && !cmd.Flags().Changed("llm") {
+		llmProvider = v
+	}
+	if v := os.Getenv(envModel); v != "" && !cmd.Flags().Changed("model") {
+		llmModel = v
+	}
+}
 
+// noLLMHint tells users without an LLM what still works: everything that
+// reads a saved plan instead of generating one.
+const noLLMHint = "lint, explain, and parse --from-json work without an LLM"
+
+// requireClaudeCLI fails early, before any output is created, when the claude
+// CLI that multi-stage generation runs is not installed. --llm doesn't apply
+// to these passes, so Codex and the API aren't offered as alternatives.
+func requireClaudeCLI(purpose string) error {
+	if !llm.NewClaudeCLIAdapter(llm.Config{}).IsAvailable() {
+		return generationErrorf("%s requires the Claude CLI - install Claude Code; %s", purpose, noLLMHint)
+	}
 	return nil
 }
 
+// newStageGenerator creates the generator for multi-stage generation, failing
+// early when the Claude CLI is missing (see requireClaudeCLI). Tests replace it
+// to generate without an LLM.
+var newStageGenerator = func(purpose string) (core.Generator, error) {
+	if err := requireClaudeCLI(purpose); err != nil {
+		return nil, err
+	}
+	return llm.NewMultiStageGenerator(buildMultiStageLLMConfig()), nil
+}
+
 func createLLMAdapter() (llm.Adapter, error) {
 	config := llm.Config{
-		Model:     llmModel,
-		PreferCLI: true,
+		Model:         llmModel,
+		PreferCLI:     true,
+		UseCLIContext: useCLIContext,
+		Progress:      progressMode,
+		MaxTokens:     responseMaxTokens(),
+		TempDir:       tempDir(),
+		PromptVersion: promptVersion,
+	}
+	if !slices.Contains(llm.ProviderNames, llmProvider) {
+		return nil, fmt.Errorf("unknown LLM provider: %s (use %s)", llmProvider, strings.Join(llm.ProviderNames, ", "))
 	}
 
 	switch llmProvider {
@@ -455,12 +1506,92 @@ func createLLMAdapter() (llm.Adapter, error) {
 	}
 }
 
-func createOutputAdapter() (output.Adapter, output.Config, error) {
+// gzipToStdout reports whether the JSON adapter streams compressed output to
+// stdout: --gzip with no --output-path, outside a dry run.
+func gzipToStdout() bool {
+	return outputAdapter == "json" && gzipOutput && outputPath == "" && !dryRun
+}
+
+// createOutputAdapter creates the --output adapter. stdout is where the JSON
+// adapter streams gzip output (nil = os.Stdout).
+func createOutputAdapter(warnings *core.WarningCollector, stdout io.Writer) (output.Adapter, output.Config, error) {
 	config := output.Config{
-		WorkingDir:     ".",
-		DryRun:         dryRun,
-		IncludeContext: true,
-		IncludeTesting: true,
+		WorkingDir:       workingDir(),
+		TempDir:          tempDir(),
+		DryRun:           dryRun,
+		IncludeContext:   true,
+		IncludeTesting:   !noTesting,
+		Warnings:         warnings,
+		IDScheme:         idScheme,
+		BeadsBulk:        beadsBulk,
+		CreateStrategy:   createStrategy,
+		EstimateFormat:   estimateFormat,
+		AcceptanceFormat: acceptanceFmt,
+		BeadsFields:      beadsFields,
+		LabelColors:      labelColors,
+		Gzip:             gzipOutput,
+		Tee:              teeOutput,
+		Stdout:           stdout,
+		JSONCase:         jsonCase,
+		DepsFormat:       depsFormat,
+		GitHubRepo:       githubRepo,
+		GitHubProject:    githubProject,
+	}
+	if err := output.ValidateFieldMap(beadsFields); err != nil {
+		return nil, config, err
+	}
+	if err := output.ValidateLabelColors(labelColors); err != nil {
+		return nil, config, err
+	}
+	for _, level := range contextLevels {
+		switch level {
+		case core.LevelEpic:
+			config.IncludeContextEpics = true
+		case core.LevelTask:
+			config.IncludeContextTasks = true
+		case core.LevelSubtask:
+			config.IncludeContextSubtasks = true
+		default:
+			return nil, config, fmt.Errorf("unknown context level: %s (use epic, task, or subtask)", level)
+		}
+	}
+	if !output.ValidJSONCase(jsonCase) {
+		return nil, config, fmt.Errorf("unknown JSON case: %s (use snake or camel)", jsonCase)
+	}
+	if !output.ValidDependenciesFormat(depsFormat) {
+		return nil, config, fmt.Errorf("unknown dependencies format: %s (use list, adjacency, or nested)", depsFormat)
+	}
+	if !output.ValidIDScheme(idScheme) {
+		return nil, config, fmt.Errorf("unknown ID scheme: %s (use ets, dotted, or auto)", idScheme)
+	}
+	if !output.ValidCreateStrategy(createStrategy) {
+		return nil, config, fmt.Errorf("unknown create strategy: %s (use phases or waves)", createStrategy)
+	}
+	if !output.ValidEstimateFormat(estimateFormat) {
+		return nil, config, fmt.Errorf("unknown estimate format: %s (use minutes or hm)", estimateFormat)
+	}
+	if !output.ValidAcceptanceFormat(acceptanceFmt) {
+		return nil, config, fmt.Errorf("unknown acceptance format: %s (use bulleted, numbered, or lines)", acceptanceFmt)
+	}
+	config.EpicPriority = core.Priority(epicPriority)
+	if epicPriority == "default" {
+		config.EpicPriority = core.Priority(defaultPriority)
+	}
+	if !output.ValidPriority(config.EpicPriority) {
+		return nil, config, fmt.Errorf("unknown epic priority: %s (use critical, high, medium, low, very-low, or default)", epicPriority)
+	}
+
+	if !slices.Contains(output.AdapterNames, outputAdapter) {
+		return nil, config, fmt.Errorf("unknown output adapter: %s (use %s)", outputAdapter, strings.Join(output.AdapterNames, ", "))
+	}
+	if teeOutput && outputAdapter != "json" {
+		return nil, config, fmt.Errorf("--tee only works with --output json")
+	}
+	if (githubRepo != "" || githubProject != 0) && outputAdapter != "github" {
+		return nil, config, fmt.Errorf("--github-repo and --github-project only work with --output github")
+	}
+	if githubProject < 0 {
+		return nil, config, fmt.Errorf("--github-project must be a positive project number")
 	}
 
 	switch outputAdapter {
@@ -472,7 +1603,25 @@ func createOutputAdapter() (output.Adapter, output.Config, error) {
 		}
 		return adapter, config, nil
 	case "json":
+		if teeOutput && outputPath == "" {
+			return nil, config, fmt.Errorf("--tee requires --output-path (without it, output already goes to stdout)")
+		}
 		return output.NewJSONAdapter(config, outputPath), config, nil
+	case "traceability":
+		return output.NewTraceabilityAdapter(config, outputPath), config, nil
+	case "markdown":
+		return output.NewMarkdownAdapter(config, outputPath), config, nil
+	case "csv":
+		return output.NewCSVAdapter(config, outputPath), config, nil
+	case "github":
+		if githubRepo == "" {
+			return nil, config, fmt.Errorf("--output github requires --github-repo owner/name")
+		}
+		adapter := output.NewGitHubAdapter(config)
+		if available, err := adapter.IsAvailable(); !available {
+			return nil, config, fmt.Errorf("GitHub not available: %w", err)
+		}
+		return adapter, config, nil
 	default:
 		return nil, config, fmt.Errorf("unknown output adapter: %s", outputAdapter)
 	}
@@ -482,8 +1631,12 @@ func createOutputAdapter() (output.Adapter, output.Config, error) {
 // This bridges the interface difference where output.Adapter.CreateItems takes
 // a Config parameter but core.OutputAdapter.CreateItems does not.
 type outputAdapterWrapper struct {
-	adapter output.Adapter
-	config  output.Config
+	adapter      output.Adapter
+	config       output.Config
+	docPath      string   // Optional Markdown record of the created plan
+	appendLabels []string // Added to every item before creation
+
+	result *output.CreateResult // Result of the last CreateItems, for --timing
 }
 
 func (w *outputAdapterWrapper) Name() string {
@@ -495,10 +1648,22 @@ func (w *outputAdapterWrapper) IsAvailable() (bool, error) {
 }
 
 func (w *outputAdapterWrapper) CreateItems(response *core.ParseResponse) (*core.OutputCreateResult, error) {
+	core.AppendLabels(response, w.appendLabels)
+
 	result, err := w.adapter.CreateItems(response, w.config)
 	if err != nil {
 		return nil, err
 	}
+	w.result = result
+
+	// Write the plan doc with the real external IDs assigned during creation
+	if w.docPath != "" {
+		if err := output.WritePlanDoc(w.docPath, response, result, w.adapter.Name()); err != nil {
+			w.config.Warnings.Add(core.WarnDocWriteFailed, "", "%v", err)
+		} else {
+			fmt.Printf("Plan doc written to: %s\n", w.docPath)
+		}
+	}
 
 	// Convert output.CreateResult to core.OutputCreateResult
 	coreResult := &core.OutputCreateResult{}
@@ -506,6 +1671,10 @@ func (w *outputAdapterWrapper) CreateItems(response *core.ParseResponse) (*core.
 	coreResult.Stats.Tasks = result.Stats.Tasks
 	coreResult.Stats.Subtasks = result.Stats.Subtasks
 	coreResult.Stats.Dependencies = result.Stats.Dependencies
+	coreResult.ExternalIDs = make(map[string]string, len(result.Created))
+	for _, item := range result.Created {
+		coreResult.ExternalIDs[item.TempID] = item.ExternalID
+	}
 
 	for _, f := range result.Failed {
 		coreResult.Failed = append(coreResult.Failed, struct {
@@ -520,25 +1689,88 @@ func (w *outputAdapterWrapper) CreateItems(response *core.ParseResponse) (*core.
 	return coreResult, nil
 }
 
-// runValidation runs the validation pass on the generated plan.
-func runValidation(ctx context.Context, response *core.ParseResponse, prdContent string, model string) (*core.ValidationResult, error) {
-	// Use the same model as parsing, or default
-	if model == "" {
-		model = "claude-sonnet-4-20250514"
+// marshalCheckpoint encodes a canonical copy of response for --save-json and
+// the checkpoints, so regenerating an equivalent plan produces a clean diff.
+// response is unchanged.
+func marshalCheckpoint(response *core.ParseResponse) ([]byte, error) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	var checkpoint core.ParseResponse
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, err
 	}
+	core.Canonicalize(&checkpoint)
+	return json.MarshalIndent(&checkpoint, "", "  ")
+}
 
-	// Build validation prompt
-	userPrompt := core.BuildValidationPrompt(response, prdContent)
+// writeCheckpointData writes checkpoint bytes to the temp file; tests replace
+// it to simulate an interrupted write.
+var writeCheckpointData = func(f *os.File, data []byte) error {
+	_, err := f.Write(data)
+	return err
+}
+
+// writeCheckpoint writes data to path atomically: it goes to a temp file in
+// the same directory that is renamed into place only once fully written, so
+// an interrupted write leaves the previous file (or none), never a partial one.
+func writeCheckpoint(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if err := writeCheckpointData(tmp, data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// defaultPassModel is the model for the review, validation, estimates, and
+// gap-fix passes when --model isn't set.
+const defaultPassModel = "claude-sonnet-4-20250514"
 
-	// Call LLM for validation
-	config := llm.Config{Model: model, PreferCLI: true}
-	adapter := llm.NewClaudeCLIAdapter(config)
+// passAdapter returns the Claude CLI on model (defaultPassModel if empty) for
+// the pass named by purpose, or an error if the CLI isn't available.
+func passAdapter(model, purpose string) (*llm.ClaudeCLIAdapter, error) {
+	if model == "" {
+		model = defaultPassModel
+	}
+	adapter := llm.NewClaudeCLIAdapter(llm.Config{Model: model, PreferCLI: true, TempDir: tempDir()})
 	if !adapter.IsAvailable() {
-		return nil, fmt.Errorf("Claude CLI not available for validation")
+		return nil, fmt.Errorf("Claude CLI not available for %s", purpose)
 	}
+	return adapter, nil
+}
+
+// runValidation runs the validation pass on the generated plan.
+func runValidation(ctx context.Context, response *core.ParseResponse, prdContent string, model string) (*core.ValidationResult, error) {
+	adapter, err := passAdapter(model, "validation")
+	if err != nil {
+		return nil, err
+	}
+	return validateWith(ctx, response, prdContent, adapter)
+}
+
+// validateWith runs the validation prompt for response through gen and parses the result.
+func validateWith(ctx context.Context, response *core.ParseResponse, prdContent string, gen core.Reviewer) (*core.ValidationResult, error) {
+	userPrompt := core.BuildValidationPrompt(response, prdContent)
 
 	// Get raw output for validation
-	output, err := adapter.GenerateRaw(ctx, core.ValidationPrompt, userPrompt)
+	output, err := gen.GenerateRaw(ctx, core.ValidationPrompt, userPrompt)
 	if err != nil {
 		return nil, fmt.Errorf("validation LLM call failed: %w", err)
 	}
@@ -547,18 +1779,99 @@ func runValidation(ctx context.Context, response *core.ParseResponse, prdContent
 	return core.ParseValidationResult(output)
 }
 
-// runReview runs the review pass to check and fix structural issues.
-func runReview(ctx context.Context, response *core.ParseResponse, prdContent string, model string) (*core.ReviewResult, error) {
-	// Use the same model as parsing, or default
-	if model == "" {
-		model = "claude-sonnet-4-20250514"
+// fillMissingEstimates runs the --require-estimates pass: one LLM call that
+// estimates only the items without estimates, then refreshes --save-json.
+// Failure is a warning.
+func fillMissingEstimates(ctx context.Context, response *core.ParseResponse, model string, warnings *core.WarningCollector) {
+	missing := len(core.CheckEstimateCoverage(response).Missing)
+	if missing == 0 {
+		return
 	}
 
-	// Create adapter for review
-	config := llm.Config{Model: model, PreferCLI: true}
-	adapter := llm.NewClaudeCLIAdapter(config)
-	if !adapter.IsAvailable() {
-		return nil, fmt.Errorf("Claude CLI not available for review")
+	fmt.Printf("\nEstimating %d items without estimates...\n", missing)
+	adapter, err := passAdapter(model, "estimates")
+	if err != nil {
+		warnings.Add(core.WarnEstimatesFailed, "", "%v", err)
+		return
+	}
+	filled, err := core.FillEstimates(ctx, response, adapter)
+	if err != nil {
+		warnings.Add(core.WarnEstimatesFailed, "", "estimates pass failed: %v", err)
+		return
+	}
+	fmt.Printf("%s Filled %d of %d missing estimates\n", tui.Sym.Check, filled, missing)
+	if filled > 0 && saveJSON != "" {
+		if data, err := marshalCheckpoint(response); err == nil && writeCheckpoint(saveJSON, data) == nil {
+			fmt.Printf("Updated checkpoint: %s\n", saveJSON)
+		}
+	}
+}
+
+// fixValidationGaps runs the --fix-gaps pass on the gaps validation found in
+// response, printing what changed and refreshing --save-json, then validates
+// the fixed plan again. It returns the fixed plan and the gaps that second
+// validation still finds (all of gaps if it fails), or nil with a warning if
+// the gap fix failed.
+func fixValidationGaps(ctx context.Context, response *core.ParseResponse, prdContent string, gaps []string, warnings *core.WarningCollector) (*core.ParseResponse, []string) {
+	fmt.Println("\nClosing validation gaps...")
+	reviewer, err := gapFixer(llmModel)
+	if err != nil {
+		warnings.Add(core.WarnGapFixFailed, "", "%v", err)
+		return nil, gaps
+	}
+	result, err := core.FixGaps(ctx, response, prdContent, gaps, reviewer)
+	if err != nil {
+		warnings.Add(core.WarnGapFixFailed, "", "gap fix failed: %v", err)
+		return nil, gaps
+	}
+	if !result.WasModified || result.Changes.Empty() {
+		warnings.Add(core.WarnGapFixFailed, "", "gap fix made no changes: %s", result.ReviewNotes)
+		return nil, gaps
+	}
+
+	fmt.Printf("%s Gap fix applied: %s\n", tui.Sym.Check, result.ReviewNotes)
+	printReviewChanges(os.Stdout, result.Changes)
+	if saveJSON != "" {
+		data, err := marshalCheckpoint(result.Response)
+		if err == nil {
+			err = writeCheckpoint(saveJSON, data)
+		}
+		if err != nil {
+			warnings.Add(core.WarnCheckpointFailed, "", "gap fix not saved to %s: %v", saveJSON, err)
+		} else {
+			fmt.Printf("Updated checkpoint: %s\n", saveJSON)
+		}
+	}
+
+	fmt.Println("\nValidating the fixed plan...")
+	revalidated, err := validateWith(ctx, result.Response, prdContent, reviewer)
+	switch {
+	case err != nil:
+		warnings.Add(core.WarnValidationFailed, "", "validation of the fixed plan failed, so its gaps may remain: %v", err)
+		return result.Response, gaps
+	case revalidated.IsValid:
+		fmt.Println(tui.Sym.Check + " Fixed plan validation passed - no gaps found")
+		return result.Response, nil
+	default:
+		fmt.Println(tui.Sym.Warn + " Fixed plan still has gaps:")
+		for _, gap := range revalidated.Gaps {
+			fmt.Printf("  %s %s\n", tui.Sym.Bullet, gap)
+		}
+		return result.Response, revalidated.Gaps
+	}
+}
+
+// gapFixer returns the LLM for the --fix-gaps pass and its validation of the
+// fixed plan. Tests replace it.
+var gapFixer = func(model string) (core.Reviewer, error) {
+	return passAdapter(model, "gap fixing")
+}
+
+// runReview runs the review pass to check and fix structural issues.
+func runReview(ctx context.Context, response *core.ParseResponse, prdContent string, model string) (*core.ReviewResult, error) {
+	adapter, err := passAdapter(model, "review")
+	if err != nil {
+		return nil, err
 	}
 
 	// Run review
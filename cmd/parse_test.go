@@ -0,0 +1,852 @@
+package cmd
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+	"github.com/dhabedank/prd-parser/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// setStrategyFlags sets the parsing strategy flags for a test and restores them afterwards.
+func setStrategyFlags(t *testing.T, force, single, multi bool, threshold int) {
+	t.Helper()
+	oldForce, oldSingle, oldMulti, oldThreshold := forceSingleCall, singleShot, multiStage, smartParseLines
+	t.Cleanup(func() {
+		forceSingleCall, singleShot, multiStage, smartParseLines = oldForce, oldSingle, oldMulti, oldThreshold
+	})
+	forceSingleCall, singleShot, multiStage, smartParseLines = force, single, multi, threshold
+}
+
+func TestChooseMultiStage(t *testing.T) {
+	tests := []struct {
+		name      string
+		force     bool
+		single    bool
+		multi     bool
+		lineCount int
+		want      bool
+	}{
+		{"small PRD uses single-shot", false, false, false, 100, false},
+		{"large PRD uses multi-stage", false, false, false, 1000, true},
+		{"force single call on large PRD", true, false, false, 1000, false},
+		{"force single call on huge PRD", true, false, false, 100000, false},
+		{"single-shot wins over multi-stage", false, true, true, 1000, false},
+		{"multi-stage on small PRD", false, false, true, 10, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setStrategyFlags(t, tt.force, tt.single, tt.multi, 300)
+			got, _ := chooseMultiStage(tt.lineCount)
+			if got != tt.want {
+				t.Errorf("chooseMultiStage(%d) = %v, want %v", tt.lineCount, got, tt.want)
+			}
+		})
+	}
+}
+
+// captureStdout runs fn with os.Stdout redirected and returns what it printed.
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	runErr := fn()
+	os.Stdout = stdout
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out), runErr
+}
+
+func TestQuietLLMPrintsOnlySummary(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir) // no ~/.prd-parser.yaml
+	checkpoint := filepath.Join(dir, "plan.json")
+	plan := `{"project":{"product_name":"Demo"},"epics":[{"temp_id":"1","title":"Auth","tasks":[{"temp_id":"1.1","title":"Login","subtasks":[{"temp_id":"1.1.1","title":"Form"}]}]}]}`
+	if err := os.WriteFile(checkpoint, []byte(plan), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldFrom, oldOut, oldPath, oldQuiet, oldJSON := fromJSON, outputAdapter, outputPath, quietLLM, jsonSummary
+	t.Cleanup(func() {
+		fromJSON, outputAdapter, outputPath, quietLLM, jsonSummary = oldFrom, oldOut, oldPath, oldQuiet, oldJSON
+	})
+	fromJSON, outputAdapter, outputPath = checkpoint, "json", filepath.Join(dir, "tasks.json")
+
+	for _, quiet := range []bool{false, true} {
+		quietLLM, jsonSummary = quiet, true
+		out, err := captureStdout(t, func() error { return runParse(ParseCmd, []string{"prd.md"}) })
+		if err != nil {
+			t.Fatalf("runParse(quiet=%v) error = %v", quiet, err)
+		}
+
+		var summary parseSummary
+		jsonErr := json.Unmarshal([]byte(out), &summary)
+		if quiet {
+			if jsonErr != nil {
+				t.Fatalf("quiet output should be only the JSON summary, got %q", out)
+			}
+			if summary.Epics != 1 || summary.Tasks != 1 || summary.Subtasks != 1 {
+				t.Errorf("summary = %+v, want 1 epic, 1 task, 1 subtask", summary)
+			}
+		} else if !strings.Contains(out, "Resuming from checkpoint") {
+			t.Errorf("non-quiet output should include progress, got %q", out)
+		}
+	}
+}
+
+func TestGzipToStdoutKeepsStreamClean(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir) // no ~/.prd-parser.yaml
+	checkpoint := filepath.Join(dir, "plan.json")
+	plan := `{"project":{"product_name":"Demo"},"epics":[{"temp_id":"1","title":"Auth","tasks":[{"temp_id":"1.1","title":"Login","subtasks":[{"temp_id":"1.1.1","title":"Form"}]}]}]}`
+	if err := os.WriteFile(checkpoint, []byte(plan), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldFrom, oldOut, oldPath, oldGzip, oldJSON := fromJSON, outputAdapter, outputPath, gzipOutput, jsonSummary
+	t.Cleanup(func() {
+		fromJSON, outputAdapter, outputPath, gzipOutput, jsonSummary = oldFrom, oldOut, oldPath, oldGzip, oldJSON
+	})
+	fromJSON, outputAdapter, outputPath, gzipOutput, jsonSummary = checkpoint, "json", "", true, true
+
+	out, err := captureStdout(t, func() error { return runParse(ParseCmd, []string{"prd.md"}) })
+	if err != nil {
+		t.Fatalf("runParse() error = %v", err)
+	}
+	zr, err := gzip.NewReader(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("stdout is not gzip: %v", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("stdout is not just the gzip stream: %v", err)
+	}
+	var written core.ParseResponse
+	if err := json.Unmarshal(data, &written); err != nil || len(written.Epics) != 1 {
+		t.Errorf("decompressed stdout = %q, want the plan", data)
+	}
+}
+
+func TestWorkDirFlowsIntoOutputConfig(t *testing.T) {
+	oldDir, oldAdapter := workDir, outputAdapter
+	t.Cleanup(func() { workDir, outputAdapter = oldDir, oldAdapter })
+
+	dir := t.TempDir()
+	workDir, outputAdapter = dir, "json"
+	if err := validateWorkDir(); err != nil {
+		t.Fatalf("validateWorkDir() error = %v", err)
+	}
+	_, config, err := createOutputAdapter(nil, nil)
+	if err != nil {
+		t.Fatalf("createOutputAdapter() error = %v", err)
+	}
+	if config.WorkingDir != dir {
+		t.Errorf("WorkingDir = %q, want %q", config.WorkingDir, dir)
+	}
+	if got, want := inWorkDir("prd.md"), filepath.Join(dir, "prd.md"); got != want {
+		t.Errorf("inWorkDir() = %q, want %q", got, want)
+	}
+
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	workDir = file
+	if err := validateWorkDir(); err == nil {
+		t.Error("validateWorkDir() should reject a file")
+	}
+
+	workDir = ""
+	if _, config, _ := createOutputAdapter(nil, nil); config.WorkingDir != "." {
+		t.Errorf("WorkingDir = %q, want \".\" by default", config.WorkingDir)
+	}
+}
+
+func TestTempDirPrecedenceAndValidation(t *testing.T) {
+	oldFlag := tempDirFlag
+	t.Cleanup(func() { tempDirFlag = oldFlag })
+
+	flagDir, envDir := t.TempDir(), t.TempDir()
+	t.Setenv(envTempDir, envDir)
+	tempDirFlag = ""
+	if got := tempDir(); got != envDir {
+		t.Errorf("tempDir() = %q, want %s from the environment", got, envTempDir)
+	}
+	tempDirFlag = flagDir
+	if got := tempDir(); got != flagDir {
+		t.Errorf("tempDir() = %q, want --temp-dir over the environment", got)
+	}
+	if err := validateTempDir(); err != nil {
+		t.Fatalf("validateTempDir() error = %v", err)
+	}
+	if config := buildParseConfig(); config.TempDir != flagDir {
+		t.Errorf("ParseConfig.TempDir = %q, want %q", config.TempDir, flagDir)
+	}
+	if left, _ := os.ReadDir(flagDir); len(left) != 0 {
+		t.Errorf("validateTempDir() left files behind: %v", left)
+	}
+
+	tempDirFlag = filepath.Join(flagDir, "missing")
+	if err := validateTempDir(); err == nil {
+		t.Error("validateTempDir() should reject a missing directory")
+	}
+	file := filepath.Join(flagDir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tempDirFlag = file
+	if err := validateTempDir(); err == nil {
+		t.Error("validateTempDir() should reject a file")
+	}
+}
+
+func TestModelAndProviderPrecedence(t *testing.T) {
+	oldConfig, oldDir, oldProvider, oldModel := configFile, workDir, llmProvider, llmModel
+	t.Cleanup(func() { configFile, workDir, llmProvider, llmModel = oldConfig, oldDir, oldProvider, oldModel })
+
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	cfgPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(cfgPath, []byte("llm: codex-cli\nmodel: config-model\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		flag         bool
+		env          bool
+		config       bool
+		wantProvider string
+		wantModel    string
+	}{
+		{"built-in default", false, false, false, "auto", ""},
+		{"config file", false, false, true, "codex-cli", "config-model"},
+		{"env over config", false, true, true, "anthropic-api", "env-model"},
+		{"env without config", false, true, false, "anthropic-api", "env-model"},
+		{"flag over env and config", true, true, true, "claude-cli", "flag-model"},
+		{"flag over config", true, false, true, "claude-cli", "flag-model"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.Flags().StringVar(&llmProvider, "llm", "auto", "")
+			cmd.Flags().StringVar(&llmModel, "model", "", "")
+			workDir, configFile = dir, ""
+			if tt.config {
+				configFile = cfgPath
+			}
+			if tt.env {
+				t.Setenv(envProvider, "anthropic-api")
+				t.Setenv(envModel, "env-model")
+			} else {
+				t.Setenv(envProvider, "")
+				t.Setenv(envModel, "")
+			}
+			if tt.flag {
+				cmd.Flags().Set("llm", "claude-cli")
+				cmd.Flags().Set("model", "flag-model")
+			}
+
+			if err := loadConfig(cmd); err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+			if llmProvider != tt.wantProvider || llmModel != tt.wantModel {
+				t.Errorf("provider, model = %q, %q; want %q, %q", llmProvider, llmModel, tt.wantProvider, tt.wantModel)
+			}
+		})
+	}
+}
+
+func TestConfigProfiles(t *testing.T) {
+	oldConfig, oldDir, oldProfile := configFile, workDir, profileName
+	oldEpics, oldTasks, oldSubtasks := targetEpics, tasksPerEpic, subtasksPerTask
+	t.Cleanup(func() {
+		configFile, workDir, profileName = oldConfig, oldDir, oldProfile
+		targetEpics, tasksPerEpic, subtasksPerTask = oldEpics, oldTasks, oldSubtasks
+	})
+
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	cfgPath := filepath.Join(dir, "config.yaml")
+	cfg := "epics: 8\ntasks_per_epic: 6\nsubtasks_per_task: 3\nprofiles:\n  mobile:\n    epics: 5\n    tasks_per_epic: 4\n  web:\n    epics: 10\n"
+	if err := os.WriteFile(cfgPath, []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                           string
+		profile                        string
+		flagTasks                      bool
+		wantEpics, wantTasks, wantSubs int
+	}{
+		{"base config", "", false, 8, 6, 3},
+		{"profile over base", "mobile", false, 5, 4, 3},
+		{"flag over profile", "mobile", true, 5, 7, 3},
+		{"other profile", "web", false, 10, 6, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.Flags().IntVar(&targetEpics, "epics", 0, "")
+			cmd.Flags().IntVar(&tasksPerEpic, "tasks", 0, "")
+			cmd.Flags().IntVar(&subtasksPerTask, "subtasks", 0, "")
+			workDir, configFile, profileName = dir, cfgPath, tt.profile
+			if tt.flagTasks {
+				cmd.Flags().Set("tasks", "7")
+			}
+
+			if err := loadConfig(cmd); err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+			if targetEpics != tt.wantEpics || tasksPerEpic != tt.wantTasks || subtasksPerTask != tt.wantSubs {
+				t.Errorf("epics, tasks, subtasks = %d, %d, %d; want %d, %d, %d",
+					targetEpics, tasksPerEpic, subtasksPerTask, tt.wantEpics, tt.wantTasks, tt.wantSubs)
+			}
+		})
+	}
+
+	profileName = "desktop"
+	err := loadConfig(&cobra.Command{})
+	if err == nil || !strings.Contains(err.Error(), "mobile, web") {
+		t.Errorf("unknown profile error = %v, want it to list mobile, web", err)
+	}
+}
+
+func TestEnforceItemCaps(t *testing.T) {
+	oldTasks, oldSubtasks, oldStrict, oldDrop := maxTasks, maxSubtasks, strict, dropOverflow
+	t.Cleanup(func() { maxTasks, maxSubtasks, strict, dropOverflow = oldTasks, oldSubtasks, oldStrict, oldDrop })
+
+	plan := func() *core.ParseResponse {
+		return &core.ParseResponse{Epics: []core.Epic{{TempID: "1", Title: "Setup", Tasks: []core.Task{
+			{TempID: "1.1", Title: "Repo"}, {TempID: "1.2", Title: "CI"}, {TempID: "1.3", Title: "Deploy"},
+		}}}}
+	}
+	maxTasks, maxSubtasks = 2, 0
+
+	strict, dropOverflow = true, false
+	if err := enforceItemCaps(plan(), core.NewWarningCollector()); ExitCode(err) != ExitValidation {
+		t.Errorf("--strict: ExitCode = %d (err %v), want %d", ExitCode(err), err, ExitValidation)
+	}
+
+	strict = false
+	warnings := core.NewWarningCollector()
+	response := plan()
+	if err := enforceItemCaps(response, warnings); err != nil {
+		t.Fatalf("enforceItemCaps() error = %v", err)
+	}
+	if len(response.Epics[0].Tasks) != 3 || len(warnings.Warnings()) != 1 {
+		t.Errorf("without --drop-overflow: %d tasks, warnings %v; want 3 tasks and one warning", len(response.Epics[0].Tasks), warnings.Warnings())
+	}
+
+	dropOverflow = true
+	if err := enforceItemCaps(response, core.NewWarningCollector()); err != nil {
+		t.Fatalf("enforceItemCaps() error = %v", err)
+	}
+	if len(response.Epics[0].Tasks) != 2 {
+		t.Errorf("--drop-overflow left %d tasks, want 2", len(response.Epics[0].Tasks))
+	}
+}
+
+func TestEnforceFoundationDeps(t *testing.T) {
+	oldStrict := strict
+	t.Cleanup(func() { strict = oldStrict })
+
+	plan := func() *core.ParseResponse {
+		return &core.ParseResponse{Epics: []core.Epic{
+			{TempID: "1", Title: "Project Foundation"},
+			{TempID: "2", Title: "Accounts", DependsOn: []string{"1"}},
+			{TempID: "3", Title: "Checkout", DependsOn: []string{"2"}},
+			{TempID: "4", Title: "Search"},
+		}}
+	}
+
+	strict = true
+	err := enforceFoundationDeps(plan())
+	if ExitCode(err) != ExitValidation || !strings.Contains(err.Error(), "3, 4") {
+		t.Errorf("--strict: ExitCode = %d (err %v), want %d naming epics 3, 4", ExitCode(err), err, ExitValidation)
+	}
+
+	strict = false
+	response := plan()
+	if _, err := captureStdout(t, func() error { return enforceFoundationDeps(response) }); err != nil {
+		t.Fatalf("enforceFoundationDeps() error = %v", err)
+	}
+	want := [][]string{nil, {"1"}, {"2", "1"}, {"1"}}
+	for i, epic := range response.Epics {
+		if !reflect.DeepEqual(epic.DependsOn, want[i]) {
+			t.Errorf("epic %s depends_on = %v, want %v", epic.TempID, epic.DependsOn, want[i])
+		}
+	}
+}
+
+func TestCheckDependenciesWarnsOrFailsUnderStrictDeps(t *testing.T) {
+	oldStrictDeps := strictDeps
+	t.Cleanup(func() { strictDeps = oldStrictDeps })
+
+	response := &core.ParseResponse{Epics: []core.Epic{{TempID: "1", Title: "Setup", Tasks: []core.Task{
+		{TempID: "1.1", Title: "Repo"}, {TempID: "1.2", Title: "CI", DependsOn: []string{"1.1", "2.7"}},
+	}}}}
+
+	strictDeps = false
+	warnings := core.NewWarningCollector()
+	if err := checkDependencies(response, warnings); err != nil {
+		t.Fatalf("checkDependencies() error = %v", err)
+	}
+	if got := warnings.Warnings(); len(got) != 1 || got[0].Code != core.WarnDanglingDependency || got[0].Item != "1.2" || !strings.Contains(got[0].Message, "2.7") {
+		t.Errorf("warnings = %v, want one dangling_dependency for 1.2 -> 2.7", got)
+	}
+
+	strictDeps = true
+	err := checkDependencies(response, core.NewWarningCollector())
+	if ExitCode(err) != ExitValidation || !strings.Contains(err.Error(), "1.2 -> 2.7") {
+		t.Errorf("--strict-deps: ExitCode = %d (err %v), want %d naming 1.2 -> 2.7", ExitCode(err), err, ExitValidation)
+	}
+}
+
+func TestCheckPromptSizeWarnsOrFailsUnderStrict(t *testing.T) {
+	oldStrict, oldModel := strict, llmModel
+	t.Cleanup(func() { strict, llmModel = oldStrict, oldModel })
+
+	llmModel = "gpt-4o"
+	prd := strings.Repeat("A requirement sentence. ", 30000) // ~180k tokens
+	config := buildParseConfig()
+
+	strict = false
+	warnings := core.NewWarningCollector()
+	if err := checkPromptSize(prd, config, false, warnings); err != nil {
+		t.Fatalf("checkPromptSize() error = %v", err)
+	}
+	if got := warnings.Warnings(); len(got) != 1 || got[0].Code != core.WarnPromptTooLarge || !strings.Contains(got[0].Message, "--multi-stage") {
+		t.Errorf("warnings = %v, want one prompt_too_large suggesting --multi-stage", got)
+	}
+
+	strict = true
+	if err := checkPromptSize(prd, config, true, core.NewWarningCollector()); ExitCode(err) != ExitValidation {
+		t.Errorf("--strict: ExitCode = %d (err %v), want %d", ExitCode(err), err, ExitValidation)
+	}
+
+	if err := checkPromptSize("A short PRD.", config, false, core.NewWarningCollector()); err != nil {
+		t.Errorf("small PRD failed the check: %v", err)
+	}
+}
+
+func TestCheckPromptSizeCoversFullContextStages(t *testing.T) {
+	oldStrict, oldModel, oldSubtask := strict, llmModel, subtaskModel
+	t.Cleanup(func() {
+		strict, llmModel, subtaskModel = oldStrict, oldModel, oldSubtask
+		delete(core.ModelContextLimits, "tiny-model")
+	})
+
+	// Fits Stage 1 and 2 on gpt-4o, but not the PRD-carrying Stage 3 prompt on a small model
+	strict, llmModel, subtaskModel = false, "gpt-4o", "tiny-model"
+	core.ModelContextLimits["tiny-model"] = core.DefaultOutputReserve + 1000
+	prd := strings.Repeat("A requirement sentence. ", 2000)
+	config := buildParseConfig()
+	config.FullContext = false
+
+	warnings := core.NewWarningCollector()
+	if err := checkPromptSize(prd, config, true, warnings); err != nil || warnings.Len() != 0 {
+		t.Fatalf("--full-context=false: err = %v, warnings = %v, want neither", err, warnings.Warnings())
+	}
+
+	config.FullContext = true
+	warnings = core.NewWarningCollector()
+	if err := checkPromptSize(prd, config, true, warnings); err != nil {
+		t.Fatalf("checkPromptSize() error = %v", err)
+	}
+	if got := warnings.Warnings(); len(got) != 1 || !strings.Contains(got[0].Message, "--subtask-model") {
+		t.Errorf("warnings = %v, want one prompt_too_large for Stage 3", got)
+	}
+}
+
+func TestResponseMaxTokensCapsSingleCallAtModelLimit(t *testing.T) {
+	oldMax, oldForce, oldModel := maxTokens, forceSingleCall, llmModel
+	t.Cleanup(func() { maxTokens, forceSingleCall, llmModel = oldMax, oldForce, oldModel })
+
+	maxTokens, forceSingleCall = 0, true
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{"", singleCallMaxTokens},
+		{"claude-sonnet-4-20250514", singleCallMaxTokens},
+		{"claude-opus-4-20250514", 32000},
+		{"claude-3-5-haiku-20241022", 8192},
+		{"claude-3-haiku-20240307", 4096},
+	}
+	for _, tt := range tests {
+		llmModel = tt.model
+		if got := responseMaxTokens(); got != tt.want {
+			t.Errorf("model %q: responseMaxTokens() = %d, want %d", tt.model, got, tt.want)
+		}
+	}
+
+	maxTokens = 2000
+	if got := responseMaxTokens(); got != 2000 {
+		t.Errorf("--max-tokens 2000: responseMaxTokens() = %d, want 2000", got)
+	}
+}
+
+func TestReadPRDRejectsNonText(t *testing.T) {
+	dir := t.TempDir()
+	utf16 := []byte{0xFF, 0xFE}
+	for _, r := range "# PRD\n" {
+		utf16 = append(utf16, byte(r), 0)
+	}
+	files := map[string][]byte{
+		"prd.md":     []byte("# Checkout\n\nUsers pay with a card — no “guest” checkout.\n"),
+		"utf16.md":   utf16,
+		"binary.pdf": {0x25, 0x50, 0x44, 0x46, 0x00, 0x01, 0x02, 0x03, 0xFF, 0xD8, 0x10, 0x11},
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := readPRD(filepath.Join(dir, "prd.md")); err != nil {
+		t.Errorf("readPRD(markdown) error = %v", err)
+	}
+	for name, want := range map[string]string{"utf16.md": "UTF-16", "binary.pdf": "binary"} {
+		_, err := readPRD(filepath.Join(dir, name))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("readPRD(%s) error = %v, want one mentioning %s", name, err, want)
+			continue
+		}
+		if code := ExitCode(err); code != ExitUsage {
+			t.Errorf("readPRD(%s) exit code = %d, want %d", name, code, ExitUsage)
+		}
+	}
+}
+
+func TestWriteCheckpointInterruptedLeavesNoPartialFile(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.json")
+	fresh := filepath.Join(dir, "fresh.json")
+	if err := os.WriteFile(existing, []byte(`{"epics":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldWrite := writeCheckpointData
+	t.Cleanup(func() { writeCheckpointData = oldWrite })
+	writeCheckpointData = func(f *os.File, data []byte) error {
+		_, _ = f.Write(data[:len(data)/2])
+		return errors.New("interrupted")
+	}
+
+	data := []byte(`{"project":{"product_name":"Shop"},"epics":[{"temp_id":"1"}]}`)
+	for _, path := range []string{existing, fresh} {
+		if err := writeCheckpoint(path, data); err == nil {
+			t.Fatalf("writeCheckpoint(%s) succeeded despite the interrupted write", path)
+		}
+	}
+
+	if got, _ := os.ReadFile(existing); string(got) != `{"epics":[]}` {
+		t.Errorf("existing checkpoint = %q, want the previous contents", got)
+	}
+	if _, err := os.Stat(fresh); !os.IsNotExist(err) {
+		t.Errorf("fresh checkpoint exists after an interrupted write (stat error %v)", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the existing checkpoint (temp files leaked?)", len(entries))
+	}
+
+	writeCheckpointData = oldWrite
+	if err := writeCheckpoint(fresh, data); err != nil {
+		t.Fatalf("writeCheckpoint() error = %v", err)
+	}
+	if got, _ := os.ReadFile(fresh); string(got) != string(data) {
+		t.Errorf("checkpoint = %q, want %q", got, data)
+	}
+}
+
+func TestWriteProjectContextPrintsAndSaves(t *testing.T) {
+	project := &core.ProjectContext{ProductName: "Shop", TechStack: core.FlexibleStringSlice{"Go"}}
+	path := filepath.Join(t.TempDir(), "project.json")
+
+	var out strings.Builder
+	if err := writeProjectContext(&out, project, path); err != nil {
+		t.Fatalf("writeProjectContext() error = %v", err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("project context not saved: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != strings.TrimSpace(string(saved)) {
+		t.Errorf("printed and saved JSON differ:\n%s\n---\n%s", out.String(), saved)
+	}
+
+	var got struct {
+		Project core.ProjectContext `json:"project"`
+	}
+	if err := json.Unmarshal(saved, &got); err != nil {
+		t.Fatalf("saved JSON doesn't parse: %v", err)
+	}
+	if got.Project.ProductName != "Shop" || len(got.Project.TechStack) != 1 {
+		t.Errorf("saved project = %+v, want product Shop with one tech", got.Project)
+	}
+}
+
+func TestSavedPlanCommandsWorkWithoutLLM(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("PATH", dir) // no claude or codex
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	checkpoint := filepath.Join(dir, "plan.json")
+	plan := `{"project":{"product_name":"Demo"},"epics":[{"temp_id":"1","title":"Auth","tasks":[` +
+		`{"temp_id":"1.1","title":"Login","subtasks":[{"temp_id":"1.1.1","title":"Form"}]},` +
+		`{"temp_id":"1.2","title":"Logout","depends_on":["1.1"]}]}]}`
+	if err := os.WriteFile(checkpoint, []byte(plan), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldFrom, oldOut, oldPath, oldEstimate, oldFill := fromJSON, outputAdapter, outputPath, requireEstimate, fillGaps
+	t.Cleanup(func() {
+		fromJSON, outputAdapter, outputPath, requireEstimate, fillGaps = oldFrom, oldOut, oldPath, oldEstimate, oldFill
+	})
+
+	if _, err := captureStdout(t, func() error { return runLint(LintCmd, []string{checkpoint}) }); err != nil {
+		t.Errorf("lint without an LLM: %v", err)
+	}
+	if _, err := captureStdout(t, func() error { return runExplain(ExplainCmd, []string{checkpoint, "1.2"}) }); err != nil {
+		t.Errorf("explain without an LLM: %v", err)
+	}
+
+	// Missing estimates are only a warning when there's no LLM to fill them
+	fromJSON, outputAdapter, outputPath, requireEstimate = checkpoint, "json", filepath.Join(dir, "tasks.json"), true
+	if _, err := captureStdout(t, func() error { return runParse(ParseCmd, []string{"prd.md"}) }); err != nil {
+		t.Fatalf("parse --from-json without an LLM: %v", err)
+	}
+	if _, err := os.Stat(outputPath); err != nil {
+		t.Errorf("parse --from-json should write %s: %v", outputPath, err)
+	}
+
+	// Generating content needs the LLM, and says what works without one
+	fromJSON, fillGaps = checkpoint, true
+	_, err := captureStdout(t, func() error { return runParse(ParseCmd, []string{"prd.md"}) })
+	if ExitCode(err) != ExitGeneration || !strings.Contains(err.Error(), "work without an LLM") {
+		t.Errorf("--fill-gaps without an LLM = %v (exit %d), want exit %d with a hint", err, ExitCode(err), ExitGeneration)
+	}
+}
+
+// gapFixReviewer closes gaps by adding a deploy task, and answers validation
+// with validation.
+type gapFixReviewer struct{ validation string }
+
+func (r gapFixReviewer) GenerateRaw(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	if systemPrompt == core.ValidationPrompt {
+		return r.validation, nil
+	}
+	return `{
+		"review_notes": "Added a deploy task",
+		"project": {"product_name": "App"},
+		"epics": [{"temp_id": "1", "title": "Auth", "depends_on": [], "tasks": [
+			{"temp_id": "1.1", "title": "Login", "depends_on": []},
+			{"temp_id": "1.2", "title": "Deploy", "depends_on": ["1.1"], "subtasks": [{"temp_id": "1.2.1", "title": "CI job"}]}]}]
+	}`, nil
+}
+
+func TestFixValidationGapsRevalidatesFixedPlan(t *testing.T) {
+	oldFixer, oldSave := gapFixer, saveJSON
+	t.Cleanup(func() { gapFixer, saveJSON = oldFixer, oldSave })
+	saveJSON = ""
+
+	plan := func() *core.ParseResponse {
+		return &core.ParseResponse{
+			Project: core.ProjectContext{ProductName: "App"},
+			Epics: []core.Epic{{TempID: "1", Title: "Auth", Tasks: []core.Task{
+				{TempID: "1.1", Title: "Login", Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "Form"}}},
+			}}},
+		}
+	}
+	gaps := []string{"No deployment task", "No rollback plan"}
+
+	gapFixer = func(string) (core.Reviewer, error) {
+		return gapFixReviewer{`{"is_valid": false, "gaps": ["No rollback plan"]}`}, nil
+	}
+	var fixed *core.ParseResponse
+	var remaining []string
+	if _, err := captureStdout(t, func() error {
+		fixed, remaining = fixValidationGaps(context.Background(), plan(), "# PRD", gaps, core.NewWarningCollector())
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if fixed == nil || len(fixed.Epics[0].Tasks) != 2 {
+		t.Fatalf("fixed plan = %+v, want the deploy task added", fixed)
+	}
+	if !reflect.DeepEqual(remaining, []string{"No rollback plan"}) {
+		t.Errorf("remaining gaps = %v, want the one validation still finds", remaining)
+	}
+
+	gapFixer = func(string) (core.Reviewer, error) { return gapFixReviewer{`{"is_valid": true}`}, nil }
+	_, _ = captureStdout(t, func() error {
+		fixed, remaining = fixValidationGaps(context.Background(), plan(), "# PRD", gaps, core.NewWarningCollector())
+		return nil
+	})
+	if fixed == nil || len(remaining) != 0 {
+		t.Errorf("fixed = %v, remaining = %v; want a fixed plan with no gaps", fixed != nil, remaining)
+	}
+
+	// A failed re-validation keeps every gap
+	gapFixer = func(string) (core.Reviewer, error) { return gapFixReviewer{"not json"}, nil }
+	warnings := core.NewWarningCollector()
+	_, _ = captureStdout(t, func() error {
+		fixed, remaining = fixValidationGaps(context.Background(), plan(), "# PRD", gaps, warnings)
+		return nil
+	})
+	if fixed == nil || !reflect.DeepEqual(remaining, gaps) {
+		t.Errorf("remaining = %v, want all of %v", remaining, gaps)
+	}
+	if got := warnings.Warnings(); len(got) != 1 || got[0].Code != core.WarnValidationFailed {
+		t.Errorf("warnings = %v, want one validation_failed", got)
+	}
+
+	// A checkpoint that can't be written is a warning, not "Updated checkpoint"
+	gapFixer = func(string) (core.Reviewer, error) { return gapFixReviewer{`{"is_valid": true}`}, nil }
+	saveJSON = filepath.Join(t.TempDir(), "missing", "plan.json")
+	warnings = core.NewWarningCollector()
+	out, _ := captureStdout(t, func() error {
+		fixValidationGaps(context.Background(), plan(), "# PRD", gaps, warnings)
+		return nil
+	})
+	if strings.Contains(out, "Updated checkpoint") {
+		t.Errorf("reported an unsaved checkpoint as updated:\n%s", out)
+	}
+	if got := warnings.Warnings(); len(got) != 1 || got[0].Code != core.WarnCheckpointFailed {
+		t.Errorf("warnings = %v, want one checkpoint_failed", got)
+	}
+}
+
+func TestFixGapsRejectsFromJSON(t *testing.T) {
+	oldFrom, oldFix := fromJSON, fixGaps
+	t.Cleanup(func() { fromJSON, fixGaps = oldFrom, oldFix })
+
+	fromJSON, fixGaps = filepath.Join(t.TempDir(), "plan.json"), true
+	if err := runParse(ParseCmd, []string{"prd.md"}); ExitCode(err) != ExitUsage || !strings.Contains(err.Error(), "--fix-gaps") {
+		t.Errorf("--fix-gaps --from-json = %v (exit %d), want a usage error", err, ExitCode(err))
+	}
+}
+
+func TestReportIDCollisions(t *testing.T) {
+	plan := &core.ParseResponse{Epics: []core.Epic{{TempID: "1", Title: "Setup", Tasks: []core.Task{{TempID: "1.1", Title: "Repo"}}}}}
+
+	var buf strings.Builder
+	collisions := []output.IDCollision{{TempID: "1.1", Title: "Repo", ID: "p-e1t1"}}
+	if err := reportIDCollisions(&buf, collisions, plan); ExitCode(err) != ExitValidation {
+		t.Errorf("collisions: ExitCode = %d (err %v), want %d", ExitCode(err), err, ExitValidation)
+	}
+	for _, want := range []string{"1 of the plan's 2 IDs already exist", "p-e1t1  1.1 Repo"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := reportIDCollisions(&buf, nil, plan); err != nil {
+		t.Errorf("no collisions: error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "None of the plan's 2 IDs exist") {
+		t.Errorf("report = %q, want no collisions", buf.String())
+	}
+}
+
+func TestRetryCommandRepeatsIDFlags(t *testing.T) {
+	oldDir, oldAdapter, oldScheme, oldStart := workDir, outputAdapter, idScheme, epicStart
+	t.Cleanup(func() { workDir, outputAdapter, idScheme, epicStart = oldDir, oldAdapter, oldScheme, oldStart })
+
+	workDir, outputAdapter, idScheme, epicStart = "", "beads", output.IDSchemeETS, 0
+	if got, want := retryCommand("prd.md", "/tmp/cp.json"), "prd-parser parse prd.md --retry-failed"; got != want {
+		t.Errorf("retryCommand() = %q, want %q", got, want)
+	}
+
+	workDir, idScheme, epicStart = "proj", output.IDSchemeDotted, 5
+	if got, want := retryCommand("prd.md", "/tmp/cp.json"), "prd-parser parse prd.md --dir proj --retry-failed --id-scheme dotted --epic-start 5"; got != want {
+		t.Errorf("retryCommand() = %q, want %q", got, want)
+	}
+
+	idScheme = output.IDSchemeAuto
+	if got, want := retryCommand("prd.md", "/tmp/cp.json"), "prd-parser parse prd.md --dir proj --from-json /tmp/cp.json"; got != want {
+		t.Errorf("retryCommand() = %q, want %q", got, want)
+	}
+}
+
+func TestFailureCheckpointPathIsKeyedByPRD(t *testing.T) {
+	oldDir := workDir
+	t.Cleanup(func() { workDir = oldDir })
+
+	workDir = ""
+	auth, billing := failureCheckpointPath("auth.md"), failureCheckpointPath("billing.md")
+	if auth == billing {
+		t.Errorf("failureCheckpointPath() = %q for both PRDs, want one per PRD", auth)
+	}
+	if auth == partialCheckpointPath("auth.md") {
+		t.Error("the --retry-budget partial plan shares the failure checkpoint path")
+	}
+	workDir = t.TempDir()
+	if failureCheckpointPath("auth.md") == auth {
+		t.Error("failureCheckpointPath() is the same in another working directory")
+	}
+}
+
+func TestApplyInferredDepsAggregatesWeakLinks(t *testing.T) {
+	oldThreshold, oldJSON := inferThreshold, jsonSummary
+	t.Cleanup(func() { inferThreshold, jsonSummary = oldThreshold, oldJSON })
+
+	plan := func() *core.ParseResponse {
+		return &core.ParseResponse{Epics: []core.Epic{{TempID: "1", Title: "Accounts", Tasks: []core.Task{
+			{TempID: "1.1", Title: "Users table schema"},
+			{TempID: "1.2", Title: "Password reset email", Description: "Sends a reset link to users"},
+			{TempID: "1.3", Title: "Welcome email", Description: "Greets new users"},
+		}}}}
+	}
+	inferThreshold = 0.99
+
+	jsonSummary = false
+	warnings := core.NewWarningCollector()
+	if _, err := captureStdout(t, func() error { applyInferredDeps(plan(), warnings); return nil }); err != nil {
+		t.Fatal(err)
+	}
+	got := warnings.Warnings()
+	if len(got) != 1 || got[0].Item != "" || !strings.Contains(got[0].Message, "inferred dependencies below confidence 0.99") {
+		t.Fatalf("warnings = %v, want one count of the weak inferences", got)
+	}
+
+	jsonSummary = true
+	warnings = core.NewWarningCollector()
+	if _, err := captureStdout(t, func() error { applyInferredDeps(plan(), warnings); return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if got := warnings.Warnings(); len(got) < 2 || got[0].Item == "" {
+		t.Errorf("--json-summary warnings = %v, want one per weak inference", got)
+	}
+}
+
+func TestRetryBudgetCheckpointHintKeepsDir(t *testing.T) {
+	oldDir, oldSave := workDir, saveJSON
+	t.Cleanup(func() { workDir, saveJSON = oldDir, oldSave })
+	workDir, saveJSON = "/work/app", filepath.Join(t.TempDir(), "partial.json")
+
+	budgetErr := &core.RetryBudgetError{Retries: 3, Partial: &core.ParseResponse{Epics: []core.Epic{{TempID: "1", Title: "Auth"}}}}
+	err := saveRetryBudgetCheckpoint(budgetErr, "docs/prd.md", "/work/app/docs/prd.md")
+	want := "Finish it with: prd-parser parse docs/prd.md --dir /work/app --from-json " + saveJSON + " --fill-gaps"
+	if ExitCode(err) != ExitGeneration || !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %v, want it to contain %q", err, want)
+	}
+}
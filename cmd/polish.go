@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+	"github.com/dhabedank/prd-parser/internal/llm"
+	"github.com/dhabedank/prd-parser/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	polishPRDPath string // PRD the plan is checked against
+	polishOutput  string // Where to write the polished plan (default: overwrite the input)
+	polishModel   string // Model for the review and validation calls
+)
+
+// PolishCmd represents the polish command
+var PolishCmd = &cobra.Command{
+	Use:   "polish <plan.json>",
+	Short: "Review and validate an existing plan checkpoint",
+	Long: `Run only the review and validation passes on a saved plan.
+
+This command:
+1. Reviews the plan's structure against the PRD and fixes issues (epic order, dependencies)
+2. Validates the reviewed plan for gaps
+3. Writes the improved plan and prints what changed
+
+Nothing is generated from scratch and nothing is created in the output system.
+
+Example:
+  prd-parser polish plan.json --prd docs/prd.md
+  prd-parser polish plan.json --prd docs/prd.md -o plan-polished.json`,
+	Args: usageArgs(cobra.ExactArgs(1)),
+	RunE: runPolish,
+}
+
+func init() {
+	PolishCmd.Flags().StringVar(&polishPRDPath, "prd", "", "Path to the PRD the plan was generated from (required)")
+	PolishCmd.Flags().StringVarP(&polishOutput, "output", "o", "", "Write the polished plan here instead of overwriting the input")
+	PolishCmd.Flags().StringVarP(&polishModel, "model", "m", "", "Model for review and validation (default: "+defaultPassModel+")")
+	_ = PolishCmd.MarkFlagRequired("prd")
+	_ = PolishCmd.RegisterFlagCompletionFunc("model", completeModels)
+}
+
+func runPolish(cmd *cobra.Command, args []string) error {
+	if err := validateWorkDir(); err != nil {
+		return usageErrorf("%w", err)
+	}
+	if err := validateTempDir(); err != nil {
+		return usageErrorf("%w", err)
+	}
+	planPath := inWorkDir(args[0])
+	outPath := inWorkDir(polishOutput)
+	if outPath == "" {
+		outPath = planPath
+	}
+
+	model := polishModel
+	if model == "" {
+		model = defaultPassModel
+	}
+	if err := requireClaudeCLI("polish"); err != nil {
+		return err
+	}
+	adapter := llm.NewClaudeCLIAdapter(llm.Config{Model: model, PreferCLI: true, TempDir: tempDir()})
+
+	return polishFile(context.Background(), os.Stdout, planPath, inWorkDir(polishPRDPath), outPath, adapter)
+}
+
+// polishFile polishes the plan at planPath against the PRD at prdPath and
+// writes the result to outPath.
+func polishFile(ctx context.Context, w io.Writer, planPath, prdPath, outPath string, reviewer core.Reviewer) error {
+	data, err := os.ReadFile(planPath)
+	if err != nil {
+		return usageErrorf("failed to read plan: %w", err)
+	}
+	var plan core.ParseResponse
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return usageErrorf("failed to parse plan: %w", err)
+	}
+	prdContent, err := readPRD(prdPath)
+	if err != nil {
+		return err
+	}
+
+	polished, err := polishPlan(ctx, w, &plan, string(prdContent), reviewer)
+	if err != nil {
+		return generationErrorf("%w", err)
+	}
+
+	data, err = marshalCheckpoint(polished)
+	if err != nil {
+		return outputErrorf("failed to marshal plan: %w", err)
+	}
+	if err := writeCheckpoint(outPath, data); err != nil {
+		return outputErrorf("failed to write plan: %w", err)
+	}
+	fmt.Fprintf(w, "\nPolished plan written to: %s\n", outPath)
+	return nil
+}
+
+// polishPlan runs the review pass and then validation on plan, reporting
+// review notes, changes, and gaps to w. It returns the reviewed plan.
+// A failed validation call is reported but doesn't discard the review.
+func polishPlan(ctx context.Context, w io.Writer, plan *core.ParseResponse, prdContent string, reviewer core.Reviewer) (*core.ParseResponse, error) {
+	fmt.Fprintln(w, "Reviewing structure...")
+	reviewResult, err := core.ReviewAndFix(ctx, plan, prdContent, reviewer)
+	if err != nil {
+		return nil, err
+	}
+
+	polished := plan
+	if reviewResult.WasModified {
+		polished = reviewResult.Response
+		fmt.Fprintf(w, "%s Review fixed issues: %s\n", tui.Sym.Check, reviewResult.ReviewNotes)
+		printReviewChanges(w, reviewResult.Changes)
+	} else {
+		fmt.Fprintln(w, tui.Sym.Check+" Review passed - no changes needed")
+	}
+
+	fmt.Fprintln(w, "\nValidating plan for gaps...")
+	for _, v := range core.CheckConstraintViolations(polished) {
+		fmt.Fprintf(w, "  %s %s\n", tui.Sym.Warn, v)
+	}
+	validationResult, err := validateWith(ctx, polished, prdContent, reviewer)
+	switch {
+	case err != nil:
+		fmt.Fprintf(w, "%s Validation failed: %v\n", tui.Sym.Warn, err)
+	case validationResult.IsValid:
+		fmt.Fprintln(w, tui.Sym.Check+" Plan validation passed - no gaps found")
+	default:
+		fmt.Fprintln(w, tui.Sym.Warn+" Plan validation found gaps:")
+		for _, gap := range validationResult.Gaps {
+			fmt.Fprintf(w, "  %s %s\n", tui.Sym.Bullet, gap)
+		}
+	}
+	if err == nil && len(validationResult.Warnings) > 0 {
+		fmt.Fprintln(w, "Warnings:")
+		for _, warning := range validationResult.Warnings {
+			fmt.Fprintf(w, "  %s %s\n", tui.Sym.Bullet, warning)
+		}
+	}
+
+	return polished, nil
+}
+
+// printReviewChanges lists the structural changes the review pass made, if any.
+func printReviewChanges(w io.Writer, changes core.ReviewChanges) {
+	lines := changes.Lines()
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nChanges (%d):\n", len(lines))
+	for _, line := range lines {
+		fmt.Fprintf(w, "  %s %s\n", tui.Sym.Bullet, line)
+	}
+}
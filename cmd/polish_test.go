@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// fakeReviewer answers the review prompt with a retitled plan and the validation prompt with a gap.
+type fakeReviewer struct{}
+
+func (fakeReviewer) GenerateRaw(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	if systemPrompt == core.ValidationPrompt {
+		return `{"is_valid": false, "gaps": ["No deployment task"]}`, nil
+	}
+	return `{
+		"review_notes": "Renamed login task",
+		"project": {"product_name": "App"},
+		"epics": [{"temp_id": "1", "title": "Auth", "tasks": [{"temp_id": "1.1", "title": "Email login", "depends_on": []}], "depends_on": []}]
+	}`, nil
+}
+
+func TestPolishWritesReviewedPlanAndReportsNotes(t *testing.T) {
+	plan := core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "App"},
+		Epics: []core.Epic{{TempID: "1", Title: "Auth", Tasks: []core.Task{
+			{TempID: "1.1", Title: "Login", Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "Form"}}},
+		}}},
+	}
+	dir := t.TempDir()
+	planPath, prdPath, outPath := filepath.Join(dir, "plan.json"), filepath.Join(dir, "prd.md"), filepath.Join(dir, "out.json")
+	data, _ := json.Marshal(plan)
+	if err := os.WriteFile(planPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(prdPath, []byte("# PRD"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := polishFile(context.Background(), &out, planPath, prdPath, outPath, fakeReviewer{}); err != nil {
+		t.Fatalf("polishFile() error = %v", err)
+	}
+	for _, want := range []string{"Renamed login task", `retitled task 1.1: "Login" -> "Email login"`, "No deployment task", outPath} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, out.String())
+		}
+	}
+
+	var written core.ParseResponse
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("polished plan not written: %v", err)
+	}
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	// The review's title is applied and the original subtasks are kept
+	task := written.Epics[0].Tasks[0]
+	if task.Title != "Email login" || len(task.Subtasks) != 1 {
+		t.Errorf("written task = %+v, want reviewed title with original subtasks", task)
+	}
+}
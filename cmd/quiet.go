@@ -0,0 +1,20 @@
+package cmd
+
+import "os"
+
+// silenceStdout points os.Stdout at the null device until restore is called.
+// Generation progress is printed from many places (stages, LLM adapters, output
+// adapters), so --quiet-llm silences the stream rather than threading a writer
+// through every layer. Errors still reach stderr.
+func silenceStdout() (restore func(), err error) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	stdout := os.Stdout
+	os.Stdout = devNull
+	return func() {
+		os.Stdout = stdout
+		devNull.Close()
+	}, nil
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 
 	"github.com/dhabedank/prd-parser/internal/core"
 	"github.com/dhabedank/prd-parser/internal/llm"
+	"github.com/dhabedank/prd-parser/internal/tui"
 	"github.com/spf13/cobra"
 )
 
@@ -18,6 +20,9 @@ var (
 	refineScanAll     bool
 	refineDryRun      bool
 	refinePRDPath     string
+	refineMatchMode   string
+	refineJSON        bool
+	refinePRDChars    int
 )
 
 // RefineCmd represents the refine command
@@ -46,6 +51,9 @@ func init() {
 	RefineCmd.Flags().BoolVar(&refineScanAll, "scan-all", true, "Scan ALL issues for the same misalignment (not just children)")
 	RefineCmd.Flags().BoolVar(&refineDryRun, "dry-run", false, "Preview changes without applying them")
 	RefineCmd.Flags().StringVar(&refinePRDPath, "prd", "", "Path to PRD file for context (recommended)")
+	RefineCmd.Flags().StringVar(&refineMatchMode, "match-mode", core.MatchSubstring, "How --scan-all matches concepts in issues: substring or token (stemmed words)")
+	RefineCmd.Flags().IntVar(&refinePRDChars, "prd-context-chars", defaultRefinePRDChars, "Max PRD characters included in the analysis prompt (0 = full PRD)")
+	RefineCmd.Flags().BoolVar(&refineJSON, "json", false, "Print only a JSON report of the refinement (for scripting and audits)")
 	_ = RefineCmd.MarkFlagRequired("feedback")
 }
 
@@ -53,10 +61,34 @@ func runRefine(cmd *cobra.Command, args []string) error {
 	issueID := args[0]
 	ctx := context.Background()
 
+	if err := validateWorkDir(); err != nil {
+		return err
+	}
+	if err := validateTempDir(); err != nil {
+		return err
+	}
+	matcher, err := core.NewConceptMatcher(refineMatchMode)
+	if err != nil {
+		return usageErrorf("%w", err)
+	}
+	if refinePRDChars < 0 {
+		return usageErrorf("--prd-context-chars must be 0 (full PRD) or positive")
+	}
+
+	// With --json, progress is hidden and only the report is printed
+	summaryOut := os.Stdout
+	if refineJSON {
+		restore, err := silenceStdout()
+		if err != nil {
+			return fmt.Errorf("failed to silence output: %w", err)
+		}
+		defer restore()
+	}
+
 	// Load PRD if provided
 	var prdContent string
 	if refinePRDPath != "" {
-		data, err := os.ReadFile(refinePRDPath)
+		data, err := os.ReadFile(inWorkDir(refinePRDPath))
 		if err != nil {
 			return fmt.Errorf("failed to read PRD: %w", err)
 		}
@@ -82,11 +114,12 @@ func runRefine(cmd *cobra.Command, args []string) error {
 	// Step 3: Create LLM adapter
 	llmConfig := llm.Config{
 		PreferCLI: true,
+		TempDir:   tempDir(),
 	}
-	adapter := llm.NewClaudeCLIAdapter(llmConfig)
-	if !adapter.IsAvailable() {
-		return fmt.Errorf("claude CLI not available")
+	if err := requireClaudeCLI("refine"); err != nil {
+		return err
 	}
+	adapter := llm.NewClaudeCLIAdapter(llmConfig)
 
 	// Step 4: Analyze misalignment and get corrections
 	fmt.Println("\nAnalyzing misalignment...")
@@ -118,7 +151,7 @@ func runRefine(cmd *cobra.Command, args []string) error {
 
 	// Scan all issues for wrong concepts if enabled
 	if refineScanAll && len(analysis.WrongConcepts) > 0 {
-		matches := findIssuesWithConcepts(allIssues, analysis.WrongConcepts, issueID)
+		matches := findIssuesWithConcepts(allIssues, analysis.WrongConcepts, issueID, matcher)
 		// Deduplicate
 		seen := make(map[string]bool)
 		for _, issue := range affectedIssues {
@@ -140,50 +173,53 @@ func runRefine(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  + %s: %s\n", issue.ID, truncate(issue.Title, 50))
 	}
 
+	report := newRefineReport(issueID, analysis, refineDryRun)
 	if refineDryRun {
+		report.plan(*targetIssue, analysis, affectedIssues)
 		fmt.Println("\n[dry-run] No changes applied")
-		return nil
+		return finishRefine(summaryOut, report)
 	}
 
-	// Apply to target
 	fmt.Printf("\nApplying corrections...\n")
-	if err := applyCorrection(issueID, analysis.CorrectedTitle, analysis.CorrectedDescription); err != nil {
-		fmt.Printf("  Warning: failed to update %s: %v\n", issueID, err)
-	} else {
-		fmt.Printf("  ✓ Updated %s\n", issueID)
+	regenerate := func(issue core.BeadsIssue) (*AnalysisResult, error) {
+		return regenerateWithContext(ctx, adapter, issue, analysis.WrongConcepts, analysis.CorrectConcepts, prdContent)
 	}
-
-	// Apply to affected issues (regenerate each with context)
-	for _, issue := range affectedIssues {
-		corrected, err := regenerateWithContext(ctx, adapter, issue, analysis.WrongConcepts, analysis.CorrectConcepts, prdContent)
-		if err != nil {
-			fmt.Printf("  Warning: failed to regenerate %s: %v\n", issue.ID, err)
-			continue
-		}
-		if err := applyCorrection(issue.ID, corrected.CorrectedTitle, corrected.CorrectedDescription); err != nil {
-			fmt.Printf("  Warning: failed to update %s: %v\n", issue.ID, err)
-		} else {
-			fmt.Printf("  ✓ Updated %s\n", issue.ID)
+	report.apply(*targetIssue, analysis, affectedIssues, regenerate, applyCorrection)
+
+	// Verify: corrections that still mention a wrong concept didn't fully take
+	report.Unresolved = findUnresolvedConcepts(report.updated, analysis.WrongConcepts, matcher)
+	if len(report.Unresolved) > 0 {
+		fmt.Printf("\n%s %d updated issues still mention wrong concepts:\n", tui.Sym.Warn, len(report.Unresolved))
+		for _, u := range report.Unresolved {
+			fmt.Printf("  - %s: %s\n", u.ID, strings.Join(u.Concepts, ", "))
 		}
+		fmt.Println("  Re-run refine on these issues with more specific feedback.")
 	}
 
 	fmt.Printf("\n--- Summary ---\n")
 	fmt.Printf("Updated: 1 target + %d related issues\n", len(affectedIssues))
 
-	return nil
+	return finishRefine(summaryOut, report)
 }
 
 // Type aliases for convenience
 type BeadsIssue = core.BeadsIssue
 type AnalysisResult = core.AnalysisResult
 
+// bdCommand returns a bd command that runs in the working directory.
+func bdCommand(args ...string) *exec.Cmd {
+	cmd := exec.Command("bd", args...)
+	cmd.Dir = workDir
+	return cmd
+}
+
 // loadBeadsIssue loads a single issue from beads
 func loadBeadsIssue(id string) (*core.BeadsIssue, error) {
-	cmd := exec.Command("bd", "show", id, "--format", "json")
+	cmd := bdCommand("show", id, "--format", "json")
 	output, err := cmd.Output()
 	if err != nil {
 		// Fallback to parsing text output
-		cmd = exec.Command("bd", "show", id)
+		cmd = bdCommand("show", id)
 		output, err = cmd.Output()
 		if err != nil {
 			return nil, err
@@ -196,6 +232,7 @@ func loadBeadsIssue(id string) (*core.BeadsIssue, error) {
 // parseBeadsShowOutput parses the text output of bd show
 func parseBeadsShowOutput(id, output string) (*core.BeadsIssue, error) {
 	issue := &core.BeadsIssue{ID: id}
+	prefix := issuePrefix(id)
 
 	lines := strings.Split(output, "\n")
 	for i, line := range lines {
@@ -220,6 +257,7 @@ func parseBeadsShowOutput(id, output string) (*core.BeadsIssue, error) {
 				if strings.HasPrefix(lines[j], "ACCEPTANCE") ||
 				   strings.HasPrefix(lines[j], "LABELS") ||
 				   strings.HasPrefix(lines[j], "DEPENDS") ||
+				   strings.HasPrefix(lines[j], "PARENT") ||
 				   strings.HasPrefix(lines[j], "CHILDREN") ||
 				   strings.HasPrefix(lines[j], "BLOCKS") {
 					break
@@ -233,14 +271,7 @@ func parseBeadsShowOutput(id, output string) (*core.BeadsIssue, error) {
 		if strings.Contains(line, "PARENT") {
 			for j := i + 1; j < len(lines); j++ {
 				if strings.Contains(lines[j], "→") {
-					// Extract parent ID
-					parts := strings.Fields(lines[j])
-					for _, p := range parts {
-						if strings.HasPrefix(p, "test-") || strings.HasPrefix(p, "prd-") {
-							issue.Parent = strings.TrimSuffix(p, ":")
-							break
-						}
-					}
+					issue.Parent = findIssueID(lines[j], prefix)
 					break
 				}
 			}
@@ -250,16 +281,60 @@ func parseBeadsShowOutput(id, output string) (*core.BeadsIssue, error) {
 	return issue, nil
 }
 
-// loadAllBeadsIssues loads all issues from beads
+// beadsIDToken matches a token shaped like a beads issue ID: a prefix, a dash,
+// and an ID such as "myproj-e1t2", "my-app-a3f8", or "bd-a3f8.1".
+var beadsIDToken = regexp.MustCompile(`^[A-Za-z0-9_-]+-[A-Za-z0-9.]+$`)
+
+// issuePrefix returns the beads prefix of an issue ID, the part before its
+// last dash ("my-app" for "my-app-e1t2"), or "" if it has none.
+func issuePrefix(id string) string {
+	if i := strings.LastIndex(id, "-"); i > 0 {
+		return id[:i]
+	}
+	return ""
+}
+
+// findIssueID returns the first token on line that looks like an issue ID,
+// preferring one with the given prefix; titles can contain dashed words too.
+func findIssueID(line, prefix string) string {
+	fallback := ""
+	for _, field := range strings.Fields(line) {
+		token := strings.Trim(field, ":,;()[]")
+		if !beadsIDToken.MatchString(token) {
+			continue
+		}
+		if prefix != "" && strings.HasPrefix(token, prefix+"-") {
+			return token
+		}
+		if fallback == "" {
+			fallback = token
+		}
+	}
+	return fallback
+}
+
+// loadAllBeadsIssues loads all issues from beads with one bd call. Full records
+// (descriptions, parents) come from --json; older bd versions without it, or
+// with JSON it can't read, fall back to the text listing, which has titles only.
 func loadAllBeadsIssues() ([]core.BeadsIssue, error) {
-	cmd := exec.Command("bd", "list", "--status=all", "--limit", "0")
+	if output, err := bdCommand("list", "--json", "--status=all", "--limit", "0").Output(); err == nil {
+		if issues, err := core.ParseBeadsListJSON(output); err == nil {
+			return issues, nil
+		}
+	}
+
+	cmd := bdCommand("list", "--status=all", "--limit", "0")
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, err
 	}
+	return parseBeadsListOutput(string(output)), nil
+}
 
+// parseBeadsListOutput parses the text output of bd list (IDs, titles, and guessed types).
+func parseBeadsListOutput(output string) []core.BeadsIssue {
 	var issues []core.BeadsIssue
-	lines := strings.Split(string(output), "\n")
+	lines := strings.Split(output, "\n")
 	for _, line := range lines {
 		if !strings.HasPrefix(line, "○") {
 			continue
@@ -298,7 +373,7 @@ func loadAllBeadsIssues() ([]core.BeadsIssue, error) {
 		})
 	}
 
-	return issues, nil
+	return issues
 }
 
 // analyzeAndCorrect uses LLM to analyze the misalignment and generate corrections
@@ -321,6 +396,22 @@ Return JSON:
 Be specific about wrong concepts - they'll be used to search other issues.
 Keep the corrected content the same length/detail as original, just fix the framing.`
 
+	userPrompt := buildAnalysisPrompt(issue, feedback, prdContent, refinePRDChars)
+
+	output, err := adapter.GenerateRaw(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return nil, err
+	}
+
+	return core.ParseAnalysisResult(output)
+}
+
+// defaultRefinePRDChars is the default --prd-context-chars.
+const defaultRefinePRDChars = 4000
+
+// buildAnalysisPrompt renders the analysis user prompt, including at most
+// prdChars characters of the PRD (all of it when prdChars is 0).
+func buildAnalysisPrompt(issue *core.BeadsIssue, feedback, prdContent string, prdChars int) string {
 	userPrompt := fmt.Sprintf(`Analyze this issue and fix the misalignment.
 
 ISSUE ID: %s
@@ -337,18 +428,22 @@ USER FEEDBACK (what's wrong):
 		userPrompt += fmt.Sprintf(`
 ORIGINAL PRD (for correct context):
 %s
-`, truncate(prdContent, 4000))
+`, prdContext(prdContent, prdChars))
 	}
 
-	userPrompt += `
+	return userPrompt + `
 Return JSON with wrong_concepts, correct_concepts, corrected_title, and corrected_description.`
+}
 
-	output, err := adapter.GenerateRaw(ctx, systemPrompt, userPrompt)
-	if err != nil {
-		return nil, err
+// prdContext truncates prdContent to limit characters; 0 means no limit.
+func prdContext(prdContent string, limit int) string {
+	switch {
+	case limit <= 0 || len(prdContent) <= limit:
+		return prdContent
+	case limit <= 3: // Too short for truncate's "..."
+		return prdContent[:limit]
 	}
-
-	return core.ParseAnalysisResult(output)
+	return truncate(prdContent, limit)
 }
 
 // findChildren finds all issues that are children of the given parent
@@ -375,8 +470,8 @@ func findChildren(allIssues []core.BeadsIssue, parentID string) []core.BeadsIssu
 	return children
 }
 
-// findIssuesWithConcepts finds issues containing any of the wrong concepts
-func findIssuesWithConcepts(allIssues []core.BeadsIssue, concepts []string, excludeID string) []core.BeadsIssue {
+// findIssuesWithConcepts finds issues whose title or description matches any of the wrong concepts
+func findIssuesWithConcepts(allIssues []core.BeadsIssue, concepts []string, excludeID string, matcher core.ConceptMatcher) []core.BeadsIssue {
 	var matches []core.BeadsIssue
 
 	for _, issue := range allIssues {
@@ -385,9 +480,9 @@ func findIssuesWithConcepts(allIssues []core.BeadsIssue, concepts []string, excl
 		}
 
 		// Check title and description for wrong concepts
-		text := strings.ToLower(issue.Title + " " + issue.Description)
+		text := issue.Title + " " + issue.Description
 		for _, concept := range concepts {
-			if strings.Contains(text, strings.ToLower(concept)) {
+			if matcher.Matches(text, concept) {
 				matches = append(matches, issue)
 				break
 			}
@@ -397,12 +492,51 @@ func findIssuesWithConcepts(allIssues []core.BeadsIssue, concepts []string, excl
 	return matches
 }
 
+// unresolvedIssue is an updated issue that still mentions wrong concepts.
+type unresolvedIssue struct {
+	ID       string   `json:"id"`
+	Concepts []string `json:"concepts"`
+}
+
+// correctedIssue returns issue as applyCorrection writes it: corrected fields
+// replace the originals, and empty ones are left unchanged.
+func correctedIssue(issue core.BeadsIssue, corrected *AnalysisResult) core.BeadsIssue {
+	if corrected.CorrectedTitle != "" {
+		issue.Title = corrected.CorrectedTitle
+	}
+	if corrected.CorrectedDescription != "" {
+		issue.Description = corrected.CorrectedDescription
+	}
+	return issue
+}
+
+// findUnresolvedConcepts re-scans updated issues and reports, in order, those
+// whose title or description still matches any of the wrong concepts.
+func findUnresolvedConcepts(updated []core.BeadsIssue, concepts []string, matcher core.ConceptMatcher) []unresolvedIssue {
+	var unresolved []unresolvedIssue
+	for _, issue := range updated {
+		text := issue.Title + " " + issue.Description
+		var found []string
+		for _, concept := range concepts {
+			if matcher.Matches(text, concept) {
+				found = append(found, concept)
+			}
+		}
+		if len(found) > 0 {
+			unresolved = append(unresolved, unresolvedIssue{ID: issue.ID, Concepts: found})
+		}
+	}
+	return unresolved
+}
+
 // regenerateWithContext regenerates an issue with correction context
 func regenerateWithContext(ctx context.Context, adapter *llm.ClaudeCLIAdapter, issue core.BeadsIssue, wrongConcepts, correctConcepts []string, prdContent string) (*AnalysisResult, error) {
-	// First load full issue details
-	fullIssue, err := loadBeadsIssue(issue.ID)
-	if err != nil {
-		fullIssue = &issue // Use what we have
+	// The issue list has full details; only the text fallback needs a fetch
+	fullIssue := &issue
+	if issue.Description == "" {
+		if loaded, err := loadBeadsIssue(issue.ID); err == nil {
+			fullIssue = loaded
+		}
 	}
 
 	systemPrompt := `You fix misaligned concepts in project issues.
@@ -445,7 +579,7 @@ Keep the same structure and detail level, just fix the conceptual framing.`,
 func applyCorrection(id, title, description string) error {
 	// Update title
 	if title != "" {
-		cmd := exec.Command("bd", "update", id, "--title", title)
+		cmd := bdCommand("update", id, "--title", title)
 		if err := cmd.Run(); err != nil {
 			return fmt.Errorf("failed to update title: %w", err)
 		}
@@ -453,7 +587,7 @@ func applyCorrection(id, title, description string) error {
 
 	// Update description
 	if description != "" {
-		cmd := exec.Command("bd", "update", id, "--description", description)
+		cmd := bdCommand("update", id, "--description", description)
 		if err := cmd.Run(); err != nil {
 			return fmt.Errorf("failed to update description: %w", err)
 		}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+	"github.com/dhabedank/prd-parser/internal/tui"
+)
+
+// Statuses of a refineChange.
+const (
+	refinePlanned = "planned" // --dry-run: would be updated
+	refineUpdated = "updated"
+	refineFailed  = "failed"
+)
+
+// refineReport is the structured result of a refine run, printed with --json.
+type refineReport struct {
+	Target          string            `json:"target"`
+	WrongConcepts   []string          `json:"wrong_concepts"`
+	CorrectConcepts []string          `json:"correct_concepts"`
+	DryRun          bool              `json:"dry_run"`
+	Changes         []refineChange    `json:"changes"` // The target first, then affected issues
+	Unresolved      []unresolvedIssue `json:"unresolved,omitempty"`
+
+	updated []core.BeadsIssue // Issues as written, for the verification re-scan
+}
+
+// refineChange is one issue refine updated or would update.
+type refineChange struct {
+	ID          string `json:"id"`
+	TitleBefore string `json:"title_before"`
+	TitleAfter  string `json:"title_after,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+func newRefineReport(target string, analysis *AnalysisResult, dryRun bool) *refineReport {
+	return &refineReport{
+		Target:          target,
+		WrongConcepts:   analysis.WrongConcepts,
+		CorrectConcepts: analysis.CorrectConcepts,
+		DryRun:          dryRun,
+		Changes:         []refineChange{},
+	}
+}
+
+// plan records the target and affected issues as planned changes (dry-run).
+// Only the target's corrected title is known before regeneration.
+func (r *refineReport) plan(target core.BeadsIssue, analysis *AnalysisResult, affected []core.BeadsIssue) {
+	r.Changes = append(r.Changes, refineChange{ID: target.ID, TitleBefore: target.Title, TitleAfter: correctedIssue(target, analysis).Title, Status: refinePlanned})
+	for _, issue := range affected {
+		r.Changes = append(r.Changes, refineChange{ID: issue.ID, TitleBefore: issue.Title, Status: refinePlanned})
+	}
+}
+
+// apply writes the target's correction, then regenerates and writes each
+// affected issue, recording the outcome of every update.
+func (r *refineReport) apply(target core.BeadsIssue, analysis *AnalysisResult, affected []core.BeadsIssue,
+	regenerate func(core.BeadsIssue) (*AnalysisResult, error), update func(id, title, description string) error) {
+	r.record(target, analysis, update(target.ID, analysis.CorrectedTitle, analysis.CorrectedDescription))
+
+	for _, issue := range affected {
+		corrected, err := regenerate(issue)
+		if err != nil {
+			fmt.Printf("  Warning: failed to regenerate %s: %v\n", issue.ID, err)
+			r.Changes = append(r.Changes, refineChange{ID: issue.ID, TitleBefore: issue.Title, Status: refineFailed, Error: err.Error()})
+			continue
+		}
+		r.record(issue, corrected, update(issue.ID, corrected.CorrectedTitle, corrected.CorrectedDescription))
+	}
+}
+
+// record prints and stores the result of updating issue with corrected.
+func (r *refineReport) record(issue core.BeadsIssue, corrected *AnalysisResult, err error) {
+	after := correctedIssue(issue, corrected)
+	change := refineChange{ID: issue.ID, TitleBefore: issue.Title, TitleAfter: after.Title, Status: refineUpdated}
+	if err != nil {
+		fmt.Printf("  Warning: failed to update %s: %v\n", issue.ID, err)
+		change.Status, change.Error = refineFailed, err.Error()
+	} else {
+		fmt.Printf("  %s Updated %s\n", tui.Sym.Check, issue.ID)
+		r.updated = append(r.updated, after)
+	}
+	r.Changes = append(r.Changes, change)
+}
+
+// finishRefine prints the report as JSON to w when --json is set.
+func finishRefine(w io.Writer, report *refineReport) error {
+	if !refineJSON {
+		return nil
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal refine report: %w", err)
+	}
+	fmt.Fprintln(w, string(data))
+	return nil
+}
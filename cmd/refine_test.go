@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+func TestFindIssuesWithConceptsMatchModes(t *testing.T) {
+	issues := []core.BeadsIssue{
+		{ID: "p-1", Title: "Target issue", Description: "Track leads in the CRM"},
+		{ID: "p-2", Title: "Lead tracking dashboard"},
+		{ID: "p-3", Title: "Tracked calls", Description: "Show calls already tracked"},
+		{ID: "p-4", Title: "Start onboarding", Description: "Smart defaults"},
+		{ID: "p-5", Title: "Export to CRM"},
+	}
+	concepts := []string{"track", "art"}
+
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		// Substring matching also hits "art" inside "Start" and "Smart"
+		{core.MatchSubstring, []string{"p-2", "p-3", "p-4"}},
+		// Token matching keeps inflections of "track" and drops the partial-word "art" hits
+		{core.MatchToken, []string{"p-2", "p-3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			matcher, err := core.NewConceptMatcher(tt.mode)
+			if err != nil {
+				t.Fatalf("NewConceptMatcher() error = %v", err)
+			}
+			matches := findIssuesWithConcepts(issues, concepts, "p-1", matcher)
+			var got []string
+			for _, issue := range matches {
+				got = append(got, issue.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("matches = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("matches = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	// Token matching finds variants that substring matching misses
+	token, _ := core.NewConceptMatcher(core.MatchToken)
+	substring, _ := core.NewConceptMatcher(core.MatchSubstring)
+	lead := []core.BeadsIssue{{ID: "p-6", Title: "Assign leads to agents"}}
+	if got := findIssuesWithConcepts(lead, []string{"assigning lead"}, "", token); len(got) != 1 {
+		t.Errorf("token matcher should match %q to %q", "assigning lead", lead[0].Title)
+	}
+	if got := findIssuesWithConcepts(lead, []string{"assigning lead"}, "", substring); len(got) != 0 {
+		t.Errorf("substring matcher unexpectedly matched %q", lead[0].Title)
+	}
+
+	if _, err := core.NewConceptMatcher("fuzzy"); err == nil {
+		t.Error("NewConceptMatcher() should reject an unknown mode")
+	}
+}
+
+func TestFindUnresolvedConceptsFlagsIncompleteCorrections(t *testing.T) {
+	wrong := []string{"pipeline tracking", "deal management"}
+	original := []core.BeadsIssue{
+		{ID: "p-2", Title: "Pipeline tracking view", Description: "Deal management board"},
+		{ID: "p-3", Title: "Deal management API"},
+	}
+	// p-2 was fully corrected; p-3's regeneration kept a wrong concept in its description
+	updated := []core.BeadsIssue{
+		correctedIssue(original[0], &core.AnalysisResult{CorrectedTitle: "Conversation timeline", CorrectedDescription: "Call history per agent"}),
+		correctedIssue(original[1], &core.AnalysisResult{CorrectedTitle: "Conversation API", CorrectedDescription: "Still supports deal management endpoints"}),
+	}
+
+	unresolved := findUnresolvedConcepts(updated, wrong, core.SubstringMatcher{})
+	if len(unresolved) != 1 || unresolved[0].ID != "p-3" {
+		t.Fatalf("unresolved = %+v, want only p-3", unresolved)
+	}
+	if len(unresolved[0].Concepts) != 1 || unresolved[0].Concepts[0] != "deal management" {
+		t.Errorf("concepts = %v, want [deal management]", unresolved[0].Concepts)
+	}
+}
+
+func TestRefineReportJSON(t *testing.T) {
+	oldJSON := refineJSON
+	t.Cleanup(func() { refineJSON = oldJSON })
+	refineJSON = true
+
+	analysis := &core.AnalysisResult{
+		WrongConcepts:   []string{"deal management"},
+		CorrectConcepts: []string{"conversation insights"},
+		CorrectedTitle:  "Conversation insights",
+	}
+	target := core.BeadsIssue{ID: "p-1", Title: "Deal dashboard"}
+	affected := []core.BeadsIssue{{ID: "p-2", Title: "Deal API"}, {ID: "p-3", Title: "Deal export"}}
+
+	regenerate := func(issue core.BeadsIssue) (*core.AnalysisResult, error) {
+		return &core.AnalysisResult{CorrectedTitle: "Insights " + issue.ID}, nil
+	}
+	update := func(id, title, description string) error {
+		if id == "p-3" {
+			return errors.New("bd update failed")
+		}
+		return nil
+	}
+
+	report := newRefineReport(target.ID, analysis, false)
+	report.apply(target, analysis, affected, regenerate, update)
+
+	var out strings.Builder
+	if err := finishRefine(&out, report); err != nil {
+		t.Fatalf("finishRefine() error = %v", err)
+	}
+	var got struct {
+		Target          string   `json:"target"`
+		WrongConcepts   []string `json:"wrong_concepts"`
+		CorrectConcepts []string `json:"correct_concepts"`
+		DryRun          bool     `json:"dry_run"`
+		Changes         []struct {
+			ID          string `json:"id"`
+			TitleBefore string `json:"title_before"`
+			TitleAfter  string `json:"title_after"`
+			Status      string `json:"status"`
+			Error       string `json:"error"`
+		} `json:"changes"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &got); err != nil {
+		t.Fatalf("report is not JSON: %v\n%s", err, out.String())
+	}
+	if got.Target != "p-1" || len(got.WrongConcepts) != 1 || len(got.CorrectConcepts) != 1 || got.DryRun {
+		t.Errorf("report header = %+v", got)
+	}
+	if len(got.Changes) != 3 {
+		t.Fatalf("changes = %+v, want target + 2 affected", got.Changes)
+	}
+	if c := got.Changes[0]; c.ID != "p-1" || c.TitleBefore != "Deal dashboard" || c.TitleAfter != "Conversation insights" || c.Status != refineUpdated {
+		t.Errorf("target change = %+v", c)
+	}
+	if c := got.Changes[2]; c.ID != "p-3" || c.Status != refineFailed || c.Error != "bd update failed" {
+		t.Errorf("failed change = %+v", c)
+	}
+}
+
+func TestAnalysisPromptRespectsPRDContextChars(t *testing.T) {
+	issue := &core.BeadsIssue{ID: "p-1", Title: "Deal dashboard"}
+	prd := strings.Repeat("a", 5000) + "END"
+
+	tests := []struct {
+		name     string
+		limit    int
+		wantFull bool
+		wantLen  int
+	}{
+		{"default", defaultRefinePRDChars, false, defaultRefinePRDChars},
+		{"small", 100, false, 100},
+		{"full", 0, true, len(prd)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(prdContext(prd, tt.limit)); got != tt.wantLen {
+				t.Errorf("len(prdContext()) = %d, want %d", got, tt.wantLen)
+			}
+			prompt := buildAnalysisPrompt(issue, "voice-first", prd, tt.limit)
+			if strings.Contains(prompt, "END") != tt.wantFull {
+				t.Errorf("prompt includes end of PRD = %v, want %v", !tt.wantFull, tt.wantFull)
+			}
+		})
+	}
+
+	if got := prdContext("short", 2); got != "sh" {
+		t.Errorf("prdContext() = %q, want %q", got, "sh")
+	}
+}
+
+func TestParseBeadsShowOutputFindsParentForAnyPrefix(t *testing.T) {
+	tests := []struct {
+		id, parentLine, wantParent string
+	}{
+		{"myproj-e1t2", "  → myproj-e1: Authentication   [● P1 · OPEN]", "myproj-e1"},
+		{"my-app-e2t1", "  → my-app-e2: Sign-up flow   [● P2 · OPEN]", "my-app-e2"},
+		{"bd-a3f8.1", "  → bd-a3f8: Set-up   [● P1 · OPEN]", "bd-a3f8"},
+		// A parent in another prefix is still found, ahead of dashed title words
+		{"myproj-e1t2", "  → shared-e3: Cross-team work", "shared-e3"},
+	}
+	for _, tt := range tests {
+		output := "○ " + tt.id + " · Add login form   [● P1 · OPEN]\n\nDESCRIPTION\nBuild the login form.\n\nPARENT\n" + tt.parentLine + "\n"
+		issue, err := parseBeadsShowOutput(tt.id, output)
+		if err != nil {
+			t.Fatalf("parseBeadsShowOutput(%s) error = %v", tt.id, err)
+		}
+		if issue.Parent != tt.wantParent {
+			t.Errorf("parseBeadsShowOutput(%s) parent = %q, want %q", tt.id, issue.Parent, tt.wantParent)
+		}
+		if issue.Description != "Build the login form." {
+			t.Errorf("parseBeadsShowOutput(%s) description = %q", tt.id, issue.Description)
+		}
+	}
+}
+
+func TestLoadAllBeadsIssuesFallsBackOnUnreadableJSON(t *testing.T) {
+	// A bd whose --json listing isn't the shape we read, with a usable text listing
+	dir := t.TempDir()
+	script := "#!/bin/sh\ncase \"$*\" in\n*--json*) echo 'not json' ;;\n*) echo '○ app-e1 [● P1] [epic] - Auth' ;;\nesac\n"
+	if err := os.WriteFile(filepath.Join(dir, "bd"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	issues, err := loadAllBeadsIssues()
+	if err != nil {
+		t.Fatalf("loadAllBeadsIssues() error = %v", err)
+	}
+	if len(issues) != 1 || issues[0].ID != "app-e1" || issues[0].Title != "Auth" {
+		t.Errorf("issues = %+v, want app-e1 Auth from the text listing", issues)
+	}
+}
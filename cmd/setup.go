@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,7 +15,14 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-var resetConfig bool
+var (
+	resetConfig bool
+
+	// Models for a non-interactive setup; all three skip the wizard
+	setupEpicModel    string
+	setupTaskModel    string
+	setupSubtaskModel string
+)
 
 // SetupCmd represents the setup command.
 var SetupCmd = &cobra.Command{
@@ -27,12 +35,19 @@ This wizard helps you select models for each parsing stage:
 - Task model: Used for generating tasks from epics (Stage 2)
 - Subtask model: Used for generating subtasks from tasks (Stage 3)
 
-Configuration is saved to ~/.prd-parser.yaml`,
+Configuration is saved to ~/.prd-parser.yaml
+
+In scripts and CI, pass all three models as flags to skip the wizard:
+  prd-parser setup --epic-model claude-opus-4-5-20251101 \
+    --task-model claude-sonnet-4-5-20250929 --subtask-model claude-haiku-4-5-20251001`,
 	RunE: runSetup,
 }
 
 func init() {
 	SetupCmd.Flags().BoolVar(&resetConfig, "reset", false, "Reset configuration to defaults")
+	SetupCmd.Flags().StringVar(&setupEpicModel, "epic-model", "", "Epic model to save without the wizard (needs --task-model and --subtask-model)")
+	SetupCmd.Flags().StringVar(&setupTaskModel, "task-model", "", "Task model to save without the wizard")
+	SetupCmd.Flags().StringVar(&setupSubtaskModel, "subtask-model", "", "Subtask model to save without the wizard")
 }
 
 // setupConfig holds the configuration being built.
@@ -50,7 +65,7 @@ func runSetup(cmd *cobra.Command, args []string) error {
 		if err := os.Remove(configPath); err != nil && !os.IsNotExist(err) {
 			return fmt.Errorf("failed to remove config: %w", err)
 		}
-		fmt.Println(tui.SuccessStyle.Render("✓") + " Configuration reset to defaults")
+		fmt.Println(tui.SuccessStyle.Render(tui.Sym.Check) + " Configuration reset to defaults")
 		fmt.Printf("  Removed: %s\n", configPath)
 		return nil
 	}
@@ -61,8 +76,18 @@ func runSetup(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no LLM providers detected. Please install Claude Code or Codex CLI")
 	}
 
+	if setupEpicModel != "" || setupTaskModel != "" || setupSubtaskModel != "" {
+		return saveSetupFlags(configPath, models)
+	}
+
+	// Start from the saved models, if any, so one can be changed without re-choosing all three
+	current, err := loadSetupConfig(configPath)
+	if err != nil {
+		return err
+	}
+
 	// Run the wizard
-	p := tea.NewProgram(newSetupModel(models))
+	p := tea.NewProgram(newSetupModel(models, current))
 	m, err := p.Run()
 	if err != nil {
 		return fmt.Errorf("wizard failed: %w", err)
@@ -85,16 +110,50 @@ func runSetup(cmd *cobra.Command, args []string) error {
 	if err := saveConfig(configPath, config); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
+	printSavedSetup(configPath, config)
+	return nil
+}
+
+// saveSetupFlags saves --epic-model, --task-model, and --subtask-model to
+// configPath without the wizard. All three must be set, to models in models.
+func saveSetupFlags(configPath string, models []llm.ModelInfo) error {
+	config := setupConfig{EpicModel: setupEpicModel, TaskModel: setupTaskModel, SubtaskModel: setupSubtaskModel}
+	if config.EpicModel == "" || config.TaskModel == "" || config.SubtaskModel == "" {
+		return usageErrorf("set all of --epic-model, --task-model, and --subtask-model to skip the wizard")
+	}
+
+	known := make(map[string]bool, len(models))
+	ids := make([]string, len(models))
+	for i, m := range models {
+		known[m.ID] = true
+		ids[i] = m.ID
+	}
+	for _, flag := range []struct{ name, model string }{
+		{"--epic-model", config.EpicModel},
+		{"--task-model", config.TaskModel},
+		{"--subtask-model", config.SubtaskModel},
+	} {
+		if !known[flag.model] {
+			return usageErrorf("%s: unknown model %s (available: %s)", flag.name, flag.model, strings.Join(ids, ", "))
+		}
+	}
+
+	if err := saveConfig(configPath, config); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	printSavedSetup(configPath, config)
+	return nil
+}
 
+// printSavedSetup reports the models saved to configPath.
+func printSavedSetup(configPath string, config setupConfig) {
 	fmt.Println()
-	fmt.Println(tui.SuccessStyle.Render("✓") + " Configuration saved to " + configPath)
+	fmt.Println(tui.SuccessStyle.Render(tui.Sym.Check) + " Configuration saved to " + configPath)
 	fmt.Println()
 	fmt.Println("Selected models:")
 	fmt.Printf("  Epic:    %s\n", tui.ModelStyle.Render(config.EpicModel))
 	fmt.Printf("  Task:    %s\n", tui.ModelStyle.Render(config.TaskModel))
 	fmt.Printf("  Subtask: %s\n", tui.ModelStyle.Render(config.SubtaskModel))
-
-	return nil
 }
 
 func getConfigPath() string {
@@ -105,6 +164,22 @@ func getConfigPath() string {
 	return filepath.Join(home, ".prd-parser.yaml")
 }
 
+// loadSetupConfig reads the models saved at path. A missing file is an empty config.
+func loadSetupConfig(path string) (setupConfig, error) {
+	var config setupConfig
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return config, nil
+}
+
 func saveConfig(path string, config setupConfig) error {
 	data, err := yaml.Marshal(config)
 	if err != nil {
@@ -132,7 +207,9 @@ func (m modelItem) Title() string       { return m.info.Name }
 func (m modelItem) Description() string { return m.info.Description }
 func (m modelItem) FilterValue() string { return m.info.Name }
 
-func newSetupModel(models []llm.ModelInfo) setupModel {
+// newSetupModel builds the wizard with each step's list on the model current
+// has for that stage, or on the first model if it has none or an unknown one.
+func newSetupModel(models []llm.ModelInfo, current setupConfig) setupModel {
 	items := make([]list.Item, len(models))
 	for i, m := range models {
 		items[i] = modelItem{info: m}
@@ -150,19 +227,26 @@ func newSetupModel(models []llm.ModelInfo) setupModel {
 	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.Foreground(lipgloss.Color("#9b59b6"))
 	delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.Foreground(lipgloss.Color("#95a5a6"))
 
+	configured := []string{current.EpicModel, current.TaskModel, current.SubtaskModel}
 	for i := 0; i < 3; i++ {
 		l := list.New(items, delegate, 60, 14)
 		l.Title = titles[i]
 		l.SetShowStatusBar(false)
 		l.SetFilteringEnabled(false)
 		l.Styles.Title = tui.TitleStyle
+		for j, m := range models {
+			if configured[i] != "" && m.ID == configured[i] {
+				l.Select(j)
+				break
+			}
+		}
 		lists[i] = l
 	}
 
 	return setupModel{
 		step:           0,
 		lists:          lists,
-		selectedModels: make([]string, 3),
+		selectedModels: configured,
 	}
 }
 
@@ -226,18 +310,18 @@ func (m setupModel) View() string {
 		if i == m.step {
 			progress += tui.SelectedStyle.Render(fmt.Sprintf("[%s]", s))
 		} else if i < m.step {
-			progress += tui.SuccessStyle.Render(fmt.Sprintf("✓ %s", s))
+			progress += tui.SuccessStyle.Render(fmt.Sprintf("%s %s", tui.Sym.Check, s))
 		} else {
-			progress += tui.UnselectedStyle.Render(fmt.Sprintf("○ %s", s))
+			progress += tui.UnselectedStyle.Render(fmt.Sprintf("%s %s", tui.Sym.Pending, s))
 		}
 		if i < len(steps)-1 {
-			progress += " → "
+			progress += " " + tui.Sym.Arrow + " "
 		}
 	}
 	progress += "\n\n"
 
 	// Help text
-	help := tui.HelpStyle.Render("\n  ↑/↓: navigate • enter: select • ←: back • q: quit")
+	help := tui.HelpStyle.Render(fmt.Sprintf("\n  %[1]s/%[2]s: navigate %[4]s enter: select %[4]s %[3]s: back %[4]s q: quit", tui.Sym.Up, tui.Sym.Down, tui.Sym.Left, tui.Sym.Bullet))
 
 	return progress + m.lists[m.step].View() + help
 }
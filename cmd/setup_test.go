@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dhabedank/prd-parser/internal/llm"
+)
+
+func TestSetupModelPreselectsConfiguredModels(t *testing.T) {
+	models := []llm.ModelInfo{
+		{ID: "claude-opus-4-5-20251101", Name: "Claude Opus 4.5"},
+		{ID: "claude-sonnet-4-5-20250929", Name: "Claude Sonnet 4.5"},
+		{ID: "claude-haiku-4-5-20251001", Name: "Claude Haiku 4.5"},
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".prd-parser.yaml")
+	config, err := loadSetupConfig(path)
+	if err != nil || config != (setupConfig{}) {
+		t.Fatalf("loadSetupConfig(missing) = %+v, %v; want an empty config", config, err)
+	}
+
+	// An unknown model falls back to the first entry
+	yaml := "task_model: claude-haiku-4-5-20251001\nsubtask_model: retired-model\n"
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err = loadSetupConfig(path)
+	if err != nil {
+		t.Fatalf("loadSetupConfig() error = %v", err)
+	}
+
+	m := newSetupModel(models, config)
+	for step, want := range []int{0, 2, 0} {
+		if got := m.lists[step].Index(); got != want {
+			t.Errorf("step %d starts on index %d, want %d", step, got, want)
+		}
+	}
+}
+
+func TestSaveSetupFlagsWritesConfig(t *testing.T) {
+	oldEpic, oldTask, oldSubtask := setupEpicModel, setupTaskModel, setupSubtaskModel
+	t.Cleanup(func() { setupEpicModel, setupTaskModel, setupSubtaskModel = oldEpic, oldTask, oldSubtask })
+	models := []llm.ModelInfo{{ID: "claude-opus-4-5-20251101"}, {ID: "claude-sonnet-4-5-20250929"}, {ID: "claude-haiku-4-5-20251001"}}
+	path := filepath.Join(t.TempDir(), ".prd-parser.yaml")
+
+	setupEpicModel, setupTaskModel, setupSubtaskModel = "claude-opus-4-5-20251101", "claude-sonnet-4-5-20250929", ""
+	if err := saveSetupFlags(path, models); ExitCode(err) != ExitUsage {
+		t.Errorf("missing --subtask-model: ExitCode = %d (err %v), want %d", ExitCode(err), err, ExitUsage)
+	}
+	setupSubtaskModel = "gpt-2"
+	if err := saveSetupFlags(path, models); ExitCode(err) != ExitUsage {
+		t.Errorf("unknown model: ExitCode = %d (err %v), want %d", ExitCode(err), err, ExitUsage)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("invalid flags should not write a config")
+	}
+
+	setupSubtaskModel = "claude-haiku-4-5-20251001"
+	if _, err := captureStdout(t, func() error { return saveSetupFlags(path, models) }); err != nil {
+		t.Fatalf("saveSetupFlags() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "epic_model: claude-opus-4-5-20251101\ntask_model: claude-sonnet-4-5-20250929\nsubtask_model: claude-haiku-4-5-20251001\n"
+	if string(data) != want {
+		t.Errorf("config =\n%s\nwant\n%s", data, want)
+	}
+}
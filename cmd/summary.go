@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+	"github.com/dhabedank/prd-parser/internal/output"
+	"github.com/dhabedank/prd-parser/internal/tui"
+)
+
+// parseSummary is the end-of-run report for parse, printed as text or JSON.
+type parseSummary struct {
+	Epics        int              `json:"epics"`
+	Tasks        int              `json:"tasks"`
+	Subtasks     int              `json:"subtasks"`
+	Dependencies int              `json:"dependencies"`
+	Depths       map[int]int      `json:"dependency_depths,omitempty"` // Item count per dependency depth
+	Failed       []summaryFailure `json:"failed,omitempty"`
+	Warnings     []core.Warning   `json:"warnings,omitempty"`
+	Ready        []readyItem      `json:"ready,omitempty"`      // --show-ready
+	Timing       *creationTiming  `json:"timing,omitempty"`     // --timing
+	EpicCosts    *epicCostReport  `json:"epic_costs,omitempty"` // --epic-costs
+
+	Assumptions   []string `json:"assumptions,omitempty"`
+	OpenQuestions []string `json:"open_questions,omitempty"`
+}
+
+// summaryFailure is an item that failed to create.
+type summaryFailure struct {
+	Item  interface{} `json:"item"`
+	Error string      `json:"error"`
+}
+
+// readyItem is an item that can be started right away.
+type readyItem struct {
+	TempID     string `json:"temp_id"`
+	ExternalID string `json:"external_id,omitempty"`
+	Title      string `json:"title"`
+}
+
+// creationTiming is how long beads creation took, with the slowest items.
+type creationTiming struct {
+	TotalMs int64        `json:"total_ms"`
+	Slowest []itemTiming `json:"slowest"`
+}
+
+// itemTiming is how long one item took to create.
+type itemTiming struct {
+	TempID     string `json:"temp_id"`
+	ExternalID string `json:"external_id"`
+	Title      string `json:"title"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// buildCreationTiming reports the total creation time and the n slowest creations in result.
+func buildCreationTiming(result *output.CreateResult, n int) *creationTiming {
+	timing := &creationTiming{TotalMs: result.Elapsed.Milliseconds(), Slowest: []itemTiming{}}
+	for _, item := range result.Slowest(n) {
+		timing.Slowest = append(timing.Slowest, itemTiming{
+			TempID:     item.TempID,
+			ExternalID: item.ExternalID,
+			Title:      item.Title,
+			DurationMs: item.Duration.Milliseconds(),
+		})
+	}
+	return timing
+}
+
+// epicCostReport is what generation cost, by epic.
+type epicCostReport struct {
+	Epics        []core.EpicCost `json:"epics"`
+	Unattributed float64         `json:"unattributed_usd"` // Stage 1 and the --summarize pre-pass
+	Total        float64         `json:"total_usd"`
+}
+
+// buildEpicCosts attributes the recorded call costs to the epics of response.
+func buildEpicCosts(response *core.ParseResponse, costs []core.CallCost) *epicCostReport {
+	epics, unattributed := core.CostByEpic(response, costs)
+	report := &epicCostReport{Epics: epics, Unattributed: unattributed, Total: unattributed}
+	for _, epic := range epics {
+		report.Total += epic.TotalUSD()
+	}
+	return report
+}
+
+// buildParseSummary assembles the end-of-run report from the plan, the creation
+// result, and collected warnings.
+func buildParseSummary(response *core.ParseResponse, result *core.OutputCreateResult, warnings *core.WarningCollector) parseSummary {
+	summary := parseSummary{
+		Epics:         result.Stats.Epics,
+		Tasks:         result.Stats.Tasks,
+		Subtasks:      result.Stats.Subtasks,
+		Dependencies:  result.Stats.Dependencies,
+		Depths:        core.DependencyDepths(response),
+		Warnings:      warnings.Warnings(),
+		Assumptions:   response.Assumptions,
+		OpenQuestions: response.OpenQuestions,
+	}
+	for _, f := range result.Failed {
+		summary.Failed = append(summary.Failed, summaryFailure{Item: f.Item, Error: f.Error})
+	}
+	return summary
+}
+
+// printSummary writes the summary as text, or as JSON when asJSON is set.
+func printSummary(w io.Writer, summary parseSummary, asJSON bool) error {
+	if asJSON {
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal summary: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	fmt.Fprintln(w, "\n--- Summary ---")
+	fmt.Fprintf(w, "Epics: %d\n", summary.Epics)
+	fmt.Fprintf(w, "Tasks: %d\n", summary.Tasks)
+	fmt.Fprintf(w, "Subtasks: %d\n", summary.Subtasks)
+	fmt.Fprintf(w, "Dependencies: %d\n", summary.Dependencies)
+	printDepths(w, summary.Depths)
+
+	if len(summary.Ready) > 0 {
+		fmt.Fprintf(w, "\nReady to start (%d):\n", len(summary.Ready))
+		for _, item := range summary.Ready {
+			if item.ExternalID != "" {
+				fmt.Fprintf(w, "  - %s %s (%s)\n", item.ExternalID, item.Title, item.TempID)
+			} else {
+				fmt.Fprintf(w, "  - %s %s\n", item.TempID, item.Title)
+			}
+		}
+	}
+
+	if t := summary.Timing; t != nil {
+		fmt.Fprintf(w, "\nCreation time: %s\n", formatMs(t.TotalMs))
+		if len(t.Slowest) > 0 {
+			fmt.Fprintf(w, "Slowest creations (%d):\n", len(t.Slowest))
+		}
+		for _, item := range t.Slowest {
+			fmt.Fprintf(w, "  %8s  %s %s (%s)\n", formatMs(item.DurationMs), item.ExternalID, item.Title, item.TempID)
+		}
+	}
+
+	if c := summary.EpicCosts; c != nil {
+		fmt.Fprintln(w, "\nCost by epic:")
+		for _, epic := range c.Epics {
+			fmt.Fprintf(w, "  %8s  %s %s (%d calls: %s tasks, %s subtasks)\n", tui.FormatCost(epic.TotalUSD()), epic.EpicID, epic.Title,
+				epic.Calls, tui.FormatCost(epic.TasksUSD), tui.FormatCost(epic.SubtasksUSD))
+		}
+		fmt.Fprintf(w, "  %8s  Stage 1 and other calls\n", tui.FormatCost(c.Unattributed))
+		fmt.Fprintf(w, "  %8s  total\n", tui.FormatCost(c.Total))
+	}
+
+	printList(w, "Assumptions", summary.Assumptions)
+	printList(w, "Open questions", summary.OpenQuestions)
+
+	if len(summary.Failed) > 0 {
+		fmt.Fprintf(w, "\nFailed to create %d items:\n", len(summary.Failed))
+		for _, f := range summary.Failed {
+			fmt.Fprintf(w, "  - %v: %s\n", f.Item, f.Error)
+		}
+	}
+
+	if len(summary.Warnings) > 0 {
+		fmt.Fprintf(w, "\nWarnings (%d):\n", len(summary.Warnings))
+		for _, warning := range summary.Warnings {
+			fmt.Fprintf(w, "  %s %s\n", tui.Sym.Bullet, warning)
+		}
+	}
+
+	return nil
+}
+
+// readyItems lists the plan's ready items with their created IDs. completed
+// holds the temp_ids of items already closed in the output system.
+func readyItems(response *core.ParseResponse, externalIDs map[string]string, completed map[string]bool) []readyItem {
+	var items []readyItem
+	for _, item := range core.ReadyItemsAfter(response, completed) {
+		items = append(items, readyItem{TempID: item.TempID(), ExternalID: externalIDs[item.TempID()], Title: item.Title()})
+	}
+	return items
+}
+
+// beadsCompleted returns the temp_ids of created items that beads already has
+// closed, so --show-ready skips them when --retry-failed resumes a plan whose
+// first issues may have been worked on since. It returns nil for other
+// adapters, dry runs, and if bd can't be read.
+func beadsCompleted(externalIDs map[string]string) map[string]bool {
+	if outputAdapter != "beads" || dryRun || len(externalIDs) == 0 {
+		return nil
+	}
+	data, err := bdCommand("list", "--json", "--status=all", "--limit", "0").Output()
+	if err != nil {
+		return nil
+	}
+	issues, err := core.ParseBeadsListJSON(data)
+	if err != nil {
+		return nil
+	}
+
+	closed := make(map[string]bool)
+	for _, issue := range issues {
+		if issue.Status == "closed" {
+			closed[issue.ID] = true
+		}
+	}
+	completed := make(map[string]bool)
+	for tempID, id := range externalIDs {
+		if closed[id] {
+			completed[tempID] = true
+		}
+	}
+	return completed
+}
+
+// printDepths writes the dependency depth distribution, shallowest first.
+func printDepths(w io.Writer, depths map[int]int) {
+	if len(depths) == 0 {
+		return
+	}
+	levels := make([]int, 0, len(depths))
+	for depth := range depths {
+		levels = append(levels, depth)
+	}
+	sort.Ints(levels)
+
+	parts := make([]string, len(levels))
+	for i, depth := range levels {
+		parts[i] = fmt.Sprintf("%d at depth %d", depths[depth], depth)
+	}
+	fmt.Fprintf(w, "Dependency depth: %s\n", strings.Join(parts, ", "))
+}
+
+// printList writes a titled bullet list, or nothing if items is empty.
+func printList(w io.Writer, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s (%d):\n", title, len(items))
+	for _, item := range items {
+		fmt.Fprintf(w, "  - %s\n", item)
+	}
+}
+
+// formatMs renders milliseconds like "850ms" or "12.3s".
+func formatMs(ms int64) string {
+	if ms < 1000 {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return fmt.Sprintf("%.1fs", float64(ms)/1000)
+}
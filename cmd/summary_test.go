@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+	"github.com/dhabedank/prd-parser/internal/output"
+)
+
+func TestPrintSummaryAssumptions(t *testing.T) {
+	result := &core.OutputCreateResult{}
+	response := &core.ParseResponse{
+		Assumptions:   []string{"Email/password login"},
+		OpenQuestions: []string{"Which payment provider?"},
+	}
+
+	var out strings.Builder
+	if err := printSummary(&out, buildParseSummary(response, result, nil), false); err != nil {
+		t.Fatalf("printSummary() error = %v", err)
+	}
+	for _, want := range []string{"Assumptions (1):", "Email/password login", "Open questions (1):", "Which payment provider?"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("summary missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := printSummary(&out, buildParseSummary(response, result, nil), true); err != nil {
+		t.Fatalf("printSummary() error = %v", err)
+	}
+	if !strings.Contains(out.String(), `"open_questions"`) {
+		t.Errorf("JSON summary missing open_questions:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := printSummary(&out, buildParseSummary(&core.ParseResponse{}, result, nil), false); err != nil {
+		t.Fatalf("printSummary() error = %v", err)
+	}
+	if strings.Contains(out.String(), "Assumptions") || strings.Contains(out.String(), "Open questions") {
+		t.Errorf("summary should omit empty sections:\n%s", out.String())
+	}
+}
+
+func TestPrintSummaryDependencyDepths(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Tasks: []core.Task{{TempID: "1.1"}, {TempID: "1.2", DependsOn: []string{"1.1"}}}},
+	}}
+
+	var out strings.Builder
+	if err := printSummary(&out, buildParseSummary(response, &core.OutputCreateResult{}, nil), false); err != nil {
+		t.Fatalf("printSummary() error = %v", err)
+	}
+	if want := "Dependency depth: 2 at depth 0, 1 at depth 1"; !strings.Contains(out.String(), want) {
+		t.Errorf("summary missing %q:\n%s", want, out.String())
+	}
+}
+
+func TestPrintSummaryReadyItems(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "Setup", Tasks: []core.Task{
+			{TempID: "1.1", Title: "Repo"},
+			{TempID: "1.2", Title: "CI", DependsOn: []string{"1.1"}},
+		}},
+	}}
+	summary := buildParseSummary(response, &core.OutputCreateResult{}, nil)
+	summary.Ready = readyItems(response, map[string]string{"1.1": "app-e1t1"}, nil)
+
+	var out strings.Builder
+	if err := printSummary(&out, summary, false); err != nil {
+		t.Fatalf("printSummary() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Ready to start (1):\n  - app-e1t1 Repo (1.1)") {
+		t.Errorf("summary missing ready items:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "CI") {
+		t.Errorf("blocked task listed as ready:\n%s", out.String())
+	}
+}
+
+func TestPrintSummaryTiming(t *testing.T) {
+	result := &output.CreateResult{
+		Created: []output.CreatedItem{
+			{TempID: "1", ExternalID: "app-e1", Title: "Setup", Duration: 200 * time.Millisecond},
+			{TempID: "1.1", ExternalID: "app-e1t1", Title: "Repo", Duration: 1500 * time.Millisecond},
+		},
+		Elapsed: 2100 * time.Millisecond,
+	}
+	summary := buildParseSummary(&core.ParseResponse{}, &core.OutputCreateResult{}, nil)
+	summary.Timing = buildCreationTiming(result, 1)
+
+	var out strings.Builder
+	if err := printSummary(&out, summary, false); err != nil {
+		t.Fatalf("printSummary() error = %v", err)
+	}
+	want := "Creation time: 2.1s\nSlowest creations (1):\n      1.5s  app-e1t1 Repo (1.1)\n"
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("summary missing timing %q:\n%s", want, out.String())
+	}
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/dhabedank/prd-parser/internal/tui"
+)
+
+// noEmoji is the --no-emoji flag: print ASCII instead of Unicode decorations.
+var noEmoji bool
+
+// plainSymbols reports whether output decorations should be ASCII: with
+// --no-emoji, when NO_COLOR is set, or when stdout isn't a terminal.
+func plainSymbols() bool {
+	if noEmoji || os.Getenv("NO_COLOR") != "" {
+		return true
+	}
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return true
+	}
+	return info.Mode()&os.ModeCharDevice == 0
+}
+
+// ApplySymbols selects the output decorations for this run. Call it before
+// running a command, once flags are parsed.
+func ApplySymbols() {
+	tui.SetPlain(plainSymbols())
+}
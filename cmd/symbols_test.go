@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+	"github.com/dhabedank/prd-parser/internal/tui"
+)
+
+func TestNoEmojiUsesASCIISymbols(t *testing.T) {
+	oldNoEmoji := noEmoji
+	t.Cleanup(func() {
+		noEmoji = oldNoEmoji
+		tui.SetPlain(false)
+	})
+
+	noEmoji = true
+	ApplySymbols()
+
+	warnings := core.NewWarningCollector()
+	warnings.Add(core.WarnReviewFailed, "", "review failed: timeout")
+
+	var out strings.Builder
+	if err := printSummary(&out, buildParseSummary(&core.ParseResponse{}, &core.OutputCreateResult{}, warnings), false); err != nil {
+		t.Fatalf("printSummary() error = %v", err)
+	}
+	stage := tui.RenderStageComplete("Stage 1", time.Second, 400, 400, "claude-sonnet-4")
+	text := out.String() + stage
+
+	if !strings.Contains(out.String(), "  - ") || !strings.Contains(stage, "[ok]") {
+		t.Errorf("expected ASCII fallbacks, got:\n%s", text)
+	}
+	for _, sym := range []string{"✓", "⚠", "•", "→"} {
+		if strings.Contains(text, sym) {
+			t.Errorf("output contains %q with --no-emoji:\n%s", sym, text)
+		}
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	noEmoji = false
+	if !plainSymbols() {
+		t.Error("plainSymbols() = false with NO_COLOR set")
+	}
+}
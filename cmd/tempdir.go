@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+)
+
+// envTempDir sets the temp directory when --temp-dir isn't given.
+const envTempDir = "PRD_PARSER_TMPDIR"
+
+// tempDirFlag is the --temp-dir directory for prompt files, debug dumps, and
+// checkpoints written to the temp directory. Empty means PRD_PARSER_TMPDIR,
+// then the system default.
+var tempDirFlag string
+
+// tempDir returns the directory for temp files: --temp-dir, then
+// PRD_PARSER_TMPDIR, then os.TempDir().
+func tempDir() string {
+	if tempDirFlag != "" {
+		return tempDirFlag
+	}
+	if dir := os.Getenv(envTempDir); dir != "" {
+		return dir
+	}
+	return os.TempDir()
+}
+
+// validateTempDir checks that the configured temp directory is a directory we
+// can create files in. The system default isn't checked.
+func validateTempDir() error {
+	if tempDirFlag == "" && os.Getenv(envTempDir) == "" {
+		return nil
+	}
+	dir := tempDir()
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("temp directory %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("temp directory %s is not a directory", dir)
+	}
+	probe, err := os.CreateTemp(dir, ".prd-parser-probe-*")
+	if err != nil {
+		return fmt.Errorf("temp directory %s is not writable: %w", dir, err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// workDir is the --dir working directory for beads operations and relative
+// paths. Empty means the current directory.
+var workDir string
+
+// AddPersistentFlags registers the flags shared by every command on root.
+func AddPersistentFlags(root *cobra.Command) {
+	root.PersistentFlags().StringVar(&workDir, "dir", "", "Working directory for beads operations and relative paths (default: current directory)")
+	root.PersistentFlags().StringVar(&tempDirFlag, "temp-dir", "", "Directory for prompt, debug, and import temp files (default: $PRD_PARSER_TMPDIR, then the system temp directory)")
+	root.PersistentFlags().BoolVar(&noEmoji, "no-emoji", false, "Print ASCII instead of Unicode symbols (also when NO_COLOR is set or output isn't a terminal)")
+}
+
+// validateWorkDir checks that the configured working directory exists and is a directory.
+func validateWorkDir() error {
+	if workDir == "" {
+		return nil
+	}
+	info, err := os.Stat(workDir)
+	if err != nil {
+		return fmt.Errorf("working directory %s: %w", workDir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("working directory %s is not a directory", workDir)
+	}
+	return nil
+}
+
+// workingDir returns the directory for bd calls and output adapters.
+func workingDir() string {
+	if workDir == "" {
+		return "."
+	}
+	return workDir
+}
+
+// inWorkDir resolves a relative path against the working directory.
+// Empty and absolute paths are returned unchanged.
+func inWorkDir(path string) string {
+	if path == "" || workDir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(workDir, path)
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// rateLimiter is implemented by errors that can say whether the provider
+// rate-limited the call (llm.Error does). core can't import llm, so rate
+// limits are recognized through this interface.
+type rateLimiter interface {
+	RateLimited() bool
+}
+
+// isRateLimited reports whether err, or an error it wraps, is a rate limit.
+func isRateLimited(err error) bool {
+	var rl rateLimiter
+	return errors.As(err, &rl) && rl.RateLimited()
+}
+
+// rateLimitRetries is how many times adaptiveLimiter.Do retries a
+// rate-limited call.
+const rateLimitRetries = 2
+
+// adaptiveLimiter bounds concurrent LLM calls in a Stage 2/3 fan-out and adapts
+// the bound to rate limits (additive-increase/multiplicative-decrease): a
+// rate-limited call halves the limit, down to 1, and each run of limit
+// consecutive successes raises it by one, back up to max. A fixed limiter
+// behaves like a plain semaphore of size max. Safe for concurrent use.
+type adaptiveLimiter struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	stage     string // Named in the message printed when the limit drops
+	fixed     bool
+	max       int
+	limit     int
+	active    int
+	successes int // Consecutive successes since the limit last changed
+}
+
+func newAdaptiveLimiter(stage string, max int, fixed bool) *adaptiveLimiter {
+	l := &adaptiveLimiter{stage: stage, fixed: fixed, max: max, limit: max}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// Acquire waits until fewer than limit calls are in flight and takes a slot.
+func (l *adaptiveLimiter) Acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+}
+
+// Release frees the slot taken by Acquire and adjusts the limit by the call's
+// outcome. Errors other than rate limits leave the limit alone.
+func (l *adaptiveLimiter) Release(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	defer l.cond.Broadcast()
+
+	if l.fixed {
+		return
+	}
+	switch {
+	case isRateLimited(err):
+		l.successes = 0
+		if l.limit == 1 {
+			return
+		}
+		l.limit /= 2
+		fmt.Printf("    Rate limited: %s concurrency reduced to %d\n", l.stage, l.limit)
+	case err == nil && l.limit < l.max:
+		l.successes++
+		if l.successes >= l.limit {
+			l.limit++
+			l.successes = 0
+		}
+	}
+}
+
+// Do runs call in a slot, as Acquire and Release around it would. A
+// rate-limited call is retried up to rateLimitRetries times, each retry taken
+// from budget, so generators that gave up on a rate limit get another try
+// once the lowered limit frees a slot. A fixed limiter doesn't retry.
+func (l *adaptiveLimiter) Do(budget *RetryBudget, call func() error) error {
+	for attempt := 0; ; attempt++ {
+		l.Acquire()
+		err := call()
+		l.Release(err)
+		if l.fixed || !isRateLimited(err) || attempt == rateLimitRetries || !budget.Take() {
+			return err
+		}
+	}
+}
+
+// Limit returns the current concurrency limit.
+func (l *adaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
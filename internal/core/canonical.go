@@ -0,0 +1,87 @@
+package core
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Canonicalize puts response in a stable form so that semantically equal plans
+// marshal to identical JSON: epics, tasks, and subtasks are ordered by temp_id,
+// labels and depends_on are sorted and deduplicated, and surrounding whitespace
+// is trimmed from titles and descriptions. It modifies response in place.
+func Canonicalize(response *ParseResponse) {
+	sortByTempID(response.Epics, func(e Epic) string { return e.TempID })
+	_ = WalkItems(response, func(item ItemRef) error {
+		switch item.Level {
+		case LevelEpic:
+			e := item.Epic
+			e.Title, e.Description = normalizeSpace(e.Title), strings.TrimSpace(e.Description)
+			e.Labels, e.DependsOn = sortedSet(e.Labels), canonicalDeps(e.DependsOn)
+			sortByTempID(e.Tasks, func(t Task) string { return t.TempID })
+		case LevelTask:
+			t := item.Task
+			t.Title, t.Description = normalizeSpace(t.Title), strings.TrimSpace(t.Description)
+			t.Labels, t.DependsOn = sortedSet(t.Labels), canonicalDeps(t.DependsOn)
+			sortByTempID(t.Subtasks, func(s Subtask) string { return s.TempID })
+		case LevelSubtask:
+			s := item.Subtask
+			s.Title, s.Description = normalizeSpace(s.Title), strings.TrimSpace(s.Description)
+			s.Labels, s.DependsOn = sortedSet(s.Labels), canonicalDeps(s.DependsOn)
+		}
+		return nil
+	})
+}
+
+// sortByTempID stably sorts items by temp_id, comparing each dotted part
+// numerically so that "1.10" follows "1.9".
+func sortByTempID[T any](items []T, tempID func(T) string) {
+	slices.SortStableFunc(items, func(a, b T) int {
+		return compareTempIDs(tempID(a), tempID(b))
+	})
+}
+
+// compareTempIDs orders temp_ids part by part, numerically where both parts are numbers.
+func compareTempIDs(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		var c int
+		if errA == nil && errB == nil {
+			c = na - nb
+		} else {
+			c = strings.Compare(pa[i], pb[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return len(pa) - len(pb)
+}
+
+// sortedSet returns values sorted with duplicates removed; empty stays nil.
+func sortedSet(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	values = slices.Clone(values)
+	slices.Sort(values)
+	return slices.Compact(values)
+}
+
+// canonicalDeps is sortedSet in temp_id order, always non-nil so the
+// required depends_on field marshals as [] rather than null.
+func canonicalDeps(deps []string) []string {
+	deps = sortedSet(deps)
+	if deps == nil {
+		return []string{}
+	}
+	slices.SortFunc(deps, compareTempIDs)
+	return deps
+}
+
+// normalizeSpace trims s and collapses internal runs of whitespace to single spaces.
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
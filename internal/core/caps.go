@@ -0,0 +1,95 @@
+package core
+
+import "fmt"
+
+// CapViolation is an epic with more tasks, or a task with more subtasks, than
+// the hard cap allows.
+type CapViolation struct {
+	ItemID string `json:"item_id"` // temp_id of the epic or task
+	Level  string `json:"level"`   // LevelTask or LevelSubtask: the kind of child over the cap
+	Count  int    `json:"count"`
+	Max    int    `json:"max"`
+}
+
+// String formats the violation for display.
+func (v CapViolation) String() string {
+	return fmt.Sprintf("%s has %d %ss (max %d)", v.ItemID, v.Count, v.Level, v.Max)
+}
+
+// CheckItemCaps reports epics with more than maxTasks tasks and tasks with more
+// than maxSubtasks subtasks. A cap of 0 means no limit.
+func CheckItemCaps(response *ParseResponse, maxTasks, maxSubtasks int) []CapViolation {
+	var violations []CapViolation
+	_ = WalkItems(response, func(item ItemRef) error {
+		switch {
+		case item.Level == LevelEpic && maxTasks > 0 && len(item.Epic.Tasks) > maxTasks:
+			violations = append(violations, CapViolation{ItemID: item.Epic.TempID, Level: LevelTask, Count: len(item.Epic.Tasks), Max: maxTasks})
+		case item.Level == LevelTask && maxSubtasks > 0 && len(item.Task.Subtasks) > maxSubtasks:
+			violations = append(violations, CapViolation{ItemID: item.Task.TempID, Level: LevelSubtask, Count: len(item.Task.Subtasks), Max: maxSubtasks})
+		}
+		return nil
+	})
+	return violations
+}
+
+// TrimToCaps drops the tasks and subtasks past each cap, keeping the first ones,
+// and removes depends_on references to the dropped items. It returns the temp_ids
+// of the dropped tasks and subtasks (a dropped task's subtasks are included).
+func TrimToCaps(response *ParseResponse, maxTasks, maxSubtasks int) []string {
+	var dropped []string
+	removed := make(map[string]bool)
+	drop := func(id string) {
+		dropped = append(dropped, id)
+		removed[id] = true
+	}
+
+	for ei := range response.Epics {
+		epic := &response.Epics[ei]
+		if maxTasks > 0 && len(epic.Tasks) > maxTasks {
+			for _, task := range epic.Tasks[maxTasks:] {
+				drop(task.TempID)
+				for _, subtask := range task.Subtasks {
+					drop(subtask.TempID)
+				}
+			}
+			epic.Tasks = epic.Tasks[:maxTasks]
+		}
+		for ti := range epic.Tasks {
+			task := &epic.Tasks[ti]
+			if maxSubtasks > 0 && len(task.Subtasks) > maxSubtasks {
+				for _, subtask := range task.Subtasks[maxSubtasks:] {
+					drop(subtask.TempID)
+				}
+				task.Subtasks = task.Subtasks[:maxSubtasks]
+			}
+		}
+	}
+	if len(dropped) == 0 {
+		return nil
+	}
+
+	keep := func(deps []string) []string {
+		if len(deps) == 0 {
+			return deps
+		}
+		kept := make([]string, 0, len(deps))
+		for _, dep := range deps {
+			if !removed[dep] {
+				kept = append(kept, dep)
+			}
+		}
+		return kept
+	}
+	_ = WalkItems(response, func(item ItemRef) error {
+		switch item.Level {
+		case LevelEpic:
+			item.Epic.DependsOn = keep(item.Epic.DependsOn)
+		case LevelTask:
+			item.Task.DependsOn = keep(item.Task.DependsOn)
+		case LevelSubtask:
+			item.Subtask.DependsOn = keep(item.Subtask.DependsOn)
+		}
+		return nil
+	})
+	return dropped
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Concept match modes for refine's issue scan (--match-mode).
+const (
+	MatchSubstring = "substring" // Case-insensitive substring; the default
+	MatchToken     = "token"     // Stemmed word sequences, so "tracking" matches "track"
+)
+
+// ConceptMatcher reports whether text mentions a concept.
+type ConceptMatcher interface {
+	Matches(text, concept string) bool
+}
+
+// NewConceptMatcher returns the matcher for a match mode ("" means substring).
+func NewConceptMatcher(mode string) (ConceptMatcher, error) {
+	switch mode {
+	case "", MatchSubstring:
+		return SubstringMatcher{}, nil
+	case MatchToken:
+		return TokenMatcher{}, nil
+	}
+	return nil, fmt.Errorf("unknown match mode: %s (use substring or token)", mode)
+}
+
+// SubstringMatcher matches when concept appears anywhere in text, ignoring case.
+type SubstringMatcher struct{}
+
+// Matches implements ConceptMatcher.
+func (SubstringMatcher) Matches(text, concept string) bool {
+	return strings.Contains(strings.ToLower(text), strings.ToLower(concept))
+}
+
+// TokenMatcher matches when the stemmed words of concept appear consecutively
+// in the stemmed words of text. It tolerates inflection ("tracking", "tracked",
+// "trackers" all match "track") and ignores partial words ("art" won't match "start").
+type TokenMatcher struct{}
+
+// Matches implements ConceptMatcher.
+func (TokenMatcher) Matches(text, concept string) bool {
+	want := stemmedTokens(concept)
+	if len(want) == 0 {
+		return false
+	}
+	have := stemmedTokens(text)
+	for i := 0; i+len(want) <= len(have); i++ {
+		match := true
+		for j, w := range want {
+			if have[i+j] != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// stemmedTokens splits s into lowercase words and stems each one.
+func stemmedTokens(s string) []string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = stem(w)
+	}
+	return words
+}
+
+// stem strips common English inflections: plurals, then -ing/-ed/-er, then a
+// silent final e, undoubling a trailing consonant ("running" -> "run"). It is
+// deliberately light; both sides of a comparison go through the same rules.
+func stem(word string) string {
+	const minStem = 3
+	cut := func(suffix string) bool {
+		if len(word)-len(suffix) >= minStem && strings.HasSuffix(word, suffix) {
+			word = word[:len(word)-len(suffix)]
+			return true
+		}
+		return false
+	}
+
+	switch {
+	case cut("ies"):
+		word += "y"
+	case strings.HasSuffix(word, "ss"):
+	default:
+		cut("s")
+	}
+
+	if cut("ing") || cut("ed") || cut("er") {
+		if n := len(word); n > minStem && word[n-1] == word[n-2] && !strings.ContainsRune("aeioulsz", rune(word[n-1])) {
+			word = word[:n-1]
+		}
+	}
+	cut("e")
+	return word
+}
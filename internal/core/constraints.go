@@ -0,0 +1,158 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ConstraintViolation is a generated item whose text appears to contradict a
+// project constraint.
+type ConstraintViolation struct {
+	ItemID     string `json:"item_id"`    // temp_id of the offending epic/task/subtask
+	Constraint string `json:"constraint"` // the constraint as written in the PRD
+	Keyword    string `json:"keyword"`    // the term in the item that triggered the match
+}
+
+// String formats the violation for display.
+func (v ConstraintViolation) String() string {
+	return fmt.Sprintf("%s mentions %q, which conflicts with constraint %q", v.ItemID, v.Keyword, v.Constraint)
+}
+
+// constraintRule maps phrases that may appear in a constraint to terms that
+// contradict it when they show up in an item.
+type constraintRule struct {
+	triggers  []string
+	forbidden []string
+}
+
+// hostedServices are common third-party services that show up in generated plans.
+var hostedServices = []string{
+	"stripe", "paypal", "braintree", "twilio", "sendgrid", "mailgun", "postmark",
+	"auth0", "okta", "clerk", "firebase", "supabase", "aws", "s3", "lambda",
+	"google cloud", "gcp", "azure", "vercel", "netlify", "heroku", "algolia",
+	"openai", "google maps", "mapbox", "segment", "mixpanel", "sentry", "datadog",
+	"pusher", "cloudinary", "third-party api", "third party api", "external api",
+}
+
+// constraintRules are the keyword-based contradictions we know how to spot.
+// Matching is deliberately conservative: a rule only fires when the constraint
+// clearly rules something out and the item names it outright.
+var constraintRules = []constraintRule{
+	{
+		triggers:  []string{"no third-party", "no third party", "no external", "without external", "without third-party", "without third party", "self-hosted only", "no cloud", "no saas"},
+		forbidden: hostedServices,
+	},
+	{
+		triggers:  []string{"offline-only", "offline only", "no internet", "no network access"},
+		forbidden: hostedServices,
+	},
+	{
+		triggers:  []string{"no database", "without a database", "no db"},
+		forbidden: []string{"database", "postgres", "postgresql", "mysql", "mongodb", "sqlite", "redis", "dynamodb"},
+	},
+	{
+		triggers:  []string{"no backend", "no server", "client-side only", "frontend only", "static site"},
+		forbidden: []string{"backend server", "api server", "server-side", "express", "django", "rails", "node server"},
+	},
+}
+
+// explicitBan matches constraints of the form "no X", "don't use X", "avoid X",
+// where X is a single technology name checked verbatim against item text.
+var explicitBan = regexp.MustCompile(`(?i)^(?:no|avoid|(?:do not|don't|must not|cannot|can't) use|without)\s+([a-z0-9.+#-]+)$`)
+
+// CheckConstraintViolations cross-checks epic, task, and subtask text against
+// the project constraints and reports obvious contradictions, such as a task to
+// "set up Stripe" under a "no third-party APIs" constraint. It is keyword-based,
+// so results are hints for review rather than proof of a problem.
+func CheckConstraintViolations(response *ParseResponse) []ConstraintViolation {
+	type check struct {
+		constraint string
+		keywords   []wordPattern
+	}
+
+	var checks []check
+	for _, constraint := range response.Project.Constraints {
+		lower := strings.ToLower(strings.TrimSpace(constraint))
+		var keywords []string
+		for _, rule := range constraintRules {
+			if containsAny(lower, rule.triggers) {
+				keywords = append(keywords, rule.forbidden...)
+			}
+		}
+		if m := explicitBan.FindStringSubmatch(strings.TrimRight(lower, ".")); m != nil {
+			keywords = append(keywords, m[1])
+		}
+		if len(keywords) > 0 {
+			checks = append(checks, check{constraint: constraint, keywords: compileWordPatterns(keywords)})
+		}
+	}
+	if len(checks) == 0 {
+		return nil
+	}
+
+	var violations []ConstraintViolation
+	scan := func(itemID string, texts ...string) {
+		text := strings.ToLower(strings.Join(texts, "\n"))
+		for _, c := range checks {
+			if keyword := firstWordMatch(text, c.keywords); keyword != "" {
+				violations = append(violations, ConstraintViolation{ItemID: itemID, Constraint: c.constraint, Keyword: keyword})
+			}
+		}
+	}
+
+	_ = WalkItems(response, func(item ItemRef) error {
+		switch item.Level {
+		case LevelEpic:
+			scan(item.Epic.TempID, item.Epic.Title, item.Epic.Description)
+		case LevelTask:
+			designNotes := ""
+			if item.Task.DesignNotes != nil {
+				designNotes = *item.Task.DesignNotes
+			}
+			scan(item.Task.TempID, item.Task.Title, item.Task.Description, designNotes)
+		case LevelSubtask:
+			scan(item.Subtask.TempID, item.Subtask.Title, item.Subtask.Description)
+		}
+		return nil
+	})
+	return violations
+}
+
+// containsAny reports whether s contains any of the substrings.
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordPattern is a keyword and the regexp matching it as a whole word.
+type wordPattern struct {
+	keyword string
+	re      *regexp.Regexp
+}
+
+// compileWordPatterns compiles each lowercase keyword once, so a constraint's
+// keywords aren't recompiled for every item scanned.
+func compileWordPatterns(keywords []string) []wordPattern {
+	patterns := make([]wordPattern, len(keywords))
+	for i, keyword := range keywords {
+		pattern := `(^|[^a-z0-9])` + regexp.QuoteMeta(keyword) + `($|[^a-z0-9])`
+		patterns[i] = wordPattern{keyword: keyword, re: regexp.MustCompile(pattern)}
+	}
+	return patterns
+}
+
+// firstWordMatch returns the first keyword found in text as a whole word, or "".
+// text is expected to be lowercase.
+func firstWordMatch(text string, keywords []wordPattern) string {
+	for _, p := range keywords {
+		if p.re.MatchString(text) {
+			return p.keyword
+		}
+	}
+	return ""
+}
@@ -0,0 +1,299 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FlexibleContext is epic/task context. LLMs return context either as a plain
+// string or as an object with ContextBlock fields (a field may be a list of
+// strings); FlexibleContext accepts both, and null, and keeps what it was
+// given so the plan marshals back to the same shape.
+type FlexibleContext struct {
+	value interface{} // nil, string, or map[string]interface{}
+}
+
+// ContextText returns plain-string context.
+func ContextText(text string) FlexibleContext {
+	return FlexibleContext{value: text}
+}
+
+// ContextObject returns object-form context with the given fields.
+func ContextObject(fields map[string]interface{}) FlexibleContext {
+	if fields == nil {
+		return FlexibleContext{}
+	}
+	return FlexibleContext{value: fields}
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling for FlexibleContext.
+func (c *FlexibleContext) UnmarshalJSON(data []byte) error {
+	// Handle null and booleans (treated as no context, as in FlexibleString)
+	switch string(data) {
+	case "null", "false", "true":
+		*c = FlexibleContext{}
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*c = ContextText(s)
+		return nil
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err == nil {
+		*c = ContextObject(obj)
+		return nil
+	}
+
+	// A list of strings is joined into plain context
+	var arr []string
+	if err := json.Unmarshal(data, &arr); err == nil {
+		*c = ContextText(strings.Join(arr, "; "))
+		return nil
+	}
+
+	return fmt.Errorf("FlexibleContext: cannot unmarshal %s", string(data))
+}
+
+// MarshalJSON writes the context in the shape it was given: a string, an
+// object, or null.
+func (c FlexibleContext) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.value)
+}
+
+// IsZero reports whether there is no context.
+func (c FlexibleContext) IsZero() bool {
+	return strings.TrimSpace(c.String()) == ""
+}
+
+// IsObject reports whether the context was given in object form.
+func (c FlexibleContext) IsObject() bool {
+	_, ok := c.value.(map[string]interface{})
+	return ok
+}
+
+// Raw returns plain-string context as given, or "" for object-form context.
+func (c FlexibleContext) Raw() string {
+	s, _ := c.value.(string)
+	return s
+}
+
+// Block returns object-form context as a ContextBlock, with list fields joined
+// with "; ". It returns nil for plain-string context or an object with none of
+// the known fields.
+func (c FlexibleContext) Block() *ContextBlock {
+	obj, ok := c.value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	field := func(key string) *string {
+		if v := contextValue(obj[key]); v != "" {
+			return &v
+		}
+		return nil
+	}
+	block := &ContextBlock{
+		BusinessContext: field("business_context"),
+		TargetUsers:     field("target_users"),
+		BrandVoice:      field("brand_voice"),
+		SuccessMetrics:  field("success_metrics"),
+	}
+	if *block == (ContextBlock{}) {
+		return nil
+	}
+	return block
+}
+
+// Value returns the context as decoded: nil, a string, or a map.
+func (c FlexibleContext) Value() interface{} {
+	return c.value
+}
+
+// String renders the context as text (see ContextToString).
+func (c FlexibleContext) String() string {
+	return ContextToString(c.value)
+}
+
+// contextFields lists the known object-form context keys and their display labels,
+// in the order they should be rendered.
+var contextFields = []struct {
+	Key   string
+	Label string
+}{
+	{"business_context", "Business Context"},
+	{"target_users", "Target Users"},
+	{"brand_voice", "Brand Voice"},
+	{"success_metrics", "Success Metrics"},
+}
+
+// ContextToString renders epic/task context as text.
+// context is a FlexibleContext, or a plain string or an object with
+// business_context/target_users/brand_voice/success_metrics fields.
+// Object context is rendered as one "- **Label:** value" line per non-empty field,
+// then any other keys, sorted, as "- key: value" lines; a field given as a list
+// of strings is joined with "; ".
+func ContextToString(context interface{}) string {
+	switch ctx := context.(type) {
+	case nil:
+		return ""
+	case FlexibleContext:
+		return ctx.String()
+	case *FlexibleContext:
+		if ctx == nil {
+			return ""
+		}
+		return ctx.String()
+	case string:
+		return ctx
+	case map[string]interface{}:
+		return strings.Join(contextLines(ctx, true), "\n")
+	default:
+		return ""
+	}
+}
+
+// contextLines renders object-form context's known fields as labeled lines
+// and, with extra, the remaining keys as "- key: value" lines.
+func contextLines(ctx map[string]interface{}, extra bool) []string {
+	parts := []string{}
+	known := make(map[string]bool, len(contextFields))
+	for _, field := range contextFields {
+		known[field.Key] = true
+		if v := contextValue(ctx[field.Key]); v != "" {
+			parts = append(parts, fmt.Sprintf("- **%s:** %s", field.Label, v))
+		}
+	}
+	if !extra {
+		return parts
+	}
+
+	var keys []string
+	for key := range ctx {
+		if !known[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if v := extraContextValue(ctx[key]); v != "" {
+			parts = append(parts, fmt.Sprintf("- %s: %s", key, v))
+		}
+	}
+	return parts
+}
+
+// extraContextValue renders the value of an unknown context key: as
+// contextValue does, or as compact JSON for numbers, booleans, and objects.
+func extraContextValue(v interface{}) string {
+	if s := contextValue(v); s != "" {
+		return s
+	}
+	switch v.(type) {
+	case nil, string, []interface{}:
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// FormatContextBlock renders context as a "**Context:**" description block, or
+// "" when there is none. context may be a FlexibleContext, a string, a *string
+// (subtask context), or object-form context; a single line follows the label,
+// object fields are listed below it.
+func FormatContextBlock(context interface{}) string {
+	if s, ok := context.(*string); ok {
+		if s == nil {
+			return ""
+		}
+		context = *s
+	}
+	text := ContextToString(context)
+	switch {
+	case strings.TrimSpace(text) == "":
+		return ""
+	case strings.Contains(text, "\n") || strings.HasPrefix(text, "- "):
+		return "**Context:**\n" + text
+	default:
+		return "**Context:** " + text
+	}
+}
+
+// FormatTesting renders testing requirements as a "**Testing Requirements:**"
+// description block listing the non-empty kinds, or "" when there are none.
+func FormatTesting(testing *TestingRequirements) string {
+	if testing == nil {
+		return ""
+	}
+	var parts []string
+	for _, req := range []struct {
+		label string
+		value *FlexibleString
+	}{
+		{"Unit Tests", testing.UnitTests},
+		{"Integration Tests", testing.IntegrationTests},
+		{"Type Tests", testing.TypeTests},
+		{"E2E Tests", testing.E2ETests},
+	} {
+		if req.value != nil && strings.TrimSpace(string(*req.value)) != "" {
+			parts = append(parts, fmt.Sprintf("- **%s:** %s", req.label, *req.value))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "**Testing Requirements:**\n" + strings.Join(parts, "\n")
+}
+
+// contextValue returns an object-form context field as text: strings as is,
+// lists of strings joined with "; ", anything else empty.
+func contextValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case []interface{}:
+		var items []string
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				items = append(items, strings.TrimSpace(s))
+			}
+		}
+		return strings.Join(items, "; ")
+	default:
+		return ""
+	}
+}
+
+// promptContext renders epic/task context for interpolation into a stage
+// prompt's "- Context: " line. Multi-line (object) context starts on its own
+// line, indented under the label, and missing context reads "none".
+func promptContext(context FlexibleContext) string {
+	return indentPromptContext(context.String())
+}
+
+// knownFieldsPromptContext is promptContext as of prompt version v2, which
+// left out object keys that aren't in contextFields.
+func knownFieldsPromptContext(context FlexibleContext) string {
+	if obj, ok := context.Value().(map[string]interface{}); ok {
+		return indentPromptContext(strings.Join(contextLines(obj, false), "\n"))
+	}
+	return promptContext(context)
+}
+
+// indentPromptContext lays out rendered context text for a "- Context: " line.
+func indentPromptContext(text string) string {
+	switch {
+	case strings.TrimSpace(text) == "":
+		return "none"
+	case strings.Contains(text, "\n") || strings.HasPrefix(text, "- "):
+		return "\n  " + strings.ReplaceAll(text, "\n", "\n  ")
+	default:
+		return text
+	}
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContextLimit is the context window assumed for models not in
+// ModelContextLimits, including the adapters' default (unset) model.
+const DefaultContextLimit = 200000
+
+// DefaultOutputReserve is the room left for the response when the output
+// token limit isn't configured.
+const DefaultOutputReserve = 16384
+
+// ModelContextLimits maps model name prefixes to context windows in tokens.
+// The longest matching prefix wins.
+var ModelContextLimits = map[string]int{
+	"claude-opus-4":     200000,
+	"claude-sonnet-4":   200000,
+	"claude-3-7-sonnet": 200000,
+	"claude-3-5-sonnet": 200000,
+	"claude-3-5-haiku":  200000,
+	"claude-3-haiku":    200000,
+	"gpt-4o":            128000,
+	"gpt-4.1":           1000000,
+	"gpt-5":             400000,
+	"o3":                200000,
+	"o4-mini":           200000,
+}
+
+// ContextLimit returns the context window for model.
+func ContextLimit(model string) int {
+	best, limit := 0, DefaultContextLimit
+	for prefix, n := range ModelContextLimits {
+		if strings.HasPrefix(model, prefix) && len(prefix) > best {
+			best, limit = len(prefix), n
+		}
+	}
+	return limit
+}
+
+// EstimateTokens roughly estimates the token count of text (about four
+// characters per token for English prose and JSON).
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// PromptSizeIssue is a prompt that likely won't fit the model's context window.
+type PromptSizeIssue struct {
+	Model        string
+	PromptTokens int // Estimated system + user prompt tokens
+	Reserve      int // Tokens left for the response
+	Limit        int // Model context window
+}
+
+// String formats the issue for display.
+func (i PromptSizeIssue) String() string {
+	model := i.Model
+	if model == "" {
+		model = "the default model"
+	}
+	return fmt.Sprintf("prompt is ~%d tokens plus %d reserved for the response, over the %d-token context of %s",
+		i.PromptTokens, i.Reserve, i.Limit, model)
+}
+
+// CheckPromptSize estimates whether the prompts plus reserve output tokens fit
+// model's context window and returns the issue if they likely don't, or nil.
+// A reserve of 0 uses DefaultOutputReserve.
+func CheckPromptSize(model, systemPrompt, userPrompt string, reserve int) *PromptSizeIssue {
+	if reserve <= 0 {
+		reserve = DefaultOutputReserve
+	}
+	issue := PromptSizeIssue{
+		Model:        model,
+		PromptTokens: EstimateTokens(systemPrompt) + EstimateTokens(userPrompt),
+		Reserve:      reserve,
+		Limit:        ContextLimit(model),
+	}
+	if issue.PromptTokens+issue.Reserve <= issue.Limit {
+		return nil
+	}
+	return &issue
+}
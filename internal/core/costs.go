@@ -0,0 +1,89 @@
+package core
+
+import "sync"
+
+// CallCost is the reported cost of one LLM call, labeled with the level it
+// generated (LevelEpic for Stage 1, LevelTask for Stage 2, LevelSubtask for
+// Stage 3) and the item it was for.
+type CallCost struct {
+	Stage  string
+	ItemID string // temp_id of the epic (Stage 2) or task (Stage 3); empty for Stage 1
+	USD    float64
+}
+
+// CostRecorder collects the costs of a run's LLM calls (--epic-costs). Safe
+// for concurrent use. A nil recorder discards costs.
+type CostRecorder struct {
+	mu    sync.Mutex
+	costs []CallCost
+}
+
+// NewCostRecorder creates an empty recorder.
+func NewCostRecorder() *CostRecorder {
+	return &CostRecorder{}
+}
+
+// Record adds the cost of one call.
+func (r *CostRecorder) Record(stage, itemID string, usd float64) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.costs = append(r.costs, CallCost{Stage: stage, ItemID: itemID, USD: usd})
+}
+
+// Costs returns a copy of the recorded costs in the order they were recorded.
+func (r *CostRecorder) Costs() []CallCost {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]CallCost(nil), r.costs...)
+}
+
+// EpicCost is what it cost to decompose one epic: its Stage 2 call and the
+// Stage 3 calls for its tasks, retries included.
+type EpicCost struct {
+	EpicID      string  `json:"epic_id"`
+	Title       string  `json:"title"`
+	Calls       int     `json:"calls"`
+	TasksUSD    float64 `json:"tasks_usd"`    // Stage 2
+	SubtasksUSD float64 `json:"subtasks_usd"` // Stage 3
+}
+
+// TotalUSD returns the epic's Stage 2 and Stage 3 cost.
+func (c EpicCost) TotalUSD() float64 {
+	return c.TasksUSD + c.SubtasksUSD
+}
+
+// CostByEpic attributes costs to the epics of response, in plan order. Stage 2
+// calls belong to the epic they were for, and Stage 3 calls to the epic of
+// their task. Everything else (Stage 1, calls for items no longer in the plan)
+// is returned as unattributed.
+func CostByEpic(response *ParseResponse, costs []CallCost) (epics []EpicCost, unattributed float64) {
+	epicOf := make(map[string]int) // temp_id of an epic or task -> index in epics
+	for i, epic := range response.Epics {
+		epics = append(epics, EpicCost{EpicID: epic.TempID, Title: epic.Title})
+		epicOf[epic.TempID] = i
+		for _, task := range epic.Tasks {
+			epicOf[task.TempID] = i
+		}
+	}
+
+	for _, cost := range costs {
+		i, ok := epicOf[cost.ItemID]
+		switch {
+		case ok && cost.Stage == LevelTask:
+			epics[i].TasksUSD += cost.USD
+		case ok && cost.Stage == LevelSubtask:
+			epics[i].SubtasksUSD += cost.USD
+		default:
+			unattributed += cost.USD
+			continue
+		}
+		epics[i].Calls++
+	}
+	return epics, unattributed
+}
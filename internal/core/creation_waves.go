@@ -0,0 +1,72 @@
+package core
+
+// CreationWaves groups items into waves that can be created together: every
+// item's parent and depends_on targets are in an earlier wave, so wave 0 holds
+// the epics with no dependencies. Items keep hierarchical order within a wave.
+// Dependencies on unknown IDs are ignored. acyclic is false when depends_on
+// forms a cycle; the waves are then nil and callers should fall back to
+// creating items in hierarchy order.
+func CreationWaves(response *ParseResponse) (waves [][]ItemRef, acyclic bool) {
+	var items []ItemRef
+	index := make(map[string]int)
+	_ = WalkItems(response, func(item ItemRef) error {
+		index[item.TempID()] = len(items)
+		items = append(items, item)
+		return nil
+	})
+
+	indegree := make([]int, len(items))
+	dependents := make([][]int, len(items))
+	for i, item := range items {
+		blockers := item.DependsOn()
+		if parent := item.ParentTempID(); parent != "" {
+			blockers = append([]string{parent}, blockers...)
+		}
+		seen := make(map[int]bool)
+		for _, id := range blockers {
+			b, ok := index[id]
+			if !ok || b == i || seen[b] {
+				continue
+			}
+			seen[b] = true
+			dependents[b] = append(dependents[b], i)
+			indegree[i]++
+		}
+	}
+
+	// Kahn's algorithm, one layer at a time
+	var wave []int
+	for i, n := range indegree {
+		if n == 0 {
+			wave = append(wave, i)
+		}
+	}
+	placed := 0
+	for len(wave) > 0 {
+		refs := make([]ItemRef, len(wave))
+		next := make(map[int]bool)
+		for j, i := range wave {
+			refs[j] = items[i]
+			for _, d := range dependents[i] {
+				indegree[d]--
+				if indegree[d] == 0 {
+					next[d] = true
+				}
+			}
+		}
+		waves = append(waves, refs)
+		placed += len(wave)
+
+		wave = nil
+		for i := range items {
+			if next[i] {
+				wave = append(wave, i)
+			}
+		}
+	}
+
+	if placed != len(items) {
+		return nil, false
+	}
+	return waves, true
+}
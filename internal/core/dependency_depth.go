@@ -0,0 +1,44 @@
+package core
+
+// DependencyDepths counts items by the length of their longest depends_on chain:
+// depth 0 has no dependencies, depth 1 depends only on depth-0 items, and so on.
+// Dependencies on unknown IDs are ignored, and a dependency that closes a cycle
+// is skipped so every item still gets a depth.
+func DependencyDepths(response *ParseResponse) map[int]int {
+	deps := make(map[string][]string)
+	var order []string
+	_ = WalkItems(response, func(item ItemRef) error {
+		id := item.TempID()
+		deps[id] = item.DependsOn()
+		order = append(order, id)
+		return nil
+	})
+
+	depth := make(map[string]int, len(order))
+	visiting := make(map[string]bool)
+	var visit func(id string) int
+	visit = func(id string) int {
+		if d, ok := depth[id]; ok {
+			return d
+		}
+		visiting[id] = true
+		d := 0
+		for _, dep := range deps[id] {
+			if _, known := deps[dep]; !known || visiting[dep] {
+				continue
+			}
+			if n := visit(dep) + 1; n > d {
+				d = n
+			}
+		}
+		visiting[id] = false
+		depth[id] = d
+		return d
+	}
+
+	counts := make(map[int]int)
+	for _, id := range order {
+		counts[visit(id)]++
+	}
+	return counts
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DependencyLevelIssue is a depends_on link between levels that is usually a
+// modeling mistake, such as a subtask depending on a whole epic.
+type DependencyLevelIssue struct {
+	ItemID     string `json:"item_id"`    // temp_id of the dependent item
+	DependsOn  string `json:"depends_on"` // temp_id of the suspicious target
+	Suggestion string `json:"suggestion"` // likely intended fix
+}
+
+// String formats the issue for display.
+func (i DependencyLevelIssue) String() string {
+	return fmt.Sprintf("%s depends on %s %s - %s", i.ItemID, tempIDLevel(i.DependsOn), i.DependsOn, i.Suggestion)
+}
+
+// CheckDependencyLevels reports depends_on links that cross levels in unusual ways:
+// a subtask depending on an epic (it should depend on one of the epic's tasks),
+// a task depending on a subtask (it should depend on the subtask's task), and an
+// epic depending on a task or subtask (it should depend on that item's epic).
+// Dependencies on unknown IDs are ignored.
+func CheckDependencyLevels(response *ParseResponse) []DependencyLevelIssue {
+	lastTask := make(map[string]string) // epic temp_id -> temp_id of its last task
+	known := make(map[string]bool)
+	_ = WalkItems(response, func(item ItemRef) error {
+		known[item.TempID()] = true
+		if item.Level == LevelTask {
+			lastTask[item.Epic.TempID] = item.Task.TempID
+		}
+		return nil
+	})
+
+	var issues []DependencyLevelIssue
+	_ = WalkItems(response, func(item ItemRef) error {
+		itemID := item.TempID()
+		for _, dep := range item.DependsOn() {
+			if !known[dep] {
+				continue
+			}
+			var suggestion string
+			switch depLevel := tempIDLevel(dep); {
+			case item.Level == LevelSubtask && depLevel == LevelEpic:
+				switch {
+				case item.Epic.TempID == dep:
+					suggestion = "already implied by the hierarchy; remove it or depend on a sibling task instead"
+				case lastTask[dep] != "":
+					suggestion = fmt.Sprintf("depend on a task in epic %s instead (e.g. its final task %s)", dep, lastTask[dep])
+				default:
+					suggestion = fmt.Sprintf("depend on a task in epic %s instead", dep)
+				}
+			case item.Level == LevelTask && depLevel == LevelSubtask:
+				if parent := tempIDPrefix(dep, 2); parent == itemID {
+					suggestion = "a task can't depend on its own subtask; remove it"
+				} else {
+					suggestion = fmt.Sprintf("depend on its task %s instead", parent)
+				}
+			case item.Level == LevelEpic && depLevel != LevelEpic:
+				if epic := tempIDPrefix(dep, 1); epic == itemID {
+					suggestion = "an epic can't depend on its own children; remove it"
+				} else {
+					suggestion = fmt.Sprintf("depend on epic %s instead", epic)
+				}
+			default:
+				continue
+			}
+			issues = append(issues, DependencyLevelIssue{ItemID: itemID, DependsOn: dep, Suggestion: suggestion})
+		}
+		return nil
+	})
+	return issues
+}
+
+// tempIDLevel returns the hierarchy level of a temp_id ("1.2" is a task).
+func tempIDLevel(tempID string) string {
+	switch strings.Count(tempID, ".") {
+	case 0:
+		return LevelEpic
+	case 1:
+		return LevelTask
+	default:
+		return LevelSubtask
+	}
+}
+
+// tempIDPrefix returns the first n parts of tempID ("1.2.3" with n=2 is "1.2").
+func tempIDPrefix(tempID string, n int) string {
+	parts := strings.Split(tempID, ".")
+	if n > len(parts) {
+		return tempID
+	}
+	return strings.Join(parts[:n], ".")
+}
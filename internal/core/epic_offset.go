@@ -0,0 +1,75 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OffsetEpicIDs renumbers the plan so its first epic is start: with epics 1-3
+// and start 5, epic "1" becomes "5", task "1.2" "5.2", and subtask "1.2.3"
+// "5.2.3". depends_on references to items in the plan are remapped to match;
+// other references are left as they are. Epic temp_ids must be integers.
+func OffsetEpicIDs(response *ParseResponse, start int) error {
+	if len(response.Epics) == 0 {
+		return nil
+	}
+	first := 0
+	for i, epic := range response.Epics {
+		n, err := strconv.Atoi(epic.TempID)
+		if err != nil {
+			return fmt.Errorf("epic temp_id %q is not a number", epic.TempID)
+		}
+		if i == 0 || n < first {
+			first = n
+		}
+	}
+	offset := start - first
+	if offset == 0 {
+		return nil
+	}
+
+	renamed := make(map[string]string)
+	_ = WalkItems(response, func(item ItemRef) error {
+		id := item.TempID()
+		renamed[id] = shiftEpicNumber(id, offset)
+		return nil
+	})
+
+	remap := func(deps []string) {
+		for i, dep := range deps {
+			if id, ok := renamed[dep]; ok {
+				deps[i] = id
+			}
+		}
+	}
+	return WalkItems(response, func(item ItemRef) error {
+		switch item.Level {
+		case LevelEpic:
+			item.Epic.TempID = renamed[item.Epic.TempID]
+			remap(item.Epic.DependsOn)
+		case LevelTask:
+			item.Task.TempID = renamed[item.Task.TempID]
+			remap(item.Task.DependsOn)
+		case LevelSubtask:
+			item.Subtask.TempID = renamed[item.Subtask.TempID]
+			remap(item.Subtask.DependsOn)
+		}
+		return nil
+	})
+}
+
+// shiftEpicNumber adds offset to the epic part of a temp_id ("1.2" +4 is "5.2").
+// IDs whose epic part isn't a number are returned unchanged.
+func shiftEpicNumber(tempID string, offset int) string {
+	epic, rest, _ := strings.Cut(tempID, ".")
+	n, err := strconv.Atoi(epic)
+	if err != nil {
+		return tempID
+	}
+	shifted := strconv.Itoa(n + offset)
+	if rest == "" {
+		return shifted
+	}
+	return shifted + "." + rest
+}
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MinEstimateCoverage is the fraction of items that should carry an estimate;
+// below it, rollups and scheduling built on the estimates aren't meaningful.
+const MinEstimateCoverage = 0.5
+
+// EstimateCoverage counts the items that have estimates: estimated_days on
+// epics, estimated_hours on tasks, estimated_minutes on subtasks.
+type EstimateCoverage struct {
+	Items     int      // All epics, tasks, and subtasks
+	Estimated int      // Items with a positive estimate
+	Missing   []string // temp_ids of items without one, in plan order
+}
+
+// Fraction returns the share of items with estimates (1 for an empty plan).
+func (c EstimateCoverage) Fraction() float64 {
+	if c.Items == 0 {
+		return 1
+	}
+	return float64(c.Estimated) / float64(c.Items)
+}
+
+// Low reports whether coverage is below MinEstimateCoverage.
+func (c EstimateCoverage) Low() bool {
+	return c.Fraction() < MinEstimateCoverage
+}
+
+// CheckEstimateCoverage reports how many items in response have estimates.
+func CheckEstimateCoverage(response *ParseResponse) EstimateCoverage {
+	var c EstimateCoverage
+	_ = WalkItems(response, func(item ItemRef) error {
+		c.Items++
+		if hasEstimate(item) {
+			c.Estimated++
+		} else {
+			c.Missing = append(c.Missing, item.TempID())
+		}
+		return nil
+	})
+	return c
+}
+
+func hasEstimate(item ItemRef) bool {
+	switch item.Level {
+	case LevelSubtask:
+		return item.Subtask.EstimatedMinutes != nil && *item.Subtask.EstimatedMinutes > 0
+	case LevelTask:
+		return item.Task.EstimatedHours != nil && *item.Task.EstimatedHours > 0
+	default:
+		return item.Epic.EstimatedDays != nil && *item.Epic.EstimatedDays > 0
+	}
+}
+
+// EstimatesSystemPrompt is the system prompt for the --require-estimates pass.
+const EstimatesSystemPrompt = `You are an experienced engineering lead estimating work items from a plan.
+
+Estimate ONLY the items you are given, in the unit stated for each level:
+- epic: working days for the whole epic (e.g. 5)
+- task: hours including its subtasks (e.g. 6)
+- subtask: minutes, between 15 and 120
+
+Base each estimate on the item's scope and the items around it. Don't change
+anything else about the plan.
+
+Return JSON only:
+{"estimates": [{"temp_id": "1.2", "value": 6}]}`
+
+// estimateUnits is the unit each level is estimated in.
+var estimateUnits = map[string]string{
+	LevelEpic:    "days",
+	LevelTask:    "hours",
+	LevelSubtask: "minutes",
+}
+
+// BuildEstimatesPrompt lists the items in response that have no estimate, with
+// their parents and descriptions, for EstimatesSystemPrompt. Items that have
+// estimates are left out.
+func BuildEstimatesPrompt(response *ParseResponse) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Project: %s\n", response.Project.ProductName)
+	if len(response.Project.TechStack) > 0 {
+		fmt.Fprintf(&b, "Tech stack: %s\n", strings.Join(response.Project.TechStack, ", "))
+	}
+	b.WriteString("\nITEMS WITHOUT ESTIMATES:\n")
+	_ = WalkItems(response, func(item ItemRef) error {
+		if hasEstimate(item) {
+			return nil
+		}
+		fmt.Fprintf(&b, "\n- %s %s [%s, estimate in %s]: %s\n", item.Level, item.TempID(), item.Title(), estimateUnits[item.Level], itemDescription(item))
+		if parent := item.ParentTempID(); parent != "" {
+			fmt.Fprintf(&b, "  Part of: %s\n", parent)
+		}
+		if item.Level == LevelTask {
+			fmt.Fprintf(&b, "  Subtasks: %d\n", len(item.Task.Subtasks))
+		}
+		return nil
+	})
+	b.WriteString("\nReturn an estimate for every item listed.")
+	return b.String()
+}
+
+// itemDescription returns the item's description, or "(no description)".
+func itemDescription(item ItemRef) string {
+	var desc string
+	switch item.Level {
+	case LevelSubtask:
+		desc = item.Subtask.Description
+	case LevelTask:
+		desc = item.Task.Description
+	default:
+		desc = item.Epic.Description
+	}
+	if strings.TrimSpace(desc) == "" {
+		return "(no description)"
+	}
+	return desc
+}
+
+// FillEstimates asks reviewer for the missing estimates and sets them on
+// response. Existing estimates, unknown temp_ids, and non-positive values are
+// left alone. It returns the number of estimates filled.
+func FillEstimates(ctx context.Context, response *ParseResponse, reviewer Reviewer) (int, error) {
+	if len(CheckEstimateCoverage(response).Missing) == 0 {
+		return 0, nil
+	}
+
+	output, err := reviewer.GenerateRaw(ctx, EstimatesSystemPrompt, BuildEstimatesPrompt(response))
+	if err != nil {
+		return 0, fmt.Errorf("estimates LLM call failed: %w", err)
+	}
+	start, end := strings.Index(output, "{"), strings.LastIndex(output, "}")
+	if start == -1 || end < start {
+		return 0, fmt.Errorf("no JSON found in estimates response")
+	}
+	var result struct {
+		Estimates []struct {
+			TempID string  `json:"temp_id"`
+			Value  float64 `json:"value"`
+		} `json:"estimates"`
+	}
+	if err := json.Unmarshal([]byte(output[start:end+1]), &result); err != nil {
+		return 0, fmt.Errorf("failed to parse estimates JSON: %w", err)
+	}
+
+	values := make(map[string]float64, len(result.Estimates))
+	for _, e := range result.Estimates {
+		if e.Value > 0 {
+			values[e.TempID] = e.Value
+		}
+	}
+	filled := 0
+	_ = WalkItems(response, func(item ItemRef) error {
+		v, ok := values[item.TempID()]
+		if !ok || hasEstimate(item) {
+			return nil
+		}
+		switch item.Level {
+		case LevelSubtask:
+			minutes := int(v + 0.5)
+			item.Subtask.EstimatedMinutes = &minutes
+		case LevelTask:
+			item.Task.EstimatedHours = &v
+		default:
+			item.Epic.EstimatedDays = &v
+		}
+		filled++
+		return nil
+	})
+	return filled, nil
+}
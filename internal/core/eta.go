@@ -0,0 +1,120 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Parallelism of the Stage 2 and Stage 3 fan-out.
+const (
+	stage2Parallelism = 3
+	stage3Parallelism = 5
+)
+
+// minETASamples is how many Stage 2 calls must finish before an ETA is shown.
+// Earlier estimates swing too much to be useful.
+const minETASamples = 2
+
+// EstimateRemaining extrapolates the wall-clock time left in the Stage 2/3 fan-out
+// from average call durations. Calls run in parallel batches, so each stage's
+// remaining calls are grouped by that stage's parallelism.
+func EstimateRemaining(avgTaskCall, avgSubtaskCall time.Duration, remainingTaskCalls, remainingSubtaskCalls int) time.Duration {
+	return time.Duration(batches(remainingTaskCalls, stage2Parallelism))*avgTaskCall +
+		time.Duration(batches(remainingSubtaskCalls, stage3Parallelism))*avgSubtaskCall
+}
+
+// FormatRemaining renders an ETA like "~2m remaining".
+func FormatRemaining(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("~%ds remaining", int(d.Round(time.Second).Seconds()))
+	}
+	minutes := int(d.Round(time.Minute).Minutes())
+	if minutes >= 60 {
+		return fmt.Sprintf("~%dh%dm remaining", minutes/60, minutes%60)
+	}
+	return fmt.Sprintf("~%dm remaining", minutes)
+}
+
+func batches(calls, parallelism int) int {
+	if calls <= 0 {
+		return 0
+	}
+	return (calls + parallelism - 1) / parallelism
+}
+
+// etaTracker records Stage 2 and Stage 3 call durations during a parse
+// so progress lines can show how long the remaining fan-out will take.
+type etaTracker struct {
+	mu sync.Mutex
+
+	totalEpics       int
+	tasksGenerated   int
+	taskCalls        []time.Duration
+	subtaskCalls     []time.Duration
+	subtaskCallsDone int
+}
+
+func newETATracker(totalEpics int) *etaTracker {
+	return &etaTracker{totalEpics: totalEpics}
+}
+
+// recordTaskCall records a completed Stage 2 call that produced tasks tasks.
+func (t *etaTracker) recordTaskCall(d time.Duration, tasks int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.taskCalls = append(t.taskCalls, d)
+	t.tasksGenerated += tasks
+}
+
+// recordSubtaskCall records a completed Stage 3 call.
+func (t *etaTracker) recordSubtaskCall(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.subtaskCalls = append(t.subtaskCalls, d)
+	t.subtaskCallsDone++
+}
+
+// remaining estimates the time left, or false if too few calls have finished.
+// Until all epics have tasks, the task count is extrapolated from the average
+// tasks per epic; until a Stage 3 call finishes, Stage 2 timing stands in for it.
+func (t *etaTracker) remaining() (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	epicsDone := len(t.taskCalls)
+	if epicsDone == 0 || (epicsDone < minETASamples && epicsDone < t.totalEpics) {
+		return 0, false
+	}
+
+	avgTask := averageDuration(t.taskCalls)
+	avgSubtask := avgTask
+	if len(t.subtaskCalls) > 0 {
+		avgSubtask = averageDuration(t.subtaskCalls)
+	}
+
+	remainingEpics := t.totalEpics - epicsDone
+	estimatedTasks := t.tasksGenerated + remainingEpics*t.tasksGenerated/epicsDone
+
+	return EstimateRemaining(avgTask, avgSubtask, remainingEpics, estimatedTasks-t.subtaskCallsDone), true
+}
+
+// suffix returns " (~2m remaining)" for progress lines, or "" if no ETA is available yet.
+func (t *etaTracker) suffix() string {
+	d, ok := t.remaining()
+	if !ok {
+		return ""
+	}
+	return " (" + FormatRemaining(d) + ")"
+}
+
+func averageDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	return total / time.Duration(len(durations))
+}
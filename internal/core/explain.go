@@ -0,0 +1,107 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Explanation collects why an item exists: where it sits in the plan, the
+// context and goals it serves, what it depends on and what depends on it.
+type Explanation struct {
+	TempID        string
+	Level         string // LevelEpic, LevelTask, or LevelSubtask
+	Title         string
+	Description   string
+	Context       string    // The item's own context, rendered as text
+	Parents       []ItemRef // Ancestors from the epic down, empty for epics
+	Goals         []string  // Project business and user goals the item's text mentions
+	SourceHint    string
+	RequirementID string
+	DependsOn     []ItemRef // Items this one depends on
+	Dependents    []ItemRef // Items that depend on this one
+	Testing       TestingRequirements
+	Labels        []string
+}
+
+// ExplainItem assembles the Explanation for the item with tempID. Dependencies
+// on unknown IDs are skipped.
+func ExplainItem(response *ParseResponse, tempID string) (*Explanation, error) {
+	byID := make(map[string]ItemRef)
+	var target *ItemRef
+	_ = WalkItems(response, func(item ItemRef) error {
+		byID[item.TempID()] = item
+		if item.TempID() == tempID && target == nil {
+			found := item
+			target = &found
+		}
+		return nil
+	})
+	if target == nil {
+		return nil, fmt.Errorf("no item with temp_id %q in the plan", tempID)
+	}
+
+	e := &Explanation{
+		TempID:        tempID,
+		Level:         target.Level,
+		Title:         target.Title(),
+		RequirementID: target.RequirementID(),
+	}
+	switch target.Level {
+	case LevelEpic:
+		e.Description, e.Testing, e.Labels = target.Epic.Description, target.Epic.Testing, target.Epic.Labels
+		e.Context = target.Epic.Context.String()
+		e.SourceHint = derefString(target.Epic.SourceHint)
+	case LevelTask:
+		e.Description, e.Testing, e.Labels = target.Task.Description, target.Task.Testing, target.Task.Labels
+		e.Context = target.Task.Context.String()
+		e.SourceHint = derefString(target.Task.SourceHint)
+		e.Parents = []ItemRef{byID[target.Epic.TempID]}
+	case LevelSubtask:
+		e.Description, e.Testing, e.Labels = target.Subtask.Description, target.Subtask.Testing, target.Subtask.Labels
+		e.Context = derefString(target.Subtask.Context)
+		e.Parents = []ItemRef{byID[target.Epic.TempID], byID[target.Task.TempID]}
+	}
+
+	for _, dep := range target.DependsOn() {
+		if item, ok := byID[dep]; ok {
+			e.DependsOn = append(e.DependsOn, item)
+		}
+	}
+	_ = WalkItems(response, func(item ItemRef) error {
+		for _, dep := range item.DependsOn() {
+			if dep == tempID {
+				e.Dependents = append(e.Dependents, item)
+				break
+			}
+		}
+		return nil
+	})
+
+	text := significantWords(strings.Join([]string{e.Title, e.Description, e.Context}, " "))
+	for _, goal := range append(response.Project.BusinessGoals.ToSlice(), response.Project.UserGoals.ToSlice()...) {
+		if mentionsGoal(text, goal) {
+			e.Goals = append(e.Goals, goal)
+		}
+	}
+	return e, nil
+}
+
+// mentionsGoal reports whether text shares at least two of goal's significant
+// words, or all of them when the goal has fewer than two.
+func mentionsGoal(text map[string]bool, goal string) bool {
+	words := significantWords(goal)
+	shared := 0
+	for w := range words {
+		if text[w] {
+			shared++
+		}
+	}
+	return shared > 0 && (shared >= 2 || shared == len(words))
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+// TasksMissingSubtasks returns the temp_ids of tasks that have no subtasks,
+// typically because their Stage 3 call failed or was skipped.
+func TasksMissingSubtasks(response *ParseResponse) []string {
+	var ids []string
+	for _, epic := range response.Epics {
+		for _, task := range epic.Tasks {
+			if len(task.Subtasks) == 0 {
+				ids = append(ids, task.TempID)
+			}
+		}
+	}
+	return ids
+}
+
+// EpicsMissingTasks returns the temp_ids of epics that have no tasks,
+// typically because their Stage 2 call failed or was never started.
+func EpicsMissingTasks(response *ParseResponse) []string {
+	var ids []string
+	for _, epic := range response.Epics {
+		if len(epic.Tasks) == 0 {
+			ids = append(ids, epic.TempID)
+		}
+	}
+	return ids
+}
+
+// FillTaskGaps runs Stage 2 for the epics that have no tasks and merges the
+// results into response; the new tasks have no subtasks, so FillSubtaskGaps
+// picks them up next. Epics that fail again stay empty and are reported as
+// warnings. It returns the temp_ids of the epics that were filled.
+func FillTaskGaps(ctx context.Context, gen Generator, response *ParseResponse, config ParseConfig, prd string) []string {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		filled = make(map[string]bool)
+	)
+	limiter := newAdaptiveLimiter("Stage 2", stage2Parallelism, config.FixedConcurrency)
+
+	for ei := range response.Epics {
+		epic := &response.Epics[ei]
+		if len(epic.Tasks) > 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var tasks []Task
+			err := limiter.Do(config.RetryBudget, func() (err error) {
+				tasks, err = gen.GenerateTasks(ctx, *epic, response.Project, config, prd)
+				return err
+			})
+			if err != nil {
+				config.Warnings.Add(WarnTasksFailed, epic.TempID, "task generation failed: %v", err)
+				return
+			}
+			epic.Tasks = tasks
+			mu.Lock()
+			filled[epic.TempID] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	var ids []string
+	for _, epic := range response.Epics {
+		if filled[epic.TempID] {
+			ids = append(ids, epic.TempID)
+		}
+	}
+	return ids
+}
+
+// FillSubtaskGaps regenerates subtasks only for tasks that have none, leaving
+// every other item as it is, and merges the results into response. Tasks that
+// fail again keep no subtasks and are reported as warnings. It returns the
+// temp_ids of the tasks that were filled.
+func FillSubtaskGaps(ctx context.Context, gen Generator, response *ParseResponse, config ParseConfig, prd string) []string {
+	return generateSubtasksWhere(ctx, gen, response, config, prd, func(task *Task) bool {
+		return len(task.Subtasks) == 0
+	})
+}
+
+// GenerateAllSubtasks runs only Stage 3: it generates subtasks for every task
+// in response, replacing any it already has, and leaves epics and tasks
+// untouched. Use it for hand-authored epics and tasks. Tasks whose call fails
+// keep their previous subtasks and are reported as warnings. It returns the
+// temp_ids of the tasks that got new subtasks.
+func GenerateAllSubtasks(ctx context.Context, gen Generator, response *ParseResponse, config ParseConfig, prd string) []string {
+	return generateSubtasksWhere(ctx, gen, response, config, prd, func(*Task) bool { return true })
+}
+
+// generateSubtasksWhere runs Stage 3 in parallel for the tasks selected by
+// want and returns the temp_ids of those that succeeded, in plan order.
+func generateSubtasksWhere(ctx context.Context, gen Generator, response *ParseResponse, config ParseConfig, prd string, want func(task *Task) bool) []string {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		filled = make(map[string]bool)
+	)
+	limiter := newAdaptiveLimiter("Stage 3", stage3Parallelism, config.FixedConcurrency)
+
+	for ei := range response.Epics {
+		epic := &response.Epics[ei]
+		epicCtx := epic.Context.String()
+		for ti := range epic.Tasks {
+			task := &epic.Tasks[ti]
+			if !want(task) {
+				continue
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var subtasks []Subtask
+				err := limiter.Do(config.RetryBudget, func() (err error) {
+					subtasks, err = gen.GenerateSubtasks(ctx, *task, epicCtx, response.Project, config, prd)
+					return err
+				})
+				if err != nil {
+					config.Warnings.Add(WarnSubtasksFailed, task.TempID, "subtask generation failed: %v", err)
+					return
+				}
+				task.Subtasks = subtasks
+				mu.Lock()
+				filled[task.TempID] = true
+				mu.Unlock()
+			}()
+		}
+	}
+	wg.Wait()
+
+	// Report in plan order rather than completion order
+	var ids []string
+	for _, epic := range response.Epics {
+		for _, task := range epic.Tasks {
+			if filled[task.TempID] {
+				ids = append(ids, task.TempID)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// FixGapsSystemPrompt asks the LLM to close validation gaps with the fewest
+// changes to a generated PRD breakdown.
+const FixGapsSystemPrompt = `You close gaps in a generated PRD breakdown. A validator found steps missing from the plan; you add the minimal tasks and edits that close them.
+
+## RULES
+
+1. Keep every existing epic and task, with its temp_id, title, and depends_on, unless a gap requires changing it
+2. Prefer adding a task to an existing epic over adding an epic
+3. Give a new task the next free temp_id in its epic (after 2.3 comes 2.4), and 2-4 subtasks with temp_ids under it (2.4.1, 2.4.2, ...)
+4. Give every new task and subtask a description, and every new epic acceptance criteria
+5. Add depends_on links so that new setup work comes before the tasks that need it
+6. Don't fix anything the gaps don't mention
+
+## OUTPUT FORMAT
+
+Return the whole structure with the changes applied:
+
+{
+  "review_notes": "What was added or changed, per gap",
+  "project": { ... },
+  "epics": [ ... ],
+  "metadata": { ... }
+}
+
+IMPORTANT:
+- Return ONLY valid JSON - no explanations before or after
+- Start your response with { and end with }`
+
+// FixGapsUserPromptTemplate is the template for the gap-fixing user prompt.
+const FixGapsUserPromptTemplate = `Close these gaps in the generated PRD breakdown with the minimal additional tasks and edits.
+
+## GAPS FOUND BY VALIDATION
+%s
+
+## GENERATED STRUCTURE
+%s
+
+## ORIGINAL PRD (for context)
+%s
+
+Return the corrected JSON with a "review_notes" field saying how each gap was closed.`
+
+// BuildFixGapsPrompt creates the user prompt for closing gaps in response.
+func BuildFixGapsPrompt(response *ParseResponse, prdContent string, gaps []string) string {
+	var list strings.Builder
+	for _, gap := range gaps {
+		fmt.Fprintf(&list, "- %s\n", gap)
+	}
+	return fmt.Sprintf(FixGapsUserPromptTemplate, strings.TrimSuffix(list.String(), "\n"), serializeForReview(response), prdContent)
+}
+
+// FixGaps asks the LLM to close validation gaps (ValidationResult.Gaps) in
+// response and merges the result as the review pass does, keeping the
+// subtasks and details of existing items. Like ReviewAndFix, it returns the
+// original response if the merged plan doesn't validate.
+func FixGaps(ctx context.Context, response *ParseResponse, prdContent string, gaps []string, reviewer Reviewer) (*ReviewResult, error) {
+	if len(gaps) == 0 {
+		return &ReviewResult{Response: response, ReviewNotes: "No gaps to fix"}, nil
+	}
+	userPrompt := BuildFixGapsPrompt(response, prdContent, gaps)
+	return reviseStructure(ctx, response, "gap fix", FixGapsSystemPrompt, userPrompt, reviewer)
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"slices"
+	"strings"
+)
+
+// FoundationEpic returns the plan's foundation epic: the first epic, when its
+// title reads like project setup and feature epics follow it. It returns nil
+// otherwise.
+func FoundationEpic(response *ParseResponse) *Epic {
+	if len(response.Epics) < 2 || !isFoundationTitle(response.Epics[0].Title) {
+		return nil
+	}
+	return &response.Epics[0]
+}
+
+// isFoundationTitle reports whether an epic title names project setup work.
+func isFoundationTitle(title string) bool {
+	title = strings.ToLower(title)
+	for _, w := range foundationWords {
+		if strings.Contains(title, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingFoundationDeps lists the feature epics (every epic after the
+// foundation) whose depends_on doesn't name the foundation epic, as the
+// generation prompts require. Epics the foundation itself depends on are left
+// out, since the dependency would close a cycle. It returns nil when the plan
+// has no foundation epic.
+func MissingFoundationDeps(response *ParseResponse) []string {
+	foundation := FoundationEpic(response)
+	if foundation == nil {
+		return nil
+	}
+	deps := make(map[string][]string, len(response.Epics))
+	for _, epic := range response.Epics {
+		deps[epic.TempID] = epic.DependsOn
+	}
+
+	var missing []string
+	for _, epic := range response.Epics[1:] {
+		if epic.TempID == foundation.TempID || slices.Contains(epic.DependsOn, foundation.TempID) || reachable(deps, foundation.TempID, epic.TempID) {
+			continue
+		}
+		missing = append(missing, epic.TempID)
+	}
+	return missing
+}
+
+// AddFoundationDeps adds the foundation epic to the depends_on of each epic
+// MissingFoundationDeps lists, and returns their temp_ids.
+func AddFoundationDeps(response *ParseResponse) []string {
+	missing := MissingFoundationDeps(response)
+	for _, id := range missing {
+		for i := range response.Epics {
+			if response.Epics[i].TempID == id {
+				response.Epics[i].DependsOn = append(response.Epics[i].DependsOn, response.Epics[0].TempID)
+			}
+		}
+	}
+	return missing
+}
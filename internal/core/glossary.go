@@ -0,0 +1,14 @@
+package core
+
+import "strings"
+
+// withGlossary appends the project glossary (ParseConfig.Glossary) to a
+// generation system prompt, marked as authoritative so generated items use
+// the project's own terms rather than the LLM's guesses at them.
+func withGlossary(prompt string, config ParseConfig) string {
+	glossary := strings.TrimSpace(config.Glossary)
+	if glossary == "" {
+		return prompt
+	}
+	return prompt + "\n\n---\nGLOSSARY (authoritative definitions of this project's terms - use them exactly as defined here, even where they differ from common usage):\n" + glossary + "\n---"
+}
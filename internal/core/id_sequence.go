@@ -0,0 +1,100 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IDSequenceIssue is a temp_id out of step with its siblings: a gap before it
+// in the numbering, or a lower number than the sibling listed before it.
+type IDSequenceIssue struct {
+	ItemID  string `json:"item_id"`
+	Message string `json:"message"`
+}
+
+// CheckIDSequence reports gaps and out-of-order temp_ids among siblings: epics
+// 1, 2, 4 (no epic 3), tasks 1.2, 1.3 (no 1.1), or task 1.3 listed before 1.2.
+// Tasks and subtasks are numbered from 1 under their parent; epics from the
+// lowest epic number, so plans offset with --epic-start aren't flagged. Nothing
+// is renumbered, and temp_ids that don't end in a number are skipped.
+func CheckIDSequence(response *ParseResponse) []IDSequenceIssue {
+	var issues []IDSequenceIssue
+	epicIDs := make([]string, len(response.Epics))
+	for i, epic := range response.Epics {
+		epicIDs[i] = epic.TempID
+	}
+	issues = append(issues, checkSiblingIDs(epicIDs, false)...)
+
+	for _, epic := range response.Epics {
+		taskIDs := make([]string, len(epic.Tasks))
+		for i, task := range epic.Tasks {
+			taskIDs[i] = task.TempID
+		}
+		issues = append(issues, checkSiblingIDs(taskIDs, true)...)
+
+		for _, task := range epic.Tasks {
+			subtaskIDs := make([]string, len(task.Subtasks))
+			for i, subtask := range task.Subtasks {
+				subtaskIDs[i] = subtask.TempID
+			}
+			issues = append(issues, checkSiblingIDs(subtaskIDs, true)...)
+		}
+	}
+	return issues
+}
+
+// checkSiblingIDs checks the numbering of one list of siblings, in plan order.
+// fromOne numbers them from 1; otherwise from the lowest number present.
+func checkSiblingIDs(ids []string, fromOne bool) []IDSequenceIssue {
+	var issues []IDSequenceIssue
+	byNumber := make(map[int]string)
+	var numbers []int
+	prevID, prev := "", 0
+	for _, id := range ids {
+		n, ok := lastIDNumber(id)
+		if !ok {
+			continue
+		}
+		if prevID != "" && n < prev {
+			issues = append(issues, IDSequenceIssue{ItemID: id, Message: fmt.Sprintf("listed after %s", prevID)})
+		}
+		prevID, prev = id, n
+		if _, seen := byNumber[n]; !seen {
+			byNumber[n] = id
+			numbers = append(numbers, n)
+		}
+	}
+	if len(numbers) == 0 {
+		return issues
+	}
+
+	sort.Ints(numbers)
+	next := numbers[0]
+	if fromOne {
+		next = 1
+	}
+	for _, n := range numbers {
+		if n > next {
+			id := byNumber[n]
+			prefix := id[:strings.LastIndex(id, ".")+1]
+			missing := prefix + strconv.Itoa(next)
+			if n-1 > next {
+				missing += "-" + prefix + strconv.Itoa(n-1)
+			}
+			issues = append(issues, IDSequenceIssue{ItemID: id, Message: fmt.Sprintf("temp_ids skip %s", missing)})
+		}
+		if n >= next {
+			next = n + 1
+		}
+	}
+	return issues
+}
+
+// lastIDNumber returns the number after the last "." of a temp_id (the whole
+// temp_id for epics).
+func lastIDNumber(id string) (int, bool) {
+	n, err := strconv.Atoi(id[strings.LastIndex(id, ".")+1:])
+	return n, err == nil
+}
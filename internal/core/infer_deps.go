@@ -0,0 +1,150 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultInferDepsThreshold is the confidence an inferred dependency needs to be applied.
+const DefaultInferDepsThreshold = 0.6
+
+// InferredDependency is a depends_on link guessed from the plan's text rather
+// than produced by the LLM.
+type InferredDependency struct {
+	ItemID     string   `json:"item_id"`    // temp_id of the dependent task
+	DependsOn  string   `json:"depends_on"` // temp_id of the task it likely builds on
+	Confidence float64  `json:"confidence"` // 0-1, from how strongly the texts match
+	Matches    []string `json:"matches"`    // Words of the blocker's title found in the dependent
+}
+
+// String formats the inference for display.
+func (d InferredDependency) String() string {
+	return fmt.Sprintf("%s may depend on %s (confidence %.2f: mentions %s)", d.ItemID, d.DependsOn, d.Confidence, strings.Join(d.Matches, ", "))
+}
+
+// inferStopWords are title words too generic to link two tasks.
+var inferStopWords = map[string]bool{
+	"implement": true, "create": true, "build": true, "setup": true, "with": true,
+	"from": true, "into": true, "that": true, "this": true, "update": true, "support": true,
+	"basic": true, "initial": true, "feature": true, "features": true, "handling": true,
+}
+
+// layerOrder ranks layer labels from the bottom of the stack up; a task in a
+// higher layer that mentions a lower-layer task likely builds on it.
+var layerOrder = map[string]int{"database": 1, "backend": 2, "api": 3, "frontend": 4, "ui": 4, "cli": 4}
+
+// InferDependencies guesses missing task dependencies: a task whose title or
+// description mentions the significant words of an earlier task's title likely
+// builds on it. Confidence is the share of the earlier title's words matched,
+// plus 0.2 when the labels follow the database → backend → api → frontend
+// chain. Links that already exist, directly or through other tasks, and links
+// that would create a cycle are skipped. Results are sorted by confidence.
+func InferDependencies(response *ParseResponse) []InferredDependency {
+	var tasks []*Task
+	for ei := range response.Epics {
+		for ti := range response.Epics[ei].Tasks {
+			tasks = append(tasks, &response.Epics[ei].Tasks[ti])
+		}
+	}
+	deps := make(map[string][]string, len(tasks))
+	for _, t := range tasks {
+		deps[t.TempID] = t.DependsOn
+	}
+
+	var inferred []InferredDependency
+	for bi, b := range tasks {
+		text := significantWords(b.Title + " " + b.Description)
+		for _, a := range tasks[:bi] {
+			if reachable(deps, b.TempID, a.TempID) || reachable(deps, a.TempID, b.TempID) {
+				continue
+			}
+
+			var words, matches []string
+			for w := range significantWords(a.Title) {
+				if inferStopWords[w] {
+					continue
+				}
+				words = append(words, w)
+				if text[w] {
+					matches = append(matches, w)
+				}
+			}
+			if len(matches) == 0 {
+				continue
+			}
+			sort.Strings(matches)
+
+			confidence := float64(len(matches)) / float64(len(words))
+			if maxLayer(a.Labels) > 0 && maxLayer(a.Labels) < maxLayer(b.Labels) {
+				confidence += 0.2
+			}
+			if confidence > 1 {
+				confidence = 1
+			}
+			inferred = append(inferred, InferredDependency{ItemID: b.TempID, DependsOn: a.TempID, Confidence: confidence, Matches: matches})
+		}
+	}
+
+	sort.SliceStable(inferred, func(i, j int) bool { return inferred[i].Confidence > inferred[j].Confidence })
+	return inferred
+}
+
+// ApplyInferredDependencies adds the inferences with at least threshold
+// confidence to their tasks' depends_on and returns them as applied; the rest
+// are returned as suggestions only. An inference that would close a cycle with
+// one applied before it is demoted to a suggestion.
+func ApplyInferredDependencies(response *ParseResponse, inferred []InferredDependency, threshold float64) (applied, suggested []InferredDependency) {
+	tasks := make(map[string]*Task)
+	deps := make(map[string][]string)
+	for ei := range response.Epics {
+		for ti := range response.Epics[ei].Tasks {
+			task := &response.Epics[ei].Tasks[ti]
+			tasks[task.TempID] = task
+			deps[task.TempID] = task.DependsOn
+		}
+	}
+
+	for _, d := range inferred {
+		task := tasks[d.ItemID]
+		if d.Confidence < threshold || task == nil || reachable(deps, d.DependsOn, d.ItemID) {
+			suggested = append(suggested, d)
+			continue
+		}
+		task.DependsOn = append(task.DependsOn, d.DependsOn)
+		deps[d.ItemID] = task.DependsOn
+		applied = append(applied, d)
+	}
+	return applied, suggested
+}
+
+// reachable reports whether from depends on to, directly or transitively.
+func reachable(deps map[string][]string, from, to string) bool {
+	seen := make(map[string]bool)
+	stack := []string{from}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, dep := range deps[id] {
+			if dep == to {
+				return true
+			}
+			if !seen[dep] {
+				seen[dep] = true
+				stack = append(stack, dep)
+			}
+		}
+	}
+	return false
+}
+
+// maxLayer returns the highest layerOrder rank among labels, or 0.
+func maxLayer(labels []string) int {
+	rank := 0
+	for _, l := range labels {
+		if r := layerOrder[strings.ToLower(l)]; r > rank {
+			rank = r
+		}
+	}
+	return rank
+}
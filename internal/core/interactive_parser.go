@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strconv"
@@ -16,7 +17,8 @@ import (
 type InteractiveParser struct {
 	generator  Generator
 	config     ParseConfig
-	prdContent string // Stored for full-context mode
+	prdContent string        // Stored for full-context mode
+	input      *bufio.Reader // Source of review answers (stdin by default)
 }
 
 // NewInteractiveParser creates an interactive parser.
@@ -24,9 +26,15 @@ func NewInteractiveParser(generator Generator, config ParseConfig) *InteractiveP
 	return &InteractiveParser{
 		generator: generator,
 		config:    config,
+		input:     bufio.NewReader(os.Stdin),
 	}
 }
 
+// SetInput replaces stdin as the source of review answers (e.g., for scripted reviews).
+func (p *InteractiveParser) SetInput(r io.Reader) {
+	p.input = bufio.NewReader(r)
+}
+
 // Parse executes the interactive multi-stage parsing pipeline.
 func (p *InteractiveParser) Parse(ctx context.Context, prdContent string) (*ParseResponse, error) {
 	// Store PRD for full-context mode
@@ -34,6 +42,7 @@ func (p *InteractiveParser) Parse(ctx context.Context, prdContent string) (*Pars
 
 	if p.config.FullContext {
 		fmt.Println("Full context mode: PRD will be passed to all stages")
+		warnIfPRDTruncated(p.config.Warnings, prdContent)
 	}
 
 	// Stage 1: Generate epics (high-level only)
@@ -49,6 +58,7 @@ func (p *InteractiveParser) Parse(ctx context.Context, prdContent string) (*Pars
 	// Interactive: Review epics
 	fmt.Printf("\n=== Stage 1 Complete: %d Epics Generated ===\n", len(epics))
 	printEpicsSummary(epics)
+	reportProjectContext(p.config.Warnings, &epicsResp.Project)
 
 	epics, err = p.interactiveEpicReview(ctx, epics, prdContent, epicsResp.Project)
 	if err != nil {
@@ -62,6 +72,12 @@ func (p *InteractiveParser) Parse(ctx context.Context, prdContent string) (*Pars
 		return nil, fmt.Errorf("stage 2 (tasks) failed: %w", err)
 	}
 
+	// Interactive: Review tasks, optionally regenerating a single epic's tasks
+	epics, err = p.interactiveTaskReview(ctx, epics, epicsResp.Project)
+	if err != nil {
+		return nil, fmt.Errorf("task review failed: %w", err)
+	}
+
 	// Count total tasks
 	totalTasks := 0
 	for _, epic := range epics {
@@ -87,17 +103,19 @@ func (p *InteractiveParser) Parse(ctx context.Context, prdContent string) (*Pars
 
 	// Build final response
 	response := &ParseResponse{
-		Project: epicsResp.Project,
-		Epics:   epics,
+		Project:       epicsResp.Project,
+		Epics:         epics,
+		Assumptions:   epicsResp.Assumptions,
+		OpenQuestions: epicsResp.OpenQuestions,
 		Metadata: ResponseMetadata{
 			TotalEpics:    len(epics),
 			TotalTasks:    totalTasks,
 			TotalSubtasks: totalSubtasks,
 			TestingCoverage: TestingCoverage{
-				HasUnitTests:        true,
-				HasIntegrationTests: true,
-				HasTypeTests:        true,
-				HasE2ETests:         true,
+				HasUnitTests:        !p.config.NoTesting,
+				HasIntegrationTests: !p.config.NoTesting,
+				HasTypeTests:        !p.config.NoTesting,
+				HasE2ETests:         !p.config.NoTesting,
 			},
 		},
 	}
@@ -110,7 +128,7 @@ func (p *InteractiveParser) interactiveEpicReview(ctx context.Context, epics []E
 	for {
 		fmt.Print("\n[Enter] continue, [e] edit in $EDITOR, [r] regenerate, [a] add epic: ")
 
-		choice, err := promptChoice()
+		choice, err := p.promptChoice()
 		if err != nil {
 			return nil, err
 		}
@@ -120,7 +138,7 @@ func (p *InteractiveParser) interactiveEpicReview(ctx context.Context, epics []E
 			return epics, nil
 
 		case "e": // Edit in editor
-			edited, err := reviewEpicsInEditor(epics)
+			edited, err := reviewEpicsInEditor(epics, p.config.TempDir)
 			if err != nil {
 				fmt.Printf("Edit failed: %v\n", err)
 				continue
@@ -146,14 +164,14 @@ func (p *InteractiveParser) interactiveEpicReview(ctx context.Context, epics []E
 
 		case "a": // Add epic
 			fmt.Print("Enter epic title: ")
-			title, _ := promptChoice()
+			title, _ := p.promptChoice()
 			title = strings.TrimSpace(title)
 			if title == "" {
 				fmt.Println("Empty title, not adding.")
 				continue
 			}
 			fmt.Print("Enter epic description (or Enter to skip): ")
-			desc, _ := promptChoice()
+			desc, _ := p.promptChoice()
 			desc = strings.TrimSpace(desc)
 
 			newID := strconv.Itoa(len(epics) + 1)
@@ -174,6 +192,64 @@ func (p *InteractiveParser) interactiveEpicReview(ctx context.Context, epics []E
 	}
 }
 
+// interactiveTaskReview lets the reviewer reject one epic's tasks and regenerate
+// only that epic's Stage 2, with optional extra guidance for the prompt.
+func (p *InteractiveParser) interactiveTaskReview(ctx context.Context, epics []Epic, project ProjectContext) ([]Epic, error) {
+	printTasksSummary(epics)
+
+	for {
+		fmt.Print("\n[Enter] continue, [r N] regenerate tasks for epic N: ")
+
+		choice, err := p.promptChoice()
+		if err != nil {
+			return nil, err
+		}
+		if choice == "" {
+			return epics, nil
+		}
+
+		if !strings.HasPrefix(choice, "r") {
+			fmt.Println("Unknown option. Press Enter to continue.")
+			continue
+		}
+		epicID := strings.TrimSpace(strings.TrimPrefix(choice, "r"))
+		idx := -1
+		for i, epic := range epics {
+			if epic.TempID == epicID {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			fmt.Printf("No epic %q. Use the epic number shown above, e.g. \"r 2\".\n", epicID)
+			continue
+		}
+
+		fmt.Printf("Guidance for epic %s (or Enter to skip): ", epicID)
+		guidance, err := p.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		config := p.config
+		config.Guidance = guidance
+
+		prd := ""
+		if p.config.FullContext {
+			prd = p.prdContent
+		}
+
+		fmt.Printf("\nRegenerating tasks for epic %s...\n", epicID)
+		tasks, err := p.generator.GenerateTasks(ctx, epics[idx], project, config, prd)
+		if err != nil {
+			fmt.Printf("Regeneration failed: %v\n", err)
+			continue
+		}
+		epics[idx].Tasks = tasks
+		printTasksSummary(epics[idx : idx+1])
+	}
+}
+
 // summariesToEpics converts EpicSummary slice to Epic slice.
 func summariesToEpics(summaries []EpicSummary) []Epic {
 	epics := make([]Epic, len(summaries))
@@ -188,6 +264,8 @@ func summariesToEpics(summaries []EpicSummary) []Epic {
 			DependsOn:          es.DependsOn,
 			EstimatedDays:      es.EstimatedDays,
 			Labels:             es.Labels,
+			SourceHint:         es.SourceHint,
+			RequirementID:      es.RequirementID,
 			Tasks:              []Task{}, // Will be filled in Stage 2
 		}
 	}
@@ -200,14 +278,12 @@ func (p *InteractiveParser) generateTasksParallel(ctx context.Context, epics []E
 	results := make([][]Task, len(epics))
 
 	var wg sync.WaitGroup
-	sem := make(chan struct{}, 3) // Limit parallelism to 3
+	limiter := newAdaptiveLimiter("Stage 2", stage2Parallelism, p.config.FixedConcurrency)
 
 	for i, epic := range epics {
 		wg.Add(1)
 		go func(idx int, e Epic) {
 			defer wg.Done()
-			sem <- struct{}{}        // Acquire
-			defer func() { <-sem }() // Release
 
 			// Pass PRD content if full-context mode is enabled
 			prd := ""
@@ -215,7 +291,11 @@ func (p *InteractiveParser) generateTasksParallel(ctx context.Context, epics []E
 				prd = p.prdContent
 			}
 
-			tasks, err := p.generator.GenerateTasks(ctx, e, project, p.config, prd)
+			var tasks []Task
+			err := limiter.Do(p.config.RetryBudget, func() (err error) {
+				tasks, err = p.generator.GenerateTasks(ctx, e, project, p.config, prd)
+				return err
+			})
 			if err != nil {
 				errs[idx] = fmt.Errorf("epic %s: %w", e.TempID, err)
 				return
@@ -244,7 +324,19 @@ func (p *InteractiveParser) generateTasksParallel(ctx context.Context, epics []E
 }
 
 // generateSubtasksParallel generates subtasks for all tasks in parallel.
+// With OrderedSubtasks, tasks wait on their in-epic dependencies instead.
 func (p *InteractiveParser) generateSubtasksParallel(ctx context.Context, epics []Epic, projectCtx ProjectContext) ([]Epic, error) {
+	if p.config.OrderedSubtasks {
+		prd := ""
+		if p.config.FullContext {
+			prd = p.prdContent
+		}
+		prdFor := func(*Epic) string { return prd }
+		epics = generateSubtasksOrdered(ctx, p.generator, epics, projectCtx, p.config, prdFor, nil)
+		reportSubtaskFailures(epics)
+		return epics, nil
+	}
+
 	// Collect all tasks to process
 	type taskRef struct {
 		epicIdx int
@@ -255,12 +347,7 @@ func (p *InteractiveParser) generateSubtasksParallel(ctx context.Context, epics
 
 	var taskRefs []taskRef
 	for ei, epic := range epics {
-		epicCtx := ""
-		if epic.Context != nil {
-			if s, ok := epic.Context.(string); ok {
-				epicCtx = s
-			}
-		}
+		epicCtx := epic.Context.String()
 		for ti, task := range epic.Tasks {
 			taskRefs = append(taskRefs, taskRef{
 				epicIdx: ei,
@@ -275,14 +362,12 @@ func (p *InteractiveParser) generateSubtasksParallel(ctx context.Context, epics
 	errs := make([]error, len(taskRefs))
 
 	var wg sync.WaitGroup
-	sem := make(chan struct{}, 5) // Higher parallelism for subtasks
+	limiter := newAdaptiveLimiter("Stage 3", stage3Parallelism, p.config.FixedConcurrency)
 
 	for i, ref := range taskRefs {
 		wg.Add(1)
 		go func(idx int, r taskRef) {
 			defer wg.Done()
-			sem <- struct{}{}        // Acquire
-			defer func() { <-sem }() // Release
 
 			// Pass PRD content if full-context mode is enabled
 			prd := ""
@@ -290,7 +375,11 @@ func (p *InteractiveParser) generateSubtasksParallel(ctx context.Context, epics
 				prd = p.prdContent
 			}
 
-			subtasks, err := p.generator.GenerateSubtasks(ctx, r.task, r.epicCtx, projectCtx, p.config, prd)
+			var subtasks []Subtask
+			err := limiter.Do(p.config.RetryBudget, func() (err error) {
+				subtasks, err = p.generator.GenerateSubtasks(ctx, r.task, r.epicCtx, projectCtx, p.config, prd)
+				return err
+			})
 			if err != nil {
 				errs[idx] = fmt.Errorf("task %s: %w", r.task.TempID, err)
 				return
@@ -319,14 +408,33 @@ func (p *InteractiveParser) generateSubtasksParallel(ctx context.Context, epics
 
 // ---- Interactive Helpers ----
 
-// promptChoice prompts the user for input and returns the trimmed string.
-func promptChoice() (string, error) {
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
+// promptChoice prompts the user for input and returns the trimmed, lowercased string.
+func (p *InteractiveParser) promptChoice() (string, error) {
+	input, err := p.readLine()
 	if err != nil {
 		return "", err
 	}
-	return strings.TrimSpace(strings.ToLower(input)), nil
+	return strings.ToLower(input), nil
+}
+
+// readLine reads one line of input, trimmed but with case preserved.
+func (p *InteractiveParser) readLine() (string, error) {
+	input, err := p.input.ReadString('\n')
+	if err != nil && !(err == io.EOF && input != "") {
+		return "", err
+	}
+	return strings.TrimSpace(input), nil
+}
+
+// printTasksSummary prints the generated tasks grouped by epic for review.
+func printTasksSummary(epics []Epic) {
+	fmt.Println("\nGenerated Tasks:")
+	for _, epic := range epics {
+		fmt.Printf("  Epic %s. %s\n", epic.TempID, epic.Title)
+		for _, task := range epic.Tasks {
+			fmt.Printf("      %s %s [%s]\n", task.TempID, task.Title, task.Priority)
+		}
+	}
 }
 
 // printEpicsSummary prints a summary of epics for review.
@@ -337,7 +445,11 @@ func printEpicsSummary(epics []Epic) {
 		if len(epic.DependsOn) > 0 {
 			deps = fmt.Sprintf(" (depends on: %s)", strings.Join(epic.DependsOn, ", "))
 		}
-		fmt.Printf("  %s. %s%s\n", epic.TempID, epic.Title, deps)
+		estimate := ""
+		if epic.EstimatedDays != nil {
+			estimate = fmt.Sprintf(" [~%g days]", *epic.EstimatedDays)
+		}
+		fmt.Printf("  %s. %s%s%s\n", epic.TempID, epic.Title, estimate, deps)
 		if epic.Description != "" {
 			// Truncate long descriptions
 			desc := epic.Description
@@ -349,13 +461,14 @@ func printEpicsSummary(epics []Epic) {
 	}
 }
 
-// reviewEpicsInEditor opens an editor with epics for human review.
-func reviewEpicsInEditor(epics []Epic) ([]Epic, error) {
+// reviewEpicsInEditor opens an editor with epics for human review. The file
+// being edited is created in tempDir ("" is the system default).
+func reviewEpicsInEditor(epics []Epic, tempDir string) ([]Epic, error) {
 	// Format epics as editable text
 	content := formatEpicsForEdit(epics)
 
 	// Write to temp file
-	tmpFile, err := os.CreateTemp("", "prd-epics-*.txt")
+	tmpFile, err := os.CreateTemp(tempDir, "prd-epics-*.txt")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temp file: %w", err)
 	}
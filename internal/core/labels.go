@@ -0,0 +1,105 @@
+package core
+
+import "strings"
+
+// Label categories from the labeling guidance in the prompts.
+const (
+	LabelCategoryLayer  = "layer"
+	LabelCategoryDomain = "domain"
+	LabelCategorySkill  = "skill"
+	LabelCategoryType   = "type"
+)
+
+// labelCategories maps each label suggested by the prompts to its category.
+var labelCategories = map[string]string{
+	"frontend": LabelCategoryLayer, "backend": LabelCategoryLayer, "api": LabelCategoryLayer,
+	"database": LabelCategoryLayer, "infra": LabelCategoryLayer, "devops": LabelCategoryLayer,
+
+	"auth": LabelCategoryDomain, "payments": LabelCategoryDomain, "search": LabelCategoryDomain,
+	"notifications": LabelCategoryDomain, "analytics": LabelCategoryDomain,
+
+	"react": LabelCategorySkill, "go": LabelCategorySkill, "sql": LabelCategorySkill,
+	"typescript": LabelCategorySkill, "css": LabelCategorySkill,
+
+	"setup": LabelCategoryType, "feature": LabelCategoryType, "refactor": LabelCategoryType,
+	"testing": LabelCategoryType, "docs": LabelCategoryType,
+}
+
+// LabelCategory returns the category of a label from the prompt taxonomy
+// (case-insensitive), or "" for labels outside it.
+func LabelCategory(label string) string {
+	return labelCategories[strings.ToLower(label)]
+}
+
+// nonInheritableLabel reports whether label is a Skill or Type label. Those
+// describe the item itself (how it's built, what kind of work it is), so unlike
+// Layer and Domain labels they are not passed down from parent to children.
+func nonInheritableLabel(label string) bool {
+	category := LabelCategory(label)
+	return category == LabelCategorySkill || category == LabelCategoryType
+}
+
+// InheritLabels unions each epic's domain/layer labels into its tasks, and each
+// task's (including inherited) labels into its subtasks, so children can be
+// filtered by the same categories as their parent. Existing labels are kept
+// and matching is case-insensitive, so no label is duplicated.
+func InheritLabels(response *ParseResponse) {
+	for ei := range response.Epics {
+		epic := &response.Epics[ei]
+		for ti := range epic.Tasks {
+			task := &epic.Tasks[ti]
+			task.Labels = mergeInheritedLabels(task.Labels, epic.Labels)
+			for si := range task.Subtasks {
+				subtask := &task.Subtasks[si]
+				subtask.Labels = mergeInheritedLabels(subtask.Labels, task.Labels)
+			}
+		}
+	}
+}
+
+// mergeInheritedLabels appends the inheritable parent labels missing from labels.
+func mergeInheritedLabels(labels, parent []string) []string {
+	var inheritable []string
+	for _, l := range parent {
+		if !nonInheritableLabel(l) {
+			inheritable = append(inheritable, l)
+		}
+	}
+	return unionLabels(labels, inheritable)
+}
+
+// AppendLabels adds labels to every epic, task, and subtask, unioned with the
+// item's existing labels (case-insensitive, so none is duplicated).
+func AppendLabels(response *ParseResponse, labels []string) {
+	if len(labels) == 0 {
+		return
+	}
+	_ = WalkItems(response, func(item ItemRef) error {
+		switch item.Level {
+		case LevelSubtask:
+			item.Subtask.Labels = unionLabels(item.Subtask.Labels, labels)
+		case LevelTask:
+			item.Task.Labels = unionLabels(item.Task.Labels, labels)
+		default:
+			item.Epic.Labels = unionLabels(item.Epic.Labels, labels)
+		}
+		return nil
+	})
+}
+
+// unionLabels appends the labels from extra missing from labels.
+func unionLabels(labels, extra []string) []string {
+	seen := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		seen[strings.ToLower(l)] = true
+	}
+	for _, l := range extra {
+		key := strings.ToLower(l)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		labels = append(labels, l)
+	}
+	return labels
+}
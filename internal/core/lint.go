@@ -0,0 +1,242 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Lint severities. Error-level issues make a plan unfit to create.
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// Lint checks, in report order.
+const (
+	LintStructure       = "structure"
+	LintIDSequence      = "id_sequence"
+	LintCycles          = "cycles"
+	LintDanglingDeps    = "dangling_deps"
+	LintDependencyLevel = "dependency_levels"
+	LintFoundation      = "foundation_epic"
+	LintAcceptance      = "acceptance"
+	LintGranularity     = "granularity"
+	LintEstimates       = "estimates"
+	LintOrphans         = "orphan_functionality"
+)
+
+// LintChecks lists every lint check in report order.
+var LintChecks = []string{
+	LintStructure, LintIDSequence, LintCycles, LintDanglingDeps, LintDependencyLevel, LintFoundation,
+	LintAcceptance, LintGranularity, LintEstimates, LintOrphans,
+}
+
+// Granularity and estimate bounds beyond which an item should probably be split.
+const (
+	lintMaxTasks          = 10
+	lintMaxSubtasks       = 8
+	lintMaxEpicDays       = 30.0
+	lintMaxTaskHours      = 40.0
+	lintMaxSubtaskMinutes = 240
+)
+
+// LintIssue is one finding from Lint.
+type LintIssue struct {
+	Check    string `json:"check"`             // One of LintChecks
+	Severity string `json:"severity"`          // LintError or LintWarning
+	ItemID   string `json:"item_id,omitempty"` // temp_id of the item, if the issue is about one
+	Message  string `json:"message"`
+}
+
+// LintReport is the result of Lint.
+type LintReport struct {
+	Issues []LintIssue `json:"issues"`
+}
+
+// Errors returns the number of error-level issues.
+func (r *LintReport) Errors() int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == LintError {
+			n++
+		}
+	}
+	return n
+}
+
+// ByCheck groups the issues by check, keeping their order within each check.
+func (r *LintReport) ByCheck() map[string][]LintIssue {
+	groups := make(map[string][]LintIssue)
+	for _, issue := range r.Issues {
+		groups[issue.Check] = append(groups[issue.Check], issue)
+	}
+	return groups
+}
+
+func (r *LintReport) add(check, severity, itemID, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, LintIssue{Check: check, Severity: severity, ItemID: itemID, Message: fmt.Sprintf(format, args...)})
+}
+
+// Lint runs every structural check on a plan, without an LLM: the fields
+// needed for creation, temp_id gaps and ordering, dependency cycles, dangling
+// references, and levels, the foundation epic, verifiable acceptance criteria,
+// decomposition granularity, estimate ranges, and orphan functionality.
+func Lint(response *ParseResponse) *LintReport {
+	report := &LintReport{}
+
+	if err := response.ValidateStructure(); err != nil {
+		report.add(LintStructure, LintError, "", "%v", err)
+	}
+	for _, issue := range CheckIDSequence(response) {
+		report.add(LintIDSequence, LintWarning, issue.ItemID, "%s", issue.Message)
+	}
+	lintDependencies(report, response)
+	for _, issue := range CheckDependencyLevels(response) {
+		report.add(LintDependencyLevel, LintWarning, issue.ItemID, "depends on %s - %s", issue.DependsOn, issue.Suggestion)
+	}
+	lintFoundation(report, response)
+	lintAcceptance(report, response)
+	lintGranularity(report, response)
+	lintEstimates(report, response)
+	for _, orphan := range CheckOrphanFunctionality(response) {
+		report.add(LintOrphans, LintWarning, orphan.EpicID, "%q has only backend/api/database tasks and no way to see it working", orphan.Title)
+	}
+
+	return report
+}
+
+// lintDependencies reports depends_on entries naming unknown items or the item
+// itself, and the items caught in depends_on cycles.
+func lintDependencies(report *LintReport, response *ParseResponse) {
+	deps := make(map[string][]string)
+	var order []string
+	_ = WalkItems(response, func(item ItemRef) error {
+		deps[item.TempID()] = item.DependsOn()
+		order = append(order, item.TempID())
+		return nil
+	})
+
+	for _, id := range order {
+		for _, dep := range deps[id] {
+			switch _, known := deps[dep]; {
+			case dep == id:
+				report.add(LintDanglingDeps, LintError, id, "depends on itself")
+			case !known:
+				report.add(LintDanglingDeps, LintError, id, "depends on %s, which is not in the plan", dep)
+			}
+		}
+	}
+
+	// Self-dependencies are already reported above
+	graph := make(map[string][]string, len(deps))
+	for id, ds := range deps {
+		for _, dep := range ds {
+			if dep != id {
+				graph[id] = append(graph[id], dep)
+			}
+		}
+	}
+	var stuck []string
+	for _, id := range order {
+		if reachable(graph, id, id) {
+			stuck = append(stuck, id)
+		}
+	}
+	if len(stuck) > 0 {
+		sort.Strings(stuck)
+		report.add(LintCycles, LintError, stuck[0], "depends_on cycle through %s", strings.Join(stuck, ", "))
+	}
+}
+
+// foundationWords in epic 1's title mark it as the project foundation.
+var foundationWords = []string{"foundation", "setup", "set up", "scaffold", "bootstrap", "infrastructure", "initial"}
+
+// lintFoundation checks that the first epic is a project foundation epic with
+// no dependencies of its own, as the generation prompts require.
+func lintFoundation(report *LintReport, response *ParseResponse) {
+	if len(response.Epics) < 2 {
+		return
+	}
+	first := response.Epics[0]
+	if !isFoundationTitle(first.Title) {
+		report.add(LintFoundation, LintWarning, first.TempID, "first epic %q doesn't look like a project foundation/setup epic", first.Title)
+	}
+	if len(first.DependsOn) > 0 {
+		report.add(LintFoundation, LintWarning, first.TempID, "foundation epic depends on %s; setup work should come first", strings.Join(first.DependsOn, ", "))
+	}
+}
+
+// verifiableWords in an acceptance criterion suggest it can be checked by
+// running, seeing, or testing something.
+var verifiableWords = []string{
+	"can", "run", "runs", "test", "tests", "pass", "passes", "return", "returns", "respond", "responds",
+	"succeed", "succeeds", "demonstrate", "verify", "verified", "load", "loads", "complete", "completes",
+}
+
+// lintAcceptance flags epics without acceptance criteria, or with none that
+// describes something that can be run, seen, or tested.
+func lintAcceptance(report *LintReport, response *ParseResponse) {
+	for _, epic := range response.Epics {
+		switch {
+		case len(epic.AcceptanceCriteria) == 0:
+			report.add(LintAcceptance, LintWarning, epic.TempID, "epic has no acceptance criteria")
+		case !hasVisibleCriterion(epic.AcceptanceCriteria) && !hasCriterionWord(epic.AcceptanceCriteria, verifiableWords):
+			report.add(LintAcceptance, LintWarning, epic.TempID, "no acceptance criterion says how to verify the epic (e.g. \"can run/see/test X\")")
+		}
+	}
+}
+
+// lintGranularity flags items left undecomposed, and epics and tasks with so
+// many children that they should probably be split.
+func lintGranularity(report *LintReport, response *ParseResponse) {
+	for _, epic := range response.Epics {
+		switch n := len(epic.Tasks); {
+		case n == 0:
+			report.add(LintGranularity, LintWarning, epic.TempID, "epic has no tasks")
+		case n > lintMaxTasks:
+			report.add(LintGranularity, LintWarning, epic.TempID, "epic has %d tasks (more than %d); consider splitting it", n, lintMaxTasks)
+		}
+		for _, task := range epic.Tasks {
+			switch n := len(task.Subtasks); {
+			case n == 0:
+				report.add(LintGranularity, LintWarning, task.TempID, "task has no subtasks")
+			case n > lintMaxSubtasks:
+				report.add(LintGranularity, LintWarning, task.TempID, "task has %d subtasks (more than %d); consider splitting it", n, lintMaxSubtasks)
+			}
+		}
+	}
+}
+
+// lintEstimates flags non-positive estimates as errors, estimates too large
+// for one item as warnings, and low estimate coverage.
+func lintEstimates(report *LintReport, response *ParseResponse) {
+	_ = WalkItems(response, func(item ItemRef) error {
+		id := item.TempID()
+		switch item.Level {
+		case LevelEpic:
+			if d := item.Epic.EstimatedDays; d != nil && *d <= 0 {
+				report.add(LintEstimates, LintError, id, "estimated_days must be positive, got %g", *d)
+			} else if d != nil && *d > lintMaxEpicDays {
+				report.add(LintEstimates, LintWarning, id, "estimated at %g days (more than %g); consider splitting the epic", *d, lintMaxEpicDays)
+			}
+		case LevelTask:
+			if h := item.Task.EstimatedHours; h != nil && *h <= 0 {
+				report.add(LintEstimates, LintError, id, "estimated_hours must be positive, got %g", *h)
+			} else if h != nil && *h > lintMaxTaskHours {
+				report.add(LintEstimates, LintWarning, id, "estimated at %g hours (more than %g); consider splitting the task", *h, lintMaxTaskHours)
+			}
+		case LevelSubtask:
+			if m := item.Subtask.EstimatedMinutes; m != nil && *m <= 0 {
+				report.add(LintEstimates, LintError, id, "estimated_minutes must be positive, got %d", *m)
+			} else if m != nil && *m > lintMaxSubtaskMinutes {
+				report.add(LintEstimates, LintWarning, id, "estimated at %d minutes (more than %d); consider splitting the subtask", *m, lintMaxSubtaskMinutes)
+			}
+		}
+		return nil
+	})
+
+	if coverage := CheckEstimateCoverage(response); coverage.Low() {
+		report.add(LintEstimates, LintWarning, "", "only %d of %d items have estimates (%.0f%%)", coverage.Estimated, coverage.Items, coverage.Fraction()*100)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 )
 
 // MultiStageParser implements progressive decomposition:
@@ -15,6 +16,8 @@ type MultiStageParser struct {
 	generator  Generator
 	config     ParseConfig
 	prdContent string // Stored for full-context mode
+	summary    string // Condensed PRD when config.Summarize is set
+	eta        *etaTracker
 }
 
 // Generator is the interface for LLM generation at each stage.
@@ -26,8 +29,10 @@ type Generator interface {
 
 // EpicsResponse is the Stage 1 response - epics without tasks.
 type EpicsResponse struct {
-	Project ProjectContext `json:"project"`
-	Epics   []EpicSummary  `json:"epics"`
+	Project       ProjectContext `json:"project"`
+	Epics         []EpicSummary  `json:"epics"`
+	Assumptions   []string       `json:"assumptions,omitempty"`
+	OpenQuestions []string       `json:"open_questions,omitempty"`
 }
 
 // EpicSummary is a lightweight epic without tasks (Stage 1).
@@ -35,12 +40,14 @@ type EpicSummary struct {
 	TempID             string              `json:"temp_id"`
 	Title              string              `json:"title"`
 	Description        string              `json:"description"`
-	Context            interface{}         `json:"context"`
+	Context            FlexibleContext     `json:"context"`
 	AcceptanceCriteria []string            `json:"acceptance_criteria"`
 	Testing            TestingRequirements `json:"testing"`
 	DependsOn          []string            `json:"depends_on"`
 	EstimatedDays      *float64            `json:"estimated_days,omitempty"`
 	Labels             []string            `json:"labels,omitempty"`
+	SourceHint         *string             `json:"source_hint,omitempty"`
+	RequirementID      string              `json:"requirement_id,omitempty"`
 }
 
 // TasksResponse is the Stage 2 response - tasks without subtasks.
@@ -53,13 +60,15 @@ type TaskSummary struct {
 	TempID         string              `json:"temp_id"`
 	Title          string              `json:"title"`
 	Description    string              `json:"description"`
-	Context        interface{}         `json:"context"`
+	Context        FlexibleContext     `json:"context"`
 	DesignNotes    *string             `json:"design_notes,omitempty"`
 	Testing        TestingRequirements `json:"testing"`
 	Priority       Priority            `json:"priority"`
 	DependsOn      []string            `json:"depends_on"`
 	EstimatedHours *float64            `json:"estimated_hours,omitempty"`
 	Labels         []string            `json:"labels,omitempty"`
+	SourceHint     *string             `json:"source_hint,omitempty"`
+	RequirementID  string              `json:"requirement_id,omitempty"`
 }
 
 // NewMultiStageParser creates a multi-stage parser.
@@ -75,21 +84,41 @@ func (p *MultiStageParser) Parse(ctx context.Context, prdContent string) (*Parse
 	// Store PRD for full-context mode
 	p.prdContent = prdContent
 
-	if p.config.FullContext {
+	if p.config.FullContext && !p.config.Summarize {
 		fmt.Println("Full context mode: PRD will be passed to all stages")
+		warnIfPRDTruncated(p.config.Warnings, prdContent)
+	}
+
+	// Optional pre-pass: Stage 1 sees a condensed PRD, later stages the relevant sections
+	stage1PRD := prdContent
+	if p.config.Summarize {
+		fmt.Println("Summarizing PRD...")
+		summary, err := SummarizePRD(ctx, p.generator, prdContent, p.config)
+		if err != nil {
+			return nil, fmt.Errorf("PRD summary failed: %w", err)
+		}
+		fmt.Printf("  Condensed %d chars to %d\n", len(prdContent), len(summary))
+		p.summary = summary
+		stage1PRD = summary
 	}
 
 	// Stage 1: Generate epics (high-level only)
 	fmt.Println("Stage 1: Generating epics from PRD...")
-	epicsResp, err := p.generator.GenerateEpics(ctx, prdContent, p.config)
+	epicsResp, err := p.generator.GenerateEpics(ctx, stage1PRD, p.config)
 	if err != nil {
 		return nil, fmt.Errorf("stage 1 (epics) failed: %w", err)
 	}
 	fmt.Printf("  Generated %d epics\n", len(epicsResp.Epics))
+	reportProjectContext(p.config.Warnings, &epicsResp.Project)
 
 	// Stage 2: Generate tasks for each epic (parallel)
+	p.eta = newETATracker(len(epicsResp.Epics))
 	fmt.Println("Stage 2: Generating tasks for each epic...")
 	epics, err := p.generateTasksParallel(ctx, epicsResp)
+	// Rate-limit retries draw on the same budget as Stage 3's
+	if p.config.RetryBudget.Exhausted() {
+		return nil, &RetryBudgetError{Retries: p.config.RetryBudget.Used(), Partial: p.buildResponse(epicsResp, epics)}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("stage 2 (tasks) failed: %w", err)
 	}
@@ -102,55 +131,77 @@ func (p *MultiStageParser) Parse(ctx context.Context, prdContent string) (*Parse
 	fmt.Printf("  Generated %d tasks across %d epics\n", totalTasks, len(epics))
 
 	// Stage 3: Generate subtasks for each task (parallel)
-	fmt.Println("Stage 3: Generating subtasks for each task...")
+	fmt.Printf("Stage 3: Generating subtasks for each task...%s\n", p.eta.suffix())
 	epics, err = p.generateSubtasksParallel(ctx, epics, epicsResp.Project)
+	// Checked before err: ordered mode reports failed tasks as warnings instead
+	if p.config.RetryBudget.Exhausted() {
+		return nil, &RetryBudgetError{Retries: p.config.RetryBudget.Used(), Partial: p.buildResponse(epicsResp, epics)}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("stage 3 (subtasks) failed: %w", err)
 	}
 
-	// Count total subtasks
-	totalSubtasks := 0
+	response := p.buildResponse(epicsResp, epics)
+	fmt.Printf("  Generated %d subtasks\n", response.Metadata.TotalSubtasks)
+	return response, nil
+}
+
+// buildResponse assembles the final response from the Stage 1 result and the
+// epics with their tasks and subtasks.
+func (p *MultiStageParser) buildResponse(epicsResp *EpicsResponse, epics []Epic) *ParseResponse {
+	totalTasks, totalSubtasks := 0, 0
 	for _, epic := range epics {
+		totalTasks += len(epic.Tasks)
 		for _, task := range epic.Tasks {
 			totalSubtasks += len(task.Subtasks)
 		}
 	}
-	fmt.Printf("  Generated %d subtasks\n", totalSubtasks)
 
-	// Build final response
-	response := &ParseResponse{
-		Project: epicsResp.Project,
-		Epics:   epics,
+	return &ParseResponse{
+		Project:       epicsResp.Project,
+		Epics:         epics,
+		Assumptions:   epicsResp.Assumptions,
+		OpenQuestions: epicsResp.OpenQuestions,
 		Metadata: ResponseMetadata{
 			TotalEpics:    len(epics),
 			TotalTasks:    totalTasks,
 			TotalSubtasks: totalSubtasks,
 			TestingCoverage: TestingCoverage{
-				HasUnitTests:        true,
-				HasIntegrationTests: true,
-				HasTypeTests:        true,
-				HasE2ETests:         true,
+				HasUnitTests:        !p.config.NoTesting,
+				HasIntegrationTests: !p.config.NoTesting,
+				HasTypeTests:        !p.config.NoTesting,
+				HasE2ETests:         !p.config.NoTesting,
 			},
 		},
 	}
+}
 
-	return response, nil
+// Scan runs only Stage 1 and prints the proposed epics.
+// This is a cheap triage pass: no tasks or subtasks are generated.
+func (p *MultiStageParser) Scan(ctx context.Context, prdContent string) (*EpicsResponse, error) {
+	fmt.Println("Stage 1: Generating epics from PRD...")
+	epicsResp, err := p.generator.GenerateEpics(ctx, prdContent, p.config)
+	if err != nil {
+		return nil, fmt.Errorf("stage 1 (epics) failed: %w", err)
+	}
+
+	printEpicsSummary(summariesToEpics(epicsResp.Epics))
+	return epicsResp, nil
 }
 
-// generateTasksParallel generates tasks for all epics in parallel.
+// generateTasksParallel generates tasks for all epics in parallel. On error
+// the epics are still returned, those whose call failed with no tasks.
 func (p *MultiStageParser) generateTasksParallel(ctx context.Context, epicsResp *EpicsResponse) ([]Epic, error) {
 	epics := make([]Epic, len(epicsResp.Epics))
 	errs := make([]error, len(epicsResp.Epics))
 
 	var wg sync.WaitGroup
-	sem := make(chan struct{}, 3) // Limit parallelism to 3
+	limiter := newAdaptiveLimiter("Stage 2", stage2Parallelism, p.config.FixedConcurrency)
 
 	for i, epicSummary := range epicsResp.Epics {
 		wg.Add(1)
 		go func(idx int, es EpicSummary) {
 			defer wg.Done()
-			sem <- struct{}{}        // Acquire
-			defer func() { <-sem }() // Release
 
 			// Convert summary to full epic for task generation
 			epic := Epic{
@@ -163,23 +214,27 @@ func (p *MultiStageParser) generateTasksParallel(ctx context.Context, epicsResp
 				DependsOn:          es.DependsOn,
 				EstimatedDays:      es.EstimatedDays,
 				Labels:             es.Labels,
+				SourceHint:         es.SourceHint,
+				RequirementID:      es.RequirementID,
 			}
 
-			// Pass PRD content if full-context mode is enabled
-			prd := ""
-			if p.config.FullContext {
-				prd = p.prdContent
-			}
-
-			tasks, err := p.generator.GenerateTasks(ctx, epic, epicsResp.Project, p.config, prd)
+			var tasks []Task
+			var start time.Time
+			err := limiter.Do(p.config.RetryBudget, func() (err error) {
+				start = time.Now()
+				tasks, err = p.generator.GenerateTasks(ctx, epic, epicsResp.Project, p.config, p.prdForEpic(&epic))
+				return err
+			})
 			if err != nil {
 				errs[idx] = fmt.Errorf("epic %s: %w", es.TempID, err)
+				epics[idx] = epic
 				return
 			}
+			p.eta.recordTaskCall(time.Since(start), len(tasks))
 
 			epic.Tasks = tasks
 			epics[idx] = epic
-			fmt.Printf("    Epic %s: %d tasks\n", es.TempID, len(tasks))
+			fmt.Printf("    Epic %s: %d tasks%s\n", es.TempID, len(tasks), p.eta.suffix())
 		}(i, epicSummary)
 	}
 
@@ -188,7 +243,7 @@ func (p *MultiStageParser) generateTasksParallel(ctx context.Context, epicsResp
 	// Check for errors
 	for _, err := range errs {
 		if err != nil {
-			return nil, err
+			return epics, err
 		}
 	}
 
@@ -196,29 +251,34 @@ func (p *MultiStageParser) generateTasksParallel(ctx context.Context, epicsResp
 }
 
 // generateSubtasksParallel generates subtasks for all tasks in parallel.
+// With OrderedSubtasks, tasks wait on their in-epic dependencies instead.
 func (p *MultiStageParser) generateSubtasksParallel(ctx context.Context, epics []Epic, projectCtx ProjectContext) ([]Epic, error) {
+	if p.config.OrderedSubtasks {
+		epics = generateSubtasksOrdered(ctx, p.generator, epics, projectCtx, p.config, p.prdForEpic, p.eta.recordSubtaskCall)
+		reportSubtaskFailures(epics)
+		return epics, nil
+	}
+
 	// Collect all tasks to process
 	type taskRef struct {
 		epicIdx int
 		taskIdx int
 		task    Task
 		epicCtx string
+		prd     string
 	}
 
 	var taskRefs []taskRef
 	for ei, epic := range epics {
-		epicCtx := ""
-		if epic.Context != nil {
-			if s, ok := epic.Context.(string); ok {
-				epicCtx = s
-			}
-		}
+		epicCtx := epic.Context.String()
+		prd := p.prdForEpic(&epics[ei])
 		for ti, task := range epic.Tasks {
 			taskRefs = append(taskRefs, taskRef{
 				epicIdx: ei,
 				taskIdx: ti,
 				task:    task,
 				epicCtx: epicCtx,
+				prd:     prd,
 			})
 		}
 	}
@@ -227,26 +287,25 @@ func (p *MultiStageParser) generateSubtasksParallel(ctx context.Context, epics [
 	errs := make([]error, len(taskRefs))
 
 	var wg sync.WaitGroup
-	sem := make(chan struct{}, 5) // Higher parallelism for subtasks (smaller requests)
+	limiter := newAdaptiveLimiter("Stage 3", stage3Parallelism, p.config.FixedConcurrency)
 
 	for i, ref := range taskRefs {
 		wg.Add(1)
 		go func(idx int, r taskRef) {
 			defer wg.Done()
-			sem <- struct{}{}        // Acquire
-			defer func() { <-sem }() // Release
 
-			// Pass PRD content if full-context mode is enabled
-			prd := ""
-			if p.config.FullContext {
-				prd = p.prdContent
-			}
-
-			subtasks, err := p.generator.GenerateSubtasks(ctx, r.task, r.epicCtx, projectCtx, p.config, prd)
+			var subtasks []Subtask
+			var start time.Time
+			err := limiter.Do(p.config.RetryBudget, func() (err error) {
+				start = time.Now()
+				subtasks, err = p.generator.GenerateSubtasks(ctx, r.task, r.epicCtx, projectCtx, p.config, r.prd)
+				return err
+			})
 			if err != nil {
 				errs[idx] = fmt.Errorf("task %s: %w", r.task.TempID, err)
 				return
 			}
+			p.eta.recordSubtaskCall(time.Since(start))
 
 			results[idx] = subtasks
 		}(i, ref)
@@ -254,17 +313,43 @@ func (p *MultiStageParser) generateSubtasksParallel(ctx context.Context, epics [
 
 	wg.Wait()
 
+	// Assign subtasks back to tasks; on failure the epics are a partial plan
+	for i, ref := range taskRefs {
+		epics[ref.epicIdx].Tasks[ref.taskIdx].Subtasks = results[i]
+	}
+
 	// Check for errors
 	for _, err := range errs {
 		if err != nil {
-			return nil, err
+			return epics, err
 		}
 	}
 
-	// Assign subtasks back to tasks
-	for i, ref := range taskRefs {
-		epics[ref.epicIdx].Tasks[ref.taskIdx].Subtasks = results[i]
+	return epics, nil
+}
+
+// prdForEpic returns the PRD text passed to Stage 2 and 3 for epic: "" unless
+// full-context mode is on, then the whole PRD, or with Summarize only the
+// sections relevant to the epic (the summary if none match).
+func (p *MultiStageParser) prdForEpic(epic *Epic) string {
+	switch {
+	case !p.config.FullContext:
+		return ""
+	case !p.config.Summarize:
+		return p.prdContent
+	}
+	if sections := RelevantPRDSections(p.prdContent, *epic); sections != "" {
+		return sections
 	}
+	return p.summary
+}
 
-	return epics, nil
+// warnIfPRDTruncated records a warning when the PRD is too long to be passed
+// in full to the Stage 2/3 prompts in full-context mode.
+func warnIfPRDTruncated(warnings *WarningCollector, prdContent string) {
+	if len(prdContent) > Stage2PRDLimit {
+		warnings.Add(WarnPRDTruncated, "",
+			"PRD is %d chars; full-context prompts include only the first %d (tasks) / %d (subtasks) chars",
+			len(prdContent), Stage2PRDLimit, Stage3PRDLimit)
+	}
 }
@@ -0,0 +1,48 @@
+package core
+
+import "regexp"
+
+// NoTestingInstruction is appended to system prompts when ParseConfig.NoTesting is set.
+const NoTestingInstruction = `
+
+## TESTING DISABLED
+
+Testing requirements are disabled for this run to keep the output small.
+Leave out the testing field entirely on every epic, task, and subtask.`
+
+var (
+	// A "## TESTING ..." section, up to the next heading (captured) or end of prompt
+	testingSection = regexp.MustCompile(`(?ms)^## TESTING[^\n]*\n.*?(^## |\z)`)
+	// An indented "testing": {...} block in a JSON output example
+	testingJSONBlock = regexp.MustCompile(`(?m)^[ \t]*"testing": \{\n(?:[^\n]*\n)*?[ \t]*\},?\n`)
+	// Single lines that ask for testing
+	testingLine = regexp.MustCompile(`(?m)^(?:Testing level:|- testing:|\d+\. Skipping tests)[^\n]*\n`)
+	// "testing" in a comma-separated list of fields to return
+	testingField = regexp.MustCompile(`, testing,`)
+)
+
+// SystemPromptFor returns the variant of a generation system prompt for config.
+// With NoTesting, testing requirements are stripped and NoTestingInstruction is
+// appended. The project glossary, if any, is appended last.
+func SystemPromptFor(prompt string, config ParseConfig) string {
+	if config.NoTesting {
+		prompt = stripTesting(prompt) + NoTestingInstruction
+	}
+	return withGlossary(prompt, config)
+}
+
+// withTestingMode strips testing requirements from a user prompt when NoTesting is set.
+func withTestingMode(prompt string, config ParseConfig) string {
+	if !config.NoTesting {
+		return prompt
+	}
+	return stripTesting(prompt)
+}
+
+// stripTesting removes testing sections, example blocks, and field mentions from prompt.
+func stripTesting(prompt string) string {
+	prompt = testingSection.ReplaceAllString(prompt, "${1}")
+	prompt = testingJSONBlock.ReplaceAllString(prompt, "")
+	prompt = testingLine.ReplaceAllString(prompt, "")
+	return testingField.ReplaceAllString(prompt, ",")
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// generateSubtasksOrdered is the OrderedSubtasks variant of Stage 3. Within each
+// epic a task's subtasks are only generated once the tasks it depends on (in the
+// same epic) have succeeded. When a task fails, it and every task depending on it
+// are left without subtasks and reported as warnings instead of failing the
+// whole stage. Epics, and independent tasks within an epic, still run in parallel.
+// prdFor returns the PRD text to send with an epic's tasks. onSuccess, if
+// non-nil, is called with the duration of each successful call.
+func generateSubtasksOrdered(ctx context.Context, gen Generator, epics []Epic, projectCtx ProjectContext, config ParseConfig, prdFor func(epic *Epic) string, onSuccess func(time.Duration)) []Epic {
+	type outcome struct {
+		done chan struct{} // closed once ok is final
+		ok   bool          // subtasks were generated
+	}
+
+	var wg sync.WaitGroup
+	limiter := newAdaptiveLimiter("Stage 3", stage3Parallelism, config.FixedConcurrency)
+
+	for ei := range epics {
+		epic := &epics[ei]
+		epicCtx := epic.Context.String()
+		prd := prdFor(epic)
+
+		deps, acyclic := intraEpicDeps(epic.Tasks)
+		if !acyclic {
+			config.Warnings.Add(WarnTaskDependencyCycle, epic.TempID,
+				"task dependencies form a cycle; generating subtasks for this epic without ordering")
+			deps = make([][]int, len(epic.Tasks))
+		}
+
+		outcomes := make([]*outcome, len(epic.Tasks))
+		for ti := range epic.Tasks {
+			outcomes[ti] = &outcome{done: make(chan struct{})}
+		}
+
+		for ti := range epic.Tasks {
+			wg.Add(1)
+			go func(task *Task, self *outcome, deps []int) {
+				defer wg.Done()
+				defer close(self.done)
+
+				for _, d := range deps {
+					<-outcomes[d].done
+					if !outcomes[d].ok {
+						config.Warnings.Add(WarnSubtasksSkipped, task.TempID,
+							"subtasks not generated: depends on task %s, which has no subtasks", epic.Tasks[d].TempID)
+						return
+					}
+				}
+
+				var subtasks []Subtask
+				var start time.Time
+				err := limiter.Do(config.RetryBudget, func() (err error) {
+					start = time.Now()
+					subtasks, err = gen.GenerateSubtasks(ctx, *task, epicCtx, projectCtx, config, prd)
+					return err
+				})
+				if err != nil {
+					config.Warnings.Add(WarnSubtasksFailed, task.TempID, "subtask generation failed: %v", err)
+					return
+				}
+				if onSuccess != nil {
+					onSuccess(time.Since(start))
+				}
+
+				task.Subtasks = subtasks
+				self.ok = true
+			}(&epic.Tasks[ti], outcomes[ti], deps[ti])
+		}
+	}
+
+	wg.Wait()
+	return epics
+}
+
+// intraEpicDeps returns, for each task, the indexes of the tasks in the same
+// epic it depends on. Dependencies on other epics are ignored. acyclic is false
+// when the in-epic dependencies contain a cycle and can't be ordered.
+func intraEpicDeps(tasks []Task) (deps [][]int, acyclic bool) {
+	index := make(map[string]int, len(tasks))
+	for i, task := range tasks {
+		index[task.TempID] = i
+	}
+
+	deps = make([][]int, len(tasks))
+	indegree := make([]int, len(tasks))
+	dependents := make([][]int, len(tasks))
+	for i, task := range tasks {
+		for _, id := range task.DependsOn {
+			d, ok := index[id]
+			if !ok || d == i {
+				continue
+			}
+			deps[i] = append(deps[i], d)
+			dependents[d] = append(dependents[d], i)
+			indegree[i]++
+		}
+	}
+
+	// Kahn's algorithm: if every task can be ordered, there is no cycle
+	var queue []int
+	for i, n := range indegree {
+		if n == 0 {
+			queue = append(queue, i)
+		}
+	}
+	ordered := 0
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		ordered++
+		for _, dep := range dependents[i] {
+			indegree[dep]--
+			if indegree[dep] == 0 {
+				queue = append(queue, dep)
+			}
+		}
+	}
+
+	return deps, ordered == len(tasks)
+}
+
+// reportSubtaskFailures prints a short note when ordered Stage 3 left tasks without subtasks.
+func reportSubtaskFailures(epics []Epic) {
+	n := 0
+	for _, epic := range epics {
+		for _, task := range epic.Tasks {
+			if len(task.Subtasks) == 0 {
+				n++
+			}
+		}
+	}
+	if n > 0 {
+		fmt.Printf("  %d tasks have no subtasks (failed or skipped) - see warnings\n", n)
+	}
+}
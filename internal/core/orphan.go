@@ -0,0 +1,83 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// hiddenLayerLabels mark work with no visible surface of its own.
+var hiddenLayerLabels = map[string]bool{"backend": true, "api": true, "database": true}
+
+// visibleLayerLabels mark work a user can see or run.
+var visibleLayerLabels = map[string]bool{"frontend": true, "ui": true, "cli": true}
+
+// visibleOutputWords in an acceptance criterion suggest the result can be seen.
+var visibleOutputWords = []string{
+	"ui", "page", "screen", "view", "display", "displays", "displayed", "shown", "shows",
+	"visible", "see", "sees", "click", "button", "dashboard", "cli", "command", "log", "logs", "output",
+}
+
+// OrphanEpic is an epic whose work nobody could see working, the "orphan
+// functionality" anti-pattern from the prompts.
+type OrphanEpic struct {
+	EpicID string `json:"epic_id"`
+	Title  string `json:"title"`
+}
+
+// String formats the finding for display.
+func (o OrphanEpic) String() string {
+	return fmt.Sprintf("epic %s %q has only backend/api/database tasks and no way to see it working", o.EpicID, o.Title)
+}
+
+// CheckOrphanFunctionality flags epics whose tasks are all labeled backend,
+// api, or database, with no frontend/ui/cli task and no acceptance criterion
+// describing visible output (a page, CLI output, logs, ...). Epics with an
+// unlabeled task are not flagged, since their layer is unknown.
+func CheckOrphanFunctionality(response *ParseResponse) []OrphanEpic {
+	var orphans []OrphanEpic
+	for _, epic := range response.Epics {
+		if len(epic.Tasks) == 0 || hasVisibleCriterion(epic.AcceptanceCriteria) {
+			continue
+		}
+		hidden := true
+		for _, task := range epic.Tasks {
+			if !hasLabel(task.Labels, hiddenLayerLabels) || hasLabel(task.Labels, visibleLayerLabels) {
+				hidden = false
+				break
+			}
+		}
+		if hidden {
+			orphans = append(orphans, OrphanEpic{EpicID: epic.TempID, Title: epic.Title})
+		}
+	}
+	return orphans
+}
+
+// hasLabel reports whether any label is in set (case-insensitive).
+func hasLabel(labels []string, set map[string]bool) bool {
+	for _, l := range labels {
+		if set[strings.ToLower(l)] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasVisibleCriterion reports whether any criterion mentions visible output.
+func hasVisibleCriterion(criteria []string) bool {
+	return hasCriterionWord(criteria, visibleOutputWords)
+}
+
+// hasCriterionWord reports whether any criterion contains one of words.
+func hasCriterionWord(criteria []string, words []string) bool {
+	for _, c := range criteria {
+		for _, w := range strings.FieldsFunc(strings.ToLower(c), func(r rune) bool { return !(r >= 'a' && r <= 'z') }) {
+			for _, v := range words {
+				if w == v {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
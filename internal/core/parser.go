@@ -24,7 +24,8 @@ type OutputCreateResult struct {
 		Subtasks     int
 		Dependencies int
 	}
-	Failed []struct {
+	ExternalIDs map[string]string // temp_id -> ID assigned by the output system
+	Failed      []struct {
 		Item  interface{}
 		Error string
 	}
@@ -98,7 +99,7 @@ func ParsePRD(ctx context.Context, opts ParseOptions) (*ParseResult, error) {
 
 	// Generate tasks via LLM
 	fmt.Printf("Generating tasks with %s...\n", opts.LLMAdapter.Name())
-	response, err := opts.LLMAdapter.Generate(ctx, SystemPrompt, userPrompt)
+	response, err := opts.LLMAdapter.Generate(ctx, SystemPromptFor(SystemPrompt, config), userPrompt)
 	if err != nil {
 		return nil, fmt.Errorf("LLM generation failed: %w", err)
 	}
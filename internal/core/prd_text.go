@@ -0,0 +1,42 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// binarySniffLen is how much of the PRD is scanned for control characters.
+const binarySniffLen = 8192
+
+// CheckPRDText rejects PRD content that isn't UTF-8 text, so binary files
+// and other encodings fail fast instead of being sent to the LLM.
+func CheckPRDText(data []byte) error {
+	if bytes.HasPrefix(data, []byte{0xFF, 0xFE}) || bytes.HasPrefix(data, []byte{0xFE, 0xFF}) {
+		return fmt.Errorf("file is UTF-16 encoded; convert it to UTF-8 (e.g. iconv -f UTF-16 -t UTF-8)")
+	}
+
+	sniff := data
+	if len(sniff) > binarySniffLen {
+		sniff = sniff[:binarySniffLen]
+	}
+	if bytes.IndexByte(sniff, 0) >= 0 {
+		return fmt.Errorf("file contains NUL bytes; it looks binary (or UTF-16 without a byte order mark), not a text PRD")
+	}
+
+	// More than 10% control characters (other than tab, newline, CR, form feed) means binary
+	control := 0
+	for _, b := range sniff {
+		if b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' {
+			control++
+		}
+	}
+	if len(sniff) > 0 && control*10 > len(sniff) {
+		return fmt.Errorf("file looks binary (%d control characters in the first %d bytes), not a text PRD", control, len(sniff))
+	}
+
+	if !utf8.Valid(data) {
+		return fmt.Errorf("file is not valid UTF-8; convert it to UTF-8 first")
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CheckProjectContext reports empty fields of the Stage 1 project context that
+// later stages rely on: the product name, elevator pitch, target audience,
+// goals, and tech stack. It returns one message per missing field.
+func CheckProjectContext(project *ProjectContext) []string {
+	var issues []string
+	if strings.TrimSpace(project.ProductName) == "" {
+		issues = append(issues, "product_name is empty")
+	}
+	if strings.TrimSpace(project.ElevatorPitch) == "" {
+		issues = append(issues, "elevator_pitch is empty")
+	}
+	if strings.TrimSpace(project.TargetAudience.String()) == "" {
+		issues = append(issues, "target_audience is empty")
+	}
+	if nonEmpty(project.BusinessGoals) == 0 && nonEmpty(project.UserGoals) == 0 {
+		issues = append(issues, "no business_goals or user_goals")
+	}
+	if nonEmpty(project.TechStack) == 0 {
+		issues = append(issues, "tech_stack is empty")
+	}
+	return issues
+}
+
+// reportProjectContext prints CheckProjectContext's findings as soon as Stage 1
+// returns, so the run can be stopped before paying for Stages 2-3, and records
+// them as warnings for the final summary.
+func reportProjectContext(warnings *WarningCollector, project *ProjectContext) {
+	issues := CheckProjectContext(project)
+	if len(issues) == 0 {
+		return
+	}
+	fmt.Printf("  Project context is incomplete: %s\n", strings.Join(issues, "; "))
+	fmt.Println("  Tasks and subtasks will have less to go on - press Ctrl-C to stop and add this to the PRD")
+	if warnings == nil {
+		return // Already printed
+	}
+	for _, issue := range issues {
+		warnings.Add(WarnIncompleteContext, "", "project context: %s", issue)
+	}
+}
+
+// nonEmpty counts the entries of values that aren't blank.
+func nonEmpty(values FlexibleStringSlice) int {
+	n := 0
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// ProjectExtractor is implemented by generators that can extract only the
+// project context from a PRD (--context-only). userPrompt is built by
+// BuildProjectContextPrompt and is sent with ProjectContextSystemPrompt.
+type ProjectExtractor interface {
+	ExtractProject(ctx context.Context, userPrompt string, config ParseConfig) (*ProjectContext, error)
+}
+
+// ProjectContextSystemPrompt is Stage 1's project extraction without the epics.
+const ProjectContextSystemPrompt = `You are a PRD parser extracting the project context from a PRD. Do NOT generate epics, tasks, or subtasks.
+
+## OUTPUT FORMAT
+
+Return a JSON object with one field:
+"project" - ` + projectContextSpec + `
+
+## FIELDS
+
+- product_name: The product's name as the PRD gives it
+- elevator_pitch: One sentence saying what the product is and why it exists
+- target_audience: Primary and secondary users
+- business_goals, user_goals, tech_stack, constraints: Arrays of short strings
+- brand_guidelines: Voice, tone, or visual identity, if the PRD has any
+
+Only use what the PRD says; leave a field empty rather than inventing it.
+
+## OUTPUT REQUIREMENTS
+
+- Return ONLY valid JSON, no markdown fencing
+- No explanations or commentary
+- Start with { and end with }`
+
+// BuildProjectContextPrompt builds the user prompt for --context-only.
+func BuildProjectContextPrompt(prdContent string) string {
+	return fmt.Sprintf("Extract the project context from this PRD.\n\n---\nPRD CONTENT:\n---\n%s\n---\n\nReturn JSON with only the \"project\" field.", prdContent)
+}
+
+// ExtractProjectContext extracts the project context from prdContent with gen,
+// which must implement ProjectExtractor. No epics are generated.
+func ExtractProjectContext(ctx context.Context, gen Generator, prdContent string, config ParseConfig) (*ProjectContext, error) {
+	extractor, ok := gen.(ProjectExtractor)
+	if !ok {
+		return nil, fmt.Errorf("generator does not support project context extraction")
+	}
+	project, err := extractor.ExtractProject(ctx, BuildProjectContextPrompt(prdContent), config)
+	if err != nil {
+		return nil, fmt.Errorf("project context extraction failed: %w", err)
+	}
+	return project, nil
+}
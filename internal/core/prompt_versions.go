@@ -0,0 +1,72 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Prompt versions (--prompt-version). Each is a frozen revision of the embedded
+// generation prompts, including the model-family adjustments the llm package
+// makes to them, so a plan can be regenerated with the prompts that produced
+// it. A change to what the prompts send gets a new version, and the previous
+// behavior stays selectable.
+const (
+	PromptV1 = "v1" // Stage 2/3 prompts show epic/task context as Go's %v of the raw value; no model tuning
+	PromptV2 = "v2" // Object context rendered as labeled key/value lines; model-family tuning
+	PromptV3 = "v3" // As v2, plus unknown context keys as key: value lines; no trailing whitespace
+
+	CurrentPromptVersion = PromptV3
+)
+
+// PromptVersions lists the selectable prompt versions, oldest first.
+var PromptVersions = []string{PromptV1, PromptV2, PromptV3}
+
+// promptSet holds what differs between prompt versions.
+type promptSet struct {
+	// stageContext renders epic/task context for a Stage 2/3 "- Context: " line.
+	stageContext func(context FlexibleContext) string
+	// modelTuning applies the model-family system prompt adjustments.
+	modelTuning bool
+	// trimLines drops trailing whitespace from each line of Stage 2/3 prompts.
+	trimLines bool
+}
+
+var promptSets = map[string]promptSet{
+	PromptV1: {stageContext: func(context FlexibleContext) string { return fmt.Sprintf("%v", context.Value()) }},
+	PromptV2: {stageContext: knownFieldsPromptContext, modelTuning: true},
+	PromptV3: {stageContext: promptContext, modelTuning: true, trimLines: true},
+}
+
+// finish applies the set's cleanup to a built Stage 2/3 prompt.
+func (s promptSet) finish(prompt string) string {
+	if !s.trimLines {
+		return prompt
+	}
+	lines := strings.Split(prompt, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ValidPromptVersion reports whether version is a known prompt version.
+// Empty means CurrentPromptVersion.
+func ValidPromptVersion(version string) bool {
+	_, ok := promptSets[version]
+	return ok || version == ""
+}
+
+// promptsFor returns the prompt set selected by config.PromptVersion,
+// falling back to the current version.
+func promptsFor(config ParseConfig) promptSet {
+	if set, ok := promptSets[config.PromptVersion]; ok {
+		return set
+	}
+	return promptSets[CurrentPromptVersion]
+}
+
+// PromptVersionTunesModels reports whether prompt version (empty means
+// CurrentPromptVersion) adjusts generation system prompts for the model family.
+func PromptVersionTunesModels(version string) bool {
+	return promptsFor(ParseConfig{PromptVersion: version}).modelTuning
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"strings"
 )
 
 // SystemPrompt is the system instruction for PRD parsing.
@@ -262,9 +263,68 @@ MANDATORY STRUCTURE (WILL BE VALIDATED):
 - Empty tasks[] or subtasks[] arrays will FAIL validation and trigger retry
 - Do NOT take shortcuts - fully decompose the PRD into tasks and subtasks`
 
+// SourceHintInstruction is appended to generation prompts when ParseConfig.SourceHints is set.
+const SourceHintInstruction = `
+
+SOURCE HINTS: For traceability, include a "source_hint" string on every epic and task:
+the PRD heading it came from, or a short quoted phrase (under 80 characters) from the PRD
+that inspired it. Omit the field only if nothing in the PRD applies.`
+
+// withSourceHints appends SourceHintInstruction to prompt if source hints are enabled.
+func withSourceHints(prompt string, config ParseConfig) string {
+	if !config.SourceHints {
+		return prompt
+	}
+	return prompt + SourceHintInstruction
+}
+
+// AssumptionsInstruction is appended to the top-level prompts (single-shot and
+// Stage 1) when ParseConfig.Assumptions is set.
+const AssumptionsInstruction = `
+
+ASSUMPTIONS: Also include two top-level string arrays next to "project":
+- "assumptions": decisions you made where the PRD was silent or ambiguous
+  (e.g. "Assumed email/password login since no auth method is specified")
+- "open_questions": questions a human should answer before implementation
+Keep each entry to one sentence. Use empty arrays if the PRD is unambiguous.`
+
+// withAssumptions appends AssumptionsInstruction to prompt if assumptions are requested.
+func withAssumptions(prompt string, config ParseConfig) string {
+	if !config.Assumptions {
+		return prompt
+	}
+	return prompt + AssumptionsInstruction
+}
+
+// RequirementIDInstruction is appended to every generation prompt when
+// ParseConfig.RequirementIDs is set.
+const RequirementIDInstruction = `
+
+REQUIREMENT IDS: If the PRD numbers its requirements (e.g. "FR-3", "REQ-12", "US-4"),
+include a "requirement_id" string on every epic, task, and subtask naming the
+requirement it implements, exactly as the PRD writes it. Separate several with
+commas ("FR-3, FR-4"). Omit the field for items that don't implement a numbered
+requirement, or if the PRD has none.`
+
+// withRunSections appends the optional per-run sections shared by all generation
+// prompts: requirement IDs, reviewer guidance (interactive regeneration), and the
+// user's --instructions, each clearly delimited from the templated prompt.
+func withRunSections(prompt string, config ParseConfig) string {
+	if config.RequirementIDs {
+		prompt += RequirementIDInstruction
+	}
+	if config.Guidance != "" {
+		prompt += "\n\nREVIEWER GUIDANCE (a previous attempt was rejected - follow this):\n" + config.Guidance
+	}
+	if instructions := strings.TrimSpace(config.Instructions); instructions != "" {
+		prompt += "\n\n---\nADDITIONAL INSTRUCTIONS (from the user for this run - follow these):\n" + instructions + "\n---"
+	}
+	return prompt
+}
+
 // BuildUserPrompt renders the user prompt with config values.
 func BuildUserPrompt(prdContent string, config ParseConfig) string {
-	return fmt.Sprintf(
+	prompt := fmt.Sprintf(
 		UserPromptTemplate,
 		config.TargetEpics,
 		config.TasksPerEpic,
@@ -274,4 +334,6 @@ func BuildUserPrompt(prdContent string, config ParseConfig) string {
 		config.PropagateContext,
 		prdContent,
 	)
+	prompt = withTestingMode(prompt, config)
+	return withRunSections(withAssumptions(withSourceHints(prompt, config), config), config)
 }
@@ -0,0 +1,82 @@
+package core
+
+// ReadyItems returns the items that can be started in a fresh plan: leaf items
+// (subtasks, and tasks or epics with no children) whose own and ancestors'
+// depends_on lists are empty, only name unknown IDs, or, outside the
+// foundation epic (see FoundationEpic), only name foundation items.
+func ReadyItems(response *ParseResponse) []ItemRef {
+	return ReadyItemsAfter(response, nil)
+}
+
+// ReadyItemsAfter is ReadyItems for a plan that is partly done. completed holds
+// the temp_ids of finished items; a parent counts as finished once all of its
+// children are. Leaf items are ready when they aren't finished and every
+// dependency of theirs and of their ancestors is, not counting dependencies
+// of feature items on the foundation, which is set up alongside them.
+func ReadyItemsAfter(response *ParseResponse, completed map[string]bool) []ItemRef {
+	done := make(map[string]bool)
+	known := make(map[string]bool)
+	onFoundation := make(map[string]bool)
+	foundation := FoundationEpic(response)
+	_ = WalkItems(response, func(item ItemRef) error {
+		known[item.TempID()] = true
+		if foundation != nil && item.Epic.TempID == foundation.TempID {
+			onFoundation[item.TempID()] = true
+		}
+		return nil
+	})
+	for _, epic := range response.Epics {
+		allTasks := len(epic.Tasks) > 0
+		for _, task := range epic.Tasks {
+			closed := completed[epic.TempID] || completed[task.TempID]
+			allSubtasks := len(task.Subtasks) > 0
+			for _, subtask := range task.Subtasks {
+				done[subtask.TempID] = closed || completed[subtask.TempID]
+				allSubtasks = allSubtasks && done[subtask.TempID]
+			}
+			done[task.TempID] = closed || allSubtasks
+			allTasks = allTasks && done[task.TempID]
+		}
+		done[epic.TempID] = completed[epic.TempID] || allTasks
+	}
+
+	var ready []ItemRef
+	_ = WalkItems(response, func(item ItemRef) error {
+		if done[item.TempID()] || !isLeaf(item) {
+			return nil
+		}
+		feature := !onFoundation[item.TempID()]
+		satisfied := func(deps []string) bool {
+			for _, dep := range deps {
+				if known[dep] && !done[dep] && !(feature && onFoundation[dep]) {
+					return false
+				}
+			}
+			return true
+		}
+		if !satisfied(item.Epic.DependsOn) {
+			return nil
+		}
+		if item.Task != nil && !satisfied(item.Task.DependsOn) {
+			return nil
+		}
+		if item.Subtask != nil && !satisfied(item.Subtask.DependsOn) {
+			return nil
+		}
+		ready = append(ready, item)
+		return nil
+	})
+	return ready
+}
+
+// isLeaf reports whether the item has no children.
+func isLeaf(item ItemRef) bool {
+	switch item.Level {
+	case LevelSubtask:
+		return true
+	case LevelTask:
+		return len(item.Task.Subtasks) == 0
+	default:
+		return len(item.Epic.Tasks) == 0
+	}
+}
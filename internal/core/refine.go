@@ -33,6 +33,53 @@ func ParseBeadsJSON(data []byte) (*BeadsIssue, error) {
 	return &issue, nil
 }
 
+// beadsListIssue is one issue in `bd list --json` output, in beads' issue schema.
+type beadsListIssue struct {
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	IssueType    string `json:"issue_type"`
+	Type         string `json:"type"`   // Older schema name for issue_type
+	Parent       string `json:"parent"` // Present in some bd versions
+	Status       string `json:"status"`
+	Dependencies []struct {
+		IssueID     string `json:"issue_id"`
+		DependsOnID string `json:"depends_on_id"`
+		Type        string `json:"type"`
+	} `json:"dependencies"`
+}
+
+// ParseBeadsListJSON parses `bd list --json` output into full issue records.
+// Parents come from the parent field or, failing that, a parent-child dependency.
+func ParseBeadsListJSON(data []byte) ([]BeadsIssue, error) {
+	var listed []beadsListIssue
+	if err := json.Unmarshal(data, &listed); err != nil {
+		return nil, fmt.Errorf("failed to parse beads list JSON: %w", err)
+	}
+
+	issues := make([]BeadsIssue, 0, len(listed))
+	for _, l := range listed {
+		issue := BeadsIssue{
+			ID:          l.ID,
+			Title:       l.Title,
+			Description: l.Description,
+			Type:        l.IssueType,
+			Parent:      l.Parent,
+			Status:      l.Status,
+		}
+		if issue.Type == "" {
+			issue.Type = l.Type
+		}
+		for _, dep := range l.Dependencies {
+			if issue.Parent == "" && dep.Type == "parent-child" && (dep.IssueID == "" || dep.IssueID == l.ID) {
+				issue.Parent = dep.DependsOnID
+			}
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
 // ParseAnalysisResult parses the LLM analysis response
 func ParseAnalysisResult(output string) (*AnalysisResult, error) {
 	output = strings.TrimSpace(output)
@@ -0,0 +1,33 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+)
+
+// requirementDefinition matches a numbered requirement where a PRD defines it:
+// at the start of a line, list item, heading, or table cell, optionally bold,
+// e.g. "- FR-3: Users can reset passwords" or "| REQ-12 | ... |". IDs mentioned
+// mid-sentence ("see FR-3") and terms like "UTF-8" in prose aren't matched.
+var requirementDefinition = regexp.MustCompile(`(?m)^[ \t]*(?:[-*+][ \t]+|\d+\.[ \t]+|#{1,6}[ \t]+|\|[ \t]*)?(?:\*\*|__)?\[?([A-Z][A-Z0-9]{0,5}-\d+(?:\.\d+)*)\b`)
+
+// FindRequirementIDs returns the numbered requirement IDs the PRD defines, in
+// order of first appearance. PRDs without numbered requirements return nil.
+func FindRequirementIDs(prd string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, m := range requirementDefinition.FindAllStringSubmatch(prd, -1) {
+		if id := m[1]; !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// SplitRequirementIDs splits an item's requirement_id field ("FR-3, FR-4") into IDs.
+func SplitRequirementIDs(field string) []string {
+	return strings.FieldsFunc(field, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t'
+	})
+}
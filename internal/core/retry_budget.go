@@ -0,0 +1,78 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrRetryBudgetExhausted is returned by generators that need a retry after
+// the run's RetryBudget has been used up.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryBudget caps the total number of LLM retries across a run, shared by
+// every stage and parallel call (--retry-budget). Safe for concurrent use.
+// A nil budget allows unlimited retries.
+type RetryBudget struct {
+	mu        sync.Mutex
+	limit     int
+	used      int
+	exhausted bool
+}
+
+// NewRetryBudget creates a budget allowing limit retries in total.
+func NewRetryBudget(limit int) *RetryBudget {
+	return &RetryBudget{limit: limit}
+}
+
+// Take uses one retry from the budget. It returns false, and marks the budget
+// exhausted, when none are left.
+func (b *RetryBudget) Take() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.used >= b.limit {
+		b.exhausted = true
+		return false
+	}
+	b.used++
+	return true
+}
+
+// Exhausted reports whether a retry has been refused. Generators check it
+// before starting a call so the run stops instead of failing call by call.
+func (b *RetryBudget) Exhausted() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exhausted
+}
+
+// Used returns the number of retries taken so far.
+func (b *RetryBudget) Used() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// RetryBudgetError aborts a multi-stage run whose retry budget ran out. It
+// carries the plan generated so far so the caller can checkpoint it.
+type RetryBudgetError struct {
+	Retries int            // Retries used before the budget ran out
+	Partial *ParseResponse // Epics, with the tasks and subtasks whose calls finished
+}
+
+func (e *RetryBudgetError) Error() string {
+	return fmt.Sprintf("%v after %d retries", ErrRetryBudgetExhausted, e.Retries)
+}
+
+func (e *RetryBudgetError) Unwrap() error {
+	return ErrRetryBudgetExhausted
+}
@@ -12,6 +12,7 @@ type ReviewResult struct {
 	Response    *ParseResponse
 	WasModified bool
 	ReviewNotes string
+	Changes     ReviewChanges // Structural changes from the original to Response
 }
 
 // RawReviewResponse is the structure returned by the LLM during review.
@@ -33,19 +34,23 @@ type Reviewer interface {
 // The review focuses on structure (epic order, dependencies) and merges changes
 // back onto the original to preserve subtasks and detailed data.
 func ReviewAndFix(ctx context.Context, response *ParseResponse, prdContent string, reviewer Reviewer) (*ReviewResult, error) {
-	// Build prompts
 	userPrompt := BuildReviewPrompt(response, prdContent)
+	return reviseStructure(ctx, response, "review", ReviewSystemPrompt, userPrompt, reviewer)
+}
 
+// reviseStructure runs one structure-revising LLM pass (pass names it in
+// errors) and merges the returned structure onto response.
+func reviseStructure(ctx context.Context, response *ParseResponse, pass, systemPrompt, userPrompt string, reviewer Reviewer) (*ReviewResult, error) {
 	// Call LLM
-	output, err := reviewer.GenerateRaw(ctx, ReviewSystemPrompt, userPrompt)
+	output, err := reviewer.GenerateRaw(ctx, systemPrompt, userPrompt)
 	if err != nil {
-		return nil, fmt.Errorf("review LLM call failed: %w", err)
+		return nil, fmt.Errorf("%s LLM call failed: %w", pass, err)
 	}
 
 	// Parse the review response
 	rawReviewed, err := parseReviewResponse(output)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse review response: %w", err)
+		return nil, fmt.Errorf("failed to parse %s response: %w", pass, err)
 	}
 
 	// Determine if it was modified
@@ -55,13 +60,15 @@ func ReviewAndFix(ctx context.Context, response *ParseResponse, prdContent strin
 	// Merge the reviewed structure with original to preserve subtasks and detailed data
 	mergedResponse := mergeReviewedStructure(response, rawReviewed)
 
-	// Validate the merged result
-	if err := mergedResponse.Validate(); err != nil {
+	// Validate the merged result's structure; incomplete decomposition and
+	// depends_on entries the plan already couldn't resolve are checked by the
+	// caller, not held against the merge
+	if err := mergedResponse.ValidateStructure(); err != nil {
 		// If validation still fails, return original with warning
 		return &ReviewResult{
 			Response:    response,
 			WasModified: false,
-			ReviewNotes: fmt.Sprintf("Review merge failed validation: %v. Using original structure.", err),
+			ReviewNotes: fmt.Sprintf("%s merge failed validation: %v. Using original structure.", strings.ToUpper(pass[:1])+pass[1:], err),
 		}, nil
 	}
 
@@ -69,6 +76,7 @@ func ReviewAndFix(ctx context.Context, response *ParseResponse, prdContent strin
 		Response:    mergedResponse,
 		WasModified: wasModified,
 		ReviewNotes: reviewNotes,
+		Changes:     DiffReview(response, mergedResponse),
 	}, nil
 }
 
@@ -195,9 +203,11 @@ func mergeReviewedStructure(original *ParseResponse, reviewed *RawReviewResponse
 
 	// Build merged response
 	merged := &ParseResponse{
-		Project:  original.Project,
-		Epics:    mergedEpics,
-		Metadata: original.Metadata,
+		Project:       original.Project,
+		Epics:         mergedEpics,
+		Metadata:      original.Metadata,
+		Assumptions:   original.Assumptions,
+		OpenQuestions: original.OpenQuestions,
 	}
 
 	// Update project if review provided changes
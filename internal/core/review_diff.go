@@ -0,0 +1,175 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ReviewChanges is what the review pass changed in a plan's structure. Items
+// are matched by temp_id, as mergeReviewedStructure matches them.
+type ReviewChanges struct {
+	Added       []ItemChange       `json:"added,omitempty"`
+	Removed     []ItemChange       `json:"removed,omitempty"`
+	Retitled    []ItemChange       `json:"retitled,omitempty"`
+	Moved       []ItemChange       `json:"moved,omitempty"`
+	AddedDeps   []DependencyChange `json:"added_dependencies,omitempty"`
+	RemovedDeps []DependencyChange `json:"removed_dependencies,omitempty"`
+}
+
+// ItemChange is an item the review added, removed, retitled, or moved.
+type ItemChange struct {
+	Level    string `json:"level"`
+	ItemID   string `json:"item_id"`
+	Title    string `json:"title"`
+	OldTitle string `json:"old_title,omitempty"` // Retitled items only
+	From     int    `json:"from,omitempty"`      // Moved items only: 1-based position among siblings before
+	To       int    `json:"to,omitempty"`        // and after
+}
+
+// DependencyChange is a depends_on entry the review added or removed.
+type DependencyChange struct {
+	Level     string `json:"level"`
+	ItemID    string `json:"item_id"`
+	DependsOn string `json:"depends_on"`
+}
+
+// Empty reports whether nothing changed.
+func (c ReviewChanges) Empty() bool {
+	return len(c.Added)+len(c.Removed)+len(c.Retitled)+len(c.Moved)+len(c.AddedDeps)+len(c.RemovedDeps) == 0
+}
+
+// Lines describes each change in one line: additions, removals, retitles,
+// moves, then dependency changes.
+func (c ReviewChanges) Lines() []string {
+	var lines []string
+	for _, ch := range c.Added {
+		lines = append(lines, fmt.Sprintf("added %s %s %q", ch.Level, ch.ItemID, ch.Title))
+	}
+	for _, ch := range c.Removed {
+		lines = append(lines, fmt.Sprintf("removed %s %s %q", ch.Level, ch.ItemID, ch.Title))
+	}
+	for _, ch := range c.Retitled {
+		lines = append(lines, fmt.Sprintf("retitled %s %s: %q -> %q", ch.Level, ch.ItemID, ch.OldTitle, ch.Title))
+	}
+	for _, ch := range c.Moved {
+		lines = append(lines, fmt.Sprintf("moved %s %s %q from position %d to %d", ch.Level, ch.ItemID, ch.Title, ch.From, ch.To))
+	}
+	for _, d := range c.AddedDeps {
+		lines = append(lines, fmt.Sprintf("%s %s now depends on %s", d.Level, d.ItemID, d.DependsOn))
+	}
+	for _, d := range c.RemovedDeps {
+		lines = append(lines, fmt.Sprintf("%s %s no longer depends on %s", d.Level, d.ItemID, d.DependsOn))
+	}
+	return lines
+}
+
+// DiffReview compares a plan before review (original) with the merged result
+// (merged): items added, removed, or retitled, items whose order among their
+// siblings changed, and depends_on entries added or removed.
+func DiffReview(original, merged *ParseResponse) ReviewChanges {
+	var changes ReviewChanges
+	before := make(map[string]ItemRef)
+	beforeSiblings := make(map[string][]string) // parent temp_id ("" for epics) -> children in order
+	_ = WalkItems(original, func(item ItemRef) error {
+		before[item.TempID()] = item
+		beforeSiblings[item.ParentTempID()] = append(beforeSiblings[item.ParentTempID()], item.TempID())
+		return nil
+	})
+
+	after := make(map[string]ItemRef)
+	afterSiblings := make(map[string][]string)
+	_ = WalkItems(merged, func(item ItemRef) error {
+		id := item.TempID()
+		after[id] = item
+		afterSiblings[item.ParentTempID()] = append(afterSiblings[item.ParentTempID()], id)
+
+		prev, ok := before[id]
+		if !ok {
+			changes.Added = append(changes.Added, ItemChange{Level: item.Level, ItemID: id, Title: item.Title()})
+			return nil
+		}
+		if prev.Title() != item.Title() {
+			changes.Retitled = append(changes.Retitled, ItemChange{Level: item.Level, ItemID: id, Title: item.Title(), OldTitle: prev.Title()})
+		}
+		for _, dep := range item.DependsOn() {
+			if !slices.Contains(prev.DependsOn(), dep) {
+				changes.AddedDeps = append(changes.AddedDeps, DependencyChange{Level: item.Level, ItemID: id, DependsOn: dep})
+			}
+		}
+		for _, dep := range prev.DependsOn() {
+			if !slices.Contains(item.DependsOn(), dep) {
+				changes.RemovedDeps = append(changes.RemovedDeps, DependencyChange{Level: item.Level, ItemID: id, DependsOn: dep})
+			}
+		}
+		return nil
+	})
+	_ = WalkItems(original, func(item ItemRef) error {
+		if _, ok := after[item.TempID()]; !ok {
+			changes.Removed = append(changes.Removed, ItemChange{Level: item.Level, ItemID: item.TempID(), Title: item.Title()})
+		}
+		return nil
+	})
+
+	// Only a change in the relative order of the siblings both plans have is a
+	// move; items added or removed around them aren't
+	moved := make(map[string]bool)
+	for parent, children := range afterSiblings {
+		for _, id := range movedSiblings(beforeSiblings[parent], children) {
+			moved[id] = true
+		}
+	}
+	_ = WalkItems(merged, func(item ItemRef) error {
+		id, parent := item.TempID(), item.ParentTempID()
+		if moved[id] {
+			changes.Moved = append(changes.Moved, ItemChange{
+				Level: item.Level, ItemID: id, Title: item.Title(),
+				From: slices.Index(beforeSiblings[parent], id) + 1, To: slices.Index(afterSiblings[parent], id) + 1,
+			})
+		}
+		return nil
+	})
+	return changes
+}
+
+// movedSiblings returns the fewest siblings whose moves explain the change from
+// the before order to the after order: those in both that are off a longest
+// run kept in the original order. On ties the later siblings are kept, so of
+// two swapped siblings the one that moved up is reported.
+func movedSiblings(before, after []string) []string {
+	pos := make(map[string]int, len(before))
+	for i, id := range before {
+		pos[id] = i
+	}
+	var common []string
+	for _, id := range after {
+		if _, ok := pos[id]; ok {
+			common = append(common, id)
+		}
+	}
+
+	length, prev := make([]int, len(common)), make([]int, len(common))
+	best := -1
+	for i := range common {
+		length[i], prev[i] = 1, -1
+		for j := 0; j < i; j++ {
+			if pos[common[j]] < pos[common[i]] && length[j]+1 > length[i] {
+				length[i], prev[i] = length[j]+1, j
+			}
+		}
+		if best < 0 || length[i] >= length[best] {
+			best = i
+		}
+	}
+	kept := make(map[string]bool)
+	for i := best; i >= 0; i = prev[i] {
+		kept[common[i]] = true
+	}
+
+	var moved []string
+	for _, id := range common {
+		if !kept[id] {
+			moved = append(moved, id)
+		}
+	}
+	return moved
+}
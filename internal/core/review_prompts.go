@@ -84,12 +84,29 @@ func BuildReviewPrompt(response *ParseResponse, prdContent string) string {
 	// Serialize the response to JSON for review
 	responseJSON := serializeForReview(response)
 
-	return fmt.Sprintf(
+	prompt := fmt.Sprintf(
 		ReviewUserPromptTemplate,
 		techStack,
 		responseJSON,
 		techStack,
 	)
+	return withOrphanFindings(prompt, CheckOrphanFunctionality(response))
+}
+
+// withOrphanFindings asks the review to fix epics flagged by
+// CheckOrphanFunctionality by adding a task that makes the work visible.
+func withOrphanFindings(prompt string, orphans []OrphanEpic) string {
+	if len(orphans) == 0 {
+		return prompt
+	}
+	var b strings.Builder
+	b.WriteString(prompt)
+	b.WriteString("\n\n## ORPHAN FUNCTIONALITY\n\nThese epics have no way to see their work running:\n")
+	for _, o := range orphans {
+		fmt.Fprintf(&b, "- %s\n", o)
+	}
+	b.WriteString("\nFor each, add a task (labeled frontend, ui, or cli) that exposes the result: a page, a CLI command, or visible output.")
+	return b.String()
 }
 
 // serializeForReview converts ParseResponse to a readable JSON string for the review prompt.
@@ -2,6 +2,10 @@ package core
 
 import "fmt"
 
+// projectContextSpec describes the "project" object that Stage 1 and
+// --context-only extract.
+const projectContextSpec = `Extracted context (product_name, elevator_pitch, target_audience, brand_guidelines, business_goals, user_goals, tech_stack, constraints)`
+
 // Stage 1: PRD → Epics (high-level structure only)
 const Stage1SystemPrompt = `You are a PRD parser performing Stage 1: Epic extraction.
 
@@ -10,7 +14,7 @@ Your job is to extract the HIGH-LEVEL EPIC STRUCTURE from a PRD. Do NOT generate
 ## OUTPUT FORMAT
 
 Return a JSON object with:
-1. "project" - Extracted context (product_name, elevator_pitch, target_audience, brand_guidelines, business_goals, user_goals, tech_stack, constraints)
+1. "project" - ` + projectContextSpec + `
 2. "epics" - Array of epic summaries (WITHOUT tasks)
 
 ## MANDATORY: EPIC 1 MUST BE PROJECT FOUNDATION (CRITICAL)
@@ -219,7 +223,7 @@ EPIC:
 - ID: %s
 - Title: %s
 - Description: %s
-- Context: %v
+- Context: %s
 - Acceptance Criteria: %v
 
 PROJECT CONTEXT:
@@ -312,7 +316,7 @@ TASK:
 - ID: %s
 - Title: %s
 - Description: %s
-- Context: %v
+- Context: %s
 - Design Notes: %v
 
 EPIC CONTEXT: %s
@@ -327,30 +331,32 @@ Return JSON with "subtasks" array.`
 
 // BuildStage1Prompt builds the Stage 1 user prompt.
 func BuildStage1Prompt(prdContent string, config ParseConfig) string {
-	return fmt.Sprintf(
+	prompt := fmt.Sprintf(
 		Stage1UserPromptTemplate,
 		config.TargetEpics,
 		config.DefaultPriority,
 		config.TestingLevel,
 		prdContent,
 	)
+	prompt = withTestingMode(prompt, config)
+	return withRunSections(withAssumptions(withSourceHints(prompt, config), config), config)
 }
 
 // BuildStage2Prompt builds the Stage 2 user prompt.
 func BuildStage2Prompt(epic Epic, project ProjectContext, config ParseConfig) string {
-	return fmt.Sprintf(
+	return promptsFor(config).finish(withRunSections(withSourceHints(fmt.Sprintf(
 		Stage2UserPromptTemplate,
 		epic.TempID,
 		epic.Title,
 		epic.Description,
-		epic.Context,
+		promptsFor(config).stageContext(epic.Context),
 		epic.AcceptanceCriteria,
 		project.ProductName,
 		project.TargetAudience,
 		project.TechStack,
 		config.TasksPerEpic,
 		config.DefaultPriority,
-	)
+	), config), config))
 }
 
 // BuildStage3Prompt builds the Stage 3 user prompt.
@@ -359,24 +365,30 @@ func BuildStage3Prompt(task Task, epicContext string, project ProjectContext, co
 	if task.DesignNotes != nil {
 		designNotes = *task.DesignNotes
 	}
-	return fmt.Sprintf(
+	return promptsFor(config).finish(withRunSections(fmt.Sprintf(
 		Stage3UserPromptTemplate,
 		task.TempID,
 		task.Title,
 		task.Description,
-		task.Context,
+		promptsFor(config).stageContext(task.Context),
 		designNotes,
 		epicContext,
 		project.ProductName,
 		project.TargetAudience,
 		config.SubtasksPerTask,
-	)
+	), config))
 }
 
 // ============================================================================
 // FULL CONTEXT MODE: Pass PRD to all stages
 // ============================================================================
 
+// Maximum PRD characters included in full-context Stage 2 and Stage 3 prompts.
+const (
+	Stage2PRDLimit = 8000
+	Stage3PRDLimit = 6000
+)
+
 // Stage2UserPromptWithPRD includes the original PRD for reference.
 const Stage2UserPromptWithPRD = `Break down this epic into tasks.
 
@@ -384,7 +396,7 @@ EPIC:
 - ID: %s
 - Title: %s
 - Description: %s
-- Context: %v
+- Context: %s
 - Acceptance Criteria: %v
 
 PROJECT CONTEXT:
@@ -413,7 +425,7 @@ TASK:
 - ID: %s
 - Title: %s
 - Description: %s
-- Context: %v
+- Context: %s
 - Design Notes: %v
 
 EPIC CONTEXT: %s
@@ -439,16 +451,16 @@ Return JSON with "subtasks" array.`
 func BuildStage2PromptWithPRD(epic Epic, project ProjectContext, config ParseConfig, prdContent string) string {
 	// Truncate PRD if too long (keep first ~8000 chars to stay within token limits)
 	prd := prdContent
-	if len(prd) > 8000 {
-		prd = prd[:8000] + "\n\n[... PRD truncated for length ...]"
+	if len(prd) > Stage2PRDLimit {
+		prd = prd[:Stage2PRDLimit] + "\n\n[... PRD truncated for length ...]"
 	}
 
-	return fmt.Sprintf(
+	return promptsFor(config).finish(withRunSections(withSourceHints(fmt.Sprintf(
 		Stage2UserPromptWithPRD,
 		epic.TempID,
 		epic.Title,
 		epic.Description,
-		epic.Context,
+		promptsFor(config).stageContext(epic.Context),
 		epic.AcceptanceCriteria,
 		project.ProductName,
 		project.TargetAudience,
@@ -456,7 +468,7 @@ func BuildStage2PromptWithPRD(epic Epic, project ProjectContext, config ParseCon
 		config.TasksPerEpic,
 		config.DefaultPriority,
 		prd,
-	)
+	), config), config))
 }
 
 // BuildStage3PromptWithPRD builds Stage 3 prompt with full PRD context.
@@ -468,21 +480,21 @@ func BuildStage3PromptWithPRD(task Task, epicContext string, project ProjectCont
 
 	// Truncate PRD if too long (keep first ~6000 chars for subtask stage)
 	prd := prdContent
-	if len(prd) > 6000 {
-		prd = prd[:6000] + "\n\n[... PRD truncated for length ...]"
+	if len(prd) > Stage3PRDLimit {
+		prd = prd[:Stage3PRDLimit] + "\n\n[... PRD truncated for length ...]"
 	}
 
-	return fmt.Sprintf(
+	return promptsFor(config).finish(withRunSections(fmt.Sprintf(
 		Stage3UserPromptWithPRD,
 		task.TempID,
 		task.Title,
 		task.Description,
-		task.Context,
+		promptsFor(config).stageContext(task.Context),
 		designNotes,
 		epicContext,
 		project.ProductName,
 		project.TargetAudience,
 		config.SubtasksPerTask,
 		prd,
-	)
+	), config))
 }
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SummaryChunkChars is the most PRD text sent in one summarization call
+// (~100k tokens). Longer PRDs are summarized chunk by chunk.
+const SummaryChunkChars = 400000
+
+// Summarizer is implemented by generators that can condense a PRD for the
+// Summarize pre-pass. userPrompt is built by BuildSummarizePrompt and is sent
+// with SummarizePRDSystemPrompt.
+type Summarizer interface {
+	Summarize(ctx context.Context, userPrompt string, config ParseConfig) (string, error)
+}
+
+// SummarizePRDSystemPrompt instructs the LLM to condense a PRD for Stage 1.
+const SummarizePRDSystemPrompt = `You are condensing a Product Requirements Document so it can be planned in a later step.
+
+Produce a structured Markdown summary that keeps everything needed to identify epics:
+- Product name, target audience, and tech stack
+- Every feature area, with its main requirements in one or two lines each
+- Requirement IDs (e.g. FR-12), constraints, non-goals, and dependencies between areas
+- Keep the PRD's own section headings so details can be looked up later
+
+Drop prose, examples, and repetition. Do not invent requirements.
+Return only the summary, no preamble.`
+
+// BuildSummarizePrompt builds the user prompt for summarizing one PRD chunk.
+func BuildSummarizePrompt(chunk string, part, parts int) string {
+	if parts > 1 {
+		return fmt.Sprintf("This is part %d of %d of the PRD. Summarize this part only.\n\n## PRD\n\n%s", part, parts, chunk)
+	}
+	return "## PRD\n\n" + chunk
+}
+
+// SummarizePRD condenses prdContent with gen, which must implement Summarizer.
+// PRDs longer than SummaryChunkChars are split at section headings and each
+// chunk is summarized separately; the summaries are joined in order.
+func SummarizePRD(ctx context.Context, gen Generator, prdContent string, config ParseConfig) (string, error) {
+	summarizer, ok := gen.(Summarizer)
+	if !ok {
+		return "", fmt.Errorf("generator does not support PRD summarization")
+	}
+
+	chunks := chunkPRD(prdContent, SummaryChunkChars)
+	summaries := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		summary, err := summarizer.Summarize(ctx, BuildSummarizePrompt(chunk, i+1, len(chunks)), config)
+		if err != nil {
+			return "", fmt.Errorf("summarizing PRD part %d of %d: %w", i+1, len(chunks), err)
+		}
+		if summary = strings.TrimSpace(summary); summary != "" {
+			summaries = append(summaries, summary)
+		}
+	}
+	if len(summaries) == 0 {
+		return "", fmt.Errorf("PRD summary is empty")
+	}
+	return strings.Join(summaries, "\n\n"), nil
+}
+
+// PRDSection is a Markdown heading and the text under it.
+type PRDSection struct {
+	Heading string
+	Body    string // Includes the heading line
+}
+
+var headingPattern = regexp.MustCompile(`(?m)^#{1,6}\s+(.+)$`)
+
+// SplitPRDSections splits a Markdown PRD at its headings. Text before the first
+// heading becomes a section with an empty heading.
+func SplitPRDSections(prd string) []PRDSection {
+	matches := headingPattern.FindAllStringSubmatchIndex(prd, -1)
+	if len(matches) == 0 {
+		return []PRDSection{{Body: prd}}
+	}
+
+	var sections []PRDSection
+	if lead := prd[:matches[0][0]]; strings.TrimSpace(lead) != "" {
+		sections = append(sections, PRDSection{Body: lead})
+	}
+	for i, m := range matches {
+		end := len(prd)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		sections = append(sections, PRDSection{
+			Heading: strings.TrimSpace(prd[m[2]:m[3]]),
+			Body:    prd[m[0]:end],
+		})
+	}
+	return sections
+}
+
+// RelevantPRDSections returns the PRD sections an epic is about: those whose
+// heading appears in the epic's source hint, or else those whose heading
+// shares a significant word with the epic's title. It returns "" when no
+// section matches.
+func RelevantPRDSections(prd string, epic Epic) string {
+	sections := SplitPRDSections(prd)
+
+	var picked []string
+	if epic.SourceHint != nil && *epic.SourceHint != "" {
+		hint := strings.ToLower(*epic.SourceHint)
+		for _, s := range sections {
+			if s.Heading != "" && strings.Contains(hint, strings.ToLower(s.Heading)) {
+				picked = append(picked, s.Body)
+			}
+		}
+	}
+	if len(picked) == 0 {
+		titleWords := significantWords(epic.Title)
+		for _, s := range sections {
+			for word := range significantWords(s.Heading) {
+				if titleWords[word] {
+					picked = append(picked, s.Body)
+					break
+				}
+			}
+		}
+	}
+	return strings.Join(picked, "")
+}
+
+// significantWords returns the lowercased words of s longer than three letters.
+func significantWords(s string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		if len(w) > 3 {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+// chunkPRD splits prd into chunks of at most max chars, breaking at section
+// headings where possible. A single section longer than max is split as is.
+func chunkPRD(prd string, max int) []string {
+	if len(prd) <= max {
+		return []string{prd}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+	}
+	for _, s := range SplitPRDSections(prd) {
+		body := s.Body
+		if current.Len()+len(body) > max {
+			flush()
+		}
+		for len(body) > max {
+			chunks = append(chunks, body[:max])
+			body = body[max:]
+		}
+		current.WriteString(body)
+	}
+	flush()
+	return chunks
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Bounds for PRDShape.LooksLikeTaskList. A short list line has at most
+// taskLineMaxWords words; prose is every other line with text.
+const (
+	taskListMinItems = 5
+	taskListMinShare = 0.75
+	taskLineMaxWords = 12
+	taskListMaxProse = 0.25
+)
+
+// taskLinePattern matches checkbox ("- [ ] x"), numbered ("1. x", "2) x"), and
+// bulleted ("- x", "* x") list lines.
+var taskLinePattern = regexp.MustCompile(`^\s*(?:[-*+]\s+\[[ xX]\]|\d+[.)]|[-*+])\s+\S`)
+
+// PRDShape counts the kinds of lines in a PRD. Headings, blank lines, and
+// fenced code are not counted.
+type PRDShape struct {
+	Lines      int // Lines with text
+	ItemLines  int // Short checkbox, numbered, or bulleted lines
+	Words      int // Words on all counted lines
+	ProseWords int // Words on lines that aren't short list items
+}
+
+// ItemShare returns the share of lines that are short list items.
+func (s PRDShape) ItemShare() float64 {
+	if s.Lines == 0 {
+		return 0
+	}
+	return float64(s.ItemLines) / float64(s.Lines)
+}
+
+// LooksLikeTaskList reports whether the PRD reads like a flat task list: many
+// short list lines and little prose. The Stage 1 prompts extract business
+// context and goals, which a task list doesn't have.
+func (s PRDShape) LooksLikeTaskList() bool {
+	if s.ItemLines < taskListMinItems || s.ItemShare() < taskListMinShare {
+		return false
+	}
+	return float64(s.ProseWords) <= taskListMaxProse*float64(s.Words)
+}
+
+// CheckPRDShape counts the list lines and prose in prd.
+func CheckPRDShape(prd string) PRDShape {
+	var s PRDShape
+	inCode := false
+	for _, line := range strings.Split(prd, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode || trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		words := len(strings.Fields(trimmed))
+		s.Lines++
+		s.Words += words
+		if taskLinePattern.MatchString(line) && words <= taskLineMaxWords {
+			s.ItemLines++
+		} else {
+			s.ProseWords += words
+		}
+	}
+	return s
+}
@@ -0,0 +1,48 @@
+package core
+
+// TopoSortTempIDs orders ids so that each id comes after the ids it depends on
+// (dependsOn maps a temp_id to its depends_on), keeping the given order
+// wherever the dependencies allow it. Dependencies on ids outside the list, and
+// on the id itself, are ignored. Ids caught in a cycle, or waiting on one, are
+// appended at the end in the given order.
+func TopoSortTempIDs(ids []string, dependsOn map[string][]string) []string {
+	inList := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		inList[id] = true
+	}
+
+	sorted := make([]string, 0, len(ids))
+	placed := make(map[string]bool, len(ids))
+	ready := func(id string) bool {
+		for _, dep := range dependsOn[id] {
+			if dep != id && inList[dep] && !placed[dep] {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Place the first unplaced id whose dependencies are placed, until none is
+	for len(sorted) < len(ids) {
+		next := ""
+		for _, id := range ids {
+			if !placed[id] && ready(id) {
+				next = id
+				break
+			}
+		}
+		if next == "" {
+			break
+		}
+		placed[next] = true
+		sorted = append(sorted, next)
+	}
+
+	for _, id := range ids {
+		if !placed[id] {
+			placed[id] = true
+			sorted = append(sorted, id)
+		}
+	}
+	return sorted
+}
@@ -125,6 +125,7 @@ type Subtask struct {
 	EstimatedMinutes *int                `json:"estimated_minutes,omitempty"` // 15-120 minutes
 	DependsOn        []string            `json:"depends_on"`                  // Temp IDs this depends on
 	Labels           []string            `json:"labels,omitempty"`            // Tags for categorization
+	RequirementID    string              `json:"requirement_id,omitempty"`    // PRD requirement(s) implemented, e.g. "FR-3"
 }
 
 // Task is a logical unit of work containing subtasks (2-8hrs total)
@@ -132,7 +133,7 @@ type Task struct {
 	TempID         string              `json:"temp_id"`                   // Hierarchical ID like "1.1"
 	Title          string              `json:"title"`                     // Clear, actionable title
 	Description    string              `json:"description"`               // What needs to be accomplished
-	Context        interface{}         `json:"context"`                   // Propagated + task-specific context (object or string)
+	Context        FlexibleContext     `json:"context"`                   // Propagated + task-specific context (object or string)
 	DesignNotes    *string             `json:"design_notes,omitempty"`    // Technical approach
 	Testing        TestingRequirements `json:"testing"`                   // Testing strategy
 	Priority       Priority            `json:"priority"`                  // critical/high/medium/low/very-low
@@ -140,6 +141,8 @@ type Task struct {
 	DependsOn      []string            `json:"depends_on"`                // Temp IDs this depends on
 	EstimatedHours *float64            `json:"estimated_hours,omitempty"` // Total including subtasks
 	Labels         []string            `json:"labels,omitempty"`          // Tags for categorization
+	SourceHint     *string             `json:"source_hint,omitempty"`     // PRD heading or phrase this came from
+	RequirementID  string              `json:"requirement_id,omitempty"`  // PRD requirement(s) implemented, e.g. "FR-3"
 }
 
 // Epic is a major feature or milestone containing tasks (1-4 weeks)
@@ -147,13 +150,15 @@ type Epic struct {
 	TempID             string              `json:"temp_id"`                  // Simple ID like "1", "2"
 	Title              string              `json:"title"`                    // Major feature or milestone
 	Description        string              `json:"description"`              // What this delivers
-	Context            interface{}         `json:"context"`                  // Business/user/brand context (object or string)
+	Context            FlexibleContext     `json:"context"`                  // Business/user/brand context (object or string)
 	AcceptanceCriteria []string            `json:"acceptance_criteria"`      // When this epic is complete
 	Testing            TestingRequirements `json:"testing"`                  // Epic-level testing strategy
 	Tasks              []Task              `json:"tasks"`                    // Tasks that complete this epic
 	DependsOn          []string            `json:"depends_on"`               // Epic temp IDs this depends on
 	EstimatedDays      *float64            `json:"estimated_days,omitempty"` // Working days for entire epic
 	Labels             []string            `json:"labels,omitempty"`         // Tags for categorization
+	SourceHint         *string             `json:"source_hint,omitempty"`    // PRD heading or phrase this came from
+	RequirementID      string              `json:"requirement_id,omitempty"` // PRD requirement(s) implemented, e.g. "FR-3"
 }
 
 // ProjectContext extracted from the PRD.
@@ -175,6 +180,10 @@ type ParseResponse struct {
 	Project  ProjectContext   `json:"project"`  // Extracted project context
 	Epics    []Epic           `json:"epics"`    // Major features/milestones
 	Metadata ResponseMetadata `json:"metadata"` // Summary statistics
+
+	// Ambiguities the LLM had to resolve, requested with ParseConfig.Assumptions.
+	Assumptions   []string `json:"assumptions,omitempty"`    // Things assumed where the PRD was silent or vague
+	OpenQuestions []string `json:"open_questions,omitempty"` // Questions a human should answer
 }
 
 // ResponseMetadata provides summary stats about the parsed PRD.
@@ -184,6 +193,7 @@ type ResponseMetadata struct {
 	TotalSubtasks      int             `json:"total_subtasks"`
 	EstimatedTotalDays *float64        `json:"estimated_total_days,omitempty"`
 	TestingCoverage    TestingCoverage `json:"testing_coverage"`
+	SourcePRD          string          `json:"source_prd,omitempty"` // Absolute path of the PRD, in failure checkpoints
 }
 
 // TestingCoverage indicates what test types are included.
@@ -214,6 +224,48 @@ type ParseConfig struct {
 	TestingLevel     string   `json:"testing_level"`     // minimal/standard/comprehensive
 	PropagateContext bool     `json:"propagate_context"` // Default: true
 	FullContext      bool     `json:"full_context"`      // Pass PRD to all stages (not just Stage 1)
+	SourceHints      bool     `json:"source_hints"`      // Ask for a source_hint (PRD reference) per epic/task
+	Assumptions      bool     `json:"assumptions"`       // Ask for assumptions/open_questions the PRD left unresolved
+	NoTesting        bool     `json:"no_testing"`        // Omit testing requirements from prompts and output
+	RequirementIDs   bool     `json:"requirement_ids"`   // Ask for the PRD requirement_id each item implements
+
+	// OrderedSubtasks runs Stage 3 in intra-epic dependency order: a task whose
+	// subtasks fail makes its dependents skip generation instead of failing the run.
+	OrderedSubtasks bool `json:"ordered_subtasks"`
+
+	// FixedConcurrency keeps Stage 2/3 parallelism constant (--fixed-concurrency)
+	// instead of backing off when the provider rate-limits calls.
+	FixedConcurrency bool `json:"fixed_concurrency"`
+
+	// Summarize condenses the PRD before Stage 1 (--summarize). Later stages get
+	// only the PRD sections relevant to each epic instead of the whole PRD.
+	Summarize bool `json:"summarize"`
+
+	// Instructions is free-form user text appended to every generation prompt (--instructions).
+	Instructions string `json:"instructions,omitempty"`
+
+	// PromptVersion selects the embedded prompt revision (--prompt-version);
+	// empty is CurrentPromptVersion.
+	PromptVersion string `json:"prompt_version,omitempty"`
+
+	// Glossary defines project terms (--glossary). It is added to every
+	// generation system prompt as authoritative.
+	Glossary string `json:"glossary,omitempty"`
+
+	// Guidance is extra reviewer direction for a regenerated stage (interactive mode).
+	Guidance string `json:"-"`
+
+	// TempDir is where the interactive editor's temp file is written (default: os.TempDir()).
+	TempDir string `json:"-"`
+
+	// RetryBudget caps LLM retries across the whole run (nil is unlimited).
+	RetryBudget *RetryBudget `json:"-"`
+
+	// Costs collects the reported cost of each generation call (nil discards them).
+	Costs *CostRecorder `json:"-"`
+
+	// Warnings collects non-fatal issues during parsing (nil prints them immediately).
+	Warnings *WarningCollector `json:"-"`
 }
 
 // DefaultParseConfig returns sensible defaults.
@@ -228,37 +280,99 @@ func DefaultParseConfig() ParseConfig {
 	}
 }
 
-// Validate checks the ParseResponse for required fields and consistency.
+// Validate checks the ParseResponse for required fields, full decomposition,
+// and depends_on entries that name items in the plan: ValidateComplete, then
+// ValidateDependencies.
 func (r *ParseResponse) Validate() error {
+	if err := r.ValidateComplete(); err != nil {
+		return err
+	}
+	return r.ValidateDependencies()
+}
+
+// UnresolvedDependency is a depends_on entry naming a temp_id that isn't in the plan.
+type UnresolvedDependency struct {
+	ItemID    string `json:"item_id"`
+	DependsOn string `json:"depends_on"`
+}
+
+// UnresolvedDependencies lists the depends_on entries, at every level, that
+// name a temp_id no epic, task, or subtask in the plan has.
+func (r *ParseResponse) UnresolvedDependencies() []UnresolvedDependency {
+	known := make(map[string]bool)
+	_ = WalkItems(r, func(item ItemRef) error {
+		known[item.TempID()] = true
+		return nil
+	})
+
+	var unresolved []UnresolvedDependency
+	_ = WalkItems(r, func(item ItemRef) error {
+		for _, dep := range item.DependsOn() {
+			if !known[dep] {
+				unresolved = append(unresolved, UnresolvedDependency{ItemID: item.TempID(), DependsOn: dep})
+			}
+		}
+		return nil
+	})
+	return unresolved
+}
+
+// ValidateDependencies fails when depends_on entries name temp_ids that aren't
+// in the plan, listing every one of them. Output adapters can't link these.
+func (r *ParseResponse) ValidateDependencies() error {
+	unresolved := r.UnresolvedDependencies()
+	if len(unresolved) == 0 {
+		return nil
+	}
+	refs := make([]string, len(unresolved))
+	for i, u := range unresolved {
+		refs[i] = fmt.Sprintf("%s -> %s", u.ItemID, u.DependsOn)
+	}
+	return &ValidationError{
+		Field:   "depends_on",
+		Message: fmt.Sprintf("%d unresolved references (%s)", len(unresolved), strings.Join(refs, ", ")),
+	}
+}
+
+// ValidateStructure checks the hard requirements for creating items: a product
+// name, at least one epic, and a title on every item. Epics without tasks and
+// tasks without subtasks are allowed (e.g., partial generation failures).
+func (r *ParseResponse) ValidateStructure() error {
 	if r.Project.ProductName == "" {
 		return &ValidationError{Field: "project.product_name", Message: "required"}
 	}
 	if len(r.Epics) == 0 {
 		return &ValidationError{Field: "epics", Message: "at least one epic required"}
 	}
-	for i, epic := range r.Epics {
-		if epic.Title == "" {
-			return &ValidationError{Field: fmt.Sprintf("epics[%d].title", i), Message: "required"}
+	return WalkItems(r, func(item ItemRef) error {
+		if item.Title() == "" {
+			return &ValidationError{Field: item.Path + ".title", Message: "required"}
 		}
-		if len(epic.Tasks) == 0 {
+		return nil
+	})
+}
+
+// ValidateComplete checks the structure plus full decomposition:
+// every epic has tasks and every task has subtasks.
+func (r *ParseResponse) ValidateComplete() error {
+	if err := r.ValidateStructure(); err != nil {
+		return err
+	}
+	return WalkItems(r, func(item ItemRef) error {
+		switch {
+		case item.Level == LevelEpic && len(item.Epic.Tasks) == 0:
 			return &ValidationError{
-				Field:   fmt.Sprintf("epics[%d].tasks", i),
-				Message: fmt.Sprintf("epic '%s' has empty tasks array - must decompose into tasks", epic.Title),
+				Field:   item.Path + ".tasks",
+				Message: fmt.Sprintf("epic '%s' has empty tasks array - must decompose into tasks", item.Epic.Title),
 			}
-		}
-		for j, task := range epic.Tasks {
-			if task.Title == "" {
-				return &ValidationError{Field: fmt.Sprintf("epics[%d].tasks[%d].title", i, j), Message: "required"}
-			}
-			if len(task.Subtasks) == 0 {
-				return &ValidationError{
-					Field:   fmt.Sprintf("epics[%d].tasks[%d].subtasks", i, j),
-					Message: fmt.Sprintf("task '%s' has empty subtasks array - must decompose into subtasks", task.Title),
-				}
+		case item.Level == LevelTask && len(item.Task.Subtasks) == 0:
+			return &ValidationError{
+				Field:   item.Path + ".subtasks",
+				Message: fmt.Sprintf("task '%s' has empty subtasks array - must decompose into subtasks", item.Task.Title),
 			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 // ValidationError represents a validation failure.
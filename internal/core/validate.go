@@ -58,15 +58,22 @@ func BuildValidationPrompt(response *ParseResponse, prdContent string) string {
 	summary.WriteString(fmt.Sprintf("Project: %s\n", response.Project.ProductName))
 	summary.WriteString(fmt.Sprintf("Tech Stack: %v\n\n", response.Project.TechStack))
 
-	for _, epic := range response.Epics {
-		summary.WriteString(fmt.Sprintf("### Epic %s: %s\n", epic.TempID, epic.Title))
-		summary.WriteString(fmt.Sprintf("Acceptance: %v\n", epic.AcceptanceCriteria))
-		for _, task := range epic.Tasks {
-			summary.WriteString(fmt.Sprintf("  - Task %s: %s\n", task.TempID, task.Title))
-			for _, subtask := range task.Subtasks {
-				summary.WriteString(fmt.Sprintf("    - %s: %s\n", subtask.TempID, subtask.Title))
+	_ = WalkItems(response, func(item ItemRef) error {
+		switch item.Level {
+		case LevelEpic:
+			if item.Epic != &response.Epics[0] {
+				summary.WriteString("\n") // Blank line closes the previous epic
 			}
+			summary.WriteString(fmt.Sprintf("### Epic %s: %s\n", item.Epic.TempID, item.Epic.Title))
+			summary.WriteString(fmt.Sprintf("Acceptance: %v\n", item.Epic.AcceptanceCriteria))
+		case LevelTask:
+			summary.WriteString(fmt.Sprintf("  - Task %s: %s\n", item.Task.TempID, item.Task.Title))
+		case LevelSubtask:
+			summary.WriteString(fmt.Sprintf("    - %s: %s\n", item.Subtask.TempID, item.Subtask.Title))
 		}
+		return nil
+	})
+	if len(response.Epics) > 0 {
 		summary.WriteString("\n")
 	}
 
@@ -0,0 +1,122 @@
+package core
+
+import "fmt"
+
+// Item levels reported by WalkItems.
+const (
+	LevelEpic    = "epic"
+	LevelTask    = "task"
+	LevelSubtask = "subtask"
+)
+
+// ItemRef is one epic, task, or subtask visited by WalkItems. The pointers
+// refer into the walked response, so callbacks may modify items in place.
+type ItemRef struct {
+	Level   string   // LevelEpic, LevelTask, or LevelSubtask
+	Epic    *Epic    // The item's epic (the item itself at LevelEpic)
+	Task    *Task    // The item's task (the item itself at LevelTask, nil for epics)
+	Subtask *Subtask // The item itself at LevelSubtask, nil otherwise
+	Path    string   // Location in the response, e.g. "epics[0].tasks[1]"
+}
+
+// TempID returns the item's temp_id.
+func (r ItemRef) TempID() string {
+	switch r.Level {
+	case LevelSubtask:
+		return r.Subtask.TempID
+	case LevelTask:
+		return r.Task.TempID
+	default:
+		return r.Epic.TempID
+	}
+}
+
+// Title returns the item's title.
+func (r ItemRef) Title() string {
+	switch r.Level {
+	case LevelSubtask:
+		return r.Subtask.Title
+	case LevelTask:
+		return r.Task.Title
+	default:
+		return r.Epic.Title
+	}
+}
+
+// DependsOn returns the item's depends_on temp IDs.
+func (r ItemRef) DependsOn() []string {
+	switch r.Level {
+	case LevelSubtask:
+		return r.Subtask.DependsOn
+	case LevelTask:
+		return r.Task.DependsOn
+	default:
+		return r.Epic.DependsOn
+	}
+}
+
+// Labels returns the item's labels.
+func (r ItemRef) Labels() []string {
+	switch r.Level {
+	case LevelSubtask:
+		return r.Subtask.Labels
+	case LevelTask:
+		return r.Task.Labels
+	default:
+		return r.Epic.Labels
+	}
+}
+
+// RequirementID returns the item's requirement_id field.
+func (r ItemRef) RequirementID() string {
+	switch r.Level {
+	case LevelSubtask:
+		return r.Subtask.RequirementID
+	case LevelTask:
+		return r.Task.RequirementID
+	default:
+		return r.Epic.RequirementID
+	}
+}
+
+// ParentTempID returns the temp_id of the item's parent, or "" for epics.
+func (r ItemRef) ParentTempID() string {
+	switch r.Level {
+	case LevelSubtask:
+		return r.Task.TempID
+	case LevelTask:
+		return r.Epic.TempID
+	default:
+		return ""
+	}
+}
+
+// WalkItems calls fn for every item in hierarchical order: each epic, then each
+// of its tasks followed by that task's subtasks. If fn returns an error the walk
+// stops and WalkItems returns it.
+func WalkItems(response *ParseResponse, fn func(item ItemRef) error) error {
+	for ei := range response.Epics {
+		epic := &response.Epics[ei]
+		epicPath := fmt.Sprintf("epics[%d]", ei)
+		if err := fn(ItemRef{Level: LevelEpic, Epic: epic, Path: epicPath}); err != nil {
+			return err
+		}
+
+		for ti := range epic.Tasks {
+			task := &epic.Tasks[ti]
+			taskPath := fmt.Sprintf("%s.tasks[%d]", epicPath, ti)
+			if err := fn(ItemRef{Level: LevelTask, Epic: epic, Task: task, Path: taskPath}); err != nil {
+				return err
+			}
+
+			for si := range task.Subtasks {
+				subtask := &task.Subtasks[si]
+				subtaskPath := fmt.Sprintf("%s.subtasks[%d]", taskPath, si)
+				if err := fn(ItemRef{Level: LevelSubtask, Epic: epic, Task: task, Subtask: subtask, Path: subtaskPath}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Warning codes for structured warnings.
+const (
+	WarnPRDTruncated         = "prd_truncated"
+	WarnSingleCallLargePRD   = "single_call_large_prd"
+	WarnValidationGap        = "validation_gap"
+	WarnValidationNote       = "validation_warning"
+	WarnConstraintViolation  = "constraint_violation"
+	WarnDependencyLevel      = "dependency_level"
+	WarnIncompletePlan       = "incomplete_plan"
+	WarnTasksFailed          = "tasks_failed"
+	WarnSubtasksFailed       = "subtasks_failed"
+	WarnSubtasksSkipped      = "subtasks_skipped"
+	WarnTaskDependencyCycle  = "task_dependency_cycle"
+	WarnValidationFailed     = "validation_failed"
+	WarnReviewFailed         = "review_failed"
+	WarnCheckpointFailed     = "checkpoint_failed"
+	WarnDocWriteFailed       = "doc_write_failed"
+	WarnAdapterCapability    = "adapter_capability"
+	WarnItemCap              = "item_cap"
+	WarnUncoveredRequirement = "uncovered_requirement"
+	WarnPromptTooLarge       = "prompt_too_large"
+	WarnOrphanFunctionality  = "orphan_functionality"
+	WarnInferredDependency   = "inferred_dependency"
+	WarnIncompleteContext    = "incomplete_project_context"
+	WarnEstimateCoverage     = "estimate_coverage"
+	WarnEstimatesFailed      = "estimates_failed"
+	WarnTaskListInput        = "task_list_input"
+	WarnIDSequence           = "id_sequence"
+	WarnGapFixFailed         = "gap_fix_failed"
+	WarnDanglingDependency   = "dangling_dependency"
+)
+
+// Warning is a structured, non-fatal issue encountered during parsing or creation.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Item    string `json:"item,omitempty"` // temp_id or external ID, if item-specific
+}
+
+// String formats the warning for display.
+func (w Warning) String() string {
+	if w.Item != "" {
+		return fmt.Sprintf("[%s] %s: %s", w.Code, w.Item, w.Message)
+	}
+	return fmt.Sprintf("[%s] %s", w.Code, w.Message)
+}
+
+// WarningCollector accumulates warnings during a run so they can be reported
+// together at the end instead of scrolling away. Safe for concurrent use.
+// A nil collector prints warnings immediately instead of collecting them.
+type WarningCollector struct {
+	mu       sync.Mutex
+	warnings []Warning
+}
+
+// NewWarningCollector creates an empty collector.
+func NewWarningCollector() *WarningCollector {
+	return &WarningCollector{}
+}
+
+// Add records a warning. item may be empty.
+func (c *WarningCollector) Add(code, item, format string, args ...interface{}) {
+	w := Warning{Code: code, Item: item, Message: fmt.Sprintf(format, args...)}
+	if c == nil {
+		fmt.Printf("Warning: %s\n", w)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warnings = append(c.warnings, w)
+}
+
+// Warnings returns a copy of the collected warnings in the order they were added.
+func (c *WarningCollector) Warnings() []Warning {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Warning(nil), c.warnings...)
+}
+
+// Len returns the number of collected warnings.
+func (c *WarningCollector) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.warnings)
+}
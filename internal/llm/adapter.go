@@ -2,6 +2,8 @@ package llm
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 
 	"github.com/dhabedank/prd-parser/internal/core"
 )
@@ -18,6 +20,10 @@ type Adapter interface {
 	Generate(ctx context.Context, systemPrompt, userPrompt string) (*core.ParseResponse, error)
 }
 
+// ProviderNames lists the --llm providers, in the order help and completion
+// show them.
+var ProviderNames = []string{"auto", "claude-cli", "codex-cli", "anthropic-api"}
+
 // Config holds configuration for LLM adapters.
 type Config struct {
 	// PreferCLI prefers CLI tools (claude, codex) over API when available.
@@ -37,6 +43,29 @@ type Config struct {
 
 	// MaxTokens limits response length.
 	MaxTokens int
+
+	// UseCLIContext lets the Claude CLI read the working directory (read-only
+	// tools, session persistence) instead of running fully isolated.
+	UseCLIContext bool
+
+	// Progress controls the indicator shown while Claude CLI calls run (auto/plain/none).
+	Progress string
+
+	// TempDir is where prompt files and debug dumps are written (default: os.TempDir()).
+	TempDir string
+
+	// PromptVersion is the --prompt-version the system prompts are tuned
+	// under (empty = current).
+	PromptVersion string
+}
+
+// tempPath returns the path of a file named name in the temp directory dir,
+// or in os.TempDir() if dir is empty.
+func tempPath(dir, name string) string {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, name)
 }
 
 // ModelForStage returns the model to use for a given stage.
@@ -14,13 +14,20 @@ import (
 // AnthropicAPIAdapter uses the Anthropic API directly.
 // Fallback when Claude CLI is not available.
 type AnthropicAPIAdapter struct {
-	client    anthropic.Client
-	model     string
-	maxTokens int
+	client        anthropic.Client
+	model         string
+	maxTokens     int
+	promptVersion string
 }
 
 // NewAnthropicAPIAdapter creates an Anthropic API adapter.
 func NewAnthropicAPIAdapter(config Config) (*AnthropicAPIAdapter, error) {
+	return newAnthropicAPIAdapter(config)
+}
+
+// newAnthropicAPIAdapter creates the adapter with extra client options (tests
+// point it at a local server).
+func newAnthropicAPIAdapter(config Config, opts ...option.RequestOption) (*AnthropicAPIAdapter, error) {
 	apiKey := config.APIKey
 	if apiKey == "" {
 		apiKey = os.Getenv("ANTHROPIC_API_KEY")
@@ -29,7 +36,7 @@ func NewAnthropicAPIAdapter(config Config) (*AnthropicAPIAdapter, error) {
 		return nil, fmt.Errorf("ANTHROPIC_API_KEY not set")
 	}
 
-	client := anthropic.NewClient(option.WithAPIKey(apiKey))
+	client := anthropic.NewClient(append([]option.RequestOption{option.WithAPIKey(apiKey)}, opts...)...)
 
 	model := config.Model
 	if model == "" {
@@ -38,13 +45,14 @@ func NewAnthropicAPIAdapter(config Config) (*AnthropicAPIAdapter, error) {
 
 	maxTokens := config.MaxTokens
 	if maxTokens == 0 {
-		maxTokens = 16384
+		maxTokens = DefaultMaxTokensFor(model)
 	}
 
 	return &AnthropicAPIAdapter{
-		client:    client,
-		model:     model,
-		maxTokens: maxTokens,
+		client:        client,
+		model:         model,
+		maxTokens:     maxTokens,
+		promptVersion: config.PromptVersion,
 	}, nil
 }
 
@@ -57,16 +65,18 @@ func (a *AnthropicAPIAdapter) IsAvailable() bool {
 }
 
 func (a *AnthropicAPIAdapter) Generate(ctx context.Context, systemPrompt, userPrompt string) (*core.ParseResponse, error) {
-	resp, err := a.client.Messages.New(ctx, anthropic.MessageNewParams{
+	params := anthropic.MessageNewParams{
 		Model:     anthropic.Model(a.model),
 		MaxTokens: int64(a.maxTokens),
 		System: []anthropic.TextBlockParam{
-			{Text: systemPrompt},
+			{Text: tunePrompt(a.promptVersion, a.model, systemPrompt)},
 		},
 		Messages: []anthropic.MessageParam{
 			anthropic.NewUserMessage(anthropic.NewTextBlock(userPrompt)),
 		},
-	})
+	}
+
+	resp, err := a.send(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("anthropic API error: %w", err)
 	}
@@ -81,3 +91,24 @@ func (a *AnthropicAPIAdapter) Generate(ctx context.Context, systemPrompt, userPr
 
 	return parseJSONResponse(output)
 }
+
+// send makes the request, streaming it when the SDK refuses max_tokens that
+// high without streaming (responses that may take over 10 minutes).
+func (a *AnthropicAPIAdapter) send(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	if _, err := anthropic.CalculateNonStreamingTimeout(a.maxTokens, params.Model, nil); err == nil {
+		return a.client.Messages.New(ctx, params)
+	}
+
+	stream := a.client.Messages.NewStreaming(ctx, params)
+	defer stream.Close()
+	message := anthropic.Message{}
+	for stream.Next() {
+		if err := message.Accumulate(stream.Current()); err != nil {
+			return nil, err
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
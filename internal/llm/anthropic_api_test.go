@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+func TestAnthropicAPIAdapterSendsMaxTokens(t *testing.T) {
+	var sent struct {
+		Model     string `json:"model"`
+		MaxTokens int    `json:"max_tokens"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &sent); err != nil {
+			t.Errorf("request body is not JSON: %v", err)
+		}
+		text, _ := json.Marshal(`{"project": {"product_name": "Test"}, "epics": [{"temp_id": "1", "title": "Epic",
+			"tasks": [{"temp_id": "1.1", "title": "Task", "subtasks": [{"temp_id": "1.1.1", "title": "Subtask"}]}]}]}`)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id": "msg_1", "type": "message", "role": "assistant", "model": "claude-sonnet-4-20250514",
+			"content": [{"type": "text", "text": `+string(text)+`}],
+			"stop_reason": "end_turn", "usage": {"input_tokens": 1, "output_tokens": 1}}`)
+	}))
+	defer server.Close()
+
+	adapter, err := newAnthropicAPIAdapter(Config{APIKey: "test", Model: "claude-sonnet-4-20250514", MaxTokens: 12345},
+		option.WithBaseURL(server.URL), option.WithMaxRetries(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := adapter.Generate(context.Background(), "system", "user")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	if sent.MaxTokens != 12345 {
+		t.Errorf("max_tokens = %d, want 12345", sent.MaxTokens)
+	}
+	if response.Project.ProductName != "Test" {
+		t.Errorf("product_name = %q, want Test", response.Project.ProductName)
+	}
+}
+
+func TestDefaultMaxTokensFor(t *testing.T) {
+	tests := map[string]int{
+		"claude-opus-4-5-20251101":  64000,
+		"claude-opus-4-20250514":    32000,
+		"claude-sonnet-4-20250514":  64000,
+		"claude-3-5-haiku-20241022": 8192,
+		"gpt-4o":                    DefaultMaxTokens,
+	}
+	for model, want := range tests {
+		if got := DefaultMaxTokensFor(model); got != want {
+			t.Errorf("DefaultMaxTokensFor(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
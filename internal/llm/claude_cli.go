@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -15,10 +14,69 @@ import (
 
 const maxRetries = 3
 
+// cliContextTools are the read-only tools enabled with UseCLIContext,
+// so Claude can inspect the repo without being able to modify it.
+const cliContextTools = "Read,Grep,Glob"
+
+// claudeRunner runs the claude CLI with args, feeding stdin, and returns stdout.
+type claudeRunner func(ctx context.Context, args []string, stdin string) ([]byte, error)
+
+// execClaude is the default claudeRunner.
+func execClaude(ctx context.Context, args []string, stdin string) ([]byte, error) {
+	return runClaude(ctx, args, stdin, nil)
+}
+
+// runClaude runs the claude CLI with extra environment variables added to ours.
+func runClaude(ctx context.Context, args []string, stdin string, env []string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "claude", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		// With --output-format json, failures still print the result wrapper
+		if _, cliErr := unwrapCLIResult(string(output)); cliErr != nil {
+			return nil, cliErr
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("claude CLI failed: %s", string(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("claude CLI failed: %w", err)
+	}
+	return output, nil
+}
+
+// claudeArgs builds the claude CLI arguments.
+// Key flags for clean, isolated execution:
+// --tools "" disables all tools so LLM just responds to the prompt
+// --output-format json returns structured result
+// --no-session-persistence avoids picking up session context
+// With useCLIContext, isolation is traded for grounding: read-only tools are
+// enabled and session persistence is left on so Claude can see the repo.
+func claudeArgs(model, systemPromptFile string, useCLIContext bool) []string {
+	args := []string{
+		"--model", model,
+		"--system-prompt-file", systemPromptFile,
+		"--print",
+		"--output-format", "json",
+	}
+	if useCLIContext {
+		return append(args, "--tools", cliContextTools)
+	}
+	return append(args, "--tools", "", "--no-session-persistence")
+}
+
 // ClaudeCLIAdapter uses the Claude Code CLI for generation.
 // This is preferred because users already have it authenticated.
 type ClaudeCLIAdapter struct {
-	model string
+	model         string
+	useCLIContext bool
+	tempDir       string // Directory for prompt files and debug dumps ("" is the system default)
+	promptVersion string
+	progress      progressIndicator
+	run           claudeRunner
 }
 
 // NewClaudeCLIAdapter creates a Claude CLI adapter.
@@ -27,7 +85,14 @@ func NewClaudeCLIAdapter(config Config) *ClaudeCLIAdapter {
 	if model == "" {
 		model = "claude-opus-4-5-20251101" // Use Opus 4.5 for best quality
 	}
-	return &ClaudeCLIAdapter{model: model}
+	return &ClaudeCLIAdapter{
+		model:         model,
+		useCLIContext: config.UseCLIContext,
+		tempDir:       config.TempDir,
+		promptVersion: config.PromptVersion,
+		progress:      newProgressIndicator(config.Progress, "  "),
+		run:           claudeRunnerFor(config),
+	}
 }
 
 func (a *ClaudeCLIAdapter) Name() string {
@@ -41,13 +106,14 @@ func (a *ClaudeCLIAdapter) IsAvailable() bool {
 }
 
 func (a *ClaudeCLIAdapter) Generate(ctx context.Context, systemPrompt, userPrompt string) (*core.ParseResponse, error) {
+	systemPrompt = tunePrompt(a.promptVersion, a.model, systemPrompt)
 	var lastErr error
 	var lastOutput string
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		if attempt > 1 {
 			fmt.Printf("Retry attempt %d/%d...\n", attempt, maxRetries)
-			time.Sleep(time.Duration(attempt) * 2 * time.Second) // Exponential backoff
+			time.Sleep(retryDelay(lastErr, attempt)) // Longer backoff for rate limits
 		}
 
 		output, err := a.callClaude(ctx, systemPrompt, userPrompt)
@@ -55,6 +121,9 @@ func (a *ClaudeCLIAdapter) Generate(ctx context.Context, systemPrompt, userPromp
 			lastErr = err
 			lastOutput = ""
 			fmt.Printf("LLM call failed: %v\n", err)
+			if !IsRetryable(err) {
+				break
+			}
 			continue
 		}
 
@@ -71,7 +140,7 @@ func (a *ClaudeCLIAdapter) Generate(ctx context.Context, systemPrompt, userPromp
 
 	// All retries failed - save raw response for debugging
 	if lastOutput != "" {
-		debugFile := filepath.Join(os.TempDir(), "prd-parser-last-response.txt")
+		debugFile := tempPath(a.tempDir, "prd-parser-last-response.txt")
 		_ = os.WriteFile(debugFile, []byte(lastOutput), 0644) // Best-effort, don't override original error
 		return nil, fmt.Errorf("%w (raw response saved to %s)", lastErr, debugFile)
 	}
@@ -79,21 +148,21 @@ func (a *ClaudeCLIAdapter) Generate(ctx context.Context, systemPrompt, userPromp
 	return nil, lastErr
 }
 
-// GenerateRaw sends prompts to Claude and returns raw string output.
-// Used for validation and other non-structured responses.
+// GenerateRaw sends prompts to Claude and returns the raw result text, with the
+// CLI's JSON wrapper removed. Used for validation and other non-structured responses.
 func (a *ClaudeCLIAdapter) GenerateRaw(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
 	return a.callClaude(ctx, systemPrompt, userPrompt)
 }
 
 func (a *ClaudeCLIAdapter) callClaude(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
 	// Write prompts to temp files (claude CLI reads from files better than stdin for long content)
-	systemFile, err := os.CreateTemp("", "prd-system-*.txt")
+	systemFile, err := os.CreateTemp(a.tempDir, "prd-system-*.txt")
 	if err != nil {
 		return "", fmt.Errorf("failed to create system prompt file: %w", err)
 	}
 	defer os.Remove(systemFile.Name())
 
-	userFile, err := os.CreateTemp("", "prd-user-*.txt")
+	userFile, err := os.CreateTemp(a.tempDir, "prd-user-*.txt")
 	if err != nil {
 		return "", fmt.Errorf("failed to create user prompt file: %w", err)
 	}
@@ -110,58 +179,19 @@ func (a *ClaudeCLIAdapter) callClaude(ctx context.Context, systemPrompt, userPro
 	userFile.Close()
 
 	// Start progress indicator in background
-	done := make(chan bool)
-	go func() {
-		startTime := time.Now()
-		ticker := time.NewTicker(10 * time.Second)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-done:
-				return
-			case <-ticker.C:
-				elapsed := time.Since(startTime).Truncate(time.Second)
-				fmt.Printf("  Still generating... (%s elapsed)\n", elapsed)
-			}
-		}
-	}()
-
-	// Build claude command
-	// Key flags for clean, isolated execution:
-	// --tools "" disables all tools so LLM just responds to the prompt
-	// --output-format json returns structured result
-	// --no-session-persistence avoids picking up session context
-	cmd := exec.CommandContext(ctx, "claude",
-		"--model", a.model,
-		"--system-prompt-file", systemFile.Name(),
-		"--print",
-		"--output-format", "json",
-		"--tools", "",
-		"--no-session-persistence",
-	)
+	stopProgress := a.progress.start()
 
 	// Pass user prompt via stdin
 	userContent, _ := os.ReadFile(userFile.Name())
-	cmd.Stdin = strings.NewReader(string(userContent))
 
-	output, err := cmd.Output()
-	close(done) // Stop progress indicator
+	output, err := a.run(ctx, claudeArgs(a.model, systemFile.Name(), a.useCLIContext), string(userContent))
+	stopProgress()
 
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return "", fmt.Errorf("claude CLI failed: %s", string(exitErr.Stderr))
-		}
-		return "", fmt.Errorf("claude CLI failed: %w", err)
+		return "", err
 	}
 
-	return string(output), nil
-}
-
-// cliJSONResponse is the wrapper structure from --output-format json
-type cliJSONResponse struct {
-	Type    string `json:"type"`
-	Result  string `json:"result"`
-	IsError bool   `json:"is_error"`
+	return unwrapCLIResult(string(output))
 }
 
 // parseJSONResponse extracts and validates JSON from LLM output.
@@ -170,52 +200,11 @@ func parseJSONResponse(output string) (*core.ParseResponse, error) {
 		return nil, fmt.Errorf("empty response from LLM")
 	}
 
-	output = strings.TrimSpace(output)
-
-	// Check if this is wrapped JSON from --output-format json
-	if strings.HasPrefix(output, "{\"type\":") {
-		var wrapper cliJSONResponse
-		if err := json.Unmarshal([]byte(output), &wrapper); err == nil {
-			if wrapper.IsError {
-				return nil, fmt.Errorf("CLI returned error: %s", wrapper.Result)
-			}
-			// Extract the actual response from the wrapper
-			output = wrapper.Result
-		}
-	}
-
-	// Find JSON in output (may be wrapped in markdown fences)
-	output = strings.TrimSpace(output)
-
-	// Remove markdown fences if present
-	if strings.HasPrefix(output, "```json") {
-		output = strings.TrimPrefix(output, "```json")
-		if idx := strings.LastIndex(output, "```"); idx != -1 {
-			output = output[:idx]
-		}
-		output = strings.TrimSpace(output)
-	} else if strings.HasPrefix(output, "```") {
-		output = strings.TrimPrefix(output, "```")
-		if idx := strings.LastIndex(output, "```"); idx != -1 {
-			output = output[:idx]
-		}
-		output = strings.TrimSpace(output)
-	}
-
-	// Find JSON object
-	start := strings.Index(output, "{")
-	end := strings.LastIndex(output, "}")
-	if start == -1 || end == -1 || end < start {
-		// Show first 200 chars to help debug
-		preview := output
-		if len(preview) > 200 {
-			preview = preview[:200] + "..."
-		}
-		return nil, fmt.Errorf("no valid JSON found in response (starts with: %q)", preview)
+	jsonStr, err := ExtractJSON(output)
+	if err != nil {
+		return nil, err
 	}
 
-	jsonStr := output[start : end+1]
-
 	var response core.ParseResponse
 	if err := json.Unmarshal([]byte(jsonStr), &response); err != nil {
 		// Try to find the error location
@@ -233,8 +222,9 @@ func parseJSONResponse(output string) (*core.ParseResponse, error) {
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
-	// Validate
-	if err := response.Validate(); err != nil {
+	// Only structural problems are worth a retry; parse reports incomplete
+	// decomposition and dangling depends_on entries (or fails under --strict-deps)
+	if err := response.ValidateStructure(); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
@@ -0,0 +1,283 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// captureRunner records the args of each claude invocation and returns output.
+type captureRunner struct {
+	args   [][]string
+	output string
+}
+
+func (r *captureRunner) run(ctx context.Context, args []string, stdin string) ([]byte, error) {
+	r.args = append(r.args, args)
+	return []byte(r.output), nil
+}
+
+func hasFlag(args []string, flag string) bool {
+	for _, a := range args {
+		if a == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// flagValue returns the value following flag in args.
+func flagValue(args []string, flag string) (string, bool) {
+	for i, a := range args {
+		if a == flag && i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+	return "", false
+}
+
+func TestCallClaudeIsolationFlags(t *testing.T) {
+	tests := []struct {
+		name          string
+		useCLIContext bool
+		wantIsolated  bool
+	}{
+		{"default isolated", false, true},
+		{"cli context", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &captureRunner{output: "{}"}
+			adapter := NewClaudeCLIAdapter(Config{UseCLIContext: tt.useCLIContext})
+			adapter.run = runner.run
+
+			if _, err := adapter.GenerateRaw(context.Background(), "system", "user"); err != nil {
+				t.Fatalf("GenerateRaw() error = %v", err)
+			}
+			if len(runner.args) != 1 {
+				t.Fatalf("claude called %d times, want 1", len(runner.args))
+			}
+			args := runner.args[0]
+
+			if got := hasFlag(args, "--no-session-persistence"); got != tt.wantIsolated {
+				t.Errorf("--no-session-persistence present = %v, want %v (args: %v)", got, tt.wantIsolated, args)
+			}
+			tools, ok := flagValue(args, "--tools")
+			if !ok {
+				t.Fatalf("--tools missing from args: %v", args)
+			}
+			if tt.wantIsolated && tools != "" {
+				t.Errorf("--tools = %q, want empty (no tools)", tools)
+			}
+			if !tt.wantIsolated && (tools == "" || strings.Contains(tools, "Edit") || strings.Contains(tools, "Bash")) {
+				t.Errorf("--tools = %q, want read-only tools", tools)
+			}
+		})
+	}
+}
+
+func TestMultiStageCallClaudeUsesCLIContext(t *testing.T) {
+	runner := &captureRunner{output: "{}"}
+	gen := NewMultiStageGenerator(Config{UseCLIContext: true})
+	gen.run = runner.run
+
+	if _, _, err := gen.callClaude(context.Background(), "model", "system", "user"); err != nil {
+		t.Fatalf("callClaude() error = %v", err)
+	}
+	if hasFlag(runner.args[0], "--no-session-persistence") {
+		t.Errorf("--no-session-persistence should be omitted with UseCLIContext (args: %v)", runner.args[0])
+	}
+}
+
+func TestProgressModes(t *testing.T) {
+	tests := []struct {
+		mode string
+		want string // substring expected in output; "" means no output at all
+	}{
+		{progressTicker, "Still generating..."},
+		{ProgressPlain, "Generated in"},
+		{ProgressNone, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			var out bytes.Buffer
+			gen := NewMultiStageGenerator(Config{Progress: tt.mode})
+			gen.progress.out = &out
+			gen.progress.interval = time.Millisecond
+			gen.run = func(ctx context.Context, args []string, stdin string) ([]byte, error) {
+				time.Sleep(20 * time.Millisecond) // long enough for several ticks
+				return []byte("{}"), nil
+			}
+
+			if _, _, err := gen.callClaude(context.Background(), "model", "system", "user"); err != nil {
+				t.Fatalf("callClaude() error = %v", err)
+			}
+
+			got := out.String()
+			if tt.want == "" {
+				if got != "" {
+					t.Errorf("progress %q wrote %q, want no output", tt.mode, got)
+				}
+				return
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("progress %q wrote %q, want it to contain %q", tt.mode, got, tt.want)
+			}
+			if tt.mode == ProgressPlain && strings.Count(got, "\n") != 1 {
+				t.Errorf("plain progress should print exactly one line, got %q", got)
+			}
+		})
+	}
+}
+
+func TestCLIErrorWrapperIsClassified(t *testing.T) {
+	tests := []struct {
+		name          string
+		result        string
+		wantKind      ErrorKind
+		wantRetryable bool
+	}{
+		{"rate limit", "API Error: 429 rate_limit_error: Number of requests has exceeded your rate limit", ErrorRateLimit, true},
+		{"overloaded", "API Error: 529 Overloaded", ErrorOverloaded, true},
+		{"auth", "Invalid API key · Please run /login", ErrorAuth, false},
+		{"other", "Prompt is malformed", ErrorOther, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapper := `{"type":"result","subtype":"success","is_error":true,"result":"` + tt.result + `"}`
+
+			_, err := ExtractJSON(wrapper)
+			var llmErr *Error
+			if !errors.As(err, &llmErr) {
+				t.Fatalf("ExtractJSON() error = %v, want *llm.Error", err)
+			}
+			if llmErr.Kind != tt.wantKind || llmErr.Message != tt.result {
+				t.Errorf("error = %+v, want kind %s with the wrapper's result text", llmErr, tt.wantKind)
+			}
+			if got := IsRetryable(fmt.Errorf("Stage 1: %w", err)); got != tt.wantRetryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.wantRetryable)
+			}
+		})
+	}
+
+	got, err := ExtractJSON(`{"type":"result","is_error":false,"result":"` + "```json\\n{\\\"epics\\\": []}\\n```" + `"}`)
+	if err != nil || got != `{"epics": []}` {
+		t.Errorf("ExtractJSON() = %q, %v; want the fenced JSON from the result", got, err)
+	}
+}
+
+func TestGenerateStopsOnNonRetryableCLIError(t *testing.T) {
+	runner := &captureRunner{output: `{"type":"result","is_error":true,"result":"Invalid API key · Please run /login"}`}
+	adapter := NewClaudeCLIAdapter(Config{Progress: ProgressNone})
+	adapter.run = runner.run
+
+	_, err := adapter.Generate(context.Background(), "system", "user")
+	var llmErr *Error
+	if !errors.As(err, &llmErr) || llmErr.Kind != ErrorAuth {
+		t.Fatalf("Generate() error = %v, want an auth *llm.Error", err)
+	}
+	if len(runner.args) != 1 {
+		t.Errorf("claude called %d times, want 1 (auth errors aren't retried)", len(runner.args))
+	}
+}
+
+func TestGenerateAcceptsIncompletePlan(t *testing.T) {
+	// A task without subtasks is reported by parse as incomplete_plan, not retried here
+	plan := `{"project": {"product_name": "App"}, "epics": [{"temp_id": "1", "title": "Auth", "tasks": [{"temp_id": "1.1", "title": "Login", "subtasks": []}]}]}`
+	result, _ := json.Marshal(plan)
+	runner := &captureRunner{output: `{"type":"result","result":` + string(result) + `}`}
+	adapter := NewClaudeCLIAdapter(Config{Progress: ProgressNone})
+	adapter.run = runner.run
+
+	response, err := adapter.Generate(context.Background(), "system", "user")
+	if err != nil {
+		t.Fatalf("Generate() error = %v, want the incomplete plan", err)
+	}
+	if len(runner.args) != 1 || len(response.Epics) != 1 {
+		t.Errorf("claude called %d times, epics = %d; want one call returning the plan", len(runner.args), len(response.Epics))
+	}
+}
+
+func TestPromptFilesCreatedInTempDir(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	run := func(ctx context.Context, args []string, stdin string) ([]byte, error) {
+		file, _ := flagValue(args, "--system-prompt-file")
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("system prompt file missing during the call: %v", err)
+		}
+		files = append(files, file)
+		return []byte("{}"), nil
+	}
+
+	adapter := NewClaudeCLIAdapter(Config{TempDir: dir})
+	adapter.run = run
+	if _, err := adapter.GenerateRaw(context.Background(), "system", "user"); err != nil {
+		t.Fatalf("GenerateRaw() error = %v", err)
+	}
+	gen := NewMultiStageGenerator(Config{TempDir: dir})
+	gen.run = run
+	if _, _, err := gen.callClaude(context.Background(), "model", "system", "user"); err != nil {
+		t.Fatalf("callClaude() error = %v", err)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("claude called %d times, want 2", len(files))
+	}
+	for _, file := range files {
+		if filepath.Dir(file) != dir {
+			t.Errorf("system prompt file %s not created in %s", file, dir)
+		}
+	}
+	if left, _ := os.ReadDir(dir); len(left) != 0 {
+		t.Errorf("temp files left behind: %v", left)
+	}
+}
+
+func TestExtractProjectReturnsOnlyProject(t *testing.T) {
+	runner := &captureRunner{output: `{"type":"result","result":"{\"project\":{\"product_name\":\"Shop\",\"tech_stack\":[\"Go\"]}}"}`}
+	gen := NewMultiStageGenerator(Config{})
+	gen.run = runner.run
+
+	project, err := gen.ExtractProject(context.Background(), core.BuildProjectContextPrompt("# PRD"), core.DefaultParseConfig())
+	if err != nil {
+		t.Fatalf("ExtractProject() error = %v", err)
+	}
+	if project.ProductName != "Shop" || len(project.TechStack) != 1 {
+		t.Errorf("project = %+v, want product Shop with one tech", project)
+	}
+
+	runner.output = `{"type":"result","result":"{\"epics\":[]}"}`
+	if _, err := gen.ExtractProject(context.Background(), "# PRD", core.DefaultParseConfig()); err == nil {
+		t.Error("ExtractProject() should fail when the response has no project")
+	}
+}
+
+func TestStageCallsRecordReportedCost(t *testing.T) {
+	runner := &captureRunner{output: `{"type":"result","result":"{\"tasks\":[{\"temp_id\":\"2.1\",\"title\":\"Login\"}]}","total_cost_usd":0.125}`}
+	gen := NewMultiStageGenerator(Config{})
+	gen.run = runner.run
+
+	config := core.DefaultParseConfig()
+	config.Costs = core.NewCostRecorder()
+	if _, err := gen.GenerateTasks(context.Background(), core.Epic{TempID: "2", Title: "Auth"}, core.ProjectContext{}, config, ""); err != nil {
+		t.Fatalf("GenerateTasks() error = %v", err)
+	}
+
+	costs := config.Costs.Costs()
+	if len(costs) != 1 || costs[0] != (core.CallCost{Stage: core.LevelTask, ItemID: "2", USD: 0.125}) {
+		t.Errorf("costs = %+v, want one Stage 2 call for epic 2 at $0.125", costs)
+	}
+}
@@ -0,0 +1,162 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrorKind classifies an error reported by an LLM provider.
+type ErrorKind string
+
+const (
+	ErrorRateLimit  ErrorKind = "rate_limit" // Rate or usage limit hit; worth retrying after a longer wait
+	ErrorOverloaded ErrorKind = "overloaded" // Service temporarily overloaded; worth retrying
+	ErrorAuth       ErrorKind = "auth"       // Not logged in or bad credentials; retrying won't help
+	ErrorOther      ErrorKind = "other"      // Anything else the provider reported
+)
+
+// Error is a failure reported by the LLM provider itself, such as a claude CLI
+// result with is_error set, classified so retry loops can act on it.
+type Error struct {
+	Kind    ErrorKind
+	Message string // The provider's message, e.g. the CLI wrapper's result text
+}
+
+func (e *Error) Error() string {
+	if e.Kind == ErrorOther {
+		return fmt.Sprintf("CLI returned error: %s", e.Message)
+	}
+	return fmt.Sprintf("CLI returned error (%s): %s", e.Kind, e.Message)
+}
+
+// Retryable reports whether trying the call again may succeed.
+func (e *Error) Retryable() bool {
+	return e.Kind != ErrorAuth
+}
+
+// RateLimited reports whether the provider rate-limited the call. The
+// multi-stage fan-out lowers its concurrency when it sees one.
+func (e *Error) RateLimited() bool {
+	return e.Kind == ErrorRateLimit
+}
+
+// errorPatterns map phrases in provider messages to an error kind, checked in order.
+var errorPatterns = []struct {
+	kind    ErrorKind
+	phrases []string
+}{
+	{ErrorRateLimit, []string{"rate limit", "rate_limit", "too many requests", "429", "usage limit"}},
+	{ErrorOverloaded, []string{"overloaded", "529", "503", "temporarily unavailable"}},
+	{ErrorAuth, []string{"invalid api key", "authentication", "unauthorized", "401", "not logged in", "/login"}},
+}
+
+// classifyError builds an Error for a provider message.
+func classifyError(message string) *Error {
+	lower := strings.ToLower(message)
+	for _, p := range errorPatterns {
+		for _, phrase := range p.phrases {
+			if strings.Contains(lower, phrase) {
+				return &Error{Kind: p.kind, Message: message}
+			}
+		}
+	}
+	return &Error{Kind: ErrorOther, Message: message}
+}
+
+// IsRetryable reports whether a failed LLM call is worth retrying. Errors the
+// provider classified are retryable unless they can't succeed (e.g. auth);
+// everything else, such as unparseable output, is retryable.
+func IsRetryable(err error) bool {
+	var llmErr *Error
+	if errors.As(err, &llmErr) {
+		return llmErr.Retryable()
+	}
+	return err != nil
+}
+
+// retryDelay is the wait before attempt (2, 3, ...); rate limits wait longer.
+func retryDelay(err error, attempt int) time.Duration {
+	var llmErr *Error
+	if errors.As(err, &llmErr) && llmErr.Kind == ErrorRateLimit {
+		return time.Duration(attempt) * 15 * time.Second
+	}
+	return time.Duration(attempt) * 2 * time.Second
+}
+
+// cliJSONResponse is the wrapper structure from --output-format json
+type cliJSONResponse struct {
+	Type         string  `json:"type"`
+	Result       string  `json:"result"`
+	IsError      bool    `json:"is_error"`
+	TotalCostUSD float64 `json:"total_cost_usd"`
+}
+
+// unwrapCLIResult returns the result text from the claude CLI's JSON wrapper
+// (--output-format json), or output unchanged if it isn't wrapped. A wrapper
+// with is_error set is returned as a classified *Error.
+func unwrapCLIResult(output string) (string, error) {
+	trimmed := strings.TrimSpace(output)
+	if !strings.HasPrefix(trimmed, "{\"type\":") {
+		return output, nil
+	}
+	var wrapper cliJSONResponse
+	if err := json.Unmarshal([]byte(trimmed), &wrapper); err != nil {
+		return output, nil
+	}
+	if wrapper.IsError {
+		return "", classifyError(wrapper.Result)
+	}
+	return wrapper.Result, nil
+}
+
+// cliResultCost returns the cost the claude CLI reported for a call, or 0 if
+// output isn't a JSON wrapper with one.
+func cliResultCost(output string) float64 {
+	var wrapper cliJSONResponse
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &wrapper); err != nil {
+		return 0
+	}
+	return wrapper.TotalCostUSD
+}
+
+// ExtractJSON returns the JSON object in LLM output, unwrapping the claude CLI
+// result wrapper and markdown fences. An is_error wrapper is returned as a
+// classified *Error, and output with no JSON object as an error.
+func ExtractJSON(output string) (string, error) {
+	output, err := unwrapCLIResult(output)
+	if err != nil {
+		return "", err
+	}
+
+	// Remove markdown fences
+	output = strings.TrimSpace(output)
+	if strings.HasPrefix(output, "```json") {
+		output = strings.TrimPrefix(output, "```json")
+		if idx := strings.LastIndex(output, "```"); idx != -1 {
+			output = output[:idx]
+		}
+		output = strings.TrimSpace(output)
+	} else if strings.HasPrefix(output, "```") {
+		output = strings.TrimPrefix(output, "```")
+		if idx := strings.LastIndex(output, "```"); idx != -1 {
+			output = output[:idx]
+		}
+		output = strings.TrimSpace(output)
+	}
+
+	// Find JSON object
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start == -1 || end == -1 || end < start {
+		// Show first 200 chars to help debug
+		preview := output
+		if len(preview) > 200 {
+			preview = preview[:200] + "..."
+		}
+		return "", fmt.Errorf("no valid JSON found in response (starts with: %q)", preview)
+	}
+	return output[start : end+1], nil
+}
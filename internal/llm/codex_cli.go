@@ -12,7 +12,8 @@ import (
 
 // CodexCLIAdapter uses the Codex CLI for generation.
 type CodexCLIAdapter struct {
-	model string
+	model   string
+	tempDir string // Directory for the prompt file ("" is the system default)
 }
 
 // NewCodexCLIAdapter creates a Codex CLI adapter.
@@ -21,7 +22,7 @@ func NewCodexCLIAdapter(config Config) *CodexCLIAdapter {
 	if model == "" {
 		model = "o3" // Default to o3 for best reasoning (latest available)
 	}
-	return &CodexCLIAdapter{model: model}
+	return &CodexCLIAdapter{model: model, tempDir: config.TempDir}
 }
 
 func (a *CodexCLIAdapter) Name() string {
@@ -40,7 +41,7 @@ func (a *CodexCLIAdapter) Generate(ctx context.Context, systemPrompt, userPrompt
 	combinedPrompt := fmt.Sprintf("SYSTEM INSTRUCTIONS:\n%s\n\nUSER REQUEST:\n%s", systemPrompt, userPrompt)
 
 	// Write to temp file
-	promptFile, err := os.CreateTemp("", "prd-prompt-*.txt")
+	promptFile, err := os.CreateTemp(a.tempDir, "prd-prompt-*.txt")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create prompt file: %w", err)
 	}
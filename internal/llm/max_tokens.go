@@ -0,0 +1,51 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxTokens is the response limit for models not in ModelMaxTokens.
+const DefaultMaxTokens = 16384
+
+// ModelMaxTokens maps model name prefixes to their maximum output tokens,
+// used as the default response limit for the API. The longest matching
+// prefix wins.
+var ModelMaxTokens = map[string]int{
+	"claude-opus-4-5":   64000,
+	"claude-opus-4":     32000,
+	"claude-sonnet-4":   64000,
+	"claude-haiku-4-5":  64000,
+	"claude-3-7-sonnet": 64000,
+	"claude-3-5-sonnet": 8192,
+	"claude-3-5-haiku":  8192,
+	"claude-3-haiku":    4096,
+}
+
+// DefaultMaxTokensFor returns the default response limit for model.
+func DefaultMaxTokensFor(model string) int {
+	best, limit := 0, DefaultMaxTokens
+	for prefix, n := range ModelMaxTokens {
+		if strings.HasPrefix(model, prefix) && len(prefix) > best {
+			best, limit = len(prefix), n
+		}
+	}
+	return limit
+}
+
+// maxTokensEnv passes the response limit to the claude CLI, which has no flag for it.
+const maxTokensEnv = "CLAUDE_CODE_MAX_OUTPUT_TOKENS"
+
+// claudeRunnerFor returns the claudeRunner for config: execClaude, with
+// config.MaxTokens passed through maxTokensEnv when set. Unset, the CLI
+// picks its own limit for the model.
+func claudeRunnerFor(config Config) claudeRunner {
+	if config.MaxTokens <= 0 {
+		return execClaude
+	}
+	env := []string{fmt.Sprintf("%s=%d", maxTokensEnv, config.MaxTokens)}
+	return func(ctx context.Context, args []string, stdin string) ([]byte, error) {
+		return runClaude(ctx, args, stdin, env)
+	}
+}
@@ -5,17 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"strings"
-	"time"
 
 	"github.com/dhabedank/prd-parser/internal/core"
 )
 
 // MultiStageGenerator implements core.Generator for multi-stage parsing.
 type MultiStageGenerator struct {
-	config Config
+	config   Config
+	progress progressIndicator
+	run      claudeRunner
 }
 
 // NewMultiStageGenerator creates a generator for multi-stage parsing.
@@ -26,7 +24,9 @@ func NewMultiStageGenerator(config Config) *MultiStageGenerator {
 	}
 
 	return &MultiStageGenerator{
-		config: config,
+		config:   config,
+		progress: newProgressIndicator(config.Progress, "    "),
+		run:      claudeRunnerFor(config),
 	}
 }
 
@@ -39,18 +39,24 @@ func (g *MultiStageGenerator) modelForStage(stage string) string {
 	return model
 }
 
+// systemPrompt returns a stage's generation system prompt for config, tuned
+// for the model that stage runs on if config's prompt version tunes prompts.
+func (g *MultiStageGenerator) systemPrompt(stage, prompt string, config core.ParseConfig) string {
+	return tunePrompt(config.PromptVersion, g.modelForStage(stage), core.SystemPromptFor(prompt, config))
+}
+
 // GenerateEpics implements Stage 1: PRD → Epics.
 func (g *MultiStageGenerator) GenerateEpics(ctx context.Context, prdContent string, config core.ParseConfig) (*core.EpicsResponse, error) {
 	userPrompt := core.BuildStage1Prompt(prdContent, config)
 
-	output, err := g.callClaude(ctx, g.modelForStage("epic"), core.Stage1SystemPrompt, userPrompt)
+	output, err := g.callStage(ctx, config, core.LevelEpic, "", g.systemPrompt("epic", core.Stage1SystemPrompt, config), userPrompt)
 	if err != nil {
 		return nil, err
 	}
 
-	jsonStr := extractJSON(output)
-	if jsonStr == "" {
-		return nil, fmt.Errorf("no valid JSON in Stage 1 response")
+	jsonStr, err := ExtractJSON(output)
+	if err != nil {
+		return nil, fmt.Errorf("Stage 1: %w", err)
 	}
 
 	var response core.EpicsResponse
@@ -65,6 +71,42 @@ func (g *MultiStageGenerator) GenerateEpics(ctx context.Context, prdContent stri
 	return &response, nil
 }
 
+// Summarize implements core.Summarizer for the --summarize pre-pass. It uses
+// the Stage 1 model, since the summary only feeds epic extraction.
+func (g *MultiStageGenerator) Summarize(ctx context.Context, userPrompt string, config core.ParseConfig) (string, error) {
+	output, err := g.callStage(ctx, config, core.LevelEpic, "", core.SummarizePRDSystemPrompt, userPrompt)
+	if err != nil {
+		return "", err
+	}
+	return output, nil
+}
+
+// ExtractProject implements core.ProjectExtractor for --context-only, with the
+// Stage 1 model and no epics.
+func (g *MultiStageGenerator) ExtractProject(ctx context.Context, userPrompt string, config core.ParseConfig) (*core.ProjectContext, error) {
+	output, err := g.callStage(ctx, config, core.LevelEpic, "", g.systemPrompt("epic", core.ProjectContextSystemPrompt, config), userPrompt)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonStr, err := ExtractJSON(output)
+	if err != nil {
+		return nil, fmt.Errorf("project context: %w", err)
+	}
+
+	var response struct {
+		Project *core.ProjectContext `json:"project"`
+	}
+	if err := json.Unmarshal([]byte(jsonStr), &response); err != nil {
+		return nil, fmt.Errorf("project context JSON parse error: %w", err)
+	}
+	if response.Project == nil {
+		return nil, fmt.Errorf("response has no \"project\" object")
+	}
+
+	return response.Project, nil
+}
+
 // GenerateTasks implements Stage 2: Epic → Tasks.
 func (g *MultiStageGenerator) GenerateTasks(ctx context.Context, epic core.Epic, project core.ProjectContext, config core.ParseConfig, prdContent string) ([]core.Task, error) {
 	var userPrompt string
@@ -74,14 +116,14 @@ func (g *MultiStageGenerator) GenerateTasks(ctx context.Context, epic core.Epic,
 		userPrompt = core.BuildStage2Prompt(epic, project, config)
 	}
 
-	output, err := g.callClaude(ctx, g.modelForStage("task"), core.Stage2SystemPrompt, userPrompt)
+	output, err := g.callStage(ctx, config, core.LevelTask, epic.TempID, g.systemPrompt("task", core.Stage2SystemPrompt, config), userPrompt)
 	if err != nil {
 		return nil, err
 	}
 
-	jsonStr := extractJSON(output)
-	if jsonStr == "" {
-		return nil, fmt.Errorf("no valid JSON in Stage 2 response for epic %s", epic.TempID)
+	jsonStr, err := ExtractJSON(output)
+	if err != nil {
+		return nil, fmt.Errorf("Stage 2 for epic %s: %w", epic.TempID, err)
 	}
 
 	var response struct {
@@ -107,18 +149,29 @@ func (g *MultiStageGenerator) GenerateSubtasks(ctx context.Context, task core.Ta
 		userPrompt = core.BuildStage3Prompt(task, epicContext, project, config)
 	}
 
+	// Once the run's retry budget is gone, stop instead of failing call by call
+	if config.RetryBudget.Exhausted() {
+		return nil, core.ErrRetryBudgetExhausted
+	}
+
 	// Retry up to 2 times for transient LLM output issues
 	var lastErr error
 	for attempt := 0; attempt < 2; attempt++ {
-		output, err := g.callClaude(ctx, g.modelForStage("subtask"), core.Stage3SystemPrompt, userPrompt)
+		if attempt > 0 && !config.RetryBudget.Take() {
+			return nil, fmt.Errorf("%w (task %s: %v)", core.ErrRetryBudgetExhausted, task.TempID, lastErr)
+		}
+		output, err := g.callStage(ctx, config, core.LevelSubtask, task.TempID, g.systemPrompt("subtask", core.Stage3SystemPrompt, config), userPrompt)
 		if err != nil {
+			if !IsRetryable(err) {
+				return nil, err
+			}
 			lastErr = err
 			continue
 		}
 
-		jsonStr := extractJSON(output)
-		if jsonStr == "" {
-			lastErr = fmt.Errorf("no valid JSON in Stage 3 response for task %s", task.TempID)
+		jsonStr, err := ExtractJSON(output)
+		if err != nil {
+			lastErr = fmt.Errorf("Stage 3 for task %s: %w", task.TempID, err)
 			continue
 		}
 
@@ -127,7 +180,7 @@ func (g *MultiStageGenerator) GenerateSubtasks(ctx context.Context, task core.Ta
 		}
 		if err := json.Unmarshal([]byte(jsonStr), &response); err != nil {
 			// Save debug info on parse error
-			debugFile := filepath.Join(os.TempDir(), fmt.Sprintf("prd-parser-stage3-%s.json", task.TempID))
+			debugFile := tempPath(g.config.TempDir, fmt.Sprintf("prd-parser-stage3-%s.json", task.TempID))
 			_ = os.WriteFile(debugFile, []byte(jsonStr), 0644)
 			lastErr = fmt.Errorf("Stage 3 JSON parse error for task %s: %w", task.TempID, err)
 			continue
@@ -144,103 +197,39 @@ func (g *MultiStageGenerator) GenerateSubtasks(ctx context.Context, task core.Ta
 	return nil, lastErr
 }
 
-// callClaude invokes the Claude CLI with the given prompts.
-func (g *MultiStageGenerator) callClaude(ctx context.Context, model, systemPrompt, userPrompt string) (string, error) {
+// callStage runs a stage's call on that stage's model and records the cost the
+// CLI reports in config.Costs, labeled with stage and itemID.
+func (g *MultiStageGenerator) callStage(ctx context.Context, config core.ParseConfig, stage, itemID, systemPrompt, userPrompt string) (string, error) {
+	output, cost, err := g.callClaude(ctx, g.modelForStage(stage), systemPrompt, userPrompt)
+	config.Costs.Record(stage, itemID, cost)
+	return output, err
+}
+
+// callClaude invokes the Claude CLI with the given prompts, returning the result
+// text and the cost the CLI reported.
+func (g *MultiStageGenerator) callClaude(ctx context.Context, model, systemPrompt, userPrompt string) (string, float64, error) {
 	// Write prompts to temp files
-	systemFile, err := os.CreateTemp("", "stage-system-*.txt")
+	systemFile, err := os.CreateTemp(g.config.TempDir, "stage-system-*.txt")
 	if err != nil {
-		return "", fmt.Errorf("failed to create system prompt file: %w", err)
+		return "", 0, fmt.Errorf("failed to create system prompt file: %w", err)
 	}
 	defer os.Remove(systemFile.Name())
 
 	if _, err := systemFile.WriteString(systemPrompt); err != nil {
-		return "", fmt.Errorf("failed to write system prompt: %w", err)
+		return "", 0, fmt.Errorf("failed to write system prompt: %w", err)
 	}
 	systemFile.Close()
 
 	// Progress indicator for longer stages
-	done := make(chan bool)
-	go func() {
-		startTime := time.Now()
-		ticker := time.NewTicker(10 * time.Second)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-done:
-				return
-			case <-ticker.C:
-				elapsed := time.Since(startTime).Truncate(time.Second)
-				fmt.Printf("    Still generating... (%s elapsed)\n", elapsed)
-			}
-		}
-	}()
+	stopProgress := g.progress.start()
 
-	cmd := exec.CommandContext(ctx, "claude",
-		"--model", model,
-		"--system-prompt-file", systemFile.Name(),
-		"--print",
-		"--output-format", "json",
-		"--tools", "",
-		"--no-session-persistence",
-	)
-
-	cmd.Stdin = strings.NewReader(userPrompt)
-
-	output, err := cmd.Output()
-	close(done)
+	output, err := g.run(ctx, claudeArgs(model, systemFile.Name(), g.config.UseCLIContext), userPrompt)
+	stopProgress()
 
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return "", fmt.Errorf("claude CLI failed: %s", string(exitErr.Stderr))
-		}
-		return "", fmt.Errorf("claude CLI failed: %w", err)
-	}
-
-	return string(output), nil
-}
-
-// extractJSON extracts JSON from CLI output, handling wrappers and markdown.
-func extractJSON(output string) string {
-	output = strings.TrimSpace(output)
-
-	// Handle CLI JSON wrapper
-	if strings.HasPrefix(output, "{\"type\":") {
-		var wrapper struct {
-			Type    string `json:"type"`
-			Result  string `json:"result"`
-			IsError bool   `json:"is_error"`
-		}
-		if err := json.Unmarshal([]byte(output), &wrapper); err == nil {
-			if wrapper.IsError {
-				return ""
-			}
-			output = wrapper.Result
-		}
-	}
-
-	output = strings.TrimSpace(output)
-
-	// Remove markdown fences
-	if strings.HasPrefix(output, "```json") {
-		output = strings.TrimPrefix(output, "```json")
-		if idx := strings.LastIndex(output, "```"); idx != -1 {
-			output = output[:idx]
-		}
-		output = strings.TrimSpace(output)
-	} else if strings.HasPrefix(output, "```") {
-		output = strings.TrimPrefix(output, "```")
-		if idx := strings.LastIndex(output, "```"); idx != -1 {
-			output = output[:idx]
-		}
-		output = strings.TrimSpace(output)
-	}
-
-	// Find JSON object
-	start := strings.Index(output, "{")
-	end := strings.LastIndex(output, "}")
-	if start == -1 || end == -1 || end < start {
-		return ""
+		return "", 0, err
 	}
 
-	return output[start : end+1]
+	result, err := unwrapCLIResult(string(output))
+	return result, cliResultCost(string(output)), err
 }
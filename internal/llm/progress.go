@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Progress modes for the indicator shown while a Claude CLI call is running.
+const (
+	ProgressAuto  = "auto"  // ticker on a TTY, plain otherwise
+	ProgressPlain = "plain" // one line when each call finishes
+	ProgressNone  = "none"  // no progress output
+)
+
+// progressTicker is the resolved auto mode on a TTY: periodic
+// "Still generating..." lines while the call runs.
+const progressTicker = "ticker"
+
+// progressInterval is how often the ticker mode reports elapsed time.
+const progressInterval = 10 * time.Second
+
+// ValidProgressMode reports whether mode is a known progress mode.
+func ValidProgressMode(mode string) bool {
+	switch mode {
+	case ProgressAuto, ProgressPlain, ProgressNone:
+		return true
+	}
+	return false
+}
+
+// progressIndicator reports on an in-flight LLM call.
+type progressIndicator struct {
+	mode     string // progressTicker, ProgressPlain, or ProgressNone
+	out      io.Writer
+	interval time.Duration
+	indent   string
+}
+
+// newProgressIndicator resolves mode (auto picks ticker on a TTY, plain otherwise)
+// and returns an indicator writing to stdout with the given line indent.
+func newProgressIndicator(mode, indent string) progressIndicator {
+	if mode == "" || mode == ProgressAuto {
+		mode = ProgressPlain
+		if isTerminal(os.Stdout) {
+			mode = progressTicker
+		}
+	}
+	return progressIndicator{mode: mode, out: os.Stdout, interval: progressInterval, indent: indent}
+}
+
+// start begins reporting and returns a function to call once the LLM call returns.
+func (p progressIndicator) start() (stop func()) {
+	startTime := time.Now()
+
+	switch p.mode {
+	case progressTicker:
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			ticker := time.NewTicker(p.interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					elapsed := time.Since(startTime).Truncate(time.Second)
+					fmt.Fprintf(p.out, "%sStill generating... (%s elapsed)\n", p.indent, elapsed)
+				}
+			}
+		}()
+		return func() {
+			close(done)
+			<-stopped
+		}
+	case ProgressPlain:
+		return func() {
+			elapsed := time.Since(startTime).Truncate(time.Second)
+			fmt.Fprintf(p.out, "%sGenerated in %s\n", p.indent, elapsed)
+		}
+	default:
+		return func() {}
+	}
+}
+
+// isTerminal reports whether f is an interactive terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
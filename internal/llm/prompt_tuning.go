@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"strings"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// Model families with their own prompt adjustments.
+const (
+	FamilyOpus   = "opus"
+	FamilySonnet = "sonnet"
+	FamilyHaiku  = "haiku"
+)
+
+// haikuReinforcement is appended to generation system prompts for Haiku, which
+// follows the output schema less reliably and tends to return empty arrays.
+const haikuReinforcement = `
+
+## REMINDER: NO EMPTY ARRAYS
+
+- Never return an empty array for a field this prompt asks you to fill (epics, tasks, subtasks, acceptance criteria, goals). Each one needs at least one item.
+- If you are unsure what to put in an array, give your best-effort items rather than leaving it empty.
+- Return one complete JSON object with every required field present, and nothing else.`
+
+// promptTuning maps a model family to the text appended to its generation
+// system prompts. Families without an entry use the prompts unchanged.
+var promptTuning = map[string]string{
+	FamilyHaiku: haikuReinforcement,
+}
+
+// ModelFamily returns the family of a resolved model ID or CLI alias
+// ("claude-3-5-haiku-20241022" and "haiku" are both FamilyHaiku), or "" if unknown.
+func ModelFamily(model string) string {
+	model = strings.ToLower(model)
+	for _, family := range []string{FamilyOpus, FamilySonnet, FamilyHaiku} {
+		if strings.Contains(model, family) {
+			return family
+		}
+	}
+	return ""
+}
+
+// TuneSystemPrompt adjusts a generation system prompt for the model it is sent to.
+func TuneSystemPrompt(model, prompt string) string {
+	return prompt + promptTuning[ModelFamily(model)]
+}
+
+// tunePrompt is TuneSystemPrompt under prompt version (--prompt-version).
+// Versions from before model tuning send prompt unchanged.
+func tunePrompt(version, model, prompt string) string {
+	if !core.PromptVersionTunesModels(version) {
+		return prompt
+	}
+	return TuneSystemPrompt(model, prompt)
+}
@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+func TestModelFamily(t *testing.T) {
+	tests := map[string]string{
+		"claude-3-5-haiku-20241022": FamilyHaiku,
+		"claude-haiku-4-5":          FamilyHaiku,
+		"haiku":                     FamilyHaiku,
+		"claude-opus-4-5-20251101":  FamilyOpus,
+		"claude-sonnet-4-20250514":  FamilySonnet,
+		"gpt-5-codex":               "",
+		"":                          "",
+	}
+	for model, want := range tests {
+		if got := ModelFamily(model); got != want {
+			t.Errorf("ModelFamily(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestStagePromptsTunedForModel(t *testing.T) {
+	// Stage 1 on Haiku, Stage 2 on Opus
+	var prompts []string
+	gen := NewMultiStageGenerator(Config{EpicModel: "claude-3-5-haiku-20241022", TaskModel: "claude-opus-4-5-20251101"})
+	gen.run = func(ctx context.Context, args []string, stdin string) ([]byte, error) {
+		file, _ := flagValue(args, "--system-prompt-file")
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("reading system prompt: %v", err)
+		}
+		prompts = append(prompts, string(data))
+		return []byte(`{"type":"result","result":"{}"}`), nil
+	}
+
+	config := core.DefaultParseConfig()
+	_, _ = gen.GenerateEpics(context.Background(), "# PRD", config)
+	_, _ = gen.GenerateTasks(context.Background(), core.Epic{TempID: "1", Title: "Auth"}, core.ProjectContext{}, config, "")
+	if len(prompts) != 2 {
+		t.Fatalf("claude called %d times, want 2", len(prompts))
+	}
+
+	haiku, opus := prompts[0], prompts[1]
+	if !strings.HasSuffix(haiku, haikuReinforcement) {
+		t.Errorf("Haiku Stage 1 prompt should end with the empty-array reinforcement")
+	}
+	if want := core.SystemPromptFor(core.Stage2SystemPrompt, config); opus != want {
+		t.Errorf("Opus Stage 2 prompt should be the standard prompt, without the Haiku reinforcement")
+	}
+}
+
+func TestPromptV1SkipsModelTuning(t *testing.T) {
+	var prompt string
+	gen := NewMultiStageGenerator(Config{Model: "claude-3-5-haiku-20241022"})
+	gen.run = func(ctx context.Context, args []string, stdin string) ([]byte, error) {
+		file, _ := flagValue(args, "--system-prompt-file")
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("reading system prompt: %v", err)
+		}
+		prompt = string(data)
+		return []byte(`{"type":"result","result":"{}"}`), nil
+	}
+
+	config := core.DefaultParseConfig()
+	config.PromptVersion = core.PromptV1
+	_, _ = gen.GenerateEpics(context.Background(), "# PRD", config)
+	if strings.Contains(prompt, haikuReinforcement) {
+		t.Error("v1 prompt should be sent without the Haiku reinforcement, which v1 predates")
+	}
+
+	if got := tunePrompt(core.PromptV1, "haiku", "prompt"); got != "prompt" {
+		t.Errorf("tunePrompt(v1) = %q, want the prompt unchanged", got)
+	}
+	if got := tunePrompt("", "haiku", "prompt"); got != "prompt"+haikuReinforcement {
+		t.Errorf("tunePrompt(current) = %q, want the reinforced prompt", got)
+	}
+}
@@ -1,6 +1,10 @@
 package output
 
 import (
+	"io"
+	"sort"
+	"time"
+
 	"github.com/dhabedank/prd-parser/internal/core"
 )
 
@@ -18,7 +22,8 @@ type CreatedItem struct {
 	TempID           string // Original temp_id for dependency mapping
 	Type             string // "epic", "task", or "subtask"
 	Title            string
-	ParentExternalID string // empty if no parent
+	ParentExternalID string        // empty if no parent
+	Duration         time.Duration // How long the create call took (zero for bulk imports)
 }
 
 // CreateResult is the result of creating all items.
@@ -27,6 +32,19 @@ type CreateResult struct {
 	Failed       []FailedItem
 	Dependencies []Dependency
 	Stats        Stats
+	Elapsed      time.Duration // Wall-clock time of CreateItems, if the adapter measures it
+}
+
+// Slowest returns the n created items that took longest to create, slowest
+// first. Items with equal durations keep their creation order.
+func (r *CreateResult) Slowest(n int) []CreatedItem {
+	items := make([]CreatedItem, len(r.Created))
+	copy(items, r.Created)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Duration > items[j].Duration })
+	if n < len(items) {
+		items = items[:n]
+	}
+	return items
 }
 
 // FailedItem represents an item that failed to create.
@@ -58,6 +76,9 @@ type Adapter interface {
 	// IsAvailable checks if the adapter can be used (e.g., CLI installed).
 	IsAvailable() (bool, error)
 
+	// Capabilities reports which plan features the target can represent.
+	Capabilities() Capabilities
+
 	// CreateItems creates hierarchical items in the target system.
 	CreateItems(response *core.ParseResponse, config Config) (*CreateResult, error)
 }
@@ -67,14 +88,165 @@ type Config struct {
 	// WorkingDir for CLI-based adapters.
 	WorkingDir string
 
+	// TempDir is where temp files such as the bd import file are written (default: os.TempDir()).
+	TempDir string
+
 	// DryRun previews without creating items.
 	DryRun bool
 
 	// IncludeContext adds context blocks to descriptions.
 	IncludeContext bool
 
+	// IncludeContextEpics, IncludeContextTasks, and IncludeContextSubtasks pick
+	// the levels that get context blocks when IncludeContext is set. Leaving all
+	// three unset includes context at every level.
+	IncludeContextEpics    bool
+	IncludeContextTasks    bool
+	IncludeContextSubtasks bool
+
 	// IncludeTesting adds testing requirements to descriptions.
 	IncludeTesting bool
+
+	// Warnings collects non-fatal issues during creation (nil prints them immediately).
+	Warnings *core.WarningCollector
+
+	// IDScheme controls the readable IDs assigned to created items (ets/dotted/auto).
+	IDScheme string
+
+	// BeadsBulk writes one import file and runs a single bd import instead of
+	// per-issue bd calls, falling back to per-issue creation if unavailable.
+	BeadsBulk bool
+
+	// EstimateFormat is how estimates are passed to bd create --estimate
+	// (EstimateFormat* values). Empty means minutes.
+	EstimateFormat string
+
+	// AcceptanceFormat is how an epic's acceptance criteria are joined for
+	// beads (AcceptanceFormat* values). Empty means bulleted.
+	AcceptanceFormat string
+
+	// CreateStrategy is the order per-issue beads creation follows (CreateStrategy*
+	// values). Empty means phases.
+	CreateStrategy string
+
+	// BeadsFields redirects plan fields without a bd flag in the target schema,
+	// e.g. {"acceptance": "description"} or {"design": "field:notes"}.
+	BeadsFields map[string]string
+
+	// LabelColors overrides label colors for adapters that create colored
+	// labels, keyed by category (layer/domain/skill/type) or label name.
+	// See NewLabelColors.
+	LabelColors map[string]string
+
+	// EpicPriority is the priority given to created epics. Empty keeps the
+	// default, high; tasks and subtasks are unaffected.
+	EpicPriority core.Priority
+
+	// Gzip compresses JSON adapter output (also enabled by a .gz output path).
+	Gzip bool
+
+	// Tee also echoes JSON adapter output to stdout when writing to a file.
+	Tee bool
+
+	// Stdout is where the JSON adapter streams gzip output when there is no
+	// output path (nil = os.Stdout), for callers that move their own output
+	// off stdout so the stream stays clean.
+	Stdout io.Writer
+
+	// JSONCase is the key style for JSON adapter output (snake or camel).
+	JSONCase string
+
+	// DepsFormat adds a top-level dependencies section to JSON adapter output
+	// in one of the DepsFormat shapes. Empty writes the plan as-is.
+	DepsFormat string
+
+	// GitHubRepo is the repository ("owner/name") the GitHub adapter creates
+	// issues in.
+	GitHubRepo string
+
+	// GitHubProject is the number of the Project (v2), owned by the
+	// repository's owner, that the GitHub adapter adds issues to. Zero adds
+	// them to no project.
+	GitHubProject int
+
+	// Requirements are the requirement IDs the PRD defines; the traceability
+	// adapter flags the ones no item covers.
+	Requirements []string
+}
+
+// AdapterNames lists the --output adapters, in the order help and completion
+// show them.
+var AdapterNames = []string{"beads", "json", "traceability", "markdown", "csv", "github"}
+
+// ID schemes for readable item IDs, shown for temp_id "2.3.1".
+const (
+	IDSchemeETS    = "ets"    // prefix-e2t3s1
+	IDSchemeDotted = "dotted" // prefix-2-3-1
+	IDSchemeAuto   = "auto"   // target system assigns the ID
+)
+
+// ValidIDScheme reports whether scheme is a known ID scheme.
+func ValidIDScheme(scheme string) bool {
+	switch scheme {
+	case IDSchemeETS, IDSchemeDotted, IDSchemeAuto:
+		return true
+	}
+	return false
+}
+
+// Beads creation strategies.
+const (
+	CreateStrategyPhases = "phases" // epics, then tasks, then subtasks, then dependencies
+	CreateStrategyWaves  = "waves"  // dependency waves, each created concurrently
+)
+
+// ValidCreateStrategy reports whether strategy is a known creation strategy.
+func ValidCreateStrategy(strategy string) bool {
+	switch strategy {
+	case CreateStrategyPhases, CreateStrategyWaves:
+		return true
+	}
+	return false
+}
+
+// Estimate formats for bd create --estimate, shown for 150 minutes.
+const (
+	EstimateFormatMinutes = "minutes" // 150
+	EstimateFormatHM      = "hm"      // 2h30m
+)
+
+// ValidEstimateFormat reports whether format is a known estimate format.
+func ValidEstimateFormat(format string) bool {
+	switch format {
+	case EstimateFormatMinutes, EstimateFormatHM:
+		return true
+	}
+	return false
+}
+
+// Acceptance criteria formats for beads, shown for criteria "A" and "B".
+const (
+	AcceptanceFormatBulleted = "bulleted" // "- A\n- B"
+	AcceptanceFormatNumbered = "numbered" // "1. A\n2. B"
+	AcceptanceFormatLines    = "lines"    // "A\nB"
+)
+
+// ValidAcceptanceFormat reports whether format is a known acceptance criteria format.
+func ValidAcceptanceFormat(format string) bool {
+	switch format {
+	case AcceptanceFormatBulleted, AcceptanceFormatNumbered, AcceptanceFormatLines:
+		return true
+	}
+	return false
+}
+
+// ValidPriority reports whether p is a known plan priority.
+func ValidPriority(p core.Priority) bool {
+	switch p {
+	case core.PriorityCritical, core.PriorityHigh, core.PriorityMedium, core.PriorityLow, core.PriorityVeryLow:
+		return true
+	}
+	return false
 }
 
 // DefaultConfig returns sensible defaults.
@@ -84,5 +256,6 @@ func DefaultConfig() Config {
 		DryRun:         false,
 		IncludeContext: true,
 		IncludeTesting: true,
+		IDScheme:       IDSchemeETS,
 	}
 }
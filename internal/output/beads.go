@@ -1,11 +1,14 @@
 package output
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"regexp"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/dhabedank/prd-parser/internal/core"
 	"github.com/dhabedank/prd-parser/internal/tui"
@@ -14,22 +17,123 @@ import (
 // BeadsAdapter creates issues in beads using the bd CLI.
 type BeadsAdapter struct {
 	workingDir     string
+	tempDir        string // Directory for the bulk import file ("" is the system default)
 	dryRun         bool
-	includeContext bool
+	includeContext map[string]bool // Levels (core.Level*) whose descriptions get context
 	includeTesting bool
-	prefix         string // Beads issue prefix (e.g., "my-project")
+	prefix         string            // Beads issue prefix (e.g., "my-project")
+	idScheme       string            // Readable ID scheme (ets/dotted/auto)
+	bulk           bool              // Create everything with one bd import when available
+	waves          bool              // Create in concurrent dependency waves (CreateStrategyWaves)
+	fieldMap       map[string]string // Where acceptance/design go (FieldTarget* values)
+	epicPriority   core.Priority     // Priority for epics (empty = high)
+	estimateFormat string            // How --estimate is written (EstimateFormat* values)
+	acceptanceFmt  string            // How acceptance criteria are joined (AcceptanceFormat* values)
+	existing       map[string]string // temp_id -> ID of items already in beads (MarkExisting)
+	run            bdRunner          // Runs bd sub-commands (dep add, update)
+	create         bdRunner          // Runs bd create, returning stdout only
+	retryBackoff   time.Duration     // Initial delay between bd retries, doubled each attempt
+	warnings       *core.WarningCollector
+	noFieldFlag    atomic.Bool // bd rejected --field as an unknown flag; skip it for the rest of the run
 }
 
+// bdRunner runs a bd command in dir and returns its combined output.
+type bdRunner func(dir string, args ...string) ([]byte, error)
+
+// bdMaxAttempts bounds retries of bd sub-commands that wire up the plan.
+const bdMaxAttempts = 3
+
 // NewBeadsAdapter creates a Beads adapter.
 func NewBeadsAdapter(config Config) *BeadsAdapter {
 	return &BeadsAdapter{
 		workingDir:     config.WorkingDir,
+		tempDir:        config.TempDir,
 		dryRun:         config.DryRun,
-		includeContext: config.IncludeContext,
+		includeContext: contextLevels(config),
 		includeTesting: config.IncludeTesting,
+		idScheme:       config.IDScheme,
+		bulk:           config.BeadsBulk,
+		waves:          config.CreateStrategy == CreateStrategyWaves,
+		fieldMap:       config.BeadsFields,
+		epicPriority:   config.EpicPriority,
+		estimateFormat: config.EstimateFormat,
+		acceptanceFmt:  config.AcceptanceFormat,
+		run:            execBd,
+		create:         execBdCreate,
+		retryBackoff:   500 * time.Millisecond,
+		warnings:       config.Warnings,
 	}
 }
 
+// contextLevels returns the levels that get context blocks under config.
+func contextLevels(config Config) map[string]bool {
+	levels := make(map[string]bool)
+	if !config.IncludeContext {
+		return levels
+	}
+	all := !config.IncludeContextEpics && !config.IncludeContextTasks && !config.IncludeContextSubtasks
+	levels[core.LevelEpic] = all || config.IncludeContextEpics
+	levels[core.LevelTask] = all || config.IncludeContextTasks
+	levels[core.LevelSubtask] = all || config.IncludeContextSubtasks
+	return levels
+}
+
+func execBd(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("bd", args...)
+	cmd.Dir = dir
+	return cmd.CombinedOutput()
+}
+
+// execBdCreate runs bd create and returns its stdout; stderr is kept in the
+// *exec.ExitError on failure.
+func execBdCreate(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("bd", args...)
+	cmd.Dir = dir
+	return cmd.Output()
+}
+
+// runBdWithRetry runs a bd sub-command, retrying with exponential backoff.
+// bd occasionally fails transiently (e.g., database locked by a concurrent write),
+// so commands get a few attempts before the failure is reported. Other
+// failures, such as an unknown flag, won't change on retry and are returned at once.
+func (a *BeadsAdapter) runBdWithRetry(args ...string) ([]byte, error) {
+	run := a.run
+	if run == nil {
+		run = execBd
+	}
+
+	delay := a.retryBackoff
+	var output []byte
+	var err error
+	for attempt := 1; attempt <= bdMaxAttempts; attempt++ {
+		output, err = run(a.workingDir, args...)
+		if err == nil {
+			return output, nil
+		}
+		if !transientBdError(output) {
+			break
+		}
+		if attempt < bdMaxAttempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return output, err
+}
+
+// transientBdError reports whether bd's output describes a failure worth
+// retrying: the SQLite database being locked or busy with another write.
+func transientBdError(output []byte) bool {
+	msg := strings.ToLower(string(output))
+	return strings.Contains(msg, "locked") || strings.Contains(msg, "busy")
+}
+
+// unknownFlagError reports whether bd's output says flag isn't one it knows.
+func unknownFlagError(output []byte, flag string) bool {
+	msg := strings.ToLower(string(output))
+	return strings.Contains(msg, "unknown flag") && strings.Contains(msg, flag)
+}
+
 // getPrefix retrieves the beads prefix from the database.
 func (a *BeadsAdapter) getPrefix() string {
 	if a.prefix != "" {
@@ -83,6 +187,10 @@ func (a *BeadsAdapter) IsAvailable() (bool, error) {
 	return true, nil
 }
 
+func (a *BeadsAdapter) Capabilities() Capabilities {
+	return Capabilities{Hierarchy: true, Dependencies: true, Labels: true, Estimates: true}
+}
+
 // BeadsStatus holds detailed status information about beads.
 type BeadsStatus struct {
 	CLIInstalled  bool
@@ -176,7 +284,39 @@ func PrintBeadsStatus(status *BeadsStatus) {
 	}
 }
 
+// CreateItems creates the plan in beads, timing each bd create and the whole run.
 func (a *BeadsAdapter) CreateItems(response *core.ParseResponse, config Config) (*CreateResult, error) {
+	start := time.Now()
+	result, err := a.createItems(response)
+	if result != nil {
+		result.Elapsed = time.Since(start)
+	}
+	return result, err
+}
+
+func (a *BeadsAdapter) createItems(response *core.ParseResponse) (*CreateResult, error) {
+	if a.bulk {
+		switch {
+		case len(a.existing) > 0:
+			fmt.Println("Bulk import can't skip existing issues; creating the missing ones one at a time")
+		case !a.bulkImportAvailable():
+			fmt.Println("Bulk import unavailable (bd import missing or --id-scheme=auto); creating issues one at a time")
+		default:
+			result, err := a.createItemsBulk(response)
+			if !errors.Is(err, errBdImport) {
+				return result, err
+			}
+			// The import failed before creating anything, so create issue by issue
+			a.warnings.Add(core.WarnAdapterCapability, "", "%v; created issues one at a time instead", err)
+		}
+	}
+	if a.waves {
+		if waves, acyclic := core.CreationWaves(response); acyclic {
+			return a.createItemsWaves(waves), nil
+		}
+		fmt.Println("Dependencies form a cycle; creating issues in phases instead of waves")
+	}
+
 	result := &CreateResult{
 		Created:      []CreatedItem{},
 		Failed:       []FailedItem{},
@@ -184,10 +324,23 @@ func (a *BeadsAdapter) CreateItems(response *core.ParseResponse, config Config)
 		Stats:        Stats{},
 	}
 	tempToExternal := make(map[string]string)
+	for tempID, id := range a.existing {
+		tempToExternal[tempID] = id
+	}
+
+	// Within each level, blockers are created before the items that depend on
+	// them, and each dependency is added as soon as both of its ends exist
+	deps := indexDependencies(response)
 
 	// Phase 1: Create all epics
-	for _, epic := range response.Epics {
-		id, err := a.createEpic(&epic)
+	for _, tempID := range core.TopoSortTempIDs(deps.levels[core.LevelEpic], deps.dependsOn) {
+		epic := deps.refs[tempID].Epic
+		if a.existing[epic.TempID] != "" {
+			continue
+		}
+		opts := a.epicOptions(epic)
+		start := time.Now()
+		id, err := a.runBdCreate(opts)
 		if err != nil {
 			result.Failed = append(result.Failed, FailedItem{
 				Item:  WorkItem{Type: "epic", TempID: epic.TempID, Title: epic.Title, ParentTempID: ""},
@@ -201,141 +354,199 @@ func (a *BeadsAdapter) CreateItems(response *core.ParseResponse, config Config)
 			Type:             "epic",
 			Title:            epic.Title,
 			ParentExternalID: "",
+			Duration:         time.Since(start),
 		})
 		tempToExternal[epic.TempID] = id
 		result.Stats.Epics++
+		a.writeCustomFields(result, WorkItem{Type: "epic", TempID: epic.TempID, Title: epic.Title}, id, opts)
+		a.linkCreated(result, tempToExternal, deps, epic.TempID)
 	}
 
 	// Phase 2: Create all tasks (as children of epics)
-	for _, epic := range response.Epics {
+	for _, tempID := range core.TopoSortTempIDs(deps.levels[core.LevelTask], deps.dependsOn) {
+		epic, task := deps.refs[tempID].Epic, deps.refs[tempID].Task
 		epicID, ok := tempToExternal[epic.TempID]
-		if !ok {
+		if !ok || a.existing[task.TempID] != "" {
 			continue
 		}
 
-		for _, task := range epic.Tasks {
-			id, err := a.createTask(&task, epicID)
-			if err != nil {
-				result.Failed = append(result.Failed, FailedItem{
-					Item:  WorkItem{Type: "task", TempID: task.TempID, Title: task.Title, ParentTempID: epic.TempID},
-					Error: err.Error(),
-				})
-				continue
-			}
-			result.Created = append(result.Created, CreatedItem{
-				ExternalID:       id,
-				TempID:           task.TempID,
-				Type:             "task",
-				Title:            task.Title,
-				ParentExternalID: epicID,
+		// Created without parent (can't use both --id and --parent)
+		opts := a.taskOptions(task)
+		start := time.Now()
+		id, err := a.runBdCreate(opts)
+		if err != nil {
+			result.Failed = append(result.Failed, FailedItem{
+				Item:  WorkItem{Type: "task", TempID: task.TempID, Title: task.Title, ParentTempID: epic.TempID},
+				Error: err.Error(),
 			})
-			tempToExternal[task.TempID] = id
-			result.Stats.Tasks++
-			// Parent-child relationship established via --parent flag in bd create
+			continue
 		}
+		result.Created = append(result.Created, CreatedItem{
+			ExternalID:       id,
+			TempID:           task.TempID,
+			Type:             "task",
+			Title:            task.Title,
+			ParentExternalID: epicID,
+			Duration:         time.Since(start),
+		})
+		tempToExternal[task.TempID] = id
+		result.Stats.Tasks++
+		a.writeCustomFields(result, WorkItem{Type: "task", TempID: task.TempID, Title: task.Title, ParentTempID: epic.TempID}, id, opts)
+
+		// Set parent after creation (can't use both --id and --parent)
+		if err := a.setParent(id, epicID); err != nil {
+			result.Failed = append(result.Failed, FailedItem{
+				Item:  WorkItem{Type: "parent", TempID: task.TempID, Title: task.Title, ParentTempID: epic.TempID},
+				Error: err.Error(),
+			})
+		}
+		a.linkCreated(result, tempToExternal, deps, task.TempID)
 	}
 
 	// Phase 3: Create all subtasks (as children of tasks)
-	for _, epic := range response.Epics {
-		for _, task := range epic.Tasks {
-			taskID, ok := tempToExternal[task.TempID]
-			if !ok {
-				continue
-			}
+	for _, tempID := range core.TopoSortTempIDs(deps.levels[core.LevelSubtask], deps.dependsOn) {
+		task, subtask := deps.refs[tempID].Task, deps.refs[tempID].Subtask
+		taskID, ok := tempToExternal[task.TempID]
+		if !ok || a.existing[subtask.TempID] != "" {
+			continue
+		}
 
-			for _, subtask := range task.Subtasks {
-				id, err := a.createSubtask(&subtask, taskID)
-				if err != nil {
-					result.Failed = append(result.Failed, FailedItem{
-						Item:  WorkItem{Type: "subtask", TempID: subtask.TempID, Title: subtask.Title, ParentTempID: task.TempID},
-						Error: err.Error(),
-					})
-					continue
-				}
-				result.Created = append(result.Created, CreatedItem{
-					ExternalID:       id,
-					TempID:           subtask.TempID,
-					Type:             "subtask",
-					Title:            subtask.Title,
-					ParentExternalID: taskID,
-				})
-				tempToExternal[subtask.TempID] = id
-				result.Stats.Subtasks++
-				// Parent-child relationship established via --parent flag in bd create
-			}
+		opts := a.subtaskOptions(subtask)
+		start := time.Now()
+		id, err := a.runBdCreate(opts)
+		if err != nil {
+			result.Failed = append(result.Failed, FailedItem{
+				Item:  WorkItem{Type: "subtask", TempID: subtask.TempID, Title: subtask.Title, ParentTempID: task.TempID},
+				Error: err.Error(),
+			})
+			continue
 		}
-	}
+		result.Created = append(result.Created, CreatedItem{
+			ExternalID:       id,
+			TempID:           subtask.TempID,
+			Type:             "subtask",
+			Title:            subtask.Title,
+			ParentExternalID: taskID,
+			Duration:         time.Since(start),
+		})
+		tempToExternal[subtask.TempID] = id
+		result.Stats.Subtasks++
+		a.writeCustomFields(result, WorkItem{Type: "subtask", TempID: subtask.TempID, Title: subtask.Title, ParentTempID: task.TempID}, id, opts)
 
-	// Phase 4: Establish explicit dependencies (depends_on relationships)
-	// Format: taskID depends on blockerID
-	for _, epic := range response.Epics {
-		epicID := tempToExternal[epic.TempID]
-		for _, depTempID := range epic.DependsOn {
-			if blockerID, ok := tempToExternal[depTempID]; ok && epicID != "" {
-				if err := a.addDependency(epicID, blockerID); err == nil {
-					result.Dependencies = append(result.Dependencies, Dependency{From: epicID, To: blockerID, Type: "depends_on"})
-					result.Stats.Dependencies++
-				}
-			}
+		// Set parent after creation (can't use both --id and --parent)
+		if err := a.setParent(id, taskID); err != nil {
+			result.Failed = append(result.Failed, FailedItem{
+				Item:  WorkItem{Type: "parent", TempID: subtask.TempID, Title: subtask.Title, ParentTempID: task.TempID},
+				Error: err.Error(),
+			})
 		}
+		a.linkCreated(result, tempToExternal, deps, subtask.TempID)
+	}
 
-		for _, task := range epic.Tasks {
-			taskID := tempToExternal[task.TempID]
-			for _, depTempID := range task.DependsOn {
-				if blockerID, ok := tempToExternal[depTempID]; ok && taskID != "" {
-					if err := a.addDependency(taskID, blockerID); err == nil {
-						result.Dependencies = append(result.Dependencies, Dependency{From: taskID, To: blockerID, Type: "depends_on"})
-						result.Stats.Dependencies++
-					}
-				}
-			}
+	return result, nil
+}
 
-			for _, subtask := range task.Subtasks {
-				subtaskID := tempToExternal[subtask.TempID]
-				for _, depTempID := range subtask.DependsOn {
-					if blockerID, ok := tempToExternal[depTempID]; ok && subtaskID != "" {
-						if err := a.addDependency(subtaskID, blockerID); err == nil {
-							result.Dependencies = append(result.Dependencies, Dependency{From: subtaskID, To: blockerID, Type: "depends_on"})
-							result.Stats.Dependencies++
-						}
-					}
-				}
-			}
+// planDependencies indexes a plan's items and depends_on links for creation in
+// phases.
+type planDependencies struct {
+	refs       map[string]core.ItemRef // Every item, by temp_id
+	levels     map[string][]string     // Level -> temp_ids in plan order
+	dependsOn  map[string][]string     // temp_id -> its depends_on
+	dependents map[string][]string     // temp_id -> items that depend on it, in plan order
+}
+
+func indexDependencies(response *core.ParseResponse) *planDependencies {
+	deps := &planDependencies{
+		refs:       make(map[string]core.ItemRef),
+		levels:     make(map[string][]string),
+		dependsOn:  make(map[string][]string),
+		dependents: make(map[string][]string),
+	}
+	_ = core.WalkItems(response, func(item core.ItemRef) error {
+		id := item.TempID()
+		deps.refs[id] = item
+		deps.levels[item.Level] = append(deps.levels[item.Level], id)
+		deps.dependsOn[id] = item.DependsOn()
+		for _, dep := range item.DependsOn() {
+			deps.dependents[dep] = append(deps.dependents[dep], id)
+		}
+		return nil
+	})
+	return deps
+}
+
+// linkCreated adds the dependencies that tempID's creation made possible: its
+// own depends_on entries whose blockers exist, and the entries of existing or
+// created items that depend on it. Each dependency is added once, when the
+// later of its two ends is created.
+func (a *BeadsAdapter) linkCreated(result *CreateResult, tempToExternal map[string]string, deps *planDependencies, tempID string) {
+	a.linkDependencies(result, tempToExternal, dependencyItem(deps.refs[tempID]), deps.dependsOn[tempID])
+	for _, dependent := range deps.dependents[tempID] {
+		if dependent != tempID {
+			a.linkDependencies(result, tempToExternal, dependencyItem(deps.refs[dependent]), []string{tempID})
 		}
 	}
+}
 
-	return result, nil
+// dependencyItem is the WorkItem recorded for a failed dependency of item.
+func dependencyItem(item core.ItemRef) WorkItem {
+	return WorkItem{Type: item.Level, TempID: item.TempID(), Title: item.Title()}
 }
 
-func (a *BeadsAdapter) createEpic(epic *core.Epic) (string, error) {
-	desc := a.buildDescription(epic.Description, epic.Context, &epic.Testing)
-	acceptance := strings.Join(epic.AcceptanceCriteria, "\n- ")
-	if acceptance != "" {
-		acceptance = "- " + acceptance
+// linkDependencies adds item's depends_on relationships, recording each in result.
+// Dependencies on items that were never created, and between two items that
+// already existed (MarkExisting), are skipped; bd failures that persist through
+// retries are recorded in result.Failed.
+func (a *BeadsAdapter) linkDependencies(result *CreateResult, tempToExternal map[string]string, item WorkItem, dependsOn []string) {
+	dependentID, ok := tempToExternal[item.TempID]
+	if !ok {
+		return
 	}
 
-	var estimateMinutes int
-	if epic.EstimatedDays != nil {
-		estimateMinutes = int(*epic.EstimatedDays * 8 * 60) // 8 hours per day
+	for _, depTempID := range dependsOn {
+		blockerID, ok := tempToExternal[depTempID]
+		if !ok || (a.existing[item.TempID] != "" && a.existing[depTempID] != "") {
+			continue
+		}
+		if err := a.addDependency(dependentID, blockerID); err != nil {
+			result.Failed = append(result.Failed, FailedItem{
+				Item:  WorkItem{Type: "dependency", TempID: item.TempID, Title: fmt.Sprintf("%s (depends on %s)", item.Title, depTempID)},
+				Error: err.Error(),
+			})
+			continue
+		}
+		result.Dependencies = append(result.Dependencies, Dependency{From: dependentID, To: blockerID, Type: "depends_on"})
+		result.Stats.Dependencies++
 	}
+}
+
+// epicOptions builds the bd fields for an epic.
+func (a *BeadsAdapter) epicOptions(epic *core.Epic) createOptions {
+	desc := a.buildDescription(core.LevelEpic, epic.Description, epic.Context, &epic.Testing) + sourceHintBlock(epic.SourceHint)
+	acceptance := formatAcceptance(epic.AcceptanceCriteria, a.acceptanceFmt)
+
+	estimateMinutes, _ := itemEstimateMinutes(core.ItemRef{Level: core.LevelEpic, Epic: epic})
 
-	// Generate readable ID like "prefix-e1"
-	readableID := tempIDToReadableID(a.getPrefix(), epic.TempID)
+	// Generate readable ID like "prefix-e1" (empty with the auto scheme: bd assigns it)
+	readableID := a.readableID(epic.TempID)
 
-	return a.runBdCreate(createOptions{
+	return a.mapFields(createOptions{
 		title:       epic.Title,
 		description: desc,
 		itemType:    "epic",
-		priority:    1, // Epics default to high priority
+		priority:    epicPriority(a.epicPriority),
 		acceptance:  acceptance,
 		estimate:    estimateMinutes,
 		labels:      epic.Labels,
 		explicitID:  readableID,
+
+		estimateFormat: a.estimateFormat,
 	})
 }
 
-func (a *BeadsAdapter) createTask(task *core.Task, parentID string) (string, error) {
-	desc := a.buildDescription(task.Description, task.Context, &task.Testing)
+// taskOptions builds the bd fields for a task.
+func (a *BeadsAdapter) taskOptions(task *core.Task) createOptions {
+	desc := a.buildDescription(core.LevelTask, task.Description, task.Context, &task.Testing) + sourceHintBlock(task.SourceHint)
 	priority := mapPriority(task.Priority)
 
 	var designNotes string
@@ -343,16 +554,12 @@ func (a *BeadsAdapter) createTask(task *core.Task, parentID string) (string, err
 		designNotes = *task.DesignNotes
 	}
 
-	var estimateMinutes int
-	if task.EstimatedHours != nil {
-		estimateMinutes = int(*task.EstimatedHours * 60)
-	}
+	estimateMinutes, _ := itemEstimateMinutes(core.ItemRef{Level: core.LevelTask, Task: task})
 
 	// Generate readable ID like "prefix-e1t1"
-	readableID := tempIDToReadableID(a.getPrefix(), task.TempID)
+	readableID := a.readableID(task.TempID)
 
-	// Create without parent (can't use both --id and --parent)
-	id, err := a.runBdCreate(createOptions{
+	return a.mapFields(createOptions{
 		title:       task.Title,
 		description: desc,
 		itemType:    "task",
@@ -361,56 +568,33 @@ func (a *BeadsAdapter) createTask(task *core.Task, parentID string) (string, err
 		estimate:    estimateMinutes,
 		labels:      task.Labels,
 		explicitID:  readableID,
-	})
-	if err != nil {
-		return "", err
-	}
 
-	// Set parent relationship after creation
-	if parentID != "" {
-		if err := a.setParent(id, parentID); err != nil {
-			// Don't fail - issue is created, just without parent
-			fmt.Printf("Warning: failed to set parent for %s: %v\n", id, err)
-		}
-	}
-
-	return id, nil
+		estimateFormat: a.estimateFormat,
+	})
 }
 
-func (a *BeadsAdapter) createSubtask(subtask *core.Subtask, parentID string) (string, error) {
-	desc := a.buildDescriptionWithContext(subtask.Description, subtask.Context, &subtask.Testing)
+// subtaskOptions builds the bd fields for a subtask.
+func (a *BeadsAdapter) subtaskOptions(subtask *core.Subtask) createOptions {
+	desc := a.buildDescription(core.LevelSubtask, subtask.Description, subtask.Context, &subtask.Testing)
 
-	var estimateMinutes int
-	if subtask.EstimatedMinutes != nil {
-		estimateMinutes = *subtask.EstimatedMinutes
-	}
+	item := core.ItemRef{Level: core.LevelSubtask, Subtask: subtask}
+	priority, _ := itemPriority(item, "")
+	estimateMinutes, _ := itemEstimateMinutes(item)
 
 	// Generate readable ID like "prefix-e1t1s1"
-	readableID := tempIDToReadableID(a.getPrefix(), subtask.TempID)
+	readableID := a.readableID(subtask.TempID)
 
-	// Create without parent (can't use both --id and --parent)
-	id, err := a.runBdCreate(createOptions{
+	return createOptions{
 		title:       subtask.Title,
 		description: desc,
 		itemType:    "task", // Beads uses "task" for subtasks too
-		priority:    2,      // Subtasks default to medium
+		priority:    mapPriority(priority),
 		estimate:    estimateMinutes,
 		labels:      subtask.Labels,
 		explicitID:  readableID,
-	})
-	if err != nil {
-		return "", err
-	}
 
-	// Set parent relationship after creation
-	if parentID != "" {
-		if err := a.setParent(id, parentID); err != nil {
-			// Don't fail - issue is created, just without parent
-			fmt.Printf("Warning: failed to set parent for %s: %v\n", id, err)
-		}
+		estimateFormat: a.estimateFormat,
 	}
-
-	return id, nil
 }
 
 // setParent sets the parent of an issue using bd update --parent
@@ -420,67 +604,37 @@ func (a *BeadsAdapter) setParent(childID, parentID string) error {
 		return nil
 	}
 
-	cmd := exec.Command("bd", "update", childID, "--parent", parentID)
-	cmd.Dir = a.workingDir
-	output, err := cmd.CombinedOutput()
+	output, err := a.runBdWithRetry("update", childID, "--parent", parentID)
 	if err != nil {
 		return fmt.Errorf("bd update failed: %s", string(output))
 	}
 	return nil
 }
 
-func (a *BeadsAdapter) buildDescription(base string, context interface{}, testing *core.TestingRequirements) string {
+// buildDescription appends the context and testing blocks enabled for level to base.
+func (a *BeadsAdapter) buildDescription(level, base string, context interface{}, testing *core.TestingRequirements) string {
 	desc := base
-
-	if a.includeContext && context != nil {
-		// Handle context as either string or object
-		switch ctx := context.(type) {
-		case string:
-			if ctx != "" {
-				desc += fmt.Sprintf("\n\n**Context:** %s", ctx)
-			}
-		case map[string]interface{}:
-			parts := []string{}
-			if bc, ok := ctx["business_context"].(string); ok && bc != "" {
-				parts = append(parts, fmt.Sprintf("- **Business Context:** %s", bc))
-			}
-			if tu, ok := ctx["target_users"].(string); ok && tu != "" {
-				parts = append(parts, fmt.Sprintf("- **Target Users:** %s", tu))
-			}
-			if bv, ok := ctx["brand_voice"].(string); ok && bv != "" {
-				parts = append(parts, fmt.Sprintf("- **Brand Voice:** %s", bv))
-			}
-			if sm, ok := ctx["success_metrics"].(string); ok && sm != "" {
-				parts = append(parts, fmt.Sprintf("- **Success Metrics:** %s", sm))
-			}
-			if len(parts) > 0 {
-				desc += "\n\n**Context:**\n" + strings.Join(parts, "\n")
-			}
+	if a.includeContext[level] {
+		if block := core.FormatContextBlock(context); block != "" {
+			desc += "\n\n" + block
 		}
 	}
-
-	if a.includeTesting && testing != nil {
-		parts := []string{}
-		if testing.UnitTests != nil {
-			parts = append(parts, fmt.Sprintf("- **Unit Tests:** %s", *testing.UnitTests))
-		}
-		if testing.IntegrationTests != nil {
-			parts = append(parts, fmt.Sprintf("- **Integration Tests:** %s", *testing.IntegrationTests))
-		}
-		if testing.TypeTests != nil {
-			parts = append(parts, fmt.Sprintf("- **Type Tests:** %s", *testing.TypeTests))
-		}
-		if testing.E2ETests != nil {
-			parts = append(parts, fmt.Sprintf("- **E2E Tests:** %s", *testing.E2ETests))
-		}
-		if len(parts) > 0 {
-			desc += "\n\n**Testing Requirements:**\n" + strings.Join(parts, "\n")
+	if a.includeTesting {
+		if block := core.FormatTesting(testing); block != "" {
+			desc += "\n\n" + block
 		}
 	}
-
 	return desc
 }
 
+// sourceHintBlock renders an item's PRD source reference for its description, or "" if absent.
+func sourceHintBlock(hint *string) string {
+	if hint == nil || *hint == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n\n**Source:** %s", *hint)
+}
+
 // createOptions holds all parameters for bd create
 type createOptions struct {
 	title       string
@@ -492,6 +646,22 @@ type createOptions struct {
 	estimate    int      // Estimate in minutes
 	labels      []string // Labels/tags
 	explicitID  string   // Readable ID (e.g., "prefix-e1", "prefix-e1t1")
+
+	customFields   []customField // Written after create with bd update --field (see mapFields)
+	estimateFormat string        // How estimate is written (EstimateFormat* values)
+}
+
+// readableID returns the explicit ID for an item under the adapter's ID scheme,
+// or "" to let bd assign one.
+func (a *BeadsAdapter) readableID(tempID string) string {
+	switch a.idScheme {
+	case IDSchemeAuto:
+		return ""
+	case IDSchemeDotted:
+		return a.getPrefix() + "-" + strings.ReplaceAll(tempID, ".", "-")
+	default:
+		return tempIDToReadableID(a.getPrefix(), tempID)
+	}
 }
 
 // tempIDToReadableID converts a temp_id like "1", "1.1", or "1.1.1" to a
@@ -516,36 +686,8 @@ func tempIDToReadableID(prefix string, tempID string) string {
 	return prefix + "-" + suffix
 }
 
-func (a *BeadsAdapter) buildDescriptionWithContext(base string, context *string, testing *core.TestingRequirements) string {
-	desc := base
-
-	if a.includeContext && context != nil {
-		desc += fmt.Sprintf("\n\n**Context:** %s", *context)
-	}
-
-	if a.includeTesting && testing != nil {
-		parts := []string{}
-		if testing.UnitTests != nil {
-			parts = append(parts, fmt.Sprintf("- **Unit Tests:** %s", *testing.UnitTests))
-		}
-		if testing.IntegrationTests != nil {
-			parts = append(parts, fmt.Sprintf("- **Integration Tests:** %s", *testing.IntegrationTests))
-		}
-		if testing.TypeTests != nil {
-			parts = append(parts, fmt.Sprintf("- **Type Tests:** %s", *testing.TypeTests))
-		}
-		if testing.E2ETests != nil {
-			parts = append(parts, fmt.Sprintf("- **E2E Tests:** %s", *testing.E2ETests))
-		}
-		if len(parts) > 0 {
-			desc += "\n\n**Testing Requirements:**\n" + strings.Join(parts, "\n")
-		}
-	}
-
-	return desc
-}
-
-func (a *BeadsAdapter) runBdCreate(opts createOptions) (string, error) {
+// createArgs builds the bd create arguments for opts.
+func createArgs(opts createOptions) []string {
 	args := []string{
 		"create",
 		opts.title,
@@ -571,7 +713,7 @@ func (a *BeadsAdapter) runBdCreate(opts createOptions) (string, error) {
 
 	// Add time estimate
 	if opts.estimate > 0 {
-		args = append(args, "--estimate", fmt.Sprintf("%d", opts.estimate))
+		args = append(args, "--estimate", formatEstimate(opts.estimate, opts.estimateFormat))
 	}
 
 	// Add labels
@@ -579,6 +721,12 @@ func (a *BeadsAdapter) runBdCreate(opts createOptions) (string, error) {
 		args = append(args, "--labels", strings.Join(opts.labels, ","))
 	}
 
+	return args
+}
+
+func (a *BeadsAdapter) runBdCreate(opts createOptions) (string, error) {
+	args := createArgs(opts)
+
 	if a.dryRun {
 		fmt.Printf("[dry-run] bd %s\n", strings.Join(args, " "))
 		if opts.explicitID != "" {
@@ -587,9 +735,26 @@ func (a *BeadsAdapter) runBdCreate(opts createOptions) (string, error) {
 		return fmt.Sprintf("dry-%d", len(opts.title)), nil
 	}
 
-	cmd := exec.Command("bd", args...)
-	cmd.Dir = a.workingDir
-	output, err := cmd.Output()
+	create := a.create
+	if create == nil {
+		create = execBdCreate
+	}
+
+	// Retried like runBdWithRetry: concurrent creates (CreateStrategyWaves) can
+	// find the database locked by another write
+	delay := a.retryBackoff
+	var output []byte
+	var err error
+	for attempt := 1; attempt <= bdMaxAttempts; attempt++ {
+		output, err = create(a.workingDir, args...)
+		if err == nil || !transientBdError(createFailure(output, err)) {
+			break
+		}
+		if attempt < bdMaxAttempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			return "", fmt.Errorf("bd create failed: %s", string(exitErr.Stderr))
@@ -612,6 +777,56 @@ func (a *BeadsAdapter) runBdCreate(opts createOptions) (string, error) {
 	return match, nil
 }
 
+// createFailure is what a failed bd create reported: its stderr, or its output
+// and error when it didn't run as a process.
+func createFailure(output []byte, err error) []byte {
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.Stderr
+	}
+	return []byte(string(output) + err.Error())
+}
+
+// listMarker matches a bullet or number the LLM already put on a criterion.
+var listMarker = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s+`)
+
+// formatAcceptance joins acceptance criteria in format, one per line. Blank
+// criteria are dropped, and a criterion's own "- " or "1. " is replaced rather
+// than doubled.
+func formatAcceptance(criteria []string, format string) string {
+	var lines []string
+	for _, c := range criteria {
+		c = listMarker.ReplaceAllString(strings.TrimSpace(c), "")
+		if c == "" {
+			continue
+		}
+		switch format {
+		case AcceptanceFormatNumbered:
+			c = fmt.Sprintf("%d. %s", len(lines)+1, c)
+		case AcceptanceFormatLines:
+		default:
+			c = "- " + c
+		}
+		lines = append(lines, c)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatEstimate renders minutes for bd create --estimate in format.
+func formatEstimate(minutes int, format string) string {
+	if format != EstimateFormatHM {
+		return fmt.Sprintf("%d", minutes)
+	}
+	h, m := minutes/60, minutes%60
+	switch {
+	case h == 0:
+		return fmt.Sprintf("%dm", m)
+	case m == 0:
+		return fmt.Sprintf("%dh", h)
+	default:
+		return fmt.Sprintf("%dh%dm", h, m)
+	}
+}
+
 // addDependency adds a dependency where dependentID depends on blockerID.
 // Syntax: bd dep add <dependent> <blocker>
 func (a *BeadsAdapter) addDependency(dependentID, blockerID string) error {
@@ -620,15 +835,21 @@ func (a *BeadsAdapter) addDependency(dependentID, blockerID string) error {
 		return nil
 	}
 
-	cmd := exec.Command("bd", "dep", "add", dependentID, blockerID)
-	cmd.Dir = a.workingDir
-	output, err := cmd.CombinedOutput()
+	output, err := a.runBdWithRetry("dep", "add", dependentID, blockerID)
 	if err != nil {
 		return fmt.Errorf("bd dep add failed: %s", string(output))
 	}
 	return nil
 }
 
+// epicPriority returns the bd priority for epics: p, or high if unset.
+func epicPriority(p core.Priority) int {
+	if p == "" {
+		return mapPriority(core.PriorityHigh)
+	}
+	return mapPriority(p)
+}
+
 func mapPriority(p core.Priority) int {
 	switch p {
 	case core.PriorityCritical:
@@ -0,0 +1,145 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// Targets for a mapped beads field (Config.BeadsFields values).
+const (
+	FieldTargetNative      = "native"      // bd's own flag (--acceptance, --design); the default
+	FieldTargetDescription = "description" // appended to the issue description
+	FieldTargetNone        = "none"        // dropped
+	FieldTargetCustom      = "field:"      // "field:<name>" sets a custom field with bd update --field
+)
+
+// mappableFields are the plan fields that can be redirected, with the heading
+// used when they are written into a description.
+var mappableFields = map[string]string{
+	"acceptance": "Acceptance Criteria",
+	"design":     "Design Notes",
+}
+
+// customField is a value written to a named custom field after create.
+type customField struct {
+	name    string
+	heading string // Description heading if the custom field is unsupported
+	value   string
+}
+
+// ValidateFieldMap checks that every key is a mappable field and every value a known target.
+func ValidateFieldMap(fields map[string]string) error {
+	for field, target := range fields {
+		if _, ok := mappableFields[field]; !ok {
+			return fmt.Errorf("unknown beads field %q (mappable: %s)", field, strings.Join(mappableFieldNames(), ", "))
+		}
+		switch {
+		case target == FieldTargetNative, target == FieldTargetDescription, target == FieldTargetNone:
+		case strings.HasPrefix(target, FieldTargetCustom) && len(target) > len(FieldTargetCustom):
+		default:
+			return fmt.Errorf("unknown target %q for beads field %s (use native, description, none, or field:<name>)", target, field)
+		}
+	}
+	return nil
+}
+
+func mappableFieldNames() []string {
+	names := make([]string, 0, len(mappableFields))
+	for name := range mappableFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mapFields moves acceptance criteria and design notes to their configured targets.
+func (a *BeadsAdapter) mapFields(opts createOptions) createOptions {
+	opts.acceptance = a.mapField(&opts, "acceptance", opts.acceptance)
+	opts.design = a.mapField(&opts, "design", opts.design)
+	return opts
+}
+
+// mapField routes value to the target configured for field and returns what
+// should remain in the field's native flag.
+func (a *BeadsAdapter) mapField(opts *createOptions, field, value string) string {
+	target := a.fieldMap[field]
+	if value == "" || target == "" || target == FieldTargetNative {
+		return value
+	}
+
+	heading := mappableFields[field]
+	switch {
+	case target == FieldTargetDescription:
+		opts.description += fieldBlock(heading, value)
+	case strings.HasPrefix(target, FieldTargetCustom):
+		name := strings.TrimPrefix(target, FieldTargetCustom)
+		opts.customFields = append(opts.customFields, customField{name: name, heading: heading, value: value})
+	}
+	return ""
+}
+
+// writeCustomFields sets opts' custom fields on a created issue. If the schema
+// doesn't support a field, its value is appended to the description instead,
+// and only if that also fails is the item recorded in result.Failed. When bd
+// doesn't know --field at all, that's warned about once and every later item's
+// fields go straight to the description.
+func (a *BeadsAdapter) writeCustomFields(result *CreateResult, item WorkItem, id string, opts createOptions) {
+	if len(opts.customFields) == 0 {
+		return
+	}
+
+	var unsupported []customField
+	for _, f := range opts.customFields {
+		arg := f.name + "=" + f.value
+		if a.dryRun {
+			fmt.Printf("[dry-run] bd update %s --field %s\n", id, arg)
+			continue
+		}
+		if a.noFieldFlag.Load() {
+			unsupported = append(unsupported, f)
+			continue
+		}
+		if output, err := a.runBdWithRetry("update", id, "--field", arg); err != nil {
+			if unknownFlagError(output, "--field") && a.noFieldFlag.CompareAndSwap(false, true) {
+				a.warnings.Add(core.WarnAdapterCapability, "", "this bd version doesn't support bd update --field; custom fields are written to descriptions instead")
+			}
+			unsupported = append(unsupported, f)
+		}
+	}
+	if len(unsupported) == 0 {
+		return
+	}
+
+	names := make([]string, len(unsupported))
+	for i, f := range unsupported {
+		names[i] = f.name
+	}
+	if !a.noFieldFlag.Load() {
+		fmt.Printf("Custom field(s) %s not supported for %s; writing to description instead\n", strings.Join(names, ", "), id)
+	}
+
+	desc := opts.description + customFieldsBlock(unsupported)
+	if output, err := a.runBdWithRetry("update", id, "--description", desc); err != nil {
+		result.Failed = append(result.Failed, FailedItem{
+			Item:  WorkItem{Type: "field", TempID: item.TempID, Title: item.Title, ParentTempID: item.ParentTempID},
+			Error: fmt.Sprintf("bd update failed for fields %s: %s", strings.Join(names, ", "), string(output)),
+		})
+	}
+}
+
+// customFieldsBlock renders custom field values as description sections.
+func customFieldsBlock(fields []customField) string {
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteString(fieldBlock(f.heading, f.value))
+	}
+	return b.String()
+}
+
+// fieldBlock renders a field as a description section.
+func fieldBlock(heading, value string) string {
+	return fmt.Sprintf("\n\n**%s:**\n%s", heading, value)
+}
@@ -0,0 +1,182 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// beadsImportIssue is one line of a bd import JSONL file, in beads' issue schema.
+type beadsImportIssue struct {
+	ID                 string                  `json:"id"`
+	Title              string                  `json:"title"`
+	Description        string                  `json:"description,omitempty"`
+	Design             string                  `json:"design,omitempty"`
+	AcceptanceCriteria string                  `json:"acceptance_criteria,omitempty"`
+	Status             string                  `json:"status"`
+	Priority           int                     `json:"priority"`
+	IssueType          string                  `json:"issue_type"`
+	EstimatedMinutes   int                     `json:"estimated_minutes,omitempty"`
+	Labels             []string                `json:"labels,omitempty"`
+	Dependencies       []beadsImportDependency `json:"dependencies,omitempty"`
+}
+
+// beadsImportDependency links an issue to the one it depends on.
+// Parents are expressed as "parent-child" dependencies, depends_on as "blocks".
+type beadsImportDependency struct {
+	IssueID     string `json:"issue_id"`
+	DependsOnID string `json:"depends_on_id"`
+	Type        string `json:"type"`
+}
+
+// errBdImport wraps a failed bd import. Nothing was created, so createItems
+// falls back to per-issue creation.
+var errBdImport = errors.New("bd import failed")
+
+// bulkImportAvailable reports whether bulk import can be used: bd must support
+// `bd import`, and every item needs an explicit ID (not the auto scheme).
+func (a *BeadsAdapter) bulkImportAvailable() bool {
+	if a.idScheme == IDSchemeAuto {
+		return false
+	}
+	if a.dryRun {
+		return true
+	}
+	run := a.run
+	if run == nil {
+		run = execBd
+	}
+	_, err := run(a.workingDir, "import", "--help")
+	return err == nil
+}
+
+// createItemsBulk writes the whole plan to one JSONL file and imports it with a
+// single `bd import`, instead of one bd call per issue, parent, and dependency.
+func (a *BeadsAdapter) createItemsBulk(response *core.ParseResponse) (*CreateResult, error) {
+	issues, result := a.buildImportIssues(response)
+
+	file, err := os.CreateTemp(a.tempDir, "prd-parser-beads-*.jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create import file: %w", err)
+	}
+	if err := writeImportIssues(file, issues); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, fmt.Errorf("failed to write import file: %w", err)
+	}
+	file.Close()
+
+	// In dry-run the file is kept so it can be inspected
+	if a.dryRun {
+		fmt.Printf("[dry-run] bd import -i %s (%d issues)\n", file.Name(), len(issues))
+		return result, nil
+	}
+	defer os.Remove(file.Name())
+
+	output, err := a.runBdWithRetry("import", "-i", file.Name())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errBdImport, strings.TrimSpace(string(output)))
+	}
+	return result, nil
+}
+
+// buildImportIssues converts the plan to beads import records, along with the
+// CreateResult the import produces when it succeeds.
+func (a *BeadsAdapter) buildImportIssues(response *core.ParseResponse) ([]beadsImportIssue, *CreateResult) {
+	result := &CreateResult{
+		Created:      []CreatedItem{},
+		Failed:       []FailedItem{},
+		Dependencies: []Dependency{},
+		Stats:        Stats{},
+	}
+	tempToExternal := make(map[string]string)
+	var issues []beadsImportIssue
+
+	add := func(opts createOptions, itemType, tempID, parentID string) {
+		issue := beadsImportIssue{
+			ID:                 opts.explicitID,
+			Title:              opts.title,
+			Description:        opts.description + customFieldsBlock(opts.customFields), // import has no custom fields
+			Design:             opts.design,
+			AcceptanceCriteria: opts.acceptance,
+			Status:             "open",
+			Priority:           opts.priority,
+			IssueType:          opts.itemType,
+			EstimatedMinutes:   opts.estimate,
+			Labels:             opts.labels,
+		}
+		if parentID != "" {
+			issue.Dependencies = append(issue.Dependencies, beadsImportDependency{IssueID: issue.ID, DependsOnID: parentID, Type: "parent-child"})
+		}
+		issues = append(issues, issue)
+		tempToExternal[tempID] = issue.ID
+		result.Created = append(result.Created, CreatedItem{
+			ExternalID:       issue.ID,
+			TempID:           tempID,
+			Type:             itemType,
+			Title:            opts.title,
+			ParentExternalID: parentID,
+		})
+	}
+
+	for _, epic := range response.Epics {
+		add(a.epicOptions(&epic), "epic", epic.TempID, "")
+		result.Stats.Epics++
+		epicID := tempToExternal[epic.TempID]
+		for _, task := range epic.Tasks {
+			add(a.taskOptions(&task), "task", task.TempID, epicID)
+			result.Stats.Tasks++
+			taskID := tempToExternal[task.TempID]
+			for _, subtask := range task.Subtasks {
+				add(a.subtaskOptions(&subtask), "subtask", subtask.TempID, taskID)
+				result.Stats.Subtasks++
+			}
+		}
+	}
+
+	// Dependencies are resolved once every ID is known, like Phase 4 of CreateItems
+	index := make(map[string]int, len(issues))
+	for i, issue := range issues {
+		index[issue.ID] = i
+	}
+	link := func(tempID string, dependsOn []string) {
+		dependentID := tempToExternal[tempID]
+		for _, depTempID := range dependsOn {
+			blockerID, ok := tempToExternal[depTempID]
+			if !ok {
+				continue
+			}
+			issue := &issues[index[dependentID]]
+			issue.Dependencies = append(issue.Dependencies, beadsImportDependency{IssueID: dependentID, DependsOnID: blockerID, Type: "blocks"})
+			result.Dependencies = append(result.Dependencies, Dependency{From: dependentID, To: blockerID, Type: "depends_on"})
+			result.Stats.Dependencies++
+		}
+	}
+	for _, epic := range response.Epics {
+		link(epic.TempID, epic.DependsOn)
+		for _, task := range epic.Tasks {
+			link(task.TempID, task.DependsOn)
+			for _, subtask := range task.Subtasks {
+				link(subtask.TempID, subtask.DependsOn)
+			}
+		}
+	}
+
+	return issues, result
+}
+
+// writeImportIssues writes issues as JSONL, one issue per line.
+func writeImportIssues(w io.Writer, issues []beadsImportIssue) error {
+	enc := json.NewEncoder(w)
+	for _, issue := range issues {
+		if err := enc.Encode(issue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
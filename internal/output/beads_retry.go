@@ -0,0 +1,64 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// ExistingIssueIDs returns the IDs of every issue already in the beads database.
+func (a *BeadsAdapter) ExistingIssueIDs() (map[string]bool, error) {
+	run := a.run
+	if run == nil {
+		run = execBd
+	}
+	data, err := run(a.workingDir, "list", "--json", "--status=all", "--limit", "0")
+	if err != nil {
+		return nil, fmt.Errorf("bd list failed: %s", string(data))
+	}
+	issues, err := core.ParseBeadsListJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(issues))
+	for _, issue := range issues {
+		ids[issue.ID] = true
+	}
+	return ids, nil
+}
+
+// IDCollision is a plan item whose readable ID is already an issue in beads.
+type IDCollision struct {
+	TempID string
+	Title  string
+	ID     string // The readable ID, e.g. "prefix-e1t2"
+}
+
+// IDCollisions returns the plan items whose readable ID is among ids, in plan
+// order. The auto ID scheme has no predictable IDs, so nothing collides with it.
+func (a *BeadsAdapter) IDCollisions(response *core.ParseResponse, ids map[string]bool) []IDCollision {
+	if a.idScheme == IDSchemeAuto {
+		return nil
+	}
+	var collisions []IDCollision
+	_ = core.WalkItems(response, func(item core.ItemRef) error {
+		if id := a.readableID(item.TempID()); ids[id] {
+			collisions = append(collisions, IDCollision{TempID: item.TempID(), Title: item.Title(), ID: id})
+		}
+		return nil
+	})
+	return collisions
+}
+
+// MarkExisting records the plan items whose readable ID is among ids, so that
+// CreateItems skips them and only creates the rest. Skipped items still serve
+// as parents and dependency targets for the new ones, but dependencies between
+// two existing items are left alone. It returns the number of items found; the
+// auto ID scheme has no predictable IDs, so nothing is found with it.
+func (a *BeadsAdapter) MarkExisting(response *core.ParseResponse, ids map[string]bool) int {
+	a.existing = make(map[string]string)
+	for _, c := range a.IDCollisions(response, ids) {
+		a.existing[c.TempID] = c.ID
+	}
+	return len(a.existing)
+}
@@ -0,0 +1,873 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// flakyRunner fails the first failures calls of each bd command, then succeeds.
+type flakyRunner struct {
+	failures int
+	calls    map[string]int
+}
+
+func (r *flakyRunner) run(dir string, args ...string) ([]byte, error) {
+	key := strings.Join(args, " ")
+	r.calls[key]++
+	if r.calls[key] <= r.failures {
+		return []byte("database is locked"), errors.New("exit status 1")
+	}
+	return nil, nil
+}
+
+func newTestBeadsAdapter(failures int) (*BeadsAdapter, *flakyRunner) {
+	runner := &flakyRunner{failures: failures, calls: make(map[string]int)}
+	return &BeadsAdapter{workingDir: ".", run: runner.run}, runner
+}
+
+func TestLinkDependenciesRetriesTransientFailure(t *testing.T) {
+	adapter, runner := newTestBeadsAdapter(1)
+	result := &CreateResult{}
+	tempToExternal := map[string]string{"1": "prd-e1", "2": "prd-e2"}
+
+	adapter.linkDependencies(result, tempToExternal, WorkItem{Type: "epic", TempID: "2", Title: "Feature"}, []string{"1"})
+
+	if got := runner.calls["dep add prd-e2 prd-e1"]; got != 2 {
+		t.Errorf("bd dep add called %d times, want 2", got)
+	}
+	if len(result.Dependencies) != 1 || result.Dependencies[0].From != "prd-e2" || result.Dependencies[0].To != "prd-e1" {
+		t.Errorf("Dependencies = %+v, want prd-e2 -> prd-e1", result.Dependencies)
+	}
+	if result.Stats.Dependencies != 1 {
+		t.Errorf("Stats.Dependencies = %d, want 1", result.Stats.Dependencies)
+	}
+	if len(result.Failed) != 0 {
+		t.Errorf("Failed = %+v, want none", result.Failed)
+	}
+}
+
+func TestLinkDependenciesRecordsPersistentFailure(t *testing.T) {
+	adapter, runner := newTestBeadsAdapter(bdMaxAttempts)
+	result := &CreateResult{}
+	tempToExternal := map[string]string{"1": "prd-e1", "2": "prd-e2"}
+
+	adapter.linkDependencies(result, tempToExternal, WorkItem{Type: "epic", TempID: "2", Title: "Feature"}, []string{"1"})
+
+	if got := runner.calls["dep add prd-e2 prd-e1"]; got != bdMaxAttempts {
+		t.Errorf("bd dep add called %d times, want %d", got, bdMaxAttempts)
+	}
+	if len(result.Dependencies) != 0 {
+		t.Errorf("Dependencies = %+v, want none", result.Dependencies)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Item.Type != "dependency" {
+		t.Fatalf("Failed = %+v, want one dependency failure", result.Failed)
+	}
+	if !strings.Contains(result.Failed[0].Error, "database is locked") {
+		t.Errorf("Failed error = %q, want bd output", result.Failed[0].Error)
+	}
+}
+
+func TestReadableIDSchemes(t *testing.T) {
+	tests := []struct {
+		scheme string
+		want   string
+	}{
+		{IDSchemeETS, "prd-e2t3s1"},
+		{"", "prd-e2t3s1"}, // ets is the default
+		{IDSchemeDotted, "prd-2-3-1"},
+		{IDSchemeAuto, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scheme, func(t *testing.T) {
+			adapter := &BeadsAdapter{prefix: "prd", idScheme: tt.scheme}
+			if got := adapter.readableID("2.3.1"); got != tt.want {
+				t.Errorf("readableID(%q) with scheme %q = %q, want %q", "2.3.1", tt.scheme, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceHintBlock(t *testing.T) {
+	hint := "## Payments"
+	if got := sourceHintBlock(&hint); !strings.Contains(got, "**Source:** ## Payments") {
+		t.Errorf("sourceHintBlock() = %q, want a Source line", got)
+	}
+	if got := sourceHintBlock(nil); got != "" {
+		t.Errorf("sourceHintBlock(nil) = %q, want empty", got)
+	}
+}
+
+func TestBulkImportFileStructure(t *testing.T) {
+	var imported []beadsImportIssue
+	var calls []string
+	adapter := &BeadsAdapter{
+		workingDir: ".",
+		prefix:     "prd",
+		idScheme:   IDSchemeETS,
+		bulk:       true,
+		run: func(dir string, args ...string) ([]byte, error) {
+			calls = append(calls, strings.Join(args, " "))
+			if len(args) == 3 && args[0] == "import" && args[1] == "-i" {
+				f, err := os.Open(args[2])
+				if err != nil {
+					return nil, err
+				}
+				defer f.Close()
+				scanner := bufio.NewScanner(f)
+				for scanner.Scan() {
+					var issue beadsImportIssue
+					if err := json.Unmarshal(scanner.Bytes(), &issue); err != nil {
+						t.Fatalf("import line is not valid JSON: %v (%s)", err, scanner.Text())
+					}
+					imported = append(imported, issue)
+				}
+			}
+			return nil, nil
+		},
+	}
+
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "Auth", Tasks: []core.Task{
+			{TempID: "1.1", Title: "Schema", Priority: core.PriorityHigh, Subtasks: []core.Subtask{
+				{TempID: "1.1.1", Title: "Users table"},
+			}},
+			{TempID: "1.2", Title: "Login API", DependsOn: []string{"1.1"}},
+		}},
+		{TempID: "2", Title: "Billing", DependsOn: []string{"1"}},
+	}}
+
+	result, err := adapter.CreateItems(response, Config{})
+	if err != nil {
+		t.Fatalf("CreateItems() error = %v", err)
+	}
+
+	if len(calls) != 2 || calls[0] != "import --help" || !strings.HasPrefix(calls[1], "import -i ") {
+		t.Fatalf("bd calls = %v, want one availability check and one import", calls)
+	}
+	if len(imported) != 5 {
+		t.Fatalf("imported %d issues, want 5", len(imported))
+	}
+
+	byID := make(map[string]beadsImportIssue)
+	for _, issue := range imported {
+		if issue.Status != "open" {
+			t.Errorf("%s status = %q, want open", issue.ID, issue.Status)
+		}
+		byID[issue.ID] = issue
+	}
+
+	if got := byID["prd-e1"].IssueType; got != "epic" {
+		t.Errorf("prd-e1 issue_type = %q, want epic", got)
+	}
+	if got := byID["prd-e1t1"].Priority; got != 1 {
+		t.Errorf("prd-e1t1 priority = %d, want 1 (high)", got)
+	}
+
+	wantDeps := map[string][]beadsImportDependency{
+		"prd-e1":     nil,
+		"prd-e1t1":   {{IssueID: "prd-e1t1", DependsOnID: "prd-e1", Type: "parent-child"}},
+		"prd-e1t1s1": {{IssueID: "prd-e1t1s1", DependsOnID: "prd-e1t1", Type: "parent-child"}},
+		"prd-e1t2": {
+			{IssueID: "prd-e1t2", DependsOnID: "prd-e1", Type: "parent-child"},
+			{IssueID: "prd-e1t2", DependsOnID: "prd-e1t1", Type: "blocks"},
+		},
+		"prd-e2": {{IssueID: "prd-e2", DependsOnID: "prd-e1", Type: "blocks"}},
+	}
+	for id, want := range wantDeps {
+		got := byID[id].Dependencies
+		if len(got) != len(want) {
+			t.Errorf("%s dependencies = %+v, want %+v", id, got, want)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s dependency %d = %+v, want %+v", id, i, got[i], want[i])
+			}
+		}
+	}
+
+	if result.Stats != (Stats{Epics: 2, Tasks: 2, Subtasks: 1, Dependencies: 2}) {
+		t.Errorf("Stats = %+v", result.Stats)
+	}
+}
+
+func TestBulkImportFailureFallsBackToPerIssueCreate(t *testing.T) {
+	var created []string
+	warnings := core.NewWarningCollector()
+	adapter := &BeadsAdapter{
+		workingDir: ".",
+		prefix:     "prd",
+		idScheme:   IDSchemeETS,
+		bulk:       true,
+		warnings:   warnings,
+		run: func(dir string, args ...string) ([]byte, error) {
+			if args[0] == "import" && args[1] == "-i" {
+				return []byte("Error: schema version mismatch"), errors.New("exit status 1")
+			}
+			return nil, nil
+		},
+		create: func(dir string, args ...string) ([]byte, error) {
+			for i, arg := range args {
+				if arg == "--id" {
+					created = append(created, args[i+1])
+				}
+			}
+			return nil, nil
+		},
+	}
+
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "Auth", Tasks: []core.Task{{TempID: "1.1", Title: "Schema"}}},
+	}}
+	result, err := adapter.CreateItems(response, Config{})
+	if err != nil {
+		t.Fatalf("CreateItems() error = %v, want a fallback to bd create", err)
+	}
+	if len(created) != 2 || len(result.Created) != 2 {
+		t.Errorf("bd create IDs = %v, Created = %+v; want both items created one at a time", created, result.Created)
+	}
+	if got := warnings.Warnings(); len(got) != 1 || !strings.Contains(got[0].Message, "schema version mismatch") {
+		t.Errorf("warnings = %v, want one naming the import failure", got)
+	}
+}
+
+func TestBulkImportNeedsExplicitIDs(t *testing.T) {
+	adapter := &BeadsAdapter{idScheme: IDSchemeAuto, bulk: true, dryRun: true}
+	if adapter.bulkImportAvailable() {
+		t.Error("bulk import should be unavailable with the auto ID scheme")
+	}
+}
+
+func TestFieldMapChangesCreateArgs(t *testing.T) {
+	epic := &core.Epic{TempID: "1", Title: "Auth", Description: "Login", AcceptanceCriteria: []string{"Users can log in"}}
+	task := &core.Task{TempID: "1.1", Title: "API", Description: "Endpoints", DesignNotes: strPtr("REST")}
+
+	tests := []struct {
+		name       string
+		fields     map[string]string
+		wantFlags  []string // flags expected in bd create args
+		noFlags    []string // flags that must not appear
+		inDesc     string   // text expected in the description
+		wantCustom int      // custom fields left for bd update
+	}{
+		{"default uses native flags", nil, []string{"--acceptance", "--design"}, nil, "", 0},
+		{"acceptance to description", map[string]string{"acceptance": FieldTargetDescription}, []string{"--design"}, []string{"--acceptance"}, "**Acceptance Criteria:**\n- Users can log in", 0},
+		{"design dropped", map[string]string{"design": FieldTargetNone}, []string{"--acceptance"}, []string{"--design"}, "", 0},
+		{"design to custom field", map[string]string{"design": "field:notes"}, []string{"--acceptance"}, []string{"--design"}, "", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &BeadsAdapter{prefix: "prd", fieldMap: tt.fields}
+			epicOpts, taskOpts := adapter.epicOptions(epic), adapter.taskOptions(task)
+			args := append(createArgs(epicOpts), createArgs(taskOpts)...)
+
+			for _, flag := range tt.wantFlags {
+				if !hasArg(args, flag) {
+					t.Errorf("args missing %s: %v", flag, args)
+				}
+			}
+			for _, flag := range tt.noFlags {
+				if hasArg(args, flag) {
+					t.Errorf("args should not include %s: %v", flag, args)
+				}
+			}
+			if tt.inDesc != "" && !strings.Contains(epicOpts.description, tt.inDesc) {
+				t.Errorf("epic description = %q, want it to contain %q", epicOpts.description, tt.inDesc)
+			}
+			if got := len(taskOpts.customFields); got != tt.wantCustom {
+				t.Errorf("custom fields = %d, want %d", got, tt.wantCustom)
+			}
+		})
+	}
+}
+
+func TestWriteCustomFieldsFallsBackToDescription(t *testing.T) {
+	var calls []string
+	supported := true
+	adapter := &BeadsAdapter{prefix: "prd", fieldMap: map[string]string{"design": "field:notes"}}
+	adapter.run = func(dir string, args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args, " "))
+		if args[2] == "--field" && !supported {
+			return []byte("unknown flag: --field"), errors.New("exit status 1")
+		}
+		return nil, nil
+	}
+	opts := adapter.taskOptions(&core.Task{TempID: "1.1", Title: "API", Description: "Endpoints", DesignNotes: strPtr("REST")})
+	item := WorkItem{Type: "task", TempID: "1.1", Title: "API"}
+
+	result := &CreateResult{}
+	adapter.writeCustomFields(result, item, "prd-e1t1", opts)
+	if len(calls) != 1 || calls[0] != "update prd-e1t1 --field notes=REST" {
+		t.Errorf("bd calls = %v, want one field update", calls)
+	}
+
+	calls, supported = nil, false
+	adapter.writeCustomFields(result, item, "prd-e1t1", opts)
+	last := calls[len(calls)-1]
+	if !strings.HasPrefix(last, "update prd-e1t1 --description ") || !strings.Contains(last, "**Design Notes:**\nREST") {
+		t.Errorf("unsupported field should fall back to the description, last call = %q", last)
+	}
+	if len(result.Failed) != 0 {
+		t.Errorf("Failed = %+v, want none after a successful fallback", result.Failed)
+	}
+	if calls[0] != "update prd-e1t1 --field notes=REST" || len(calls) != 2 {
+		t.Errorf("bd calls = %v, want one --field attempt (unknown flags aren't retried), then the description", calls)
+	}
+
+	// Once bd has rejected --field, later items skip it
+	calls = nil
+	adapter.writeCustomFields(result, WorkItem{Type: "task", TempID: "1.2", Title: "API"}, "prd-e1t2", opts)
+	if len(calls) != 1 || !strings.HasPrefix(calls[0], "update prd-e1t2 --description ") {
+		t.Errorf("bd calls = %v, want only the description update", calls)
+	}
+}
+
+func TestRunBdWithRetryOnlyRetriesTransientErrors(t *testing.T) {
+	calls := 0
+	adapter := &BeadsAdapter{run: func(dir string, args ...string) ([]byte, error) {
+		calls++
+		return []byte("Error: unknown flag: --parent"), errors.New("exit status 1")
+	}}
+	if _, err := adapter.runBdWithRetry("update", "prd-e1t1", "--parent", "prd-e1"); err == nil {
+		t.Fatal("runBdWithRetry() error = nil, want the bd failure")
+	}
+	if calls != 1 {
+		t.Errorf("bd called %d times for a non-transient error, want 1", calls)
+	}
+}
+
+func TestValidateFieldMap(t *testing.T) {
+	valid := map[string]string{"acceptance": "description", "design": "field:notes"}
+	if err := ValidateFieldMap(valid); err != nil {
+		t.Errorf("ValidateFieldMap(%v) = %v, want nil", valid, err)
+	}
+	for _, invalid := range []map[string]string{{"priority": "description"}, {"design": "comments"}, {"design": "field:"}} {
+		if err := ValidateFieldMap(invalid); err == nil {
+			t.Errorf("ValidateFieldMap(%v) should fail", invalid)
+		}
+	}
+}
+
+func hasArg(args []string, want string) bool {
+	for _, a := range args {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestContextLevels(t *testing.T) {
+	epic := &core.Epic{TempID: "1", Title: "Auth", Description: "Login", Context: core.ContextText("epic context")}
+	task := &core.Task{TempID: "1.1", Title: "API", Description: "Endpoints", Context: core.ContextText("task context")}
+	subtask := &core.Subtask{TempID: "1.1.1", Title: "Route", Description: "POST /login", Context: strPtr("subtask context")}
+
+	tests := []struct {
+		name   string
+		config Config
+		want   [3]bool // context in epic, task, subtask descriptions
+	}{
+		{"all levels by default", Config{IncludeContext: true}, [3]bool{true, true, true}},
+		{"epics and tasks only", Config{IncludeContext: true, IncludeContextEpics: true, IncludeContextTasks: true}, [3]bool{true, true, false}},
+		{"subtasks only", Config{IncludeContext: true, IncludeContextSubtasks: true}, [3]bool{false, false, true}},
+		{"coarse flag overrides levels", Config{IncludeContext: false, IncludeContextEpics: true}, [3]bool{false, false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := NewBeadsAdapter(tt.config)
+			descs := [3]string{
+				adapter.epicOptions(epic).description,
+				adapter.taskOptions(task).description,
+				adapter.subtaskOptions(subtask).description,
+			}
+			for i, level := range []string{"epic", "task", "subtask"} {
+				if got := strings.Contains(descs[i], "**Context:** "+level+" context"); got != tt.want[i] {
+					t.Errorf("%s description has context = %v, want %v: %q", level, got, tt.want[i], descs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAppendLabelsReachBeadsArgs(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "Checkout", Labels: []string{"payments"}, Tasks: []core.Task{
+			{TempID: "1.1", Title: "Cart API", Labels: []string{"Q1-2026"}, Subtasks: []core.Subtask{
+				{TempID: "1.1.1", Title: "Cart table"},
+			}},
+		}},
+	}}
+	core.AppendLabels(response, []string{"q1-2026", "team-payments"})
+
+	adapter := &BeadsAdapter{workingDir: ".", prefix: "prd"}
+	epic := &response.Epics[0]
+	task := &epic.Tasks[0]
+	items := map[string]createOptions{
+		"epic":    adapter.epicOptions(epic),
+		"task":    adapter.taskOptions(task),
+		"subtask": adapter.subtaskOptions(&task.Subtasks[0]),
+	}
+	want := map[string]string{
+		"epic":    "payments,q1-2026,team-payments",
+		"task":    "Q1-2026,team-payments", // existing label kept, not duplicated
+		"subtask": "q1-2026,team-payments",
+	}
+
+	for level, opts := range items {
+		args := createArgs(opts)
+		got := ""
+		for i, arg := range args {
+			if arg == "--labels" && i+1 < len(args) {
+				got = args[i+1]
+			}
+		}
+		if got != want[level] {
+			t.Errorf("%s --labels = %q, want %q", level, got, want[level])
+		}
+	}
+}
+
+func TestEpicPriorityReachesBeadsArgs(t *testing.T) {
+	epic := &core.Epic{TempID: "1", Title: "Checkout", Tasks: []core.Task{
+		{TempID: "1.1", Title: "Cart API", Priority: core.PriorityLow},
+	}}
+	priorityArg := func(opts createOptions) string {
+		args := createArgs(opts)
+		for i, arg := range args {
+			if arg == "--priority" && i+1 < len(args) {
+				return args[i+1]
+			}
+		}
+		return ""
+	}
+
+	tests := []struct {
+		priority core.Priority
+		want     string
+	}{
+		{"", "1"}, // Unset keeps epics high
+		{core.PriorityMedium, "2"},
+		{core.PriorityVeryLow, "4"},
+	}
+	for _, tt := range tests {
+		adapter := NewBeadsAdapter(Config{EpicPriority: tt.priority})
+		if got := priorityArg(adapter.epicOptions(epic)); got != tt.want {
+			t.Errorf("EpicPriority %q: epic --priority = %q, want %q", tt.priority, got, tt.want)
+		}
+		if got := priorityArg(adapter.taskOptions(&epic.Tasks[0])); got != "3" {
+			t.Errorf("EpicPriority %q: task --priority = %q, want its own 3", tt.priority, got)
+		}
+	}
+}
+
+func TestCreateItemsWavesRespectsWaveOrder(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "Setup", Tasks: []core.Task{
+			{TempID: "1.1", Title: "Repo", Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "Init"}}},
+			{TempID: "1.2", Title: "CI", DependsOn: []string{"1.1"}},
+		}},
+		{TempID: "2", Title: "API", Tasks: []core.Task{
+			{TempID: "2.1", Title: "Routes", DependsOn: []string{"1.2"}},
+		}},
+	}}
+
+	var mu sync.Mutex
+	var created []string // explicit IDs in creation order
+	var calls []string   // update and dep add calls
+	adapter := &BeadsAdapter{
+		workingDir: ".",
+		prefix:     "p",
+		idScheme:   IDSchemeETS,
+		waves:      true,
+		create: func(dir string, args ...string) ([]byte, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			for i, arg := range args {
+				if arg == "--id" {
+					created = append(created, args[i+1])
+				}
+			}
+			return nil, nil
+		},
+		run: func(dir string, args ...string) ([]byte, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, strings.Join(args, " "))
+			return nil, nil
+		},
+	}
+
+	result, err := adapter.CreateItems(response, Config{})
+	if err != nil {
+		t.Fatalf("CreateItems() error = %v", err)
+	}
+	if len(result.Failed) != 0 {
+		t.Fatalf("CreateItems() failed items: %+v", result.Failed)
+	}
+
+	// 2.1 waits for 1.2, which waits for its sibling 1.1
+	waves := [][]string{{"p-e1", "p-e2"}, {"p-e1t1"}, {"p-e1t1s1", "p-e1t2"}, {"p-e2t1"}}
+	pos := make(map[string]int)
+	for i, id := range created {
+		pos[id] = i
+	}
+	if len(created) != 6 {
+		t.Fatalf("created %v, want 6 items", created)
+	}
+	for w := 1; w < len(waves); w++ {
+		for _, before := range waves[w-1] {
+			for _, after := range waves[w] {
+				if pos[before] > pos[after] {
+					t.Errorf("%s (wave %d) created after %s (wave %d): %v", before, w-1, after, w, created)
+				}
+			}
+		}
+	}
+
+	want := []string{
+		"update p-e1t1 --parent p-e1",
+		"update p-e1t2 --parent p-e1",
+		"update p-e1t1s1 --parent p-e1t1",
+		"update p-e2t1 --parent p-e2",
+		"dep add p-e1t2 p-e1t1",
+		"dep add p-e2t1 p-e1t2",
+	}
+	for _, call := range want {
+		found := false
+		for _, c := range calls {
+			found = found || c == call
+		}
+		if !found {
+			t.Errorf("missing bd %s in %v", call, calls)
+		}
+	}
+	if result.Stats.Epics != 2 || result.Stats.Tasks != 3 || result.Stats.Subtasks != 1 || result.Stats.Dependencies != 2 {
+		t.Errorf("Stats = %+v, want 2 epics, 3 tasks, 1 subtask, 2 dependencies", result.Stats)
+	}
+	if result.Created[0].ExternalID != "p-e1" || result.Created[1].ExternalID != "p-e2" {
+		t.Errorf("Created should follow wave order, got %+v", result.Created[:2])
+	}
+}
+
+func TestCreateItemsPhasesOrdersByDependencies(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "API", DependsOn: []string{"2"}, Tasks: []core.Task{
+			{TempID: "1.1", Title: "Routes", DependsOn: []string{"1.2"}},
+			{TempID: "1.2", Title: "Schema", DependsOn: []string{"2.1.1"}},
+		}},
+		{TempID: "2", Title: "Setup", Tasks: []core.Task{
+			{TempID: "2.1", Title: "Repo", Subtasks: []core.Subtask{{TempID: "2.1.1", Title: "Init"}}},
+		}},
+		{TempID: "3", Title: "Loop A", DependsOn: []string{"4"}},
+		{TempID: "4", Title: "Loop B", DependsOn: []string{"3"}},
+	}}
+
+	var calls []string // bd create (by --id), update, and dep add calls in order
+	adapter := &BeadsAdapter{
+		workingDir: ".",
+		prefix:     "p",
+		idScheme:   IDSchemeETS,
+		create: func(dir string, args ...string) ([]byte, error) {
+			for i, arg := range args {
+				if arg == "--id" {
+					calls = append(calls, "create "+args[i+1])
+				}
+			}
+			return nil, nil
+		},
+		run: func(dir string, args ...string) ([]byte, error) {
+			calls = append(calls, strings.Join(args, " "))
+			return nil, nil
+		},
+	}
+
+	result, err := adapter.CreateItems(response, Config{})
+	if err != nil {
+		t.Fatalf("CreateItems() error = %v", err)
+	}
+
+	// Blockers first within each level, the cycle in plan order, and each
+	// dependency right after its second end
+	want := []string{
+		"create p-e2",
+		"create p-e1",
+		"dep add p-e1 p-e2",
+		"create p-e3",
+		"create p-e4",
+		"dep add p-e4 p-e3",
+		"dep add p-e3 p-e4",
+		"create p-e1t2",
+		"update p-e1t2 --parent p-e1",
+		"create p-e1t1",
+		"update p-e1t1 --parent p-e1",
+		"dep add p-e1t1 p-e1t2",
+		"create p-e2t1",
+		"update p-e2t1 --parent p-e2",
+		"create p-e2t1s1",
+		"update p-e2t1s1 --parent p-e2t1",
+		"dep add p-e1t2 p-e2t1s1",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("bd calls =\n%s\nwant\n%s", strings.Join(calls, "\n"), strings.Join(want, "\n"))
+	}
+	if result.Stats.Dependencies != 5 || len(result.Failed) != 0 {
+		t.Errorf("Stats = %+v, Failed = %+v, want 5 dependencies and no failures", result.Stats, result.Failed)
+	}
+}
+
+func TestIDCollisions(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "Setup", Tasks: []core.Task{{TempID: "1.1", Title: "Repo"}, {TempID: "1.2", Title: "CI"}}},
+		{TempID: "2", Title: "API"},
+	}}
+	existing := map[string]bool{"p-e1": true, "p-e1t2": true, "p-1-1": true, "other-1": true}
+
+	tests := []struct {
+		scheme string
+		want   []IDCollision
+	}{
+		{IDSchemeETS, []IDCollision{{TempID: "1", Title: "Setup", ID: "p-e1"}, {TempID: "1.2", Title: "CI", ID: "p-e1t2"}}},
+		{IDSchemeDotted, []IDCollision{{TempID: "1.1", Title: "Repo", ID: "p-1-1"}}},
+		{IDSchemeAuto, nil},
+	}
+	for _, tt := range tests {
+		adapter := &BeadsAdapter{prefix: "p", idScheme: tt.scheme}
+		if got := adapter.IDCollisions(response, existing); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: IDCollisions() = %+v, want %+v", tt.scheme, got, tt.want)
+		}
+	}
+}
+
+func TestRetryCreatesOnlyMissingItems(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "Setup", Tasks: []core.Task{
+			{TempID: "1.1", Title: "Repo", Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "Init"}}},
+			{TempID: "1.2", Title: "CI", DependsOn: []string{"1.1"}},
+		}},
+		{TempID: "2", Title: "API", DependsOn: []string{"1"}, Tasks: []core.Task{
+			{TempID: "2.1", Title: "Routes", DependsOn: []string{"1.2"}},
+		}},
+	}}
+
+	for _, strategy := range []string{CreateStrategyPhases, CreateStrategyWaves} {
+		t.Run(strategy, func(t *testing.T) {
+			var mu sync.Mutex
+			var created, calls []string
+			adapter := &BeadsAdapter{
+				workingDir: ".",
+				prefix:     "p",
+				idScheme:   IDSchemeETS,
+				waves:      strategy == CreateStrategyWaves,
+				create: func(dir string, args ...string) ([]byte, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					created = append(created, args[1])
+					return nil, nil
+				},
+				run: func(dir string, args ...string) ([]byte, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					if args[0] == "list" {
+						// The first run created epic 1 and task 1.1; other-1 isn't part of the plan
+						return []byte(`[{"id":"p-e1","title":"Setup"},{"id":"p-e1t1","title":"Repo"},{"id":"other-1","title":"Unrelated"}]`), nil
+					}
+					calls = append(calls, strings.Join(args, " "))
+					return nil, nil
+				},
+			}
+
+			ids, err := adapter.ExistingIssueIDs()
+			if err != nil {
+				t.Fatalf("ExistingIssueIDs() error = %v", err)
+			}
+			if n := adapter.MarkExisting(response, ids); n != 2 {
+				t.Fatalf("MarkExisting() = %d, want 2", n)
+			}
+			result, err := adapter.CreateItems(response, Config{})
+			if err != nil {
+				t.Fatalf("CreateItems() error = %v", err)
+			}
+
+			sort.Strings(created)
+			if got := strings.Join(created, ","); got != "API,CI,Init,Routes" {
+				t.Errorf("created %v, want only the missing Init, CI, API, Routes", created)
+			}
+			if result.Stats.Epics != 1 || result.Stats.Tasks != 2 || result.Stats.Subtasks != 1 {
+				t.Errorf("Stats = %+v, want 1 epic, 2 tasks, 1 subtask", result.Stats)
+			}
+
+			want := map[string]bool{
+				"update p-e1t1s1 --parent p-e1t1": true, // New subtask under an existing task
+				"update p-e1t2 --parent p-e1":     true,
+				"update p-e2t1 --parent p-e2":     true,
+				"dep add p-e1t2 p-e1t1":           true, // New item on an existing blocker
+				"dep add p-e2 p-e1":               true,
+				"dep add p-e2t1 p-e1t2":           true,
+			}
+			for _, call := range calls {
+				if !want[call] {
+					t.Errorf("unexpected bd %s", call)
+				}
+				delete(want, call)
+			}
+			for call := range want {
+				t.Errorf("missing bd %s", call)
+			}
+		})
+	}
+}
+
+func TestCreateItemsWavesRetriesLockedCreates(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "Setup"},
+		{TempID: "2", Title: "API"},
+		{TempID: "3", Title: "Web"},
+	}}
+
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	adapter := &BeadsAdapter{
+		workingDir: ".",
+		prefix:     "p",
+		idScheme:   IDSchemeETS,
+		waves:      true,
+		create: func(dir string, args ...string) ([]byte, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts[args[1]]++
+			if attempts[args[1]] == 1 {
+				return []byte("database is locked"), errors.New("exit status 1")
+			}
+			return nil, nil
+		},
+		run: func(dir string, args ...string) ([]byte, error) { return nil, nil },
+	}
+
+	result, err := adapter.CreateItems(response, Config{})
+	if err != nil {
+		t.Fatalf("CreateItems() error = %v", err)
+	}
+	if result.Stats.Epics != 3 || len(result.Failed) != 0 {
+		t.Errorf("Stats = %+v, Failed = %+v, want 3 epics created after one retry each", result.Stats, result.Failed)
+	}
+	for title, n := range attempts {
+		if n != 2 {
+			t.Errorf("bd create %s attempted %d times, want 2", title, n)
+		}
+	}
+}
+
+func TestEstimateFormatInBeadsArgs(t *testing.T) {
+	minutes := 150
+	subtask := &core.Subtask{TempID: "1.1.1", Title: "Hash passwords", EstimatedMinutes: &minutes}
+	estimateArg := func(opts createOptions) string {
+		args := createArgs(opts)
+		for i, arg := range args {
+			if arg == "--estimate" && i+1 < len(args) {
+				return args[i+1]
+			}
+		}
+		return ""
+	}
+
+	for format, want := range map[string]string{"": "150", EstimateFormatMinutes: "150", EstimateFormatHM: "2h30m"} {
+		adapter := NewBeadsAdapter(Config{EstimateFormat: format})
+		if got := estimateArg(adapter.subtaskOptions(subtask)); got != want {
+			t.Errorf("EstimateFormat %q: --estimate = %q, want %q", format, got, want)
+		}
+	}
+
+	for minutes, want := range map[int]string{30: "30m", 120: "2h", 150: "2h30m"} {
+		if got := formatEstimate(minutes, EstimateFormatHM); got != want {
+			t.Errorf("formatEstimate(%d, hm) = %q, want %q", minutes, got, want)
+		}
+	}
+}
+
+func TestAcceptanceFormatInBeadsArgs(t *testing.T) {
+	epic := &core.Epic{TempID: "1", Title: "Auth", AcceptanceCriteria: []string{
+		"- Users can sign up", "Users can log in", "  ", "2. Sessions expire after 30 minutes",
+	}}
+
+	for format, want := range map[string]string{
+		"":                       "- Users can sign up\n- Users can log in\n- Sessions expire after 30 minutes",
+		AcceptanceFormatBulleted: "- Users can sign up\n- Users can log in\n- Sessions expire after 30 minutes",
+		AcceptanceFormatNumbered: "1. Users can sign up\n2. Users can log in\n3. Sessions expire after 30 minutes",
+		AcceptanceFormatLines:    "Users can sign up\nUsers can log in\nSessions expire after 30 minutes",
+	} {
+		adapter := NewBeadsAdapter(Config{AcceptanceFormat: format})
+		if got := adapter.epicOptions(epic).acceptance; got != want {
+			t.Errorf("AcceptanceFormat %q: --acceptance = %q, want %q", format, got, want)
+		}
+	}
+
+	if got := formatAcceptance(nil, AcceptanceFormatNumbered); got != "" {
+		t.Errorf("formatAcceptance(nil) = %q, want empty", got)
+	}
+}
+
+func TestCreateItemsRecordsTiming(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "Setup", Tasks: []core.Task{
+			{TempID: "1.1", Title: "Repo", Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "Init"}}},
+			{TempID: "1.2", Title: "CI"},
+		}},
+	}}
+	delays := map[string]time.Duration{"Setup": 5 * time.Millisecond, "Repo": 30 * time.Millisecond, "Init": 15 * time.Millisecond, "CI": 0}
+
+	for _, strategy := range []string{CreateStrategyPhases, CreateStrategyWaves} {
+		t.Run(strategy, func(t *testing.T) {
+			adapter := &BeadsAdapter{
+				workingDir: ".",
+				prefix:     "p",
+				idScheme:   IDSchemeETS,
+				waves:      strategy == CreateStrategyWaves,
+				create: func(dir string, args ...string) ([]byte, error) {
+					time.Sleep(delays[args[1]])
+					return nil, nil
+				},
+				run: func(dir string, args ...string) ([]byte, error) { return nil, nil },
+			}
+
+			result, err := adapter.CreateItems(response, Config{})
+			if err != nil {
+				t.Fatalf("CreateItems() error = %v", err)
+			}
+
+			var titles []string
+			for _, item := range result.Slowest(3) {
+				titles = append(titles, item.Title)
+			}
+			if got := strings.Join(titles, ","); got != "Repo,Init,Setup" {
+				t.Errorf("Slowest(3) = %s, want Repo,Init,Setup", got)
+			}
+			for _, item := range result.Created {
+				if item.Duration < delays[item.Title] {
+					t.Errorf("%s took %v, want at least the simulated %v", item.Title, item.Duration, delays[item.Title])
+				}
+			}
+			// Tasks 1.1 and 1.2 are in one wave, but each wave waits on the one before
+			if min := 50 * time.Millisecond; result.Elapsed < min {
+				t.Errorf("Elapsed = %v, want at least %v", result.Elapsed, min)
+			}
+			if n := len(result.Slowest(10)); n != 4 {
+				t.Errorf("Slowest(10) returned %d items, want all 4", n)
+			}
+		})
+	}
+}
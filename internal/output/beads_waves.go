@@ -0,0 +1,142 @@
+package output
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// waveParallelism bounds concurrent bd calls within a wave. They share one
+// beads database, so creates and links retry when another write holds it.
+const waveParallelism = 4
+
+// createItemsWaves creates the plan one dependency wave at a time (see
+// core.CreationWaves). Items in a wave are created concurrently, and each is
+// given its parent and dependencies as soon as it exists, since those were all
+// created in earlier waves. Items whose parent failed are skipped, as in phases.
+func (a *BeadsAdapter) createItemsWaves(waves [][]core.ItemRef) *CreateResult {
+	result := &CreateResult{
+		Created:      []CreatedItem{},
+		Failed:       []FailedItem{},
+		Dependencies: []Dependency{},
+		Stats:        Stats{},
+	}
+	tempToExternal := make(map[string]string)
+	for tempID, id := range a.existing {
+		tempToExternal[tempID] = id
+	}
+
+	for _, wave := range waves {
+		// tempToExternal is only read during a wave, and updated between waves
+		outcomes := make([]*CreateResult, len(wave))
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, waveParallelism)
+		for i, item := range wave {
+			wg.Add(1)
+			go func(i int, item core.ItemRef) {
+				defer wg.Done()
+				sem <- struct{}{}        // Acquire
+				defer func() { <-sem }() // Release
+				outcomes[i] = a.createWaveItem(item, tempToExternal)
+			}(i, item)
+		}
+		wg.Wait()
+
+		// Merge in wave order so the result doesn't depend on scheduling
+		for _, outcome := range outcomes {
+			for _, created := range outcome.Created {
+				tempToExternal[created.TempID] = created.ExternalID
+			}
+			result.Created = append(result.Created, outcome.Created...)
+			result.Failed = append(result.Failed, outcome.Failed...)
+			result.Dependencies = append(result.Dependencies, outcome.Dependencies...)
+			result.Stats.Epics += outcome.Stats.Epics
+			result.Stats.Tasks += outcome.Stats.Tasks
+			result.Stats.Subtasks += outcome.Stats.Subtasks
+			result.Stats.Dependencies += outcome.Stats.Dependencies
+		}
+	}
+
+	return result
+}
+
+// createWaveItem creates one item, sets its parent, and links its dependencies,
+// returning what it did as a CreateResult. An item that already exists is only
+// linked to its dependencies.
+func (a *BeadsAdapter) createWaveItem(item core.ItemRef, tempToExternal map[string]string) *CreateResult {
+	result := &CreateResult{}
+
+	var opts createOptions
+	switch item.Level {
+	case core.LevelEpic:
+		opts = a.epicOptions(item.Epic)
+	case core.LevelTask:
+		opts = a.taskOptions(item.Task)
+	default:
+		opts = a.subtaskOptions(item.Subtask)
+	}
+	work := WorkItem{Type: item.Level, TempID: item.TempID(), Title: item.Title(), ParentTempID: item.ParentTempID()}
+
+	parentID := ""
+	if work.ParentTempID != "" {
+		var ok bool
+		if parentID, ok = tempToExternal[work.ParentTempID]; !ok {
+			return result
+		}
+	}
+
+	if id := a.existing[work.TempID]; id != "" {
+		a.linkWaveDependencies(result, item, id, tempToExternal)
+		return result
+	}
+
+	start := time.Now()
+	id, err := a.runBdCreate(opts)
+	if err != nil {
+		result.Failed = append(result.Failed, FailedItem{Item: work, Error: err.Error()})
+		return result
+	}
+	result.Created = append(result.Created, CreatedItem{
+		ExternalID:       id,
+		TempID:           work.TempID,
+		Type:             work.Type,
+		Title:            work.Title,
+		ParentExternalID: parentID,
+		Duration:         time.Since(start),
+	})
+	switch item.Level {
+	case core.LevelEpic:
+		result.Stats.Epics++
+	case core.LevelTask:
+		result.Stats.Tasks++
+	default:
+		result.Stats.Subtasks++
+	}
+	a.writeCustomFields(result, work, id, opts)
+
+	// Set parent after creation (can't use both --id and --parent)
+	if parentID != "" {
+		if err := a.setParent(id, parentID); err != nil {
+			result.Failed = append(result.Failed, FailedItem{
+				Item:  WorkItem{Type: "parent", TempID: work.TempID, Title: work.Title, ParentTempID: work.ParentTempID},
+				Error: err.Error(),
+			})
+		}
+	}
+
+	a.linkWaveDependencies(result, item, id, tempToExternal)
+	return result
+}
+
+// linkWaveDependencies links item, created as id, to its dependencies from earlier waves.
+func (a *BeadsAdapter) linkWaveDependencies(result *CreateResult, item core.ItemRef, id string, tempToExternal map[string]string) {
+	// linkDependencies looks the item up by temp ID, so give it a view with its own ID
+	known := map[string]string{item.TempID(): id}
+	for _, dep := range item.DependsOn() {
+		if depID, ok := tempToExternal[dep]; ok {
+			known[dep] = depID
+		}
+	}
+	a.linkDependencies(result, known, WorkItem{Type: item.Level, TempID: item.TempID(), Title: item.Title()}, item.DependsOn())
+}
@@ -0,0 +1,51 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// Capabilities describes which plan features an adapter represents natively.
+type Capabilities struct {
+	Hierarchy    bool // Parent/child links between epics, tasks, and subtasks
+	Dependencies bool // depends_on relations between items
+	Labels       bool // Item labels
+	Estimates    bool // Time estimates
+}
+
+// CapabilityGaps lists the features used by response that adapter can't
+// represent, e.g. "csv adapter flattens dependencies".
+func CapabilityGaps(adapter Adapter, response *core.ParseResponse) []string {
+	caps := adapter.Capabilities()
+
+	var hasChildren, hasDeps, hasLabels, hasEstimates bool
+	_ = core.WalkItems(response, func(item core.ItemRef) error {
+		hasChildren = hasChildren || item.Level != core.LevelEpic
+		hasDeps = hasDeps || len(item.DependsOn()) > 0
+		switch item.Level {
+		case core.LevelEpic:
+			hasLabels = hasLabels || len(item.Epic.Labels) > 0
+			hasEstimates = hasEstimates || item.Epic.EstimatedDays != nil
+		case core.LevelTask:
+			hasLabels = hasLabels || len(item.Task.Labels) > 0
+			hasEstimates = hasEstimates || item.Task.EstimatedHours != nil
+		case core.LevelSubtask:
+			hasLabels = hasLabels || len(item.Subtask.Labels) > 0
+			hasEstimates = hasEstimates || item.Subtask.EstimatedMinutes != nil
+		}
+		return nil
+	})
+
+	var gaps []string
+	gap := func(used, supported bool, what string) {
+		if used && !supported {
+			gaps = append(gaps, fmt.Sprintf("%s adapter %s", adapter.Name(), what))
+		}
+	}
+	gap(hasChildren, caps.Hierarchy, "flattens the epic/task/subtask hierarchy")
+	gap(hasDeps, caps.Dependencies, "flattens dependencies")
+	gap(hasLabels, caps.Labels, "drops labels")
+	gap(hasEstimates, caps.Estimates, "drops estimates")
+	return gaps
+}
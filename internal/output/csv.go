@@ -0,0 +1,102 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// csvColumns is the CSV adapter's header row.
+var csvColumns = []string{"temp_id", "type", "title", "parent_temp_id", "priority", "estimate_minutes", "labels", "depends_on"}
+
+// CSVAdapter flattens the plan into spreadsheet rows, one per epic, task, and
+// subtask in plan order: CSV by default, tab-separated for a .tsv output path.
+type CSVAdapter struct {
+	outputPath   string
+	dryRun       bool
+	epicPriority core.Priority // Priority for epics (empty = high)
+}
+
+// NewCSVAdapter creates a CSV adapter.
+func NewCSVAdapter(config Config, outputPath string) *CSVAdapter {
+	return &CSVAdapter{
+		outputPath:   outputPath,
+		dryRun:       config.DryRun,
+		epicPriority: config.EpicPriority,
+	}
+}
+
+func (a *CSVAdapter) Name() string {
+	return "csv"
+}
+
+func (a *CSVAdapter) IsAvailable() (bool, error) {
+	return true, nil // Always available
+}
+
+func (a *CSVAdapter) Capabilities() Capabilities {
+	return Capabilities{Hierarchy: true, Dependencies: true, Labels: true, Estimates: true} // As columns
+}
+
+func (a *CSVAdapter) CreateItems(response *core.ParseResponse, config Config) (*CreateResult, error) {
+	output, err := a.Render(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render CSV: %w", err)
+	}
+
+	if a.dryRun {
+		fmt.Println("[dry-run] Would write:")
+		fmt.Print(output)
+	} else if a.outputPath != "" {
+		if err := os.WriteFile(a.outputPath, []byte(output), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write file: %w", err)
+		}
+		fmt.Printf("Rows written to %s\n", a.outputPath)
+	} else {
+		fmt.Print(output)
+	}
+
+	return planResult(response), nil
+}
+
+// Render renders response as a header row and a row per item, with the
+// priority and estimate the other adapters give each item. Labels and
+// depends_on are joined with ";".
+func (a *CSVAdapter) Render(response *core.ParseResponse) (string, error) {
+	records := [][]string{csvColumns}
+	_ = core.WalkItems(response, func(item core.ItemRef) error {
+		priority, _ := itemPriority(item, a.epicPriority)
+
+		var estimate string
+		if minutes, ok := itemEstimateMinutes(item); ok {
+			estimate = strconv.Itoa(minutes)
+		}
+
+		records = append(records, []string{
+			item.TempID(),
+			item.Level,
+			item.Title(),
+			item.ParentTempID(),
+			string(priority),
+			estimate,
+			strings.Join(item.Labels(), ";"),
+			strings.Join(item.DependsOn(), ";"),
+		})
+		return nil
+	})
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if strings.HasSuffix(a.outputPath, ".tsv") {
+		w.Comma = '\t'
+	}
+	if err := w.WriteAll(records); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
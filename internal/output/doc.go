@@ -0,0 +1,92 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// RenderPlanDoc renders a human-readable Markdown record of a created plan.
+// Each item is annotated with the external ID assigned by the target system
+// (from result.Created), so the doc can be shared as a map into the tracker.
+func RenderPlanDoc(response *core.ParseResponse, result *CreateResult, adapterName string) string {
+	// Map "type:temp_id" -> external ID
+	externalIDs := make(map[string]string)
+	if result != nil {
+		for _, c := range result.Created {
+			externalIDs[c.Type+":"+c.TempID] = c.ExternalID
+		}
+	}
+
+	idFor := func(itemType, tempID string) string {
+		if id, ok := externalIDs[itemType+":"+tempID]; ok {
+			return fmt.Sprintf("`%s`", id)
+		}
+		return "_(not created)_"
+	}
+
+	var sb strings.Builder
+
+	title := response.Project.ProductName
+	if title == "" {
+		title = "Plan"
+	}
+	sb.WriteString(fmt.Sprintf("# %s\n\n", title))
+	if response.Project.ElevatorPitch != "" {
+		sb.WriteString(response.Project.ElevatorPitch + "\n\n")
+	}
+	if adapterName != "" {
+		sb.WriteString(fmt.Sprintf("Created in: %s\n\n", adapterName))
+	}
+
+	for _, epic := range response.Epics {
+		sb.WriteString(fmt.Sprintf("## %s Epic %s: %s\n\n", idFor("epic", epic.TempID), epic.TempID, epic.Title))
+		if epic.Description != "" {
+			sb.WriteString(epic.Description + "\n\n")
+		}
+		for _, block := range []string{core.FormatContextBlock(epic.Context), core.FormatTesting(&epic.Testing)} {
+			if block != "" {
+				sb.WriteString(block + "\n\n")
+			}
+		}
+		if epic.SourceHint != nil && *epic.SourceHint != "" {
+			sb.WriteString(fmt.Sprintf("Source: %s\n\n", *epic.SourceHint))
+		}
+		if len(epic.DependsOn) > 0 {
+			sb.WriteString(fmt.Sprintf("Depends on: %s\n\n", strings.Join(epic.DependsOn, ", ")))
+		}
+
+		for _, task := range epic.Tasks {
+			source := ""
+			if task.SourceHint != nil && *task.SourceHint != "" {
+				source = fmt.Sprintf(" _(source: %s)_", *task.SourceHint)
+			}
+			sb.WriteString(fmt.Sprintf("- %s **%s** %s%s\n", idFor("task", task.TempID), task.TempID, task.Title, source))
+			for _, subtask := range task.Subtasks {
+				sb.WriteString(fmt.Sprintf("  - %s %s %s\n", idFor("subtask", subtask.TempID), subtask.TempID, subtask.Title))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	if result != nil && len(result.Failed) > 0 {
+		sb.WriteString("## Failed Items\n\n")
+		for _, f := range result.Failed {
+			sb.WriteString(fmt.Sprintf("- %s %s: %s (%s)\n", f.Item.Type, f.Item.TempID, f.Item.Title, f.Error))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// WritePlanDoc writes the Markdown record of a created plan to path.
+func WritePlanDoc(path string, response *core.ParseResponse, result *CreateResult, adapterName string) error {
+	doc := RenderPlanDoc(response, result, adapterName)
+	if err := os.WriteFile(path, []byte(doc), 0644); err != nil {
+		return fmt.Errorf("failed to write plan doc: %w", err)
+	}
+	return nil
+}
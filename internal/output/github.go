@@ -0,0 +1,725 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// GitHub API endpoints: GraphQL for issues and projects, REST for labels.
+const (
+	githubGraphQLEndpoint = "https://api.github.com/graphql"
+	githubRESTEndpoint    = "https://api.github.com"
+)
+
+// GitHub rate limits: a rate-limited request is retried up to
+// githubRateLimitRetries times, after the wait GitHub asks for, if that is at
+// most githubMaxRetryWait.
+const (
+	githubRateLimitRetries = 3
+	githubMaxRetryWait     = 2 * time.Minute
+)
+
+// httpDoer sends HTTP requests; *http.Client satisfies it.
+type httpDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// GitHubAdapter creates the plan as GitHub issues. Tasks become sub-issues of
+// their epic and subtasks of their task, and each issue's body lists its
+// priority, estimate, and depends_on as issue references. Labels the
+// repository doesn't have yet are created, colored by category (LabelColors).
+// With a project number, every issue is also added to that Project (v2) and
+// its Priority and Estimate fields are set.
+type GitHubAdapter struct {
+	owner          string
+	repo           string
+	project        int // Project (v2) number under owner; 0 adds issues to no project
+	token          string
+	endpoint       string // GraphQL endpoint
+	restEndpoint   string // REST API root, for creating labels
+	dryRun         bool
+	includeContext map[string]bool // Levels (core.Level*) whose bodies get context
+	includeTesting bool
+	epicPriority   core.Priority // Priority for epics (empty = high)
+	acceptanceFmt  string
+	labelColors    *LabelColors
+
+	doer         httpDoer            // Sends GraphQL requests; nil uses a default client
+	sleep        func(time.Duration) // Waits out rate limits; nil uses time.Sleep
+	dryRunIssues int                 // Issues "created" so far in a dry run
+}
+
+// NewGitHubAdapter creates a GitHub adapter for config.GitHubRepo
+// ("owner/name"), authenticated with GITHUB_TOKEN or GH_TOKEN.
+func NewGitHubAdapter(config Config) *GitHubAdapter {
+	owner, repo, _ := strings.Cut(config.GitHubRepo, "/")
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	return &GitHubAdapter{
+		owner:          owner,
+		repo:           repo,
+		project:        config.GitHubProject,
+		token:          token,
+		endpoint:       githubGraphQLEndpoint,
+		restEndpoint:   githubRESTEndpoint,
+		dryRun:         config.DryRun,
+		includeContext: contextLevels(config),
+		includeTesting: config.IncludeTesting,
+		epicPriority:   config.EpicPriority,
+		acceptanceFmt:  config.AcceptanceFormat,
+		labelColors:    NewLabelColors(config.LabelColors),
+	}
+}
+
+func (a *GitHubAdapter) Name() string {
+	return "github"
+}
+
+func (a *GitHubAdapter) IsAvailable() (bool, error) {
+	if a.owner == "" || a.repo == "" {
+		return false, fmt.Errorf("no GitHub repository (use owner/name)")
+	}
+	if a.token == "" && !a.dryRun {
+		return false, fmt.Errorf("GITHUB_TOKEN or GH_TOKEN is not set")
+	}
+	return true, nil
+}
+
+func (a *GitHubAdapter) Capabilities() Capabilities {
+	// Dependencies are issue references in the body, estimates are in the body
+	// and the project's Estimate field
+	return Capabilities{Hierarchy: true, Dependencies: true, Labels: true, Estimates: true}
+}
+
+// githubIssue is a created issue: its node ID for mutations and its number
+// for references.
+type githubIssue struct {
+	ID     string `json:"id"`
+	Number int    `json:"number"`
+}
+
+func (i githubIssue) ref() string {
+	return fmt.Sprintf("#%d", i.Number)
+}
+
+// githubProject is a Project (v2) and the fields the adapter sets.
+type githubProject struct {
+	id       string
+	priority *githubField // nil if the project has no Priority field
+	estimate *githubField // nil if the project has no Estimate field
+}
+
+// githubField is a project field. Options maps each single-select option's
+// lowercased name to its ID.
+type githubField struct {
+	id       string
+	dataType string
+	options  map[string]string
+}
+
+// CreateItems creates an issue per item, level by level with blockers first
+// (as the beads adapter does), so most depends_on entries can reference an
+// issue number; the rest stay temp_ids. Items whose parent failed are skipped.
+// In a dry run the mutations are printed and nothing is sent.
+//
+// depends_on entries are only references in issue bodies, not links GitHub
+// tracks, so they aren't reported as Dependencies. Nothing records which
+// issues a run created, so when some fail a warning says that rerunning
+// duplicates the rest.
+func (a *GitHubAdapter) CreateItems(response *core.ParseResponse, config Config) (*CreateResult, error) {
+	var repoID string
+	repoLabels := make(map[string]string) // Lowercased label name -> label ID
+	var project *githubProject
+	if !a.dryRun {
+		var err error
+		if repoID, repoLabels, err = a.loadRepository(); err != nil {
+			return nil, err
+		}
+		if a.project != 0 {
+			if project, err = a.loadProject(); err != nil {
+				return nil, err
+			}
+			if project.priority == nil {
+				config.Warnings.Add(core.WarnAdapterCapability, "", "project %d has no Priority field; priorities are only in issue bodies", a.project)
+			}
+			if project.estimate == nil {
+				config.Warnings.Add(core.WarnAdapterCapability, "", "project %d has no Estimate field; estimates are only in issue bodies", a.project)
+			}
+		}
+	} else if a.project != 0 {
+		project = &githubProject{id: "dry-run", priority: &githubField{}, estimate: &githubField{}}
+	}
+
+	result := &CreateResult{
+		Created:      []CreatedItem{},
+		Failed:       []FailedItem{},
+		Dependencies: []Dependency{},
+		Stats:        Stats{},
+	}
+	issues := make(map[string]githubIssue) // temp_id -> created issue
+	deps := indexDependencies(response)
+	labelIDs := a.ensureLabels(response, repoLabels, config.Warnings)
+
+	for _, level := range []string{core.LevelEpic, core.LevelTask, core.LevelSubtask} {
+		for _, tempID := range core.TopoSortTempIDs(deps.levels[level], deps.dependsOn) {
+			item := deps.refs[tempID]
+			parentTempID := item.ParentTempID()
+			parent, hasParent := issues[parentTempID]
+			if parentTempID != "" && !hasParent {
+				continue
+			}
+			work := WorkItem{Type: level, TempID: tempID, Title: item.Title(), ParentTempID: parentTempID}
+
+			start := time.Now()
+			issue, err := a.createIssue(repoID, item.Title(), a.issueBody(item, issues), itemLabelIDs(item, labelIDs))
+			if err != nil {
+				result.Failed = append(result.Failed, FailedItem{Item: work, Error: err.Error()})
+				continue
+			}
+			issues[tempID] = issue
+			created := CreatedItem{
+				ExternalID: issue.ref(),
+				TempID:     tempID,
+				Type:       level,
+				Title:      item.Title(),
+				Duration:   time.Since(start),
+			}
+			if hasParent {
+				created.ParentExternalID = parent.ref()
+			}
+			result.Created = append(result.Created, created)
+			switch level {
+			case core.LevelEpic:
+				result.Stats.Epics++
+			case core.LevelTask:
+				result.Stats.Tasks++
+			default:
+				result.Stats.Subtasks++
+			}
+
+			if hasParent {
+				if err := a.addSubIssue(parent, issue); err != nil {
+					failed := work
+					failed.Type = "parent"
+					result.Failed = append(result.Failed, FailedItem{Item: failed, Error: err.Error()})
+				}
+			}
+			if project != nil {
+				a.addToProject(result, config.Warnings, project, work, issue, item)
+			}
+		}
+	}
+
+	if len(result.Failed) > 0 && len(result.Created) > 0 {
+		config.Warnings.Add(core.WarnAdapterCapability, "",
+			"%d GitHub items failed; rerunning creates all %d issues created in this run again, so delete them first or create the failed items by hand",
+			len(result.Failed), len(result.Created))
+	}
+	return result, nil
+}
+
+// issueBody is the Markdown body of item's issue: its priority and estimate,
+// description with the enabled context and testing blocks, acceptance
+// criteria or design notes, source, and dependencies, referencing the issues
+// already created and the temp_ids of the rest.
+func (a *GitHubAdapter) issueBody(item core.ItemRef, issues map[string]githubIssue) string {
+	var sb strings.Builder
+	priority, _ := itemPriority(item, a.epicPriority)
+	var estimate string
+	switch item.Level {
+	case core.LevelEpic:
+		if item.Epic.EstimatedDays != nil {
+			estimate = strconv.FormatFloat(*item.Epic.EstimatedDays, 'f', -1, 64) + " days"
+		}
+		writeBlock(&sb, "", itemFacts(priority, estimate, nil, nil))
+		writeBlock(&sb, "", itemDescription(a.includeContext, a.includeTesting, item.Level, item.Epic.Description, item.Epic.Context, &item.Epic.Testing))
+		if len(item.Epic.AcceptanceCriteria) > 0 {
+			writeBlock(&sb, "", "**Acceptance Criteria:**\n"+formatAcceptance(item.Epic.AcceptanceCriteria, a.acceptanceFmt))
+		}
+		writeBlock(&sb, "", sourceHintBlock(item.Epic.SourceHint))
+	case core.LevelTask:
+		if item.Task.EstimatedHours != nil {
+			estimate = strconv.FormatFloat(*item.Task.EstimatedHours, 'f', -1, 64) + "h"
+		}
+		writeBlock(&sb, "", itemFacts(priority, estimate, nil, nil))
+		writeBlock(&sb, "", itemDescription(a.includeContext, a.includeTesting, item.Level, item.Task.Description, item.Task.Context, &item.Task.Testing))
+		if item.Task.DesignNotes != nil && *item.Task.DesignNotes != "" {
+			writeBlock(&sb, "", "**Design Notes:** "+*item.Task.DesignNotes)
+		}
+		writeBlock(&sb, "", sourceHintBlock(item.Task.SourceHint))
+	default:
+		if item.Subtask.EstimatedMinutes != nil {
+			estimate = strconv.Itoa(*item.Subtask.EstimatedMinutes) + " min"
+		}
+		writeBlock(&sb, "", itemFacts("", estimate, nil, nil))
+		writeBlock(&sb, "", itemDescription(a.includeContext, a.includeTesting, item.Level, item.Subtask.Description, item.Subtask.Context, &item.Subtask.Testing))
+	}
+
+	var refs []string
+	for _, dep := range item.DependsOn() {
+		if issue, ok := issues[dep]; ok {
+			refs = append(refs, issue.ref())
+		} else {
+			refs = append(refs, dep)
+		}
+	}
+	if len(refs) > 0 {
+		writeBlock(&sb, "", "**Depends on:** "+strings.Join(refs, ", "))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// itemEstimateHours is item's estimate in hours, converted from the minutes
+// the other adapters use. ok is false if the item has none.
+func itemEstimateHours(item core.ItemRef) (hours float64, ok bool) {
+	minutes, ok := itemEstimateMinutes(item)
+	return float64(minutes) / 60, ok
+}
+
+// addToProject adds issue to the project and sets its Priority and Estimate
+// fields, recording failures in result. A priority with no matching option,
+// or an Estimate field that isn't a number, is a warning.
+func (a *GitHubAdapter) addToProject(result *CreateResult, warnings *core.WarningCollector, project *githubProject, work WorkItem, issue githubIssue, item core.ItemRef) {
+	itemID, err := a.addProjectItem(project, issue)
+	if err != nil {
+		work.Type = "project"
+		result.Failed = append(result.Failed, FailedItem{Item: work, Error: err.Error()})
+		return
+	}
+
+	if p, ok := itemPriority(item, a.epicPriority); ok && project.priority != nil {
+		if a.dryRun {
+			fmt.Printf("[dry-run] set Priority of %s to %s\n", issue.ref(), p)
+		} else if optionID, ok := project.priority.option(p); ok {
+			if err := a.setFieldValue(project, project.priority, itemID, map[string]interface{}{"singleSelectOptionId": optionID}); err != nil {
+				work.Type = "field"
+				result.Failed = append(result.Failed, FailedItem{Item: work, Error: fmt.Sprintf("Priority: %v", err)})
+			}
+		} else {
+			warnings.Add(core.WarnAdapterCapability, work.TempID, "project Priority field has no option for %s (or P%d)", p, mapPriority(p))
+		}
+	}
+
+	if hours, ok := itemEstimateHours(item); ok && project.estimate != nil {
+		if a.dryRun {
+			fmt.Printf("[dry-run] set Estimate of %s to %s\n", issue.ref(), strconv.FormatFloat(hours, 'f', -1, 64))
+		} else if project.estimate.dataType == "NUMBER" {
+			if err := a.setFieldValue(project, project.estimate, itemID, map[string]interface{}{"number": hours}); err != nil {
+				work.Type = "field"
+				result.Failed = append(result.Failed, FailedItem{Item: work, Error: fmt.Sprintf("Estimate: %v", err)})
+			}
+		} else {
+			warnings.Add(core.WarnAdapterCapability, work.TempID, "project Estimate field is %s, not a number; estimate left unset", project.estimate.dataType)
+		}
+	}
+}
+
+// option returns the ID of the single-select option for p: the one named
+// after the priority ("high", "very-low") or its P-level ("P1"), ignoring case.
+func (f *githubField) option(p core.Priority) (string, bool) {
+	for _, name := range []string{string(p), fmt.Sprintf("p%d", mapPriority(p))} {
+		if id, ok := f.options[name]; ok {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// loadRepository looks up the node ID of the target repository and its labels
+// (the first 100), by lowercased name.
+func (a *GitHubAdapter) loadRepository() (string, map[string]string, error) {
+	const query = `query($owner: String!, $name: String!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    id
+    labels(first: 100, after: $after) {
+      nodes { id name }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`
+	labels := make(map[string]string)
+	variables := map[string]interface{}{"owner": a.owner, "name": a.repo}
+	for {
+		var data struct {
+			Repository *struct {
+				ID     string `json:"id"`
+				Labels struct {
+					Nodes []struct {
+						ID   string `json:"id"`
+						Name string `json:"name"`
+					} `json:"nodes"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"labels"`
+			} `json:"repository"`
+		}
+		if err := a.graphql(query, variables, &data); err != nil {
+			return "", nil, fmt.Errorf("failed to look up %s/%s: %w", a.owner, a.repo, err)
+		}
+		if data.Repository == nil {
+			return "", nil, fmt.Errorf("repository %s/%s not found", a.owner, a.repo)
+		}
+		for _, label := range data.Repository.Labels.Nodes {
+			labels[strings.ToLower(label.Name)] = label.ID
+		}
+		// Labels past the first page would otherwise look missing, and fail to create
+		page := data.Repository.Labels.PageInfo
+		if !page.HasNextPage || page.EndCursor == "" {
+			return data.Repository.ID, labels, nil
+		}
+		variables["after"] = page.EndCursor
+	}
+}
+
+// ensureLabels returns the label ID of every label the plan uses, by lowercased
+// name, creating the ones not in existing in their LabelColors color. A label
+// that can't be created is a warning, and is left off its issues.
+func (a *GitHubAdapter) ensureLabels(response *core.ParseResponse, existing map[string]string, warnings *core.WarningCollector) map[string]string {
+	ids := make(map[string]string, len(existing))
+	for name, id := range existing {
+		ids[name] = id
+	}
+	_ = core.WalkItems(response, func(item core.ItemRef) error {
+		for _, label := range item.Labels() {
+			key := strings.ToLower(label)
+			if _, ok := ids[key]; ok {
+				continue
+			}
+			id, err := a.createLabel(label, a.labelColors.Color(label))
+			if err != nil {
+				warnings.Add(core.WarnAdapterCapability, label, "label not created, so it's left off its issues: %v", err)
+			}
+			ids[key] = id
+		}
+		return nil
+	})
+	return ids
+}
+
+// itemLabelIDs returns the IDs of item's labels that exist.
+func itemLabelIDs(item core.ItemRef, labelIDs map[string]string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, label := range item.Labels() {
+		if id := labelIDs[strings.ToLower(label)]; id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// createLabel creates a label in the repository, returning its node ID.
+func (a *GitHubAdapter) createLabel(name, color string) (string, error) {
+	if a.dryRun {
+		fmt.Printf("[dry-run] create label %s in %s/%s (color %s)\n", name, a.owner, a.repo, color)
+		return "dry-run-" + name, nil
+	}
+
+	var label struct {
+		NodeID string `json:"node_id"`
+	}
+	path := fmt.Sprintf("/repos/%s/%s/labels", a.owner, a.repo)
+	if err := a.rest("POST", path, map[string]interface{}{"name": name, "color": color}, &label); err != nil {
+		return "", fmt.Errorf("create label failed: %w", err)
+	}
+	return label.NodeID, nil
+}
+
+// githubProjectFields is the selection of a project's ID and fields, for
+// users and organizations alike.
+const githubProjectFields = `projectV2(number: $number) {
+      id
+      fields(first: 50) {
+        nodes {
+          ... on ProjectV2Field { id name dataType }
+          ... on ProjectV2SingleSelectField { id name dataType options { id name } }
+        }
+      }
+    }`
+
+// loadProject looks up the project numbered a.project under the repository
+// owner and its Priority and Estimate fields (matched ignoring case).
+func (a *GitHubAdapter) loadProject() (*githubProject, error) {
+	query := `query($owner: String!, $number: Int!) {
+  repositoryOwner(login: $owner) {
+    ... on User { ` + githubProjectFields + ` }
+    ... on Organization { ` + githubProjectFields + ` }
+  }
+}`
+	var data struct {
+		RepositoryOwner *struct {
+			ProjectV2 *struct {
+				ID     string `json:"id"`
+				Fields struct {
+					Nodes []struct {
+						ID       string `json:"id"`
+						Name     string `json:"name"`
+						DataType string `json:"dataType"`
+						Options  []struct {
+							ID   string `json:"id"`
+							Name string `json:"name"`
+						} `json:"options"`
+					} `json:"nodes"`
+				} `json:"fields"`
+			} `json:"projectV2"`
+		} `json:"repositoryOwner"`
+	}
+	if err := a.graphql(query, map[string]interface{}{"owner": a.owner, "number": a.project}, &data); err != nil {
+		return nil, fmt.Errorf("failed to look up project %d: %w", a.project, err)
+	}
+	if data.RepositoryOwner == nil || data.RepositoryOwner.ProjectV2 == nil {
+		return nil, fmt.Errorf("project %d not found for %s", a.project, a.owner)
+	}
+
+	project := &githubProject{id: data.RepositoryOwner.ProjectV2.ID}
+	for _, node := range data.RepositoryOwner.ProjectV2.Fields.Nodes {
+		field := &githubField{id: node.ID, dataType: node.DataType, options: make(map[string]string)}
+		for _, option := range node.Options {
+			field.options[strings.ToLower(option.Name)] = option.ID
+		}
+		switch strings.ToLower(node.Name) {
+		case "priority":
+			if len(node.Options) > 0 {
+				project.priority = field
+			}
+		case "estimate":
+			project.estimate = field
+		}
+	}
+	return project, nil
+}
+
+// createIssue creates an issue in the repository with the given labels.
+func (a *GitHubAdapter) createIssue(repoID, title, body string, labelIDs []string) (githubIssue, error) {
+	if a.dryRun {
+		a.dryRunIssues++
+		fmt.Printf("[dry-run] createIssue %s/%s: %s\n", a.owner, a.repo, title)
+		return githubIssue{ID: fmt.Sprintf("dry-%d", a.dryRunIssues), Number: a.dryRunIssues}, nil
+	}
+
+	const mutation = `mutation($input: CreateIssueInput!) {
+  createIssue(input: $input) { issue { id number } }
+}`
+	var data struct {
+		CreateIssue struct {
+			Issue githubIssue `json:"issue"`
+		} `json:"createIssue"`
+	}
+	input := map[string]interface{}{"repositoryId": repoID, "title": title, "body": body}
+	if len(labelIDs) > 0 {
+		input["labelIds"] = labelIDs
+	}
+	if err := a.graphql(mutation, map[string]interface{}{"input": input}, &data); err != nil {
+		return githubIssue{}, fmt.Errorf("createIssue failed: %w", err)
+	}
+	return data.CreateIssue.Issue, nil
+}
+
+// addSubIssue makes child a sub-issue of parent.
+func (a *GitHubAdapter) addSubIssue(parent, child githubIssue) error {
+	if a.dryRun {
+		fmt.Printf("[dry-run] addSubIssue %s under %s\n", child.ref(), parent.ref())
+		return nil
+	}
+
+	const mutation = `mutation($input: AddSubIssueInput!) {
+  addSubIssue(input: $input) { issue { id } }
+}`
+	input := map[string]interface{}{"issueId": parent.ID, "subIssueId": child.ID}
+	if err := a.graphql(mutation, map[string]interface{}{"input": input}, nil); err != nil {
+		return fmt.Errorf("addSubIssue failed: %w", err)
+	}
+	return nil
+}
+
+// addProjectItem adds issue to the project, returning the project item ID.
+func (a *GitHubAdapter) addProjectItem(project *githubProject, issue githubIssue) (string, error) {
+	if a.dryRun {
+		fmt.Printf("[dry-run] addProjectV2ItemById %s to project %d\n", issue.ref(), a.project)
+		return "dry-run", nil
+	}
+
+	const mutation = `mutation($input: AddProjectV2ItemByIdInput!) {
+  addProjectV2ItemById(input: $input) { item { id } }
+}`
+	var data struct {
+		AddProjectV2ItemByID struct {
+			Item struct {
+				ID string `json:"id"`
+			} `json:"item"`
+		} `json:"addProjectV2ItemById"`
+	}
+	input := map[string]interface{}{"projectId": project.id, "contentId": issue.ID}
+	if err := a.graphql(mutation, map[string]interface{}{"input": input}, &data); err != nil {
+		return "", fmt.Errorf("addProjectV2ItemById failed: %w", err)
+	}
+	return data.AddProjectV2ItemByID.Item.ID, nil
+}
+
+// setFieldValue sets field on a project item to value, a ProjectV2FieldValue
+// such as {"number": 4}.
+func (a *GitHubAdapter) setFieldValue(project *githubProject, field *githubField, itemID string, value map[string]interface{}) error {
+	const mutation = `mutation($input: UpdateProjectV2ItemFieldValueInput!) {
+  updateProjectV2ItemFieldValue(input: $input) { projectV2Item { id } }
+}`
+	input := map[string]interface{}{"projectId": project.id, "itemId": itemID, "fieldId": field.id, "value": value}
+	if err := a.graphql(mutation, map[string]interface{}{"input": input}, nil); err != nil {
+		return fmt.Errorf("updateProjectV2ItemFieldValue failed: %w", err)
+	}
+	return nil
+}
+
+// graphql sends query with variables and decodes the response's data into out
+// (if non-nil). GraphQL errors are returned as an error.
+func (a *GitHubAdapter) graphql(query string, variables map[string]interface{}, out interface{}) error {
+	body, err := a.send("POST", a.endpoint, map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+		graphqlErrors
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("invalid GitHub API response: %w", err)
+	}
+	if len(envelope.Errors) > 0 {
+		messages := make([]string, len(envelope.Errors))
+		for i, e := range envelope.Errors {
+			messages[i] = e.Message
+		}
+		return fmt.Errorf("%s", strings.Join(messages, "; "))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
+
+// graphqlErrors is the errors list of a GraphQL response. GitHub reports its
+// GraphQL rate limit as a 200 whose errors have type RATE_LIMITED.
+type graphqlErrors struct {
+	Errors []struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// rest sends a REST API request with a JSON payload to path and decodes the
+// response into out.
+func (a *GitHubAdapter) rest(method, path string, payload, out interface{}) error {
+	body, err := a.send(method, a.restEndpoint+path, payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, out)
+}
+
+// send sends payload as JSON to url and returns the response body, or an
+// error for a non-2xx status. Rate-limited requests are retried after the
+// wait GitHub asks for (see githubRateLimitRetries).
+func (a *GitHubAdapter) send(method, url string, payload interface{}) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	doer := a.doer
+	if doer == nil {
+		doer = &http.Client{Timeout: 30 * time.Second}
+	}
+	sleep := a.sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(method, url, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+a.token)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/vnd.github+json")
+
+		resp, err := doer.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		wait, limited := rateLimitWait(resp, body, time.Now())
+		if !limited && resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+			return body, nil
+		}
+
+		err = fmt.Errorf("GitHub API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		if !limited || attempt == githubRateLimitRetries {
+			return nil, err
+		}
+		if wait > githubMaxRetryWait {
+			return nil, fmt.Errorf("%w (rate limited; retry after %s)", err, wait.Round(time.Second))
+		}
+		fmt.Printf("  GitHub rate limit: retrying in %s...\n", wait.Round(time.Second))
+		sleep(wait)
+	}
+}
+
+// rateLimitWait reports whether resp (with the given body) is a rate limit and
+// how long GitHub asks to wait: a 403 or 429 with Retry-After (secondary
+// limits), or with no requests remaining until x-ratelimit-reset (the primary
+// limit). A 429 or a GraphQL RATE_LIMITED error with neither waits a minute,
+// as GitHub's docs advise.
+func rateLimitWait(resp *http.Response, body []byte, now time.Time) (time.Duration, bool) {
+	retryable := resp.StatusCode == http.StatusTooManyRequests || graphqlRateLimited(resp, body)
+	if resp.StatusCode != http.StatusForbidden && !retryable {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return max(time.Unix(reset, 0).Sub(now), 0), true
+		}
+	}
+	if retryable {
+		return time.Minute, true
+	}
+	return 0, false
+}
+
+// graphqlRateLimited reports whether resp is a 200 GraphQL response whose
+// errors include RATE_LIMITED.
+func graphqlRateLimited(resp *http.Response, body []byte) bool {
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+	var envelope graphqlErrors
+	if json.Unmarshal(body, &envelope) != nil {
+		return false
+	}
+	for _, e := range envelope.Errors {
+		if e.Type == "RATE_LIMITED" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,322 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// graphqlRequest is a decoded GraphQL request body.
+type graphqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// fakeGitHub answers the adapter's GraphQL requests and records them, and its
+// REST label creations. Issues get numbers 1, 2, ... and node IDs I_1, I_2,
+// ...; project items PVTI_I_1, ...; labels LA_<name>. The repository has a
+// "backend" label, and a "bug" label on the second page of labels.
+type fakeGitHub struct {
+	t        *testing.T
+	requests []graphqlRequest
+	labels   []map[string]interface{} // Created labels
+	issues   int
+}
+
+func (f *fakeGitHub) Do(req *http.Request) (*http.Response, error) {
+	if got := req.Header.Get("Authorization"); got != "Bearer test-token" {
+		f.t.Errorf("Authorization = %q, want Bearer test-token", got)
+	}
+	if req.URL.Path == "/repos/acme/app/labels" {
+		var label map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&label); err != nil {
+			f.t.Fatalf("decode request: %v", err)
+		}
+		f.labels = append(f.labels, label)
+		return &http.Response{
+			StatusCode: http.StatusCreated,
+			Body:       io.NopCloser(strings.NewReader(fmt.Sprintf(`{"node_id": "LA_%s"}`, label["name"]))),
+		}, nil
+	}
+	var body graphqlRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		f.t.Fatalf("decode request: %v", err)
+	}
+	f.requests = append(f.requests, body)
+
+	var data string
+	input, _ := body.Variables["input"].(map[string]interface{})
+	switch {
+	case strings.Contains(body.Query, "repositoryOwner("):
+		data = `{"repositoryOwner": {"projectV2": {"id": "PVT_1", "fields": {"nodes": [
+			{"id": "F_TITLE", "name": "Title", "dataType": "TITLE"},
+			{"id": "F_PRI", "name": "Priority", "dataType": "SINGLE_SELECT", "options": [
+				{"id": "OPT_P0", "name": "P0"}, {"id": "OPT_P1", "name": "P1"}, {"id": "OPT_P2", "name": "P2"}]},
+			{"id": "F_EST", "name": "Estimate", "dataType": "NUMBER"}]}}}}`
+	case strings.Contains(body.Query, "repository("):
+		if body.Variables["after"] == "C1" {
+			data = `{"repository": {"id": "R_1", "labels": {"nodes": [{"id": "LA_bug", "name": "bug"}], "pageInfo": {"hasNextPage": false}}}}`
+		} else {
+			data = `{"repository": {"id": "R_1", "labels": {"nodes": [{"id": "LA_backend", "name": "Backend"}], "pageInfo": {"hasNextPage": true, "endCursor": "C1"}}}}`
+		}
+	case strings.Contains(body.Query, "createIssue("):
+		f.issues++
+		data = fmt.Sprintf(`{"createIssue": {"issue": {"id": "I_%d", "number": %d}}}`, f.issues, f.issues)
+	case strings.Contains(body.Query, "addProjectV2ItemById("):
+		data = fmt.Sprintf(`{"addProjectV2ItemById": {"item": {"id": "PVTI_%s"}}}`, input["contentId"])
+	default:
+		data = `{}`
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"data": ` + data + `}`)),
+	}, nil
+}
+
+// inputs returns the input variable of each recorded request for mutation.
+func (f *fakeGitHub) inputs(mutation string) []map[string]interface{} {
+	var inputs []map[string]interface{}
+	for _, req := range f.requests {
+		if strings.Contains(req.Query, mutation+"(") {
+			input, _ := req.Variables["input"].(map[string]interface{})
+			inputs = append(inputs, input)
+		}
+	}
+	return inputs
+}
+
+func TestGitHubAdapterCreatesProjectItems(t *testing.T) {
+	days, hours, minutes := 2.0, 4.0, 30
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{
+			TempID: "1", Title: "Foundation", Description: "Set up the project.", EstimatedDays: &days,
+			Tasks: []core.Task{{
+				TempID: "1.1", Title: "Scaffold app", Description: "Create the app skeleton.", Priority: core.PriorityCritical, EstimatedHours: &hours,
+				Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "Init module", Description: "Run go mod init.", EstimatedMinutes: &minutes}},
+			}},
+		},
+		{TempID: "2", Title: "Accounts", Description: "User accounts.", DependsOn: []string{"1"}},
+	}}
+
+	fake := &fakeGitHub{t: t}
+	adapter := &GitHubAdapter{owner: "acme", repo: "app", project: 7, token: "test-token", endpoint: githubGraphQLEndpoint, doer: fake}
+	result, err := adapter.CreateItems(response, Config{})
+	if err != nil {
+		t.Fatalf("CreateItems() error = %v", err)
+	}
+
+	var titles []string
+	for _, input := range fake.inputs("createIssue") {
+		if input["repositoryId"] != "R_1" {
+			t.Errorf("createIssue repositoryId = %v, want R_1", input["repositoryId"])
+		}
+		titles = append(titles, input["title"].(string))
+	}
+	if want := []string{"Foundation", "Accounts", "Scaffold app", "Init module"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("createIssue titles = %v, want %v", titles, want)
+	}
+	if body := fake.inputs("createIssue")[1]["body"].(string); !strings.Contains(body, "**Depends on:** #1") {
+		t.Errorf("Accounts body = %q, want a reference to #1", body)
+	}
+
+	subIssues := fake.inputs("addSubIssue")
+	wantSubIssues := []map[string]interface{}{
+		{"issueId": "I_1", "subIssueId": "I_3"},
+		{"issueId": "I_3", "subIssueId": "I_4"},
+	}
+	if !reflect.DeepEqual(subIssues, wantSubIssues) {
+		t.Errorf("addSubIssue inputs = %v, want %v", subIssues, wantSubIssues)
+	}
+
+	var added []string
+	for _, input := range fake.inputs("addProjectV2ItemById") {
+		if input["projectId"] != "PVT_1" {
+			t.Errorf("addProjectV2ItemById projectId = %v, want PVT_1", input["projectId"])
+		}
+		added = append(added, input["contentId"].(string))
+	}
+	if want := []string{"I_1", "I_2", "I_3", "I_4"}; !reflect.DeepEqual(added, want) {
+		t.Errorf("addProjectV2ItemById contentIds = %v, want %v", added, want)
+	}
+
+	var updates []string
+	for _, input := range fake.inputs("updateProjectV2ItemFieldValue") {
+		if input["projectId"] != "PVT_1" {
+			t.Errorf("updateProjectV2ItemFieldValue projectId = %v, want PVT_1", input["projectId"])
+		}
+		updates = append(updates, fmt.Sprintf("%s %s %v", input["itemId"], input["fieldId"], input["value"]))
+	}
+	wantUpdates := []string{
+		"PVTI_I_1 F_PRI map[singleSelectOptionId:OPT_P1]", // Epics default to high
+		"PVTI_I_1 F_EST map[number:16]",                   // 2 days of 8 hours
+		"PVTI_I_2 F_PRI map[singleSelectOptionId:OPT_P1]",
+		"PVTI_I_3 F_PRI map[singleSelectOptionId:OPT_P0]",
+		"PVTI_I_3 F_EST map[number:4]",
+		"PVTI_I_4 F_PRI map[singleSelectOptionId:OPT_P2]", // Subtasks are medium
+		"PVTI_I_4 F_EST map[number:0.5]",
+	}
+	if !reflect.DeepEqual(updates, wantUpdates) {
+		t.Errorf("updateProjectV2ItemFieldValue inputs =\n%s\nwant\n%s", strings.Join(updates, "\n"), strings.Join(wantUpdates, "\n"))
+	}
+
+	var created []string
+	for _, c := range result.Created {
+		created = append(created, fmt.Sprintf("%s %s %s parent=%s", c.ExternalID, c.Type, c.TempID, c.ParentExternalID))
+	}
+	wantCreated := []string{"#1 epic 1 parent=", "#2 epic 2 parent=", "#3 task 1.1 parent=#1", "#4 subtask 1.1.1 parent=#3"}
+	if !reflect.DeepEqual(created, wantCreated) {
+		t.Errorf("Created = %v, want %v", created, wantCreated)
+	}
+	if want := (Stats{Epics: 2, Tasks: 1, Subtasks: 1}); result.Stats != want {
+		t.Errorf("Stats = %+v, want %+v", result.Stats, want)
+	}
+	if len(result.Dependencies) != 0 {
+		t.Errorf("Dependencies = %+v, want none for body references", result.Dependencies)
+	}
+	if len(result.Failed) != 0 {
+		t.Errorf("Failed = %+v, want none", result.Failed)
+	}
+}
+
+func TestGitHubAdapterCreatesMissingLabels(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{{
+		TempID: "1", Title: "Billing", Description: "Take payments.", Labels: []string{"backend", "payments"},
+		Tasks: []core.Task{{TempID: "1.1", Title: "Stripe", Description: "Charge cards.", Priority: core.PriorityHigh, Labels: []string{"payments", "bug"}}},
+	}}}
+
+	fake := &fakeGitHub{t: t}
+	adapter := &GitHubAdapter{
+		owner: "acme", repo: "app", token: "test-token",
+		endpoint: githubGraphQLEndpoint, restEndpoint: githubRESTEndpoint, doer: fake,
+		labelColors: NewLabelColors(map[string]string{"payments": "#b60205"}),
+	}
+	if _, err := adapter.CreateItems(response, Config{}); err != nil {
+		t.Fatalf("CreateItems() error = %v", err)
+	}
+
+	// backend and bug (on the second page) already exist; payments is created
+	wantLabels := []map[string]interface{}{
+		{"name": "payments", "color": "b60205"},
+	}
+	if !reflect.DeepEqual(fake.labels, wantLabels) {
+		t.Errorf("created labels = %v, want %v", fake.labels, wantLabels)
+	}
+
+	var labelIDs []string
+	for _, input := range fake.inputs("createIssue") {
+		labelIDs = append(labelIDs, fmt.Sprint(input["labelIds"]))
+	}
+	if want := []string{"[LA_backend LA_payments]", "[LA_payments LA_bug]"}; !reflect.DeepEqual(labelIDs, want) {
+		t.Errorf("createIssue labelIds = %v, want %v", labelIDs, want)
+	}
+}
+
+// graphqlRateLimitBody is how GitHub answers a GraphQL request over the
+// primary rate limit: a 200 with a RATE_LIMITED error.
+const graphqlRateLimitBody = `{"errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded"}]}`
+
+// rateLimitedDoer answers the first `limited` requests with a secondary rate
+// limit (or, if graphql is set, a GraphQL RATE_LIMITED error), then passes
+// requests to next.
+type rateLimitedDoer struct {
+	next    httpDoer
+	limited int
+	graphql bool
+	calls   int
+}
+
+func (d *rateLimitedDoer) Do(req *http.Request) (*http.Response, error) {
+	d.calls++
+	if d.calls <= d.limited && d.graphql {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Retry-After": []string{"10"}},
+			Body:       io.NopCloser(strings.NewReader(graphqlRateLimitBody)),
+		}, nil
+	}
+	if d.calls <= d.limited {
+		return &http.Response{
+			StatusCode: http.StatusForbidden,
+			Header:     http.Header{"Retry-After": []string{"30"}},
+			Body:       io.NopCloser(strings.NewReader(`{"message": "You have exceeded a secondary rate limit."}`)),
+		}, nil
+	}
+	return d.next.Do(req)
+}
+
+func TestGitHubAdapterRetriesRateLimitedRequests(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{{TempID: "1", Title: "Foundation", Description: "Set up."}}}
+
+	var waits []time.Duration
+	doer := &rateLimitedDoer{next: &fakeGitHub{t: t}, limited: 2}
+	adapter := &GitHubAdapter{
+		owner: "acme", repo: "app", token: "test-token", endpoint: githubGraphQLEndpoint, doer: doer,
+		sleep: func(d time.Duration) { waits = append(waits, d) },
+	}
+	result, err := adapter.CreateItems(response, Config{})
+	if err != nil {
+		t.Fatalf("CreateItems() error = %v", err)
+	}
+	if len(result.Created) != 1 {
+		t.Errorf("Created = %+v, want the epic", result.Created)
+	}
+	if want := []time.Duration{30 * time.Second, 30 * time.Second}; !reflect.DeepEqual(waits, want) {
+		t.Errorf("waits = %v, want %v", waits, want)
+	}
+
+	// Retries are bounded: a limit that doesn't lift fails the request
+	waits = nil
+	doer = &rateLimitedDoer{next: &fakeGitHub{t: t}, limited: 100}
+	adapter.doer = doer
+	if _, err := adapter.CreateItems(response, Config{}); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("CreateItems() error = %v, want the 403", err)
+	}
+	if doer.calls != githubRateLimitRetries+1 {
+		t.Errorf("%d requests, want %d", doer.calls, githubRateLimitRetries+1)
+	}
+
+	// GraphQL reports its rate limit as a 200 with a RATE_LIMITED error
+	waits = nil
+	doer = &rateLimitedDoer{next: &fakeGitHub{t: t}, limited: 1, graphql: true}
+	adapter.doer = doer
+	if _, err := adapter.CreateItems(response, Config{}); err != nil {
+		t.Fatalf("CreateItems() error = %v", err)
+	}
+	if want := []time.Duration{10 * time.Second}; !reflect.DeepEqual(waits, want) {
+		t.Errorf("waits = %v, want %v", waits, want)
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tests := []struct {
+		name    string
+		status  int
+		header  http.Header
+		body    string
+		want    time.Duration
+		limited bool
+	}{
+		{"retry-after", http.StatusForbidden, http.Header{"Retry-After": []string{"5"}}, "", 5 * time.Second, true},
+		{"primary limit", http.StatusForbidden, http.Header{"X-Ratelimit-Remaining": []string{"0"}, "X-Ratelimit-Reset": []string{"1090"}}, "", 90 * time.Second, true},
+		{"429 without headers", http.StatusTooManyRequests, http.Header{}, "", time.Minute, true},
+		{"graphql limit", http.StatusOK, http.Header{"X-Ratelimit-Remaining": []string{"0"}, "X-Ratelimit-Reset": []string{"1090"}}, graphqlRateLimitBody, 90 * time.Second, true},
+		{"graphql limit without headers", http.StatusOK, http.Header{}, graphqlRateLimitBody, time.Minute, true},
+		{"graphql error", http.StatusOK, http.Header{}, `{"errors": [{"type": "NOT_FOUND", "message": "Not found"}]}`, 0, false},
+		{"permission error", http.StatusForbidden, http.Header{}, "", 0, false},
+		{"server error", http.StatusBadGateway, http.Header{"Retry-After": []string{"5"}}, "", 0, false},
+	}
+	for _, tt := range tests {
+		wait, limited := rateLimitWait(&http.Response{StatusCode: tt.status, Header: tt.header}, []byte(tt.body), now)
+		if wait != tt.want || limited != tt.limited {
+			t.Errorf("%s: rateLimitWait() = %v, %v; want %v, %v", tt.name, wait, limited, tt.want, tt.limited)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package output
+
+import "github.com/dhabedank/prd-parser/internal/core"
+
+// itemPriority is the priority every adapter gives item: the configured epic
+// priority (high if unset) for epics, the task's own for tasks, and medium for
+// subtasks, which have none in the plan. ok is false for a task without one.
+func itemPriority(item core.ItemRef, epicPriority core.Priority) (p core.Priority, ok bool) {
+	switch item.Level {
+	case core.LevelEpic:
+		if epicPriority == "" {
+			return core.PriorityHigh, true
+		}
+		return epicPriority, true
+	case core.LevelTask:
+		return item.Task.Priority, item.Task.Priority != ""
+	default:
+		return core.PriorityMedium, true
+	}
+}
+
+// itemEstimateMinutes is item's estimate in minutes: 8 hours per epic day, 60
+// minutes per task hour. ok is false if the item has no estimate.
+func itemEstimateMinutes(item core.ItemRef) (minutes int, ok bool) {
+	switch item.Level {
+	case core.LevelEpic:
+		if item.Epic.EstimatedDays != nil {
+			return int(*item.Epic.EstimatedDays * 8 * 60), true
+		}
+	case core.LevelTask:
+		if item.Task.EstimatedHours != nil {
+			return int(*item.Task.EstimatedHours * 60), true
+		}
+	case core.LevelSubtask:
+		if item.Subtask.EstimatedMinutes != nil {
+			return *item.Subtask.EstimatedMinutes, true
+		}
+	}
+	return 0, false
+}
@@ -1,9 +1,11 @@
 package output
 
 import (
-	"encoding/json"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/dhabedank/prd-parser/internal/core"
 )
@@ -12,6 +14,11 @@ import (
 type JSONAdapter struct {
 	outputPath string
 	dryRun     bool
+	gzip       bool   // Compress output (file or stdout); implied by a .gz output path
+	keyCase    string // JSONCaseSnake or JSONCaseCamel
+	depsFormat string // DepsFormat shape of the dependencies section, or "" for none
+	tee        bool   // Echo what is written to outputPath to stdout as well
+	stdout     io.Writer
 }
 
 // NewJSONAdapter creates a JSON adapter.
@@ -19,6 +26,11 @@ func NewJSONAdapter(config Config, outputPath string) *JSONAdapter {
 	return &JSONAdapter{
 		outputPath: outputPath,
 		dryRun:     config.DryRun,
+		gzip:       config.Gzip || strings.HasSuffix(outputPath, ".gz"),
+		keyCase:    config.JSONCase,
+		depsFormat: config.DepsFormat,
+		tee:        config.Tee,
+		stdout:     config.Stdout,
 	}
 }
 
@@ -30,25 +42,49 @@ func (a *JSONAdapter) IsAvailable() (bool, error) {
 	return true, nil // Always available
 }
 
+func (a *JSONAdapter) Capabilities() Capabilities {
+	return Capabilities{Hierarchy: true, Dependencies: true, Labels: true, Estimates: true} // Writes the plan as-is
+}
+
 func (a *JSONAdapter) CreateItems(response *core.ParseResponse, config Config) (*CreateResult, error) {
-	output, err := json.MarshalIndent(response, "", "  ")
+	output, err := marshalJSONCase(withDependencies(response, a.depsFormat), a.keyCase)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
 	}
 
+	// Dry-run always shows readable JSON, even with gzip
 	if a.dryRun {
 		fmt.Println("[dry-run] Would write:")
 		fmt.Println(string(output))
 	} else if a.outputPath != "" {
-		if err := os.WriteFile(a.outputPath, output, 0644); err != nil {
+		if err := a.writeFile(output); err != nil {
 			return nil, fmt.Errorf("failed to write file: %w", err)
 		}
-		fmt.Printf("Tasks written to %s\n", a.outputPath)
+		if a.tee {
+			// The note goes to stderr so it doesn't run into the echoed plan
+			fmt.Println()
+			fmt.Fprintf(os.Stderr, "Tasks written to %s\n", a.outputPath)
+		} else {
+			fmt.Printf("Tasks written to %s\n", a.outputPath)
+		}
+	} else if a.gzip {
+		stdout := a.stdout
+		if stdout == nil {
+			stdout = os.Stdout
+		}
+		if err := writeGzip(stdout, output); err != nil {
+			return nil, fmt.Errorf("failed to write gzip output: %w", err)
+		}
 	} else {
 		fmt.Println(string(output))
 	}
 
-	// Build result from response
+	return planResult(response), nil
+}
+
+// planResult reports every item in response as created, with synthetic IDs and
+// parent-child and depends_on links, for adapters that write the plan as a file.
+func planResult(response *core.ParseResponse) *CreateResult {
 	result := &CreateResult{
 		Created:      []CreatedItem{},
 		Failed:       []FailedItem{},
@@ -56,89 +92,85 @@ func (a *JSONAdapter) CreateItems(response *core.ParseResponse, config Config) (
 		Stats:        Stats{},
 	}
 
-	// Add all items as "created"
-	for _, epic := range response.Epics {
-		result.Created = append(result.Created, CreatedItem{
-			ExternalID:       fmt.Sprintf("epic-%s", epic.TempID),
-			TempID:           epic.TempID,
-			Type:             "epic",
-			Title:            epic.Title,
-			ParentExternalID: "",
-		})
-		result.Stats.Epics++
-
-		for _, task := range epic.Tasks {
-			result.Created = append(result.Created, CreatedItem{
-				ExternalID:       fmt.Sprintf("task-%s", task.TempID),
-				TempID:           task.TempID,
-				Type:             "task",
-				Title:            task.Title,
-				ParentExternalID: fmt.Sprintf("epic-%s", epic.TempID),
-			})
+	// Add all items as "created", with parent-child and depends_on links
+	_ = core.WalkItems(response, func(item core.ItemRef) error {
+		externalID := jsonItemID(item.Level, item.TempID())
+		parentExternalID := ""
+		switch item.Level {
+		case core.LevelTask:
+			parentExternalID = jsonItemID(core.LevelEpic, item.Epic.TempID)
 			result.Stats.Tasks++
-
-			for _, subtask := range task.Subtasks {
-				result.Created = append(result.Created, CreatedItem{
-					ExternalID:       fmt.Sprintf("subtask-%s", subtask.TempID),
-					TempID:           subtask.TempID,
-					Type:             "subtask",
-					Title:            subtask.Title,
-					ParentExternalID: fmt.Sprintf("task-%s", task.TempID),
-				})
-				result.Stats.Subtasks++
-			}
+		case core.LevelSubtask:
+			parentExternalID = jsonItemID(core.LevelTask, item.Task.TempID)
+			result.Stats.Subtasks++
+		default:
+			result.Stats.Epics++
 		}
-	}
 
-	// Add dependencies
-	for _, epic := range response.Epics {
-		for _, dep := range epic.DependsOn {
+		result.Created = append(result.Created, CreatedItem{
+			ExternalID:       externalID,
+			TempID:           item.TempID(),
+			Type:             item.Level,
+			Title:            item.Title(),
+			ParentExternalID: parentExternalID,
+		})
+
+		if parentExternalID != "" {
 			result.Dependencies = append(result.Dependencies, Dependency{
-				From: fmt.Sprintf("epic-%s", dep),
-				To:   fmt.Sprintf("epic-%s", epic.TempID),
-				Type: "blocks",
+				From: parentExternalID,
+				To:   externalID,
+				Type: "parent-child",
 			})
-			result.Stats.Dependencies++
 		}
-
-		for _, task := range epic.Tasks {
-			// Parent-child
+		for _, dep := range item.DependsOn() {
 			result.Dependencies = append(result.Dependencies, Dependency{
-				From: fmt.Sprintf("epic-%s", epic.TempID),
-				To:   fmt.Sprintf("task-%s", task.TempID),
-				Type: "parent-child",
+				From: jsonItemID(item.Level, dep),
+				To:   externalID,
+				Type: "blocks",
 			})
-			result.Stats.Dependencies++
-
-			for _, dep := range task.DependsOn {
-				result.Dependencies = append(result.Dependencies, Dependency{
-					From: fmt.Sprintf("task-%s", dep),
-					To:   fmt.Sprintf("task-%s", task.TempID),
-					Type: "blocks",
-				})
-				result.Stats.Dependencies++
-			}
-
-			for _, subtask := range task.Subtasks {
-				// Parent-child
-				result.Dependencies = append(result.Dependencies, Dependency{
-					From: fmt.Sprintf("task-%s", task.TempID),
-					To:   fmt.Sprintf("subtask-%s", subtask.TempID),
-					Type: "parent-child",
-				})
-				result.Stats.Dependencies++
-
-				for _, dep := range subtask.DependsOn {
-					result.Dependencies = append(result.Dependencies, Dependency{
-						From: fmt.Sprintf("subtask-%s", dep),
-						To:   fmt.Sprintf("subtask-%s", subtask.TempID),
-						Type: "blocks",
-					})
-					result.Stats.Dependencies++
-				}
-			}
 		}
+		return nil
+	})
+	result.Stats.Dependencies = len(result.Dependencies)
+
+	return result
+}
+
+// jsonItemID returns the synthetic external ID for an item, e.g. "task-1.2".
+func jsonItemID(level, tempID string) string {
+	return level + "-" + tempID
+}
+
+// writeFile writes output to the adapter's path, gzip-compressed if enabled.
+// With tee, output is also written to stdout, uncompressed so it's readable.
+func (a *JSONAdapter) writeFile(output []byte) error {
+	file, err := os.OpenFile(a.outputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
 	}
+	if a.gzip {
+		err = writeGzip(file, output)
+	} else {
+		_, err = file.Write(output)
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if a.tee {
+		_, err = os.Stdout.Write(output)
+	}
+	return err
+}
 
-	return result, nil
+// writeGzip writes data to w as a complete gzip stream.
+func writeGzip(w io.Writer, data []byte) error {
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	return zw.Close()
 }
@@ -0,0 +1,118 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Key styles for JSON adapter output (Config.JSONCase).
+const (
+	JSONCaseSnake = "snake" // temp_id, depends_on; the struct tags, and the default
+	JSONCaseCamel = "camel" // tempId, dependsOn
+)
+
+// ValidJSONCase reports whether c is a known JSON key style ("" means snake).
+func ValidJSONCase(c string) bool {
+	switch c {
+	case "", JSONCaseSnake, JSONCaseCamel:
+		return true
+	}
+	return false
+}
+
+// marshalJSONCase marshals v as indented JSON with keys in the given style.
+// Keys are rewritten on the encoded output, so field order is preserved.
+func marshalJSONCase(v interface{}, keyCase string) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if keyCase == JSONCaseCamel {
+		if data, err = renameKeys(data, snakeToCamel); err != nil {
+			return nil, err
+		}
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// renameKeys re-encodes compact JSON with every object key passed through rename.
+func renameKeys(data []byte, rename func(string) string) ([]byte, error) {
+	type container struct {
+		object bool
+		n      int // Tokens written so far; in objects, even n means a key is next
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var buf bytes.Buffer
+	var stack []container
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return buf.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			buf.WriteByte(byte(delim))
+			continue
+		}
+
+		isKey := false
+		if len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			isKey = top.object && top.n%2 == 0
+			if top.n > 0 && (!top.object || isKey) {
+				buf.WriteByte(',')
+			}
+			top.n++
+		}
+
+		switch t := tok.(type) {
+		case json.Delim:
+			buf.WriteByte(byte(t))
+			stack = append(stack, container{object: t == '{'})
+		case json.Number:
+			buf.WriteString(t.String())
+		case string:
+			if isKey {
+				t = rename(t)
+			}
+			encoded, err := json.Marshal(t)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(encoded)
+			if isKey {
+				buf.WriteByte(':')
+			}
+		default: // bool or nil
+			encoded, err := json.Marshal(t)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(encoded)
+		}
+	}
+}
+
+// snakeToCamel converts a snake_case key to camelCase ("depends_on" -> "dependsOn").
+func snakeToCamel(key string) string {
+	parts := strings.Split(key, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,95 @@
+package output
+
+import "github.com/dhabedank/prd-parser/internal/core"
+
+// Shapes for the JSON adapter's top-level "dependencies" section
+// (Config.DepsFormat). Each is built from the same parent-child and
+// blocks links, between the synthetic IDs the adapter reports ("task-1.2").
+const (
+	DepsFormatList      = "list"      // [{"from", "to", "type"}, ...]
+	DepsFormatAdjacency = "adjacency" // {"blocks": {from: [to, ...]}, "parent-child": {...}}
+	DepsFormatNested    = "nested"    // [{"id", "blocks", "children": [...]}, ...] following the hierarchy
+)
+
+// ValidDependenciesFormat reports whether f is a known dependencies format (""
+// means none: the plan is written as-is, with only each item's depends_on).
+func ValidDependenciesFormat(f string) bool {
+	switch f {
+	case "", DepsFormatList, DepsFormatAdjacency, DepsFormatNested:
+		return true
+	}
+	return false
+}
+
+// jsonPlanWithDeps is the plan with a dependencies section after its own fields.
+type jsonPlanWithDeps struct {
+	*core.ParseResponse
+	Dependencies interface{} `json:"dependencies"`
+}
+
+// jsonDependency is one link in the list format.
+type jsonDependency struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"` // "blocks" or "parent-child"
+}
+
+// nestedDependencies is one item in the nested format: the items it blocks,
+// and its children with their own links.
+type nestedDependencies struct {
+	ID       string               `json:"id"`
+	Blocks   []string             `json:"blocks,omitempty"`
+	Children []nestedDependencies `json:"children,omitempty"`
+}
+
+// withDependencies returns what the JSON adapter marshals for response: the
+// plan itself, or for a dependencies format, the plan with that section added.
+func withDependencies(response *core.ParseResponse, format string) interface{} {
+	links := planResult(response).Dependencies
+	switch format {
+	case DepsFormatList:
+		list := make([]jsonDependency, len(links))
+		for i, l := range links {
+			list[i] = jsonDependency{From: l.From, To: l.To, Type: l.Type}
+		}
+		return jsonPlanWithDeps{response, list}
+	case DepsFormatAdjacency:
+		adjacency := map[string]map[string][]string{"blocks": {}, "parent-child": {}}
+		for _, l := range links {
+			adjacency[l.Type][l.From] = append(adjacency[l.Type][l.From], l.To)
+		}
+		return jsonPlanWithDeps{response, adjacency}
+	case DepsFormatNested:
+		return jsonPlanWithDeps{response, nestDependencies(response, links)}
+	default:
+		return response
+	}
+}
+
+// nestDependencies arranges the blocks links in links along the plan's hierarchy.
+func nestDependencies(response *core.ParseResponse, links []Dependency) []nestedDependencies {
+	blocks := make(map[string][]string)
+	for _, l := range links {
+		if l.Type == "blocks" {
+			blocks[l.From] = append(blocks[l.From], l.To)
+		}
+	}
+	node := func(level, tempID string) nestedDependencies {
+		id := jsonItemID(level, tempID)
+		return nestedDependencies{ID: id, Blocks: blocks[id]}
+	}
+
+	epics := []nestedDependencies{}
+	for _, epic := range response.Epics {
+		e := node(core.LevelEpic, epic.TempID)
+		for _, task := range epic.Tasks {
+			t := node(core.LevelTask, task.TempID)
+			for _, subtask := range task.Subtasks {
+				t.Children = append(t.Children, node(core.LevelSubtask, subtask.TempID))
+			}
+			e.Children = append(e.Children, t)
+		}
+		epics = append(epics, e)
+	}
+	return epics
+}
@@ -0,0 +1,66 @@
+package output
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// DefaultLabelColor is used for labels outside the prompt taxonomy.
+const DefaultLabelColor = "cfd3d7" // Gray
+
+// CategoryColors are the default label colors per taxonomy category, as
+// six-digit hex without "#" (the form GitHub and Linear accept).
+var CategoryColors = map[string]string{
+	core.LabelCategoryLayer:  "1d76db", // Blue
+	core.LabelCategoryDomain: "0e8a16", // Green
+	core.LabelCategorySkill:  "5319e7", // Purple
+	core.LabelCategoryType:   "fbca04", // Yellow
+}
+
+var hexColorPattern = regexp.MustCompile(`^#?[0-9a-fA-F]{6}$`)
+
+// ValidateLabelColors checks that every override is a six-digit hex color.
+func ValidateLabelColors(overrides map[string]string) error {
+	for key, color := range overrides {
+		if !hexColorPattern.MatchString(color) {
+			return fmt.Errorf("invalid color %q for label %s (use six-digit hex, e.g. 1d76db)", color, key)
+		}
+	}
+	return nil
+}
+
+// LabelColors picks consistent colors for labels so adapters that create
+// labels in systems with label colors can color them by category.
+type LabelColors struct {
+	overrides map[string]string // lowercased category or label -> color
+}
+
+// NewLabelColors returns a palette with user overrides (Config.LabelColors)
+// applied. Keys may be a category ("domain") or a single label ("payments");
+// a label override wins over its category. Colors may include a leading "#".
+func NewLabelColors(overrides map[string]string) *LabelColors {
+	c := &LabelColors{overrides: make(map[string]string, len(overrides))}
+	for key, color := range overrides {
+		c.overrides[strings.ToLower(key)] = strings.TrimPrefix(color, "#")
+	}
+	return c
+}
+
+// Color returns the color for label: its own override, then its category's
+// override or default color, then DefaultLabelColor.
+func (c *LabelColors) Color(label string) string {
+	if color, ok := c.overrides[strings.ToLower(label)]; ok {
+		return color
+	}
+	category := core.LabelCategory(label)
+	if color, ok := c.overrides[category]; ok && category != "" {
+		return color
+	}
+	if color, ok := CategoryColors[category]; ok {
+		return color
+	}
+	return DefaultLabelColor
+}
@@ -0,0 +1,247 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// MarkdownAdapter writes the plan as a Markdown outline for review before it
+// goes into a tracker: the project context, then a section per epic and task
+// and a checkbox per subtask.
+type MarkdownAdapter struct {
+	outputPath     string
+	dryRun         bool
+	includeContext map[string]bool // Levels (core.Level*) that get context blocks
+	includeTesting bool
+	epicPriority   core.Priority // Shown on epics when set
+}
+
+// NewMarkdownAdapter creates a Markdown adapter.
+func NewMarkdownAdapter(config Config, outputPath string) *MarkdownAdapter {
+	return &MarkdownAdapter{
+		outputPath:     outputPath,
+		dryRun:         config.DryRun,
+		includeContext: contextLevels(config),
+		includeTesting: config.IncludeTesting,
+		epicPriority:   config.EpicPriority,
+	}
+}
+
+func (a *MarkdownAdapter) Name() string {
+	return "markdown"
+}
+
+func (a *MarkdownAdapter) IsAvailable() (bool, error) {
+	return true, nil // Always available
+}
+
+func (a *MarkdownAdapter) Capabilities() Capabilities {
+	return Capabilities{Hierarchy: true, Dependencies: true, Labels: true, Estimates: true} // Listed inline with each item
+}
+
+func (a *MarkdownAdapter) CreateItems(response *core.ParseResponse, config Config) (*CreateResult, error) {
+	output := a.Render(response)
+
+	if a.dryRun {
+		fmt.Println("[dry-run] Would write:")
+		fmt.Println(output)
+	} else if a.outputPath != "" {
+		if err := os.WriteFile(a.outputPath, []byte(output), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write file: %w", err)
+		}
+		fmt.Printf("Plan written to %s\n", a.outputPath)
+	} else {
+		fmt.Println(output)
+	}
+
+	return planResult(response), nil
+}
+
+// Render renders response as a Markdown outline: "# Product", the project
+// context, "## Epic N: Title", "### Task N.M: Title", and a "- [ ]" checkbox
+// per subtask. Each item lists its priority, estimate, labels, and depends_on,
+// and its description gets the context and testing blocks the beads adapter
+// would add.
+func (a *MarkdownAdapter) Render(response *core.ParseResponse) string {
+	var sb strings.Builder
+
+	title := response.Project.ProductName
+	if title == "" {
+		title = "Plan"
+	}
+	fmt.Fprintf(&sb, "# %s\n\n", title)
+	writeProjectContext(&sb, &response.Project)
+
+	for _, epic := range response.Epics {
+		fmt.Fprintf(&sb, "## Epic %s: %s\n\n", epic.TempID, epic.Title)
+		var estimate string
+		if epic.EstimatedDays != nil {
+			estimate = strconv.FormatFloat(*epic.EstimatedDays, 'f', -1, 64) + " days"
+		}
+		writeBlock(&sb, "", itemFacts(a.epicPriority, estimate, epic.Labels, epic.DependsOn))
+		writeBlock(&sb, "", a.description(core.LevelEpic, epic.Description, epic.Context, &epic.Testing))
+		if len(epic.AcceptanceCriteria) > 0 {
+			writeBlock(&sb, "", "**Acceptance Criteria:**\n"+formatAcceptance(epic.AcceptanceCriteria, AcceptanceFormatBulleted))
+		}
+		writeBlock(&sb, "", strings.TrimPrefix(sourceHintBlock(epic.SourceHint), "\n\n"))
+
+		for _, task := range epic.Tasks {
+			fmt.Fprintf(&sb, "### Task %s: %s\n\n", task.TempID, task.Title)
+			estimate = ""
+			if task.EstimatedHours != nil {
+				estimate = strconv.FormatFloat(*task.EstimatedHours, 'f', -1, 64) + "h"
+			}
+			writeBlock(&sb, "", itemFacts(task.Priority, estimate, task.Labels, task.DependsOn))
+			writeBlock(&sb, "", a.description(core.LevelTask, task.Description, task.Context, &task.Testing))
+			if task.DesignNotes != nil && *task.DesignNotes != "" {
+				writeBlock(&sb, "", "**Design Notes:** "+*task.DesignNotes)
+			}
+			writeBlock(&sb, "", strings.TrimPrefix(sourceHintBlock(task.SourceHint), "\n\n"))
+
+			detailed := false // Whether the last subtask's details ended in a blank line
+			for _, subtask := range task.Subtasks {
+				fmt.Fprintf(&sb, "- [ ] **%s** %s\n", subtask.TempID, subtask.Title)
+				estimate = ""
+				if subtask.EstimatedMinutes != nil {
+					estimate = strconv.Itoa(*subtask.EstimatedMinutes) + " min"
+				}
+				// Indented under the checkbox, so they stay part of its list item
+				facts := itemFacts("", estimate, subtask.Labels, subtask.DependsOn)
+				desc := a.description(core.LevelSubtask, subtask.Description, subtask.Context, &subtask.Testing)
+				detailed = false
+				for _, block := range []string{facts, desc} {
+					if strings.TrimSpace(block) != "" {
+						if !detailed {
+							sb.WriteString("\n")
+						}
+						writeBlock(&sb, "  ", block)
+						detailed = true
+					}
+				}
+			}
+			if len(task.Subtasks) > 0 && !detailed {
+				sb.WriteString("\n")
+			}
+		}
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// description appends the context and testing blocks enabled for level to
+// base, as BeadsAdapter.buildDescription does.
+func (a *MarkdownAdapter) description(level, base string, context interface{}, testing *core.TestingRequirements) string {
+	return itemDescription(a.includeContext, a.includeTesting, level, base, context, testing)
+}
+
+// itemDescription joins base and the context and testing blocks enabled for
+// level with blank lines, leaving out the empty ones.
+func itemDescription(includeContext map[string]bool, includeTesting bool, level, base string, context interface{}, testing *core.TestingRequirements) string {
+	var blocks []string
+	if strings.TrimSpace(base) != "" {
+		blocks = append(blocks, base)
+	}
+	if includeContext[level] {
+		if block := core.FormatContextBlock(context); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	if includeTesting {
+		if block := core.FormatTesting(testing); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// writeProjectContext writes the project context fields that are set.
+func writeProjectContext(sb *strings.Builder, project *core.ProjectContext) {
+	writeBlock(sb, "", project.ElevatorPitch)
+	writeBlock(sb, "", labeledText("Target Audience", string(project.TargetAudience)))
+	writeBlock(sb, "", labeledList("Business Goals", project.BusinessGoals))
+	writeBlock(sb, "", labeledList("User Goals", project.UserGoals))
+	writeBlock(sb, "", brandGuidelines(project.BrandGuidelines))
+	if len(project.TechStack) > 0 {
+		writeBlock(sb, "", labeledText("Tech Stack", strings.Join(project.TechStack, ", ")))
+	}
+	writeBlock(sb, "", labeledList("Constraints", project.Constraints))
+}
+
+// itemFacts is the line of an item's priority, estimate, labels, and
+// dependencies, leaving out the ones that are empty.
+func itemFacts(priority core.Priority, estimate string, labels, dependsOn []string) string {
+	var facts []string
+	if priority != "" {
+		facts = append(facts, "**Priority:** "+string(priority))
+	}
+	if estimate != "" {
+		facts = append(facts, "**Estimate:** "+estimate)
+	}
+	if len(labels) > 0 {
+		facts = append(facts, "**Labels:** `"+strings.Join(labels, "`, `")+"`")
+	}
+	if len(dependsOn) > 0 {
+		facts = append(facts, "**Depends on:** "+strings.Join(dependsOn, ", "))
+	}
+	return strings.Join(facts, " · ")
+}
+
+// writeBlock writes a non-empty block as a paragraph, each line indented by indent.
+func writeBlock(sb *strings.Builder, indent, block string) {
+	block = strings.TrimSpace(block)
+	if block == "" {
+		return
+	}
+	for _, line := range strings.Split(block, "\n") {
+		if line != "" {
+			sb.WriteString(indent)
+		}
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("\n")
+}
+
+// brandGuidelines renders the project's brand guidelines, given as a string or
+// as an object such as {"voice": ..., "tone": ...}.
+func brandGuidelines(guidelines interface{}) string {
+	fields, ok := guidelines.(map[string]interface{})
+	if !ok {
+		text, _ := guidelines.(string)
+		return labeledText("Brand Guidelines", text)
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var lines []string
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("%s: %v", key, fields[key]))
+	}
+	return labeledList("Brand Guidelines", lines)
+}
+
+func labeledText(label, text string) string {
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+	return fmt.Sprintf("**%s:** %s", label, strings.TrimSpace(text))
+}
+
+func labeledList(label string, items []string) string {
+	var lines []string
+	for _, item := range items {
+		if strings.TrimSpace(item) != "" {
+			lines = append(lines, "- "+strings.TrimSpace(item))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("**%s:**\n%s", label, strings.Join(lines, "\n"))
+}
@@ -0,0 +1,191 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+)
+
+// Coverage statuses in the traceability matrix.
+const (
+	CoverageCovered   = "covered"
+	CoverageUncovered = "uncovered"  // The PRD defines the requirement but no item names it
+	CoverageNotInPRD  = "not in PRD" // Items name a requirement the PRD doesn't appear to define
+)
+
+// RequirementCoverage is one row of the traceability matrix.
+type RequirementCoverage struct {
+	Requirement string
+	Status      string     // CoverageCovered, CoverageUncovered, or CoverageNotInPRD
+	Items       []WorkItem // Epics, tasks, and subtasks whose requirement_id names it
+}
+
+// TraceabilityAdapter writes a requirements-traceability matrix instead of
+// creating items: Markdown by default, CSV for a .csv output path.
+type TraceabilityAdapter struct {
+	outputPath string
+	dryRun     bool
+}
+
+// NewTraceabilityAdapter creates a traceability adapter.
+func NewTraceabilityAdapter(config Config, outputPath string) *TraceabilityAdapter {
+	return &TraceabilityAdapter{
+		outputPath: outputPath,
+		dryRun:     config.DryRun,
+	}
+}
+
+func (a *TraceabilityAdapter) Name() string {
+	return "traceability"
+}
+
+func (a *TraceabilityAdapter) IsAvailable() (bool, error) {
+	return true, nil // Always available
+}
+
+func (a *TraceabilityAdapter) Capabilities() Capabilities {
+	return Capabilities{Hierarchy: true} // Items are listed by temp_id; the rest of the plan isn't written
+}
+
+func (a *TraceabilityAdapter) CreateItems(response *core.ParseResponse, config Config) (*CreateResult, error) {
+	rows := BuildTraceabilityMatrix(response, config.Requirements)
+	for _, row := range rows {
+		if row.Status == CoverageUncovered {
+			config.Warnings.Add(core.WarnUncoveredRequirement, row.Requirement, "no epic, task, or subtask covers this requirement")
+		}
+	}
+
+	var output string
+	if strings.HasSuffix(a.outputPath, ".csv") {
+		csvOutput, err := RenderTraceabilityCSV(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render CSV: %w", err)
+		}
+		output = csvOutput
+	} else {
+		output = RenderTraceabilityMarkdown(response.Project.ProductName, rows)
+	}
+
+	if a.dryRun {
+		fmt.Println("[dry-run] Would write:")
+		fmt.Println(output)
+	} else if a.outputPath != "" {
+		if err := os.WriteFile(a.outputPath, []byte(output), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write file: %w", err)
+		}
+		fmt.Printf("Traceability matrix written to %s\n", a.outputPath)
+	} else {
+		fmt.Println(output)
+	}
+
+	return planResult(response), nil
+}
+
+// BuildTraceabilityMatrix maps requirements to the items whose requirement_id
+// names them. requirements are the IDs the PRD defines (see
+// core.FindRequirementIDs), listed first and in order; IDs that only items
+// mention follow. With no requirements, every named ID counts as covered.
+func BuildTraceabilityMatrix(response *core.ParseResponse, requirements []string) []RequirementCoverage {
+	index := make(map[string]int)
+	var rows []RequirementCoverage
+	for _, id := range requirements {
+		if _, ok := index[id]; !ok {
+			index[id] = len(rows)
+			rows = append(rows, RequirementCoverage{Requirement: id, Status: CoverageUncovered})
+		}
+	}
+	defined := len(rows)
+
+	_ = core.WalkItems(response, func(item core.ItemRef) error {
+		for _, id := range core.SplitRequirementIDs(item.RequirementID()) {
+			i, ok := index[id]
+			if !ok {
+				i = len(rows)
+				index[id] = i
+				status := CoverageCovered
+				if defined > 0 {
+					status = CoverageNotInPRD
+				}
+				rows = append(rows, RequirementCoverage{Requirement: id, Status: status})
+			}
+			row := &rows[i]
+			if row.Status == CoverageUncovered {
+				row.Status = CoverageCovered
+			}
+			row.Items = append(row.Items, WorkItem{Type: item.Level, TempID: item.TempID(), Title: item.Title(), ParentTempID: item.ParentTempID()})
+		}
+		return nil
+	})
+	return rows
+}
+
+// RenderTraceabilityMarkdown renders the matrix as a Markdown table, followed by
+// a coverage line listing any uncovered requirements.
+func RenderTraceabilityMarkdown(productName string, rows []RequirementCoverage) string {
+	var sb strings.Builder
+
+	if productName == "" {
+		productName = "Plan"
+	}
+	sb.WriteString(fmt.Sprintf("# Requirements Traceability: %s\n\n", productName))
+	if len(rows) == 0 {
+		sb.WriteString("No requirement IDs found in the PRD or on any item.\n")
+		return sb.String()
+	}
+
+	sb.WriteString("| Requirement | Status | Covered by |\n")
+	sb.WriteString("|-------------|--------|------------|\n")
+	var uncovered []string
+	covered := 0
+	for _, row := range rows {
+		items := make([]string, len(row.Items))
+		for i, item := range row.Items {
+			items[i] = fmt.Sprintf("%s %s: %s", item.Type, item.TempID, markdownCell(item.Title))
+		}
+		status := row.Status
+		switch row.Status {
+		case CoverageUncovered:
+			status = "**uncovered**"
+			uncovered = append(uncovered, row.Requirement)
+		case CoverageCovered:
+			covered++
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s |\n", row.Requirement, status, strings.Join(items, "<br>")))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n%d of %d requirements covered.", covered, covered+len(uncovered)))
+	if len(uncovered) > 0 {
+		sb.WriteString(fmt.Sprintf(" Uncovered: %s", strings.Join(uncovered, ", ")))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// RenderTraceabilityCSV renders the matrix with one row per requirement and
+// covering item; uncovered requirements get a row with empty item columns.
+func RenderTraceabilityCSV(rows []RequirementCoverage) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	records := [][]string{{"requirement", "status", "level", "temp_id", "title"}}
+	for _, row := range rows {
+		if len(row.Items) == 0 {
+			records = append(records, []string{row.Requirement, row.Status, "", "", ""})
+		}
+		for _, item := range row.Items {
+			records = append(records, []string{row.Requirement, row.Status, item.Type, item.TempID, item.Title})
+		}
+	}
+	if err := w.WriteAll(records); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// markdownCell escapes text for use inside a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", " ")
+}
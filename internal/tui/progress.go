@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -20,13 +21,18 @@ type StageInfo struct {
 }
 
 // ProgressDisplay is a Bubble Tea model for showing parsing progress.
+// StartStage, CompleteStage, and Stop may be called from multiple goroutines
+// (e.g. parallel Stage 2/3 calls) while the display renders; the stages and the
+// running cost total are guarded by a mutex.
 type ProgressDisplay struct {
-	spinner     spinner.Model
-	stages      []StageInfo
-	currentIdx  int
-	isRunning   bool
-	totalCost   float64
-	quitting    bool
+	mu sync.Mutex // Guards everything below except spinner
+
+	spinner    spinner.Model
+	stages     []StageInfo
+	currentIdx int
+	isRunning  bool
+	totalCost  float64
+	quitting   bool
 }
 
 // NewProgressDisplay creates a new progress display.
@@ -51,29 +57,70 @@ func (p *ProgressDisplay) StartStage(name, model string, inputChars int) {
 		InputChars: inputChars,
 		StartTime:  time.Now(),
 	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.stages = append(p.stages, stage)
 	p.currentIdx = len(p.stages) - 1
 	p.isRunning = true
 }
 
-// CompleteStage marks the current stage as complete.
+// CompleteStage marks the most recently started stage that is still running
+// as complete and adds its cost to the total. With stages running in parallel
+// each call completes a different stage, so no stage is counted twice.
 func (p *ProgressDisplay) CompleteStage(outputChars int) {
-	if p.currentIdx >= 0 && p.currentIdx < len(p.stages) {
-		p.stages[p.currentIdx].IsComplete = true
-		p.stages[p.currentIdx].EndTime = time.Now()
-		p.stages[p.currentIdx].OutputChars = outputChars
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	idx := -1
+	for i := len(p.stages) - 1; i >= 0; i-- {
+		if !p.stages[i].IsComplete {
+			idx = i
+			break
+		}
+	}
+	if idx >= 0 {
+		stage := &p.stages[idx]
+		stage.IsComplete = true
+		stage.EndTime = time.Now()
+		stage.OutputChars = outputChars
 
 		// Calculate cost for this stage
-		inputTokens := EstimateTokens(p.stages[p.currentIdx].InputChars)
+		inputTokens := EstimateTokens(stage.InputChars)
 		outputTokens := EstimateTokens(outputChars)
-		cost := EstimateCost(p.stages[p.currentIdx].Model, inputTokens, outputTokens)
-		p.totalCost += cost
+		p.totalCost += EstimateCost(stage.Model, inputTokens, outputTokens)
 	}
-	p.isRunning = false
+	p.isRunning = p.hasRunningStage()
+}
+
+// hasRunningStage reports whether any stage is still running. p.mu must be held.
+func (p *ProgressDisplay) hasRunningStage() bool {
+	for _, stage := range p.stages {
+		if !stage.IsComplete {
+			return true
+		}
+	}
+	return false
+}
+
+// TotalCost returns the estimated cost of the completed stages.
+func (p *ProgressDisplay) TotalCost() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.totalCost
+}
+
+// Stages returns a copy of the tracked stages.
+func (p *ProgressDisplay) Stages() []StageInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]StageInfo(nil), p.stages...)
 }
 
 // Stop stops the progress display.
 func (p *ProgressDisplay) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.isRunning = false
 	p.quitting = true
 }
@@ -89,7 +136,9 @@ func (p *ProgressDisplay) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "q", "ctrl+c":
+			p.mu.Lock()
 			p.quitting = true
+			p.mu.Unlock()
 			return p, tea.Quit
 		}
 
@@ -104,6 +153,9 @@ func (p *ProgressDisplay) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // View implements tea.Model.
 func (p *ProgressDisplay) View() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	if p.quitting {
 		return p.summaryView()
 	}
@@ -121,7 +173,7 @@ func (p *ProgressDisplay) View() string {
 	if p.isRunning {
 		status = p.spinner.View()
 	} else {
-		status = SuccessStyle.Render("✓")
+		status = SuccessStyle.Render(Sym.Check)
 	}
 
 	line := fmt.Sprintf("%s %s  %s  %s  ~%s input",
@@ -135,7 +187,7 @@ func (p *ProgressDisplay) View() string {
 	return line
 }
 
-// summaryView shows the final summary after completion.
+// summaryView shows the final summary after completion. p.mu must be held.
 func (p *ProgressDisplay) summaryView() string {
 	if len(p.stages) == 0 {
 		return ""
@@ -166,7 +218,7 @@ func (p *ProgressDisplay) summaryView() string {
 func RenderStageStart(name, model string, inputChars int) string {
 	inputTokens := EstimateTokens(inputChars)
 	return fmt.Sprintf("%s %s  %s  ~%s input tokens",
-		SpinnerStyle.Render("→"),
+		SpinnerStyle.Render(Sym.Arrow),
 		StageStyle.Render(name),
 		ModelStyle.Render(model),
 		FormatTokens(inputTokens),
@@ -180,7 +232,7 @@ func RenderStageComplete(name string, duration time.Duration, inputChars, output
 	cost := EstimateCost(model, inputTokens, outputTokens)
 
 	return fmt.Sprintf("%s %s  %s  ~%s tokens  %s",
-		SuccessStyle.Render("✓"),
+		SuccessStyle.Render(Sym.Check),
 		StageStyle.Render(name),
 		HelpStyle.Render(duration.Truncate(time.Second).String()),
 		FormatTokens(inputTokens+outputTokens),
@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"sync"
+	"testing"
+)
+
+// Run with -race: parallel generation starts and completes stages concurrently.
+func TestProgressDisplayConcurrentStages(t *testing.T) {
+	p := NewProgressDisplay()
+
+	const workers, calls = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < calls; i++ {
+				p.StartStage("Stage 3", "claude-sonnet-4", 4000)
+				_ = p.View()
+				p.CompleteStage(4000)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stages := p.Stages()
+	if len(stages) != workers*calls {
+		t.Fatalf("stages = %d, want %d", len(stages), workers*calls)
+	}
+	for i, stage := range stages {
+		if !stage.IsComplete {
+			t.Fatalf("stage %d not complete", i)
+		}
+	}
+
+	perStage := EstimateCost("claude-sonnet-4", EstimateTokens(4000), EstimateTokens(4000))
+	want := perStage * workers * calls
+	if got := p.TotalCost(); got < want*0.999 || got > want*1.001 {
+		t.Errorf("TotalCost() = %f, want %f", got, want)
+	}
+}
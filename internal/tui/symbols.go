@@ -0,0 +1,32 @@
+package tui
+
+// Symbols are the decorations printed in status lines and lists.
+type Symbols struct {
+	Check   string // Success
+	Warn    string // Warning heading or item
+	Bullet  string // List item
+	Arrow   string // Stage start, step separator
+	Pending string // Step not reached yet
+	Up      string // Key hints
+	Down    string
+	Left    string
+}
+
+var (
+	unicodeSymbols = Symbols{Check: "✓", Warn: "⚠", Bullet: "•", Arrow: "→", Pending: "○", Up: "↑", Down: "↓", Left: "←"}
+	asciiSymbols   = Symbols{Check: "[ok]", Warn: "[!]", Bullet: "-", Arrow: "->", Pending: "[ ]", Up: "up", Down: "down", Left: "left"}
+)
+
+// Sym holds the active decorations. It is Unicode by default; SetPlain
+// switches everything to ASCII for terminals and log aggregators that
+// mangle Unicode.
+var Sym = unicodeSymbols
+
+// SetPlain selects ASCII (true) or Unicode (false) decorations.
+func SetPlain(plain bool) {
+	if plain {
+		Sym = asciiSymbols
+	} else {
+		Sym = unicodeSymbols
+	}
+}
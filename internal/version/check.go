@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,6 +19,9 @@ const (
 
 	// CheckInterval is how often to check for updates (24 hours).
 	CheckInterval = 24 * time.Hour
+
+	// NoUpdateCheckEnv disables the update check when set (any value except "0" or "false").
+	NoUpdateCheckEnv = "PRD_PARSER_NO_UPDATE_CHECK"
 )
 
 // GitHubRelease represents a GitHub release.
@@ -35,13 +39,18 @@ type CheckResult struct {
 }
 
 // CheckForUpdate checks if a newer version is available.
-// Returns nil if check should be skipped (checked recently) or on error.
+// Returns nil if check should be skipped (checked recently, opted out) or on error.
 func CheckForUpdate(currentVersion string) *CheckResult {
 	// Skip if running dev version
 	if currentVersion == "dev" || currentVersion == "" {
 		return nil
 	}
 
+	// Skip if opted out via environment (e.g., locked-down environments)
+	if UpdateCheckDisabled() {
+		return nil
+	}
+
 	// Check if we should skip (checked recently)
 	if shouldSkipCheck() {
 		return nil
@@ -72,6 +81,15 @@ func CheckForUpdate(currentVersion string) *CheckResult {
 	return nil
 }
 
+// UpdateCheckDisabled reports whether the update check is disabled via NoUpdateCheckEnv.
+func UpdateCheckDisabled() bool {
+	switch strings.ToLower(os.Getenv(NoUpdateCheckEnv)) {
+	case "", "0", "false":
+		return false
+	}
+	return true
+}
+
 // PrintUpdateNotice prints a notice if an update is available.
 func PrintUpdateNotice(result *CheckResult) {
 	if result == nil || !result.UpdateAvailable {
@@ -151,26 +169,103 @@ func getMarkerPath() string {
 }
 
 // isNewerVersion returns true if latest is newer than current.
-// Simple comparison: splits by dots and compares numerically.
+// Follows semver precedence: dot-separated parts compare numerically, and a
+// pre-release (e.g., "1.0.0-rc1") is older than the stable release of the same base.
 func isNewerVersion(latest, current string) bool {
-	latestParts := strings.Split(latest, ".")
-	currentParts := strings.Split(current, ".")
+	return compareVersions(latest, current) > 0
+}
+
+// compareVersions returns 1 if a is newer than b, -1 if older, 0 if equal.
+func compareVersions(a, b string) int {
+	aBase, aPre := splitPreRelease(a)
+	bBase, bPre := splitPreRelease(b)
+
+	aParts := strings.Split(aBase, ".")
+	bParts := strings.Split(bBase, ".")
 
 	// Compare each part
-	for i := 0; i < len(latestParts) && i < len(currentParts); i++ {
-		l := parseVersionPart(latestParts[i])
-		c := parseVersionPart(currentParts[i])
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		l := parseVersionPart(aParts[i])
+		c := parseVersionPart(bParts[i])
 
 		if l > c {
-			return true
+			return 1
 		}
 		if l < c {
-			return false
+			return -1
 		}
 	}
 
 	// If all compared parts are equal, longer version is newer
-	return len(latestParts) > len(currentParts)
+	if len(aParts) != len(bParts) {
+		if len(aParts) > len(bParts) {
+			return 1
+		}
+		return -1
+	}
+
+	return comparePreRelease(aPre, bPre)
+}
+
+// splitPreRelease splits "1.0.0-rc1+build" into "1.0.0" and "rc1".
+// Build metadata is ignored, as in semver.
+func splitPreRelease(v string) (base, pre string) {
+	if idx := strings.Index(v, "+"); idx != -1 {
+		v = v[:idx]
+	}
+	if idx := strings.Index(v, "-"); idx != -1 {
+		return v[:idx], v[idx+1:]
+	}
+	return v, ""
+}
+
+// comparePreRelease compares pre-release tags of equal base versions.
+// No tag (stable) is newer than any tag; otherwise dot-separated identifiers
+// compare numerically when both are numbers, lexically otherwise, and numbers
+// sort before words ("beta" > "alpha", "rc.2" > "rc.1", "rc2" > "rc1").
+func comparePreRelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	aIDs := strings.Split(a, ".")
+	bIDs := strings.Split(b, ".")
+	for i := 0; i < len(aIDs) && i < len(bIDs); i++ {
+		aNum, aErr := strconv.Atoi(aIDs[i])
+		bNum, bErr := strconv.Atoi(bIDs[i])
+
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum != bNum {
+				if aNum > bNum {
+					return 1
+				}
+				return -1
+			}
+		case aErr == nil:
+			return -1 // Numeric identifiers have lower precedence
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(aIDs[i], bIDs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	// A larger set of identifiers is newer if all preceding ones are equal
+	switch {
+	case len(aIDs) > len(bIDs):
+		return 1
+	case len(aIDs) < len(bIDs):
+		return -1
+	}
+	return 0
 }
 
 // parseVersionPart extracts a number from a version part (e.g., "1" from "1-beta").
@@ -1,6 +1,9 @@
 package version
 
-import "testing"
+import (
+	"os"
+	"testing"
+)
 
 func TestIsNewerVersion(t *testing.T) {
 	tests := []struct {
@@ -17,6 +20,14 @@ func TestIsNewerVersion(t *testing.T) {
 		{"v prefix handled", "0.4.1", "0.4.0", true},
 		{"longer version newer", "1.0.0.1", "1.0.0", true},
 		{"double digit", "1.10.0", "1.9.0", true},
+		{"stable newer than rc", "1.0.0", "1.0.0-rc1", true},
+		{"rc older than stable", "1.0.0-rc1", "1.0.0", false},
+		{"beta of next minor newer", "1.1.0-beta", "1.0.0", true},
+		{"rc2 newer than rc1", "1.0.0-rc2", "1.0.0-rc1", true},
+		{"dotted rc numeric", "1.0.0-rc.10", "1.0.0-rc.9", true},
+		{"beta newer than alpha", "1.0.0-beta", "1.0.0-alpha", true},
+		{"same pre-release", "1.0.0-rc1", "1.0.0-rc1", false},
+		{"build metadata ignored", "1.0.0+build5", "1.0.0", false},
 	}
 
 	for _, tt := range tests {
@@ -51,3 +62,38 @@ func TestParseVersionPart(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckForUpdateSkippedWhenEnvSet(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(NoUpdateCheckEnv, "1")
+
+	if result := CheckForUpdate("0.0.1"); result != nil {
+		t.Errorf("CheckForUpdate() = %+v, want nil when %s is set", result, NoUpdateCheckEnv)
+	}
+	if _, err := os.Stat(getMarkerPath()); !os.IsNotExist(err) {
+		t.Error("CheckForUpdate() should not run (or mark) the check when opted out")
+	}
+}
+
+func TestUpdateCheckDisabled(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"", false},
+		{"0", false},
+		{"false", false},
+		{"1", true},
+		{"true", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv(NoUpdateCheckEnv, tt.value)
+			if got := UpdateCheckDisabled(); got != tt.want {
+				t.Errorf("UpdateCheckDisabled() with %q = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -20,31 +20,55 @@ var (
 func main() {
 	versionStr := fmt.Sprintf("%s (commit: %s, built: %s)", version, commit, date)
 
+	// Completion output is read by the shell, so it gets no notices
+	completing := cmd.IsCompletion(os.Args[1:])
+
 	// Check for first run
-	if versionpkg.IsFirstRun() {
+	if !completing && versionpkg.IsFirstRun() {
 		versionpkg.PrintFirstRunNotice()
 	}
 
-	// Check for updates (runs in background, cached for 24h)
-	updateResult := versionpkg.CheckForUpdate(versionNum)
+	var noUpdateCheck bool
+	var updateResult *versionpkg.CheckResult
 
 	rootCmd := &cobra.Command{
 		Use:     "prd-parser",
 		Short:   "Parse PRDs into structured tasks with LLM guardrails",
 		Version: versionStr,
-		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRun: func(c *cobra.Command, args []string) {
+			cmd.ApplySymbols()
+
+			// Check for updates (cached for 24h), unless opted out by flag or env
+			if !noUpdateCheck && !completing {
+				updateResult = versionpkg.CheckForUpdate(versionNum)
+			}
+		},
+		PersistentPostRun: func(c *cobra.Command, args []string) {
 			// Show update notice after command completes
 			versionpkg.PrintUpdateNotice(updateResult)
 		},
 	}
+	cmd.AddPersistentFlags(rootCmd)
+	rootCmd.PersistentFlags().BoolVar(&noUpdateCheck, "no-update-check", false,
+		"Skip the GitHub update check (or set "+versionpkg.NoUpdateCheckEnv+"=1)")
+
+	// Flag errors are usage errors
+	rootCmd.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+		return cmd.UsageError(err)
+	})
 
 	// Add commands
 	rootCmd.AddCommand(cmd.ParseCmd)
+	rootCmd.AddCommand(cmd.BatchCmd)
 	rootCmd.AddCommand(cmd.RefineCmd)
+	rootCmd.AddCommand(cmd.PolishCmd)
+	rootCmd.AddCommand(cmd.ExplainCmd)
+	rootCmd.AddCommand(cmd.LintCmd)
 	rootCmd.AddCommand(cmd.SetupCmd)
+	rootCmd.AddCommand(cmd.CompletionCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
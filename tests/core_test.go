@@ -1,6 +1,10 @@
 package tests
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -147,6 +151,43 @@ func TestValidationError(t *testing.T) {
 	}
 }
 
+func TestValidateReportsUnresolvedDependencies(t *testing.T) {
+	resp := core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Shop"},
+		Epics: []core.Epic{
+			{TempID: "1", Title: "Setup", Tasks: []core.Task{
+				{TempID: "1.1", Title: "Repo", Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "Init", DependsOn: []string{"1.0.9"}}}},
+			}},
+			{TempID: "2", Title: "Checkout", DependsOn: []string{"1", "4"}, Tasks: []core.Task{
+				{TempID: "2.1", Title: "Cart", DependsOn: []string{"1.1", "2.7"}, Subtasks: []core.Subtask{{TempID: "2.1.1", Title: "Model"}}},
+			}},
+		},
+	}
+
+	want := []core.UnresolvedDependency{
+		{ItemID: "1.1.1", DependsOn: "1.0.9"},
+		{ItemID: "2", DependsOn: "4"},
+		{ItemID: "2.1", DependsOn: "2.7"},
+	}
+	if got := resp.UnresolvedDependencies(); !reflect.DeepEqual(got, want) {
+		t.Errorf("UnresolvedDependencies() = %+v, want %+v", got, want)
+	}
+
+	err := resp.Validate()
+	var validationErr *core.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "depends_on" {
+		t.Fatalf("Validate() error = %v, want a depends_on ValidationError", err)
+	}
+	for _, ref := range []string{"1.1.1 -> 1.0.9", "2 -> 4", "2.1 -> 2.7"} {
+		if !strings.Contains(err.Error(), ref) {
+			t.Errorf("Validate() error %q should list %s", err, ref)
+		}
+	}
+	if err := resp.ValidateComplete(); err != nil {
+		t.Errorf("ValidateComplete() error = %v, want nil: dangling dependencies are checked separately", err)
+	}
+}
+
 func TestPriorityConstants(t *testing.T) {
 	// Verify priority constants have expected values
 	if core.PriorityCritical != "critical" {
@@ -162,3 +203,1370 @@ func TestPriorityConstants(t *testing.T) {
 		t.Errorf("PriorityLow = %s, want low", core.PriorityLow)
 	}
 }
+
+func TestWarningCollector(t *testing.T) {
+	warnings := core.NewWarningCollector()
+	warnings.Add(core.WarnValidationGap, "1.2", "missing %s", "error handling")
+	warnings.Add(core.WarnPRDTruncated, "", "PRD truncated to %d chars", core.Stage2PRDLimit)
+
+	if warnings.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", warnings.Len())
+	}
+
+	got := warnings.Warnings()
+	for i, want := range []string{"[validation_gap] 1.2: missing error handling", "[prd_truncated] PRD truncated"} {
+		if !strings.HasPrefix(got[i].String(), want) {
+			t.Errorf("Warnings()[%d] = %q, want prefix %q", i, got[i], want)
+		}
+	}
+
+	var nilCollector *core.WarningCollector
+	if nilCollector.Len() != 0 || nilCollector.Warnings() != nil {
+		t.Error("nil collector should report no warnings")
+	}
+}
+
+func TestInheritLabels(t *testing.T) {
+	resp := &core.ParseResponse{
+		Epics: []core.Epic{{
+			TempID: "1",
+			Labels: []string{"auth", "backend", "setup"},
+			Tasks: []core.Task{{
+				TempID: "1.1",
+				Labels: []string{"Backend", "go"},
+				Subtasks: []core.Subtask{
+					{TempID: "1.1.1"},
+					{TempID: "1.1.2", Labels: []string{"auth", "testing"}},
+				},
+			}},
+		}},
+	}
+
+	core.InheritLabels(resp)
+
+	task := resp.Epics[0].Tasks[0]
+	assertLabels(t, "task", task.Labels, []string{"Backend", "go", "auth"})
+	assertLabels(t, "subtask 1.1.1", task.Subtasks[0].Labels, []string{"Backend", "auth"})
+	assertLabels(t, "subtask 1.1.2", task.Subtasks[1].Labels, []string{"auth", "testing", "Backend"})
+}
+
+func assertLabels(t *testing.T, item string, got, want []string) {
+	t.Helper()
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("%s labels = %v, want %v", item, got, want)
+	}
+}
+
+func TestParseResponseValidationLevels(t *testing.T) {
+	partial := core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Test"},
+		Epics: []core.Epic{
+			{
+				TempID: "1",
+				Title:  "Epic with full decomposition",
+				Tasks: []core.Task{
+					{TempID: "1.1", Title: "Task", Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "Subtask"}}},
+				},
+			},
+			{
+				TempID: "2",
+				Title:  "Epic with a task lacking subtasks",
+				Tasks:  []core.Task{{TempID: "2.1", Title: "Task", Subtasks: []core.Subtask{}}},
+			},
+		},
+	}
+
+	if err := partial.ValidateStructure(); err != nil {
+		t.Errorf("ValidateStructure() error = %v, want nil for a plan with empty subtask arrays", err)
+	}
+	if err := partial.ValidateComplete(); err == nil {
+		t.Error("ValidateComplete() should reject a task with empty subtasks")
+	}
+
+	untitled := partial
+	untitled.Epics = []core.Epic{{TempID: "1", Title: "Epic", Tasks: []core.Task{{TempID: "1.1"}}}}
+	if err := untitled.ValidateStructure(); err == nil {
+		t.Error("ValidateStructure() should reject a task without a title")
+	}
+}
+
+func TestSourceHintRoundTrip(t *testing.T) {
+	hint := "## Authentication"
+	epic := core.Epic{TempID: "1", Title: "Auth", SourceHint: &hint}
+
+	data, err := json.Marshal(epic)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"source_hint":"## Authentication"`) {
+		t.Errorf("marshaled epic should contain source_hint, got %s", data)
+	}
+
+	var decoded core.Epic
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded.SourceHint == nil || *decoded.SourceHint != hint {
+		t.Errorf("SourceHint = %v, want %q", decoded.SourceHint, hint)
+	}
+
+	var absent core.Task
+	if err := json.Unmarshal([]byte(`{"temp_id":"1.1","title":"Task"}`), &absent); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if absent.SourceHint != nil {
+		t.Errorf("SourceHint = %q, want nil when absent", *absent.SourceHint)
+	}
+}
+
+func TestSourceHintInstructionIsOptional(t *testing.T) {
+	config := core.DefaultParseConfig()
+	if strings.Contains(core.BuildStage1Prompt("# PRD", config), "source_hint") {
+		t.Error("Stage 1 prompt should not ask for source_hint by default")
+	}
+
+	config.SourceHints = true
+	if !strings.Contains(core.BuildStage1Prompt("# PRD", config), "source_hint") {
+		t.Error("Stage 1 prompt should ask for source_hint when SourceHints is set")
+	}
+	if !strings.Contains(core.BuildStage2Prompt(core.Epic{TempID: "1"}, core.ProjectContext{}, config), "source_hint") {
+		t.Error("Stage 2 prompt should ask for source_hint when SourceHints is set")
+	}
+}
+
+func TestCheckConstraintViolations(t *testing.T) {
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{
+			ProductName: "Payments",
+			Constraints: core.FlexibleStringSlice{"No third-party APIs", "No Redux"},
+		},
+		Epics: []core.Epic{{
+			TempID: "1",
+			Title:  "Checkout",
+			Tasks: []core.Task{
+				{TempID: "1.1", Title: "Set up Stripe billing", Description: "Create a checkout session"},
+				{TempID: "1.2", Title: "Build cart state", Description: "Store the cart with Redux"},
+				{TempID: "1.3", Title: "Render receipts", Description: "Use the in-house PDF generator",
+					Subtasks: []core.Subtask{{TempID: "1.3.1", Title: "Upload receipts to S3"}}},
+			},
+		}},
+	}
+
+	violations := core.CheckConstraintViolations(response)
+
+	got := make(map[string]string)
+	for _, v := range violations {
+		got[v.ItemID] = v.Keyword
+	}
+	want := map[string]string{"1.1": "stripe", "1.2": "redux", "1.3.1": "s3"}
+	if len(got) != len(want) {
+		t.Fatalf("violations = %v, want items %v", violations, want)
+	}
+	for id, keyword := range want {
+		if got[id] != keyword {
+			t.Errorf("item %s: keyword = %q, want %q", id, got[id], keyword)
+		}
+	}
+
+	response.Project.Constraints = core.FlexibleStringSlice{"Must ship by Q3"}
+	if v := core.CheckConstraintViolations(response); len(v) != 0 {
+		t.Errorf("unrelated constraint should not flag anything, got %v", v)
+	}
+}
+
+func TestCheckDependencyLevels(t *testing.T) {
+	plan := func() *core.ParseResponse {
+		return &core.ParseResponse{Epics: []core.Epic{
+			{TempID: "1", Title: "Setup", Tasks: []core.Task{
+				{TempID: "1.1", Title: "Repo", Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "Init"}}},
+				{TempID: "1.2", Title: "CI", Subtasks: []core.Subtask{{TempID: "1.2.1", Title: "Pipeline"}}},
+			}},
+			{TempID: "2", Title: "Auth", Tasks: []core.Task{
+				{TempID: "2.1", Title: "Login", Subtasks: []core.Subtask{{TempID: "2.1.1", Title: "Form"}}},
+			}},
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		mutate   func(r *core.ParseResponse)
+		wantItem string
+		wantDep  string
+		wantHint string
+	}{
+		{"subtask depends on epic", func(r *core.ParseResponse) {
+			r.Epics[1].Tasks[0].Subtasks[0].DependsOn = []string{"1"}
+		}, "2.1.1", "1", "1.2"},
+		{"subtask depends on own epic", func(r *core.ParseResponse) {
+			r.Epics[1].Tasks[0].Subtasks[0].DependsOn = []string{"2"}
+		}, "2.1.1", "2", "implied by the hierarchy"},
+		{"task depends on subtask", func(r *core.ParseResponse) {
+			r.Epics[1].Tasks[0].DependsOn = []string{"1.2.1"}
+		}, "2.1", "1.2.1", "1.2"},
+		{"task depends on own subtask", func(r *core.ParseResponse) {
+			r.Epics[0].Tasks[0].DependsOn = []string{"1.1.1"}
+		}, "1.1", "1.1.1", "own subtask"},
+		{"epic depends on task", func(r *core.ParseResponse) {
+			r.Epics[1].DependsOn = []string{"1.1"}
+		}, "2", "1.1", "epic 1"},
+		{"epic depends on subtask", func(r *core.ParseResponse) {
+			r.Epics[1].DependsOn = []string{"1.2.1"}
+		}, "2", "1.2.1", "epic 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := plan()
+			tt.mutate(response)
+
+			issues := core.CheckDependencyLevels(response)
+			if len(issues) != 1 {
+				t.Fatalf("issues = %v, want exactly one", issues)
+			}
+			issue := issues[0]
+			if issue.ItemID != tt.wantItem || issue.DependsOn != tt.wantDep {
+				t.Errorf("issue = %s -> %s, want %s -> %s", issue.ItemID, issue.DependsOn, tt.wantItem, tt.wantDep)
+			}
+			if !strings.Contains(issue.Suggestion, tt.wantHint) {
+				t.Errorf("suggestion = %q, want it to mention %q", issue.Suggestion, tt.wantHint)
+			}
+		})
+	}
+
+	// Same-level and downward-to-parent-level links are normal
+	response := plan()
+	response.Epics[1].DependsOn = []string{"1"}
+	response.Epics[1].Tasks[0].DependsOn = []string{"1.2", "1"}
+	response.Epics[1].Tasks[0].Subtasks[0].DependsOn = []string{"1.1.1", "1.2", "9.9"}
+	if issues := core.CheckDependencyLevels(response); len(issues) != 0 {
+		t.Errorf("normal dependencies flagged: %v", issues)
+	}
+}
+
+func TestWalkItemsVisitsEveryItemOnceInOrder(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "Setup", Tasks: []core.Task{
+			{TempID: "1.1", Title: "Repo", Subtasks: []core.Subtask{{TempID: "1.1.1"}, {TempID: "1.1.2"}}},
+			{TempID: "1.2", Title: "CI"},
+		}},
+		{TempID: "2", Title: "Auth", Tasks: []core.Task{
+			{TempID: "2.1", Title: "Login", Subtasks: []core.Subtask{{TempID: "2.1.1"}}},
+		}},
+	}}
+
+	var visited []string
+	err := core.WalkItems(response, func(item core.ItemRef) error {
+		visited = append(visited, item.Level+" "+item.TempID()+" "+item.Path+" <"+item.ParentTempID())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkItems() error = %v", err)
+	}
+
+	want := []string{
+		"epic 1 epics[0] <",
+		"task 1.1 epics[0].tasks[0] <1",
+		"subtask 1.1.1 epics[0].tasks[0].subtasks[0] <1.1",
+		"subtask 1.1.2 epics[0].tasks[0].subtasks[1] <1.1",
+		"task 1.2 epics[0].tasks[1] <1",
+		"epic 2 epics[1] <",
+		"task 2.1 epics[1].tasks[0] <2",
+		"subtask 2.1.1 epics[1].tasks[0].subtasks[0] <2.1",
+	}
+	if strings.Join(visited, "\n") != strings.Join(want, "\n") {
+		t.Errorf("visited:\n%s\nwant:\n%s", strings.Join(visited, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Items are visited by pointer, so callbacks can edit the response
+	_ = core.WalkItems(response, func(item core.ItemRef) error {
+		if item.Level == core.LevelSubtask {
+			item.Subtask.Title = "Edited " + item.Subtask.TempID
+		}
+		return nil
+	})
+	if got := response.Epics[1].Tasks[0].Subtasks[0].Title; got != "Edited 2.1.1" {
+		t.Errorf("subtask title = %q, want the edit to stick", got)
+	}
+
+	// An error stops the walk and is returned
+	stop := errors.New("stop")
+	count := 0
+	err = core.WalkItems(response, func(item core.ItemRef) error {
+		count++
+		if item.TempID() == "1.1" {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || count != 2 {
+		t.Errorf("WalkItems() = %v after %d items, want stop after 2", err, count)
+	}
+}
+
+func TestAssumptionsRoundTrip(t *testing.T) {
+	response := core.ParseResponse{
+		Assumptions:   []string{"Email/password login"},
+		OpenQuestions: []string{"Which payment provider?"},
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var decoded core.ParseResponse
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(decoded.Assumptions) != 1 || decoded.Assumptions[0] != "Email/password login" {
+		t.Errorf("Assumptions = %v, want round-tripped value", decoded.Assumptions)
+	}
+	if len(decoded.OpenQuestions) != 1 || decoded.OpenQuestions[0] != "Which payment provider?" {
+		t.Errorf("OpenQuestions = %v, want round-tripped value", decoded.OpenQuestions)
+	}
+
+	empty, _ := json.Marshal(core.ParseResponse{})
+	if strings.Contains(string(empty), "assumptions") || strings.Contains(string(empty), "open_questions") {
+		t.Errorf("empty fields should be omitted, got %s", empty)
+	}
+
+	config := core.DefaultParseConfig()
+	if strings.Contains(core.BuildStage1Prompt("# PRD", config), "open_questions") {
+		t.Error("Stage 1 prompt should not ask for open_questions by default")
+	}
+	config.Assumptions = true
+	if !strings.Contains(core.BuildStage1Prompt("# PRD", config), "open_questions") {
+		t.Error("Stage 1 prompt should ask for open_questions when Assumptions is set")
+	}
+}
+
+func TestCanonicalizeMakesEqualPlansIdentical(t *testing.T) {
+	a := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "App"},
+		Epics: []core.Epic{
+			{TempID: "2", Title: "Billing", DependsOn: []string{"1"}, Tasks: []core.Task{
+				{TempID: "2.10", Title: "Refunds", DependsOn: []string{"2.9", "1.1"}},
+				{TempID: "2.9", Title: "  Invoices\t page ", Labels: []string{"ui", "api"}},
+			}},
+			{TempID: "1", Title: "Auth", Tasks: []core.Task{{TempID: "1.1", Title: "Login", Description: "Email login\n"}}},
+		},
+	}
+	b := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "App"},
+		Epics: []core.Epic{
+			{TempID: "1", Title: "Auth", Tasks: []core.Task{{TempID: "1.1", Title: "Login", Description: "Email login"}}},
+			{TempID: "2", Title: "Billing", DependsOn: []string{"1", "1"}, Tasks: []core.Task{
+				{TempID: "2.9", Title: "Invoices page", Labels: []string{"api", "ui"}, DependsOn: []string{}},
+				{TempID: "2.10", Title: "Refunds", DependsOn: []string{"1.1", "2.9"}},
+			}},
+		},
+	}
+
+	core.Canonicalize(a)
+	core.Canonicalize(b)
+	dataA, _ := json.MarshalIndent(a, "", "  ")
+	dataB, _ := json.MarshalIndent(b, "", "  ")
+	if string(dataA) != string(dataB) {
+		t.Errorf("canonical JSON differs:\n%s\nvs\n%s", dataA, dataB)
+	}
+
+	// "2.9" sorts before "2.10", and nil depends_on becomes []
+	if got := a.Epics[1].Tasks[0].TempID; got != "2.9" {
+		t.Errorf("first task of epic 2 = %s, want 2.9", got)
+	}
+	if a.Epics[0].Tasks[0].DependsOn == nil {
+		t.Error("DependsOn should be [] rather than nil after Canonicalize")
+	}
+}
+
+func TestNoTestingStripsTestingInstructions(t *testing.T) {
+	config := core.DefaultParseConfig()
+	config.NoTesting = true
+
+	prompts := map[string]string{
+		"single-shot system": core.SystemPromptFor(core.SystemPrompt, config),
+		"stage 1 system":     core.SystemPromptFor(core.Stage1SystemPrompt, config),
+		"stage 2 system":     core.SystemPromptFor(core.Stage2SystemPrompt, config),
+		"stage 3 system":     core.SystemPromptFor(core.Stage3SystemPrompt, config),
+		"single-shot user":   core.BuildUserPrompt("# PRD", config),
+		"stage 1 user":       core.BuildStage1Prompt("# PRD", config),
+	}
+	for name, prompt := range prompts {
+		for _, banned := range []string{"unit_tests", "e2e_tests", "TESTING REQUIREMENTS", "TESTING AT EVERY LEVEL", "Testing level", `"testing"`, ", testing,"} {
+			if strings.Contains(prompt, banned) {
+				t.Errorf("%s prompt contains %q with NoTesting", name, banned)
+			}
+		}
+	}
+	if !strings.Contains(prompts["stage 2 system"], "TESTING DISABLED") {
+		t.Error("system prompt should tell the LLM to leave out testing")
+	}
+
+	// The rest of the prompt survives
+	if !strings.Contains(prompts["stage 2 system"], "## DEPENDENCIES") {
+		t.Error("stripping testing removed the following section")
+	}
+
+	config.NoTesting = false
+	if core.SystemPromptFor(core.Stage3SystemPrompt, config) != core.Stage3SystemPrompt {
+		t.Error("system prompt should be unchanged without NoTesting")
+	}
+	if !strings.Contains(core.BuildStage1Prompt("# PRD", config), "Testing level") {
+		t.Error("Stage 1 prompt should keep the testing level by default")
+	}
+}
+
+func TestParseBeadsListJSON(t *testing.T) {
+	data := []byte(`[
+		{"id": "app-e1", "title": "Foundation", "description": "Set up the project", "issue_type": "epic", "status": "open"},
+		{"id": "app-e1t1", "title": "Init repo", "description": "Create the repo", "issue_type": "task", "status": "open",
+		 "dependencies": [{"issue_id": "app-e1t1", "depends_on_id": "app-e1", "type": "parent-child"}]},
+		{"id": "app-e1t1s1", "title": "git init", "type": "subtask", "parent": "app-e1t1", "status": "closed"}
+	]`)
+
+	issues, err := core.ParseBeadsListJSON(data)
+	if err != nil {
+		t.Fatalf("ParseBeadsListJSON() error = %v", err)
+	}
+	want := []core.BeadsIssue{
+		{ID: "app-e1", Title: "Foundation", Description: "Set up the project", Type: "epic", Status: "open"},
+		{ID: "app-e1t1", Title: "Init repo", Description: "Create the repo", Type: "task", Parent: "app-e1", Status: "open"},
+		{ID: "app-e1t1s1", Title: "git init", Type: "subtask", Parent: "app-e1t1", Status: "closed"},
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("got %d issues, want %d", len(issues), len(want))
+	}
+	for i := range want {
+		if issues[i] != want[i] {
+			t.Errorf("issue %d = %+v, want %+v", i, issues[i], want[i])
+		}
+	}
+
+	if _, err := core.ParseBeadsListJSON([]byte("○ app-e1 - Foundation")); err == nil {
+		t.Error("ParseBeadsListJSON() should fail on text output")
+	}
+}
+
+func TestDependencyDepths(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Tasks: []core.Task{
+			{TempID: "1.1", Subtasks: []core.Subtask{{TempID: "1.1.1"}}},
+			{TempID: "1.2", DependsOn: []string{"1.1"}, Subtasks: []core.Subtask{
+				{TempID: "1.2.1", DependsOn: []string{"1.2"}},
+			}},
+		}},
+		{TempID: "2", DependsOn: []string{"1"}, Tasks: []core.Task{
+			{TempID: "2.1", DependsOn: []string{"1.2", "9.9"}, Subtasks: []core.Subtask{
+				{TempID: "2.1.1", DependsOn: []string{"2.1", "1.1.1"}},
+			}},
+		}},
+	}}
+
+	got := core.DependencyDepths(response)
+	want := map[int]int{0: 3, 1: 2, 2: 2, 3: 1}
+	if len(got) != len(want) {
+		t.Fatalf("DependencyDepths() = %v, want %v", got, want)
+	}
+	for depth, n := range want {
+		if got[depth] != n {
+			t.Errorf("depth %d: got %d items, want %d", depth, got[depth], n)
+		}
+	}
+
+	// A cycle still gives every item a depth
+	response.Epics[0].Tasks[0].DependsOn = []string{"1.2"}
+	total := 0
+	for _, n := range core.DependencyDepths(response) {
+		total += n
+	}
+	if total != 8 {
+		t.Errorf("cyclic plan counted %d items, want 8", total)
+	}
+}
+
+func TestCreationWaves(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Tasks: []core.Task{
+			{TempID: "1.1", Subtasks: []core.Subtask{{TempID: "1.1.1"}}},
+			{TempID: "1.2", DependsOn: []string{"1.1"}, Subtasks: []core.Subtask{
+				{TempID: "1.2.1", DependsOn: []string{"1.2"}},
+			}},
+		}},
+		{TempID: "2", DependsOn: []string{"1"}, Tasks: []core.Task{
+			{TempID: "2.1", DependsOn: []string{"1.2", "9.9"}, Subtasks: []core.Subtask{
+				{TempID: "2.1.1", DependsOn: []string{"2.1", "1.1.1"}},
+			}},
+		}},
+	}}
+
+	waves, acyclic := core.CreationWaves(response)
+	if !acyclic {
+		t.Fatal("CreationWaves() reported a cycle in an acyclic plan")
+	}
+	var got []string
+	for _, wave := range waves {
+		ids := make([]string, len(wave))
+		for i, item := range wave {
+			ids[i] = item.TempID()
+		}
+		got = append(got, strings.Join(ids, " "))
+	}
+	want := []string{"1", "1.1 2", "1.1.1 1.2", "1.2.1 2.1", "2.1.1"}
+	if strings.Join(got, " | ") != strings.Join(want, " | ") {
+		t.Errorf("CreationWaves() = %q, want %q", got, want)
+	}
+
+	response.Epics[0].Tasks[0].DependsOn = []string{"1.2"}
+	if waves, acyclic := core.CreationWaves(response); acyclic || waves != nil {
+		t.Errorf("cyclic plan: got %d waves, acyclic %v; want none, false", len(waves), acyclic)
+	}
+}
+
+func TestReadyItems(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "Core", Tasks: []core.Task{
+			{TempID: "1.1", Title: "Repo", Subtasks: []core.Subtask{
+				{TempID: "1.1.1", Title: "Init"},
+				{TempID: "1.1.2", Title: "Lint", DependsOn: []string{"1.1.1"}},
+			}},
+			{TempID: "1.2", Title: "CI", DependsOn: []string{"9.9"}},
+			{TempID: "1.3", Title: "Deploy", DependsOn: []string{"1.1"}},
+		}},
+		{TempID: "2", Title: "Auth", DependsOn: []string{"1"}, Tasks: []core.Task{
+			{TempID: "2.1", Title: "Login"},
+		}},
+	}}
+
+	ids := func(items []core.ItemRef) []string {
+		var out []string
+		for _, item := range items {
+			out = append(out, item.TempID())
+		}
+		return out
+	}
+
+	// Parents aren't listed, and unknown dependencies don't block
+	if got, want := ids(core.ReadyItems(response)), []string{"1.1.1", "1.2"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ReadyItems() = %v, want %v", got, want)
+	}
+
+	// Closing 1.1.1 unblocks 1.1.2; closing all of task 1.1 unblocks 1.3
+	if got, want := ids(core.ReadyItemsAfter(response, map[string]bool{"1.1.1": true})), []string{"1.1.2", "1.2"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ReadyItemsAfter(1.1.1) = %v, want %v", got, want)
+	}
+	if got, want := ids(core.ReadyItemsAfter(response, map[string]bool{"1.1": true})), []string{"1.2", "1.3"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ReadyItemsAfter(1.1) = %v, want %v", got, want)
+	}
+
+	// Epic 2 waits for all of epic 1
+	if got, want := ids(core.ReadyItemsAfter(response, map[string]bool{"1": true})), []string{"2.1"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ReadyItemsAfter(1) = %v, want %v", got, want)
+	}
+
+	// A dependency on the foundation epic doesn't hold feature work back,
+	// but items inside the foundation still wait for each other
+	response.Epics[0].Title = "Project Setup"
+	if got, want := ids(core.ReadyItems(response)), []string{"1.1.1", "1.2", "2.1"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ReadyItems() with a foundation epic = %v, want %v", got, want)
+	}
+}
+
+func TestOffsetEpicIDs(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "Setup", Tasks: []core.Task{
+			{TempID: "1.1", Title: "Repo", Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "Init"}}},
+		}},
+		{TempID: "2", Title: "Auth", DependsOn: []string{"1"}, Tasks: []core.Task{
+			{TempID: "2.1", Title: "Login", DependsOn: []string{"1.1", "external"}, Subtasks: []core.Subtask{
+				{TempID: "2.1.1", Title: "Form", DependsOn: []string{"1.1.1"}},
+			}},
+		}},
+	}}
+
+	if err := core.OffsetEpicIDs(response, 5); err != nil {
+		t.Fatalf("OffsetEpicIDs() error = %v", err)
+	}
+
+	var got []string
+	_ = core.WalkItems(response, func(item core.ItemRef) error {
+		got = append(got, item.TempID()+"->"+strings.Join(item.DependsOn(), ","))
+		return nil
+	})
+	want := []string{"5->", "5.1->", "5.1.1->", "6->5", "6.1->5.1,external", "6.1.1->5.1.1"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("offset plan = %v, want %v", got, want)
+	}
+
+	// Already starting at 5: nothing changes
+	if err := core.OffsetEpicIDs(response, 5); err != nil || response.Epics[0].TempID != "5" {
+		t.Errorf("second offset changed the plan: %v, err %v", response.Epics[0].TempID, err)
+	}
+
+	bad := &core.ParseResponse{Epics: []core.Epic{{TempID: "E1", Title: "Setup"}}}
+	if err := core.OffsetEpicIDs(bad, 5); err == nil {
+		t.Error("OffsetEpicIDs() should reject non-numeric epic temp_ids")
+	}
+}
+
+func TestItemCaps(t *testing.T) {
+	plan := func() *core.ParseResponse {
+		return &core.ParseResponse{Epics: []core.Epic{
+			{TempID: "1", Title: "Setup", Tasks: []core.Task{
+				{TempID: "1.1", Title: "Repo", Subtasks: []core.Subtask{
+					{TempID: "1.1.1", Title: "Init"},
+					{TempID: "1.1.2", Title: "Lint"},
+					{TempID: "1.1.3", Title: "Format", DependsOn: []string{"1.1.1"}},
+				}},
+				{TempID: "1.2", Title: "CI"},
+				{TempID: "1.3", Title: "Deploy", Subtasks: []core.Subtask{{TempID: "1.3.1", Title: "Script"}}},
+			}},
+			{TempID: "2", Title: "Auth", Tasks: []core.Task{
+				{TempID: "2.1", Title: "Login", DependsOn: []string{"1.3", "1.2"}, Subtasks: []core.Subtask{
+					{TempID: "2.1.1", Title: "Form", DependsOn: []string{"1.1.3", "1.3.1"}},
+				}},
+			}},
+		}}
+	}
+
+	violations := core.CheckItemCaps(plan(), 2, 2)
+	if len(violations) != 2 {
+		t.Fatalf("violations = %v, want 2", violations)
+	}
+	if v := violations[0]; v.ItemID != "1" || v.Level != core.LevelTask || v.Count != 3 || v.Max != 2 {
+		t.Errorf("violations[0] = %+v, want epic 1 with 3 of max 2 tasks", v)
+	}
+	if v := violations[1]; v.ItemID != "1.1" || v.Level != core.LevelSubtask || v.Count != 3 {
+		t.Errorf("violations[1] = %+v, want task 1.1 with 3 subtasks", v)
+	}
+	if got := core.CheckItemCaps(plan(), 0, 0); len(got) != 0 {
+		t.Errorf("zero caps should be unlimited, got %v", got)
+	}
+
+	response := plan()
+	dropped := core.TrimToCaps(response, 2, 2)
+	if got, want := strings.Join(dropped, ","), "1.3,1.3.1,1.1.3"; got != want {
+		t.Errorf("dropped = %s, want %s", got, want)
+	}
+	if len(response.Epics[0].Tasks) != 2 || len(response.Epics[0].Tasks[0].Subtasks) != 2 {
+		t.Errorf("plan not trimmed to caps: %+v", response.Epics[0])
+	}
+	if got := response.Epics[1].Tasks[0].DependsOn; len(got) != 1 || got[0] != "1.2" {
+		t.Errorf("task 2.1 depends_on = %v, want [1.2]", got)
+	}
+	if got := response.Epics[1].Tasks[0].Subtasks[0].DependsOn; len(got) != 0 {
+		t.Errorf("subtask 2.1.1 depends_on = %v, want dropped items removed", got)
+	}
+	if got := core.CheckItemCaps(response, 2, 2); len(got) != 0 {
+		t.Errorf("trimmed plan still over caps: %v", got)
+	}
+}
+
+func TestFindRequirementIDs(t *testing.T) {
+	prd := `# Shop
+
+## Requirements
+- FR-1: Users can sign up
+- **FR-2**: Users can log in (see FR-1)
+1. NFR-1.2 Pages load in under 2s
+
+### REQ-7 Checkout
+| US-3 | As a buyer I can pay |
+
+Text is stored as UTF-8 and FR-99 is only mentioned here.`
+
+	got := core.FindRequirementIDs(prd)
+	want := []string{"FR-1", "FR-2", "NFR-1.2", "REQ-7", "US-3"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("FindRequirementIDs() = %v, want %v", got, want)
+	}
+	if got := core.FindRequirementIDs("A PRD without numbered requirements."); got != nil {
+		t.Errorf("FindRequirementIDs() = %v, want nil", got)
+	}
+	if got := core.SplitRequirementIDs("FR-3, FR-4;FR-5"); strings.Join(got, ",") != "FR-3,FR-4,FR-5" {
+		t.Errorf("SplitRequirementIDs() = %v", got)
+	}
+
+	config := core.DefaultParseConfig()
+	if strings.Contains(core.BuildUserPrompt("prd", config), "requirement_id") {
+		t.Error("requirement_id instruction should be opt-in")
+	}
+	config.RequirementIDs = true
+	if !strings.Contains(core.BuildUserPrompt("prd", config), core.RequirementIDInstruction) {
+		t.Error("prompt missing requirement_id instruction")
+	}
+}
+
+func TestCheckPromptSize(t *testing.T) {
+	prompt := func(tokens int) string { return strings.Repeat("abcd", tokens) }
+
+	tests := []struct {
+		name    string
+		model   string
+		tokens  int // user prompt tokens
+		reserve int
+		want    bool // issue reported
+		limit   int
+	}{
+		{"opus fits", "claude-opus-4-5-20251101", 150000, 0, false, 200000},
+		{"opus over with default reserve", "claude-opus-4-5-20251101", 190000, 0, true, 200000},
+		{"reserve counts against the limit", "claude-sonnet-4-20250514", 150000, 64000, true, 200000},
+		{"gpt-4o has a smaller window", "gpt-4o-mini", 120000, 0, true, 128000},
+		{"gpt-4.1 has a larger window", "gpt-4.1", 500000, 0, false, 1000000},
+		{"unknown model uses the default", "", 190000, 0, true, core.DefaultContextLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := core.ContextLimit(tt.model); got != tt.limit {
+				t.Errorf("ContextLimit(%q) = %d, want %d", tt.model, got, tt.limit)
+			}
+			issue := core.CheckPromptSize(tt.model, "", prompt(tt.tokens), tt.reserve)
+			if (issue != nil) != tt.want {
+				t.Fatalf("CheckPromptSize() = %v, want issue: %v", issue, tt.want)
+			}
+			if issue != nil && (issue.PromptTokens != tt.tokens || issue.Limit != tt.limit) {
+				t.Errorf("issue = %+v, want %d prompt tokens against %d", issue, tt.tokens, tt.limit)
+			}
+		})
+	}
+}
+
+func TestTopoSortTempIDs(t *testing.T) {
+	tests := []struct {
+		name      string
+		ids       []string
+		dependsOn map[string][]string
+		want      []string
+	}{
+		{"plan order kept", []string{"1.1", "1.2", "2.1"}, map[string][]string{"1.2": {"1.1"}}, []string{"1.1", "1.2", "2.1"}},
+		{"blocker moved ahead", []string{"1.1", "1.2", "1.3"}, map[string][]string{"1.1": {"1.3"}}, []string{"1.2", "1.3", "1.1"}},
+		{"other levels and self ignored", []string{"1.1", "1.2"}, map[string][]string{"1.1": {"1", "1.1", "9.9"}, "1.2": {"1.1.1"}}, []string{"1.1", "1.2"}},
+		{"cycle last in plan order", []string{"1", "2", "3", "4"}, map[string][]string{"1": {"3"}, "3": {"1"}, "4": {"3"}}, []string{"2", "1", "3", "4"}},
+	}
+	for _, tt := range tests {
+		if got := core.TopoSortTempIDs(tt.ids, tt.dependsOn); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: TopoSortTempIDs() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMissingFoundationDeps(t *testing.T) {
+	tests := []struct {
+		name  string
+		epics []core.Epic
+		want  []string
+	}{
+		{"all depend on foundation", []core.Epic{
+			{TempID: "1", Title: "Project Setup"}, {TempID: "2", Title: "Auth", DependsOn: []string{"1"}},
+		}, nil},
+		{"missing and cycle-closing", []core.Epic{
+			{TempID: "1", Title: "Foundation & Core Setup", DependsOn: []string{"3"}},
+			{TempID: "2", Title: "Auth"}, {TempID: "3", Title: "Billing"}, {TempID: "4", Title: "Search", DependsOn: []string{"2"}},
+		}, []string{"2", "4"}},
+		{"no foundation epic", []core.Epic{{TempID: "1", Title: "Auth"}, {TempID: "2", Title: "Billing"}}, nil},
+		{"offset epics", []core.Epic{{TempID: "5", Title: "Bootstrap repo"}, {TempID: "6", Title: "Auth"}}, []string{"6"}},
+	}
+	for _, tt := range tests {
+		response := &core.ParseResponse{Epics: tt.epics}
+		if got := core.MissingFoundationDeps(response); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: MissingFoundationDeps() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckIDSequence(t *testing.T) {
+	epic := func(id string, tasks ...core.Task) core.Epic {
+		return core.Epic{TempID: id, Title: "Epic " + id, Tasks: tasks}
+	}
+	task := func(id string, subtasks ...string) core.Task {
+		t := core.Task{TempID: id, Title: "Task " + id}
+		for _, s := range subtasks {
+			t.Subtasks = append(t.Subtasks, core.Subtask{TempID: s, Title: "Subtask " + s})
+		}
+		return t
+	}
+
+	tests := []struct {
+		name  string
+		epics []core.Epic
+		want  []core.IDSequenceIssue
+	}{
+		{
+			name:  "contiguous",
+			epics: []core.Epic{epic("1", task("1.1", "1.1.1", "1.1.2"), task("1.2")), epic("2", task("2.1"))},
+		},
+		{
+			name:  "offset epics",
+			epics: []core.Epic{epic("5", task("5.1")), epic("6", task("6.1"))},
+		},
+		{
+			name:  "epic gap",
+			epics: []core.Epic{epic("1"), epic("2"), epic("4")},
+			want:  []core.IDSequenceIssue{{ItemID: "4", Message: "temp_ids skip 3"}},
+		},
+		{
+			name:  "task and subtask gaps",
+			epics: []core.Epic{epic("1", task("1.2", "1.2.1", "1.2.5"))},
+			want: []core.IDSequenceIssue{
+				{ItemID: "1.2", Message: "temp_ids skip 1.1"},
+				{ItemID: "1.2.5", Message: "temp_ids skip 1.2.2-1.2.4"},
+			},
+		},
+		{
+			name:  "out of order",
+			epics: []core.Epic{epic("2", task("2.1"), task("2.3"), task("2.2")), epic("1")},
+			want: []core.IDSequenceIssue{
+				{ItemID: "1", Message: "listed after 2"},
+				{ItemID: "2.2", Message: "listed after 2.3"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := core.CheckIDSequence(&core.ParseResponse{Epics: tt.epics})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckIDSequence() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// foundationReviewer answers the review prompt by adding a foundation epic that
+// the existing epic depends on, and moving the second task first.
+type foundationReviewer struct{}
+
+func (foundationReviewer) GenerateRaw(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	return `{
+		"review_notes": "Added Project Foundation epic",
+		"project": {"product_name": "Shop"},
+		"epics": [
+			{"temp_id": "0", "title": "Project Foundation", "depends_on": [], "tasks": [
+				{"temp_id": "0.1", "title": "Init repo", "depends_on": [], "subtasks": [{"temp_id": "0.1.1", "title": "git init"}]}
+			]},
+			{"temp_id": "1", "title": "Checkout", "depends_on": ["0"], "tasks": [
+				{"temp_id": "1.2", "title": "Payment", "depends_on": []},
+				{"temp_id": "1.1", "title": "Cart", "depends_on": []}
+			]}
+		]
+	}`, nil
+}
+
+func TestReviewAndFixDiffsStructure(t *testing.T) {
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Shop"},
+		Epics: []core.Epic{{TempID: "1", Title: "Checkout", Tasks: []core.Task{
+			{TempID: "1.1", Title: "Cart", Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "Cart model"}}},
+			{TempID: "1.2", Title: "Payment", Subtasks: []core.Subtask{{TempID: "1.2.1", Title: "Stripe client"}}},
+		}}},
+	}
+
+	result, err := core.ReviewAndFix(context.Background(), response, "# PRD", foundationReviewer{})
+	if err != nil {
+		t.Fatalf("ReviewAndFix() error = %v", err)
+	}
+	want := []string{
+		`added epic 0 "Project Foundation"`,
+		`added task 0.1 "Init repo"`,
+		`added subtask 0.1.1 "git init"`,
+		`moved task 1.2 "Payment" from position 2 to 1`,
+		`epic 1 now depends on 0`,
+	}
+	if got := result.Changes.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Changes.Lines() = %q, want %q (notes %q)", got, want, result.ReviewNotes)
+	}
+
+	if changes := core.DiffReview(response, response); !changes.Empty() {
+		t.Errorf("DiffReview() of an unchanged plan = %+v, want no changes", changes)
+	}
+}
+
+func TestReviewAndFixKeepsIncompleteMerge(t *testing.T) {
+	// The added foundation task has no subtasks; that's an advisory
+	// incomplete_plan warning for parse, not a reason to drop the review
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Shop"},
+		Epics: []core.Epic{{TempID: "1", Title: "Checkout", Tasks: []core.Task{
+			{TempID: "1.1", Title: "Cart", Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "Cart model"}}},
+			{TempID: "1.2", Title: "Payment"},
+		}}},
+	}
+
+	result, err := core.ReviewAndFix(context.Background(), response, "# PRD", foundationReviewer{})
+	if err != nil {
+		t.Fatalf("ReviewAndFix() error = %v", err)
+	}
+	if !result.WasModified || len(result.Response.Epics) != 2 {
+		t.Errorf("review not applied to a plan with a subtask-less task: %s", result.ReviewNotes)
+	}
+}
+
+// gapClosingReviewer answers the gap-fixing prompt with an install task added to
+// the foundation epic, and records the prompt it was given.
+type gapClosingReviewer struct{ prompt *string }
+
+func (r gapClosingReviewer) GenerateRaw(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	*r.prompt = userPrompt
+	return "```json\n" + `{
+		"review_notes": "Added a dependency install task for the missing setup step",
+		"project": {"product_name": "Shop"},
+		"epics": [
+			{"temp_id": "1", "title": "Project Foundation", "depends_on": [], "tasks": [
+				{"temp_id": "1.1", "title": "Init repo", "depends_on": []},
+				{"temp_id": "1.2", "title": "Install dependencies", "description": "npm install and lockfile", "depends_on": ["1.1"],
+					"subtasks": [{"temp_id": "1.2.1", "title": "Add package.json", "description": "Declare runtime deps"}]}
+			]},
+			{"temp_id": "2", "title": "Checkout", "depends_on": ["1"], "tasks": [{"temp_id": "2.1", "title": "Cart", "depends_on": ["1.2"]}]}
+		]
+	}` + "\n```", nil
+}
+
+func TestFixGapsMergesGapClosingTask(t *testing.T) {
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Shop"},
+		Epics: []core.Epic{
+			{TempID: "1", Title: "Project Foundation", Tasks: []core.Task{
+				{TempID: "1.1", Title: "Init repo", Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "git init"}}},
+			}},
+			{TempID: "2", Title: "Checkout", DependsOn: []string{"1"}, Tasks: []core.Task{
+				{TempID: "2.1", Title: "Cart", Subtasks: []core.Subtask{{TempID: "2.1.1", Title: "Cart model"}}},
+			}},
+		},
+	}
+	gaps := []string{"Missing dependency install task"}
+
+	var prompt string
+	result, err := core.FixGaps(context.Background(), response, "# Shop PRD", gaps, gapClosingReviewer{&prompt})
+	if err != nil {
+		t.Fatalf("FixGaps() error = %v", err)
+	}
+	if !strings.Contains(prompt, "- Missing dependency install task") || !strings.Contains(prompt, "# Shop PRD") {
+		t.Errorf("prompt should list the gaps and include the PRD, got %q", prompt)
+	}
+	if !result.WasModified {
+		t.Fatalf("FixGaps() did not apply the fix: %s", result.ReviewNotes)
+	}
+
+	foundation := result.Response.Epics[0]
+	if len(foundation.Tasks) != 2 || foundation.Tasks[1].Title != "Install dependencies" || len(foundation.Tasks[1].Subtasks) != 1 {
+		t.Fatalf("foundation tasks = %+v, want the install task merged with its subtask", foundation.Tasks)
+	}
+	// Existing tasks keep their subtasks
+	if cart := result.Response.Epics[1].Tasks[0]; len(cart.Subtasks) != 1 || !reflect.DeepEqual(cart.DependsOn, []string{"1.2"}) {
+		t.Errorf("cart task = %+v, want its subtask kept and a dependency on 1.2", cart)
+	}
+	want := []string{
+		`added task 1.2 "Install dependencies"`,
+		`added subtask 1.2.1 "Add package.json"`,
+		`task 2.1 now depends on 1.2`,
+	}
+	if got := result.Changes.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Changes.Lines() = %q, want %q", got, want)
+	}
+}
+
+func TestLintAggregatesChecks(t *testing.T) {
+	negative := -15
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Shop"},
+		Epics: []core.Epic{
+			{TempID: "1", Title: "Checkout", DependsOn: []string{"2"}, AcceptanceCriteria: []string{"Payments are secure"},
+				Tasks: []core.Task{
+					{TempID: "1.1", Title: "Cart", DependsOn: []string{"9.9"}, Subtasks: []core.Subtask{
+						{TempID: "1.1.1", Title: "Cart model", EstimatedMinutes: &negative},
+					}},
+				}},
+			{TempID: "2", Title: "Catalog", DependsOn: []string{"1"}, Tasks: []core.Task{
+				{TempID: "2.1", Title: "Listing"},
+			}},
+		},
+	}
+
+	report := core.Lint(response)
+	groups := report.ByCheck()
+	want := map[string][]string{ // check -> severities, in order
+		core.LintCycles:       {core.LintError},
+		core.LintDanglingDeps: {core.LintError},
+		core.LintFoundation:   {core.LintWarning, core.LintWarning},
+		core.LintAcceptance:   {core.LintWarning, core.LintWarning},
+		core.LintGranularity:  {core.LintWarning},
+		core.LintEstimates:    {core.LintError, core.LintWarning},
+	}
+	for _, check := range core.LintChecks {
+		var got []string
+		for _, issue := range groups[check] {
+			got = append(got, issue.Severity)
+		}
+		if strings.Join(got, ",") != strings.Join(want[check], ",") {
+			t.Errorf("%s: severities %v, want %v (issues: %+v)", check, got, want[check], groups[check])
+		}
+	}
+	if n := report.Errors(); n != 3 {
+		t.Errorf("Errors() = %d, want 3", n)
+	}
+	if cycle := groups[core.LintCycles]; len(cycle) == 1 && !strings.Contains(cycle[0].Message, "1, 2") {
+		t.Errorf("cycle message = %q, want it to name epics 1 and 2", cycle[0].Message)
+	}
+	if dangling := groups[core.LintDanglingDeps]; len(dangling) == 1 && dangling[0].ItemID != "1.1" {
+		t.Errorf("dangling dependency reported on %q, want 1.1", dangling[0].ItemID)
+	}
+
+	// Fixing the errors leaves only warnings
+	response.Epics[0].DependsOn = nil
+	response.Epics[0].Tasks[0].DependsOn = nil
+	response.Epics[0].Tasks[0].Subtasks[0].EstimatedMinutes = nil
+	if n := core.Lint(response).Errors(); n != 0 {
+		t.Errorf("after fixes, Errors() = %d, want 0", n)
+	}
+}
+
+func TestCheckOrphanFunctionality(t *testing.T) {
+	response := &core.ParseResponse{
+		Epics: []core.Epic{
+			{
+				TempID: "1",
+				Title:  "Billing engine",
+				Tasks: []core.Task{
+					{TempID: "1.1", Title: "Invoice schema", Labels: []string{"database"}},
+					{TempID: "1.2", Title: "Invoice endpoints", Labels: []string{"api", "backend"}},
+				},
+			},
+			{
+				TempID: "2",
+				Title:  "Search",
+				Tasks: []core.Task{
+					{TempID: "2.1", Title: "Search index", Labels: []string{"backend"}},
+					{TempID: "2.2", Title: "Search page", Labels: []string{"Frontend"}},
+				},
+			},
+			{
+				TempID:             "3",
+				Title:              "Exports",
+				AcceptanceCriteria: []string{"Export job logs its progress"},
+				Tasks:              []core.Task{{TempID: "3.1", Title: "Export worker", Labels: []string{"backend"}}},
+			},
+		},
+	}
+
+	orphans := core.CheckOrphanFunctionality(response)
+	if len(orphans) != 1 || orphans[0].EpicID != "1" {
+		t.Fatalf("CheckOrphanFunctionality() = %v, want only epic 1", orphans)
+	}
+
+	prompt := core.BuildReviewPrompt(response, "")
+	if !strings.Contains(prompt, "ORPHAN FUNCTIONALITY") || !strings.Contains(prompt, `epic 1 "Billing engine"`) {
+		t.Errorf("review prompt doesn't flag the orphan epic:\n%s", prompt)
+	}
+}
+
+func TestInferDependenciesThreshold(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{{
+		TempID: "1",
+		Title:  "Accounts",
+		Tasks: []core.Task{
+			{TempID: "1.1", Title: "Users table schema", Labels: []string{"database"}},
+			{TempID: "1.2", Title: "Login endpoint", Description: "Checks credentials against the users table", Labels: []string{"api"}},
+			{TempID: "1.3", Title: "Password reset email", Description: "Sends a reset link to users"},
+		},
+	}}}
+
+	inferred := core.InferDependencies(response)
+	byLink := make(map[string]core.InferredDependency)
+	for _, d := range inferred {
+		byLink[d.ItemID+"->"+d.DependsOn] = d
+	}
+	strong, ok := byLink["1.2->1.1"]
+	if !ok || strong.Confidence < 0.6 {
+		t.Fatalf("1.2->1.1 = %+v, want a high-confidence inference", strong)
+	}
+	weak, ok := byLink["1.3->1.1"]
+	if !ok || weak.Confidence >= 0.6 {
+		t.Fatalf("1.3->1.1 = %+v, want a low-confidence inference", weak)
+	}
+
+	applied, suggested := core.ApplyInferredDependencies(response, inferred, 0.6)
+	tasks := response.Epics[0].Tasks
+	if len(applied) != 1 || strings.Join(tasks[1].DependsOn, ",") != "1.1" {
+		t.Errorf("applied = %v, 1.2 depends_on = %v; want only 1.2->1.1 applied", applied, tasks[1].DependsOn)
+	}
+	if len(tasks[2].DependsOn) != 0 {
+		t.Errorf("low-confidence inference applied: 1.3 depends_on = %v", tasks[2].DependsOn)
+	}
+	found := false
+	for _, d := range suggested {
+		found = found || (d.ItemID == "1.3" && d.DependsOn == "1.1")
+	}
+	if !found {
+		t.Errorf("suggested = %v, want 1.3->1.1 reported", suggested)
+	}
+
+	// Existing links are not inferred again
+	for _, d := range core.InferDependencies(response) {
+		if d.ItemID == "1.2" && d.DependsOn == "1.1" {
+			t.Error("inferred a dependency that already exists")
+		}
+	}
+}
+
+func TestExplainItem(t *testing.T) {
+	unit := core.FlexibleString("hashes and verifies passwords")
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{
+			BusinessGoals: core.FlexibleStringSlice{"Store passwords safely", "Reduce support tickets"},
+			UserGoals:     core.FlexibleStringSlice{"Sign in securely", "Export reports"},
+		},
+		Epics: []core.Epic{
+			{TempID: "1", Title: "Accounts", Tasks: []core.Task{
+				{TempID: "1.1", Title: "Users table"},
+			}},
+			{TempID: "2", Title: "Authentication", Tasks: []core.Task{
+				{TempID: "2.1", Title: "Sessions"},
+				{TempID: "2.2", Title: "Password storage", Description: "Store passwords so users can sign in securely",
+					Context: core.ContextText("Security review requires bcrypt"), DependsOn: []string{"1.1", "9.9"},
+					Testing: core.TestingRequirements{UnitTests: &unit}, RequirementID: "FR-4"},
+				{TempID: "2.3", Title: "Login endpoint", DependsOn: []string{"2.2"}, Subtasks: []core.Subtask{
+					{TempID: "2.3.1", Title: "Rate limiting", DependsOn: []string{"2.2"}},
+				}},
+			}},
+		},
+	}
+
+	e, err := core.ExplainItem(response, "2.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := func(items []core.ItemRef) string {
+		var out []string
+		for _, item := range items {
+			out = append(out, item.TempID())
+		}
+		return strings.Join(out, ",")
+	}
+	if e.Level != core.LevelTask || e.Title != "Password storage" || e.Context != "Security review requires bcrypt" {
+		t.Errorf("explanation = %+v", e)
+	}
+	if got := ids(e.Parents); got != "2" {
+		t.Errorf("parents = %s, want 2", got)
+	}
+	if got := ids(e.DependsOn); got != "1.1" {
+		t.Errorf("depends on = %s, want 1.1 (unknown 9.9 skipped)", got)
+	}
+	if got := ids(e.Dependents); got != "2.3,2.3.1" {
+		t.Errorf("dependents = %s, want 2.3,2.3.1", got)
+	}
+	if strings.Join(e.Goals, "|") != "Store passwords safely|Sign in securely" {
+		t.Errorf("goals = %v", e.Goals)
+	}
+	if e.RequirementID != "FR-4" || e.Testing.UnitTests == nil {
+		t.Errorf("requirement = %q, unit tests = %v", e.RequirementID, e.Testing.UnitTests)
+	}
+
+	if _, err := core.ExplainItem(response, "7"); err == nil {
+		t.Error("expected an error for an unknown temp_id")
+	}
+}
+
+func TestCheckProjectContext(t *testing.T) {
+	complete := func() core.ProjectContext {
+		return core.ProjectContext{
+			ProductName:    "Herd",
+			ElevatorPitch:  "Voice-first CRM for ranchers",
+			TargetAudience: "Ranch owners",
+			UserGoals:      core.FlexibleStringSlice{"Log calls hands-free"},
+			TechStack:      core.FlexibleStringSlice{"Go", "Postgres"},
+		}
+	}
+	if issues := core.CheckProjectContext(&core.ProjectContext{}); len(issues) != 5 {
+		t.Errorf("empty context: issues = %v, want all 5 fields reported", issues)
+	}
+	full := complete()
+	if issues := core.CheckProjectContext(&full); len(issues) != 0 {
+		t.Errorf("complete context: issues = %v, want none", issues)
+	}
+
+	tests := []struct {
+		name  string
+		clear func(*core.ProjectContext)
+		want  string
+	}{
+		{"missing product name", func(p *core.ProjectContext) { p.ProductName = "  " }, "product_name is empty"},
+		{"empty tech stack", func(p *core.ProjectContext) { p.TechStack = core.FlexibleStringSlice{""} }, "tech_stack is empty"},
+		{"empty audience", func(p *core.ProjectContext) { p.TargetAudience = "" }, "target_audience is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := complete()
+			tt.clear(&project)
+			issues := core.CheckProjectContext(&project)
+			if len(issues) != 1 || issues[0] != tt.want {
+				t.Errorf("issues = %v, want [%s]", issues, tt.want)
+			}
+		})
+	}
+}
+
+// cannedReviewer is a core.Reviewer that returns output and records the prompt.
+type cannedReviewer struct {
+	output     string
+	userPrompt string
+}
+
+func (r *cannedReviewer) GenerateRaw(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	r.userPrompt = userPrompt
+	return r.output, nil
+}
+
+// estimatePlan has one estimated epic, task, and subtask out of six items.
+func estimatePlan() *core.ParseResponse {
+	days, hours, minutes := 5.0, 4.0, 30
+	return &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "Accounts", EstimatedDays: &days, Tasks: []core.Task{
+			{TempID: "1.1", Title: "Users table", EstimatedHours: &hours, Subtasks: []core.Subtask{
+				{TempID: "1.1.1", Title: "Migration", EstimatedMinutes: &minutes},
+				{TempID: "1.1.2", Title: "Indexes", Description: "Add email index"},
+			}},
+			{TempID: "1.2", Title: "Login endpoint"},
+		}},
+		{TempID: "2", Title: "Search"},
+	}}
+}
+
+func TestCheckEstimateCoverage(t *testing.T) {
+	coverage := core.CheckEstimateCoverage(estimatePlan())
+	if coverage.Items != 6 || coverage.Estimated != 3 {
+		t.Errorf("coverage = %d of %d, want 3 of 6", coverage.Estimated, coverage.Items)
+	}
+	if got := strings.Join(coverage.Missing, ","); got != "1.1.2,1.2,2" {
+		t.Errorf("missing = %s, want 1.1.2,1.2,2", got)
+	}
+	if coverage.Fraction() != 0.5 || coverage.Low() {
+		t.Errorf("fraction = %v, low = %v; want 0.5, not low", coverage.Fraction(), coverage.Low())
+	}
+
+	zero := 0.0
+	plan := estimatePlan()
+	plan.Epics[0].EstimatedDays = &zero // Zero counts as missing
+	if coverage := core.CheckEstimateCoverage(plan); coverage.Estimated != 2 || !coverage.Low() {
+		t.Errorf("coverage = %d (low %v), want 2 and low", coverage.Estimated, coverage.Low())
+	}
+	if coverage := core.CheckEstimateCoverage(&core.ParseResponse{}); coverage.Low() {
+		t.Error("empty plan should not report low coverage")
+	}
+}
+
+func TestFillEstimatesPromptsOnlyMissingItems(t *testing.T) {
+	plan := estimatePlan()
+	prompt := core.BuildEstimatesPrompt(plan)
+	for _, want := range []string{
+		"subtask 1.1.2 [Indexes, estimate in minutes]: Add email index",
+		"task 1.2 [Login endpoint, estimate in hours]",
+		"epic 2 [Search, estimate in days]",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	for _, estimated := range []string{"Migration", "Users table,", "Accounts,"} {
+		if strings.Contains(prompt, estimated) {
+			t.Errorf("prompt should not list estimated item %q:\n%s", estimated, prompt)
+		}
+	}
+
+	reviewer := &cannedReviewer{output: `{"estimates": [
+		{"temp_id": "1.1.2", "value": 44.6},
+		{"temp_id": "1.2", "value": 3},
+		{"temp_id": "1.1", "value": 99},
+		{"temp_id": "2", "value": 0},
+		{"temp_id": "7", "value": 2}
+	]}`}
+	filled, err := core.FillEstimates(context.Background(), plan, reviewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filled != 2 {
+		t.Errorf("filled = %d, want 2", filled)
+	}
+	task := plan.Epics[0].Tasks[0]
+	if *task.Subtasks[1].EstimatedMinutes != 45 || *plan.Epics[0].Tasks[1].EstimatedHours != 3 {
+		t.Errorf("filled estimates = %d min, %v h", *task.Subtasks[1].EstimatedMinutes, *plan.Epics[0].Tasks[1].EstimatedHours)
+	}
+	if *task.EstimatedHours != 4 || plan.Epics[1].EstimatedDays != nil {
+		t.Errorf("existing or zero estimates changed: 1.1 = %v h, 2 = %v", *task.EstimatedHours, plan.Epics[1].EstimatedDays)
+	}
+}
+
+func TestCheckPRDShapeDetectsTaskLists(t *testing.T) {
+	prd := `# Recipe Sharing App
+
+## Overview
+Home cooks lose track of recipes spread across bookmarks, screenshots, and notebooks.
+This app gives them one place to save, organize, and share recipes with friends and family.
+
+## Goals
+- Save a recipe from any website in one tap
+- Share collections with other users
+
+## Users
+Our primary users cook at home several times a week and plan meals in advance.
+They care more about speed than about advanced features like nutrition tracking.
+`
+	tasks := `# TODO
+
+- [ ] Set up the repo
+- [ ] Add CI pipeline
+- [x] Create users table
+1. Build login endpoint
+2. Build signup form
+3) Write API docs
+
+` + "```" + `
+make test
+` + "```" + `
+`
+	if shape := core.CheckPRDShape(prd); shape.LooksLikeTaskList() {
+		t.Errorf("prose PRD flagged as a task list: %+v", shape)
+	}
+	shape := core.CheckPRDShape(tasks)
+	if !shape.LooksLikeTaskList() {
+		t.Errorf("task list not flagged: %+v", shape)
+	}
+	if shape.Lines != 6 || shape.ItemLines != 6 {
+		t.Errorf("lines, items = %d, %d; want 6, 6 (headings and code not counted)", shape.Lines, shape.ItemLines)
+	}
+
+	// A handful of items isn't enough to call it a task list
+	if shape := core.CheckPRDShape("- [ ] one\n- [ ] two\n- [ ] three\n"); shape.LooksLikeTaskList() {
+		t.Errorf("three-line list flagged as a task list: %+v", shape)
+	}
+}
+
+func TestCostByEpic(t *testing.T) {
+	plan := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "Setup", Tasks: []core.Task{{TempID: "1.1"}, {TempID: "1.2"}}},
+		{TempID: "2", Title: "Auth", Tasks: []core.Task{{TempID: "2.1"}}},
+	}}
+	costs := []core.CallCost{
+		{Stage: core.LevelEpic, USD: 0.50},
+		{Stage: core.LevelTask, ItemID: "1", USD: 0.20},
+		{Stage: core.LevelTask, ItemID: "2", USD: 0.30},
+		{Stage: core.LevelSubtask, ItemID: "1.1", USD: 0.05},
+		{Stage: core.LevelSubtask, ItemID: "1.2", USD: 0.05},
+		{Stage: core.LevelSubtask, ItemID: "1.2", USD: 0.05}, // Retry
+		{Stage: core.LevelSubtask, ItemID: "2.1", USD: 0.10},
+		{Stage: core.LevelSubtask, ItemID: "3.1", USD: 0.25}, // Task no longer in the plan
+	}
+
+	epics, unattributed := core.CostByEpic(plan, costs)
+	if len(epics) != 2 {
+		t.Fatalf("got %d epics, want 2", len(epics))
+	}
+	near := func(a, b float64) bool { return a-b < 1e-9 && b-a < 1e-9 }
+	setup, auth := epics[0], epics[1]
+	if setup.EpicID != "1" || setup.Calls != 4 || !near(setup.TasksUSD, 0.20) || !near(setup.SubtasksUSD, 0.15) {
+		t.Errorf("epic 1 = %+v, want 4 calls, $0.20 tasks, $0.15 subtasks", setup)
+	}
+	if auth.EpicID != "2" || auth.Calls != 2 || !near(auth.TotalUSD(), 0.40) {
+		t.Errorf("epic 2 = %+v, want 2 calls, $0.40 total", auth)
+	}
+	if !near(unattributed, 0.75) {
+		t.Errorf("unattributed = %v, want 0.75 (Stage 1 and the unknown task)", unattributed)
+	}
+}
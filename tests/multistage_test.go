@@ -0,0 +1,856 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dhabedank/prd-parser/internal/core"
+	"github.com/dhabedank/prd-parser/internal/llm"
+)
+
+// fakeGenerator is a core.Generator that returns canned responses and
+// records the arguments it was called with.
+type fakeGenerator struct {
+	mu sync.Mutex
+
+	epics *core.EpicsResponse
+
+	epicCalls    int
+	epicPRD      string            // prdContent of the last Stage 1 call
+	summaryCalls []string          // user prompts sent to Summarize
+	projectCalls []string          // user prompts sent to ExtractProject
+	taskPRD      map[string]string // epic temp_id -> prdContent of its Stage 2 call
+	taskCalls    []string          // epic temp_ids
+	subtaskCalls []string          // task temp_ids
+	epicContexts map[string]string
+	taskGuidance map[string]string // epic temp_id -> config.Guidance of the last call
+
+	tasks         map[string][]core.Task // epic temp_id -> canned Stage 2 tasks (default: one task)
+	failSubtasks  map[string]bool        // task temp_ids whose Stage 3 call fails
+	flakySubtasks map[string]bool        // task temp_ids whose Stage 3 attempts all fail, retried through config.RetryBudget
+	retries       int                    // retries taken from the budget
+}
+
+func newFakeGenerator(epics ...core.EpicSummary) *fakeGenerator {
+	return &fakeGenerator{
+		epics: &core.EpicsResponse{
+			Project: core.ProjectContext{ProductName: "Test Product"},
+			Epics:   epics,
+		},
+		epicContexts: make(map[string]string),
+		taskGuidance: make(map[string]string),
+		taskPRD:      make(map[string]string),
+	}
+}
+
+func (g *fakeGenerator) Summarize(ctx context.Context, userPrompt string, config core.ParseConfig) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.summaryCalls = append(g.summaryCalls, userPrompt)
+	return "SUMMARY: checkout and search", nil
+}
+
+func (g *fakeGenerator) ExtractProject(ctx context.Context, userPrompt string, config core.ParseConfig) (*core.ProjectContext, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.projectCalls = append(g.projectCalls, userPrompt)
+	project := g.epics.Project
+	return &project, nil
+}
+
+func (g *fakeGenerator) GenerateEpics(ctx context.Context, prdContent string, config core.ParseConfig) (*core.EpicsResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.epicCalls++
+	g.epicPRD = prdContent
+	return g.epics, nil
+}
+
+func (g *fakeGenerator) GenerateTasks(ctx context.Context, epic core.Epic, project core.ProjectContext, config core.ParseConfig, prdContent string) ([]core.Task, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.taskCalls = append(g.taskCalls, epic.TempID)
+	g.taskGuidance[epic.TempID] = config.Guidance
+	g.taskPRD[epic.TempID] = prdContent
+	if tasks, ok := g.tasks[epic.TempID]; ok {
+		return tasks, nil
+	}
+	return []core.Task{
+		{TempID: epic.TempID + ".1", Title: "Task for " + epic.Title},
+	}, nil
+}
+
+func (g *fakeGenerator) GenerateSubtasks(ctx context.Context, task core.Task, epicContext string, project core.ProjectContext, config core.ParseConfig, prdContent string) ([]core.Subtask, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.subtaskCalls = append(g.subtaskCalls, task.TempID)
+	g.epicContexts[task.TempID] = epicContext
+	if g.failSubtasks[task.TempID] {
+		return nil, fmt.Errorf("no valid JSON in Stage 3 response for task %s", task.TempID)
+	}
+	if g.flakySubtasks[task.TempID] {
+		// Like MultiStageGenerator: up to 2 retries, each taken from the shared budget
+		if config.RetryBudget.Exhausted() {
+			return nil, core.ErrRetryBudgetExhausted
+		}
+		for attempt := 1; attempt <= 2; attempt++ {
+			if !config.RetryBudget.Take() {
+				return nil, core.ErrRetryBudgetExhausted
+			}
+			g.retries++
+		}
+		return nil, fmt.Errorf("no valid JSON in Stage 3 response for task %s", task.TempID)
+	}
+	return []core.Subtask{
+		{TempID: task.TempID + ".1", Title: "Subtask for " + task.Title},
+	}, nil
+}
+
+func TestMultiStageObjectEpicContextReachesStage3(t *testing.T) {
+	gen := newFakeGenerator(core.EpicSummary{
+		TempID: "1",
+		Title:  "Auth",
+		Context: core.ContextObject(map[string]interface{}{
+			"business_context": "Users must trust the product",
+			"target_users":     "Busy parents",
+		}),
+	})
+
+	parser := core.NewMultiStageParser(gen, core.DefaultParseConfig())
+	if _, err := parser.Parse(context.Background(), "# PRD"); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	epicCtx := gen.epicContexts["1.1"]
+	if epicCtx == "" {
+		t.Fatal("object-form epic context should produce a non-empty epicCtx")
+	}
+
+	prompt := core.BuildStage3Prompt(core.Task{TempID: "1.1", Title: "Task"}, epicCtx, core.ProjectContext{}, core.DefaultParseConfig())
+	if !strings.Contains(prompt, "Users must trust the product") {
+		t.Error("Stage 3 prompt should contain business context from object-form epic context")
+	}
+	if !strings.Contains(prompt, "Busy parents") {
+		t.Error("Stage 3 prompt should contain target users from object-form epic context")
+	}
+}
+
+func TestContextToString(t *testing.T) {
+	tests := []struct {
+		name    string
+		context interface{}
+		want    []string
+	}{
+		{"nil", nil, nil},
+		{"string", "Plain context", []string{"Plain context"}},
+		{
+			"object",
+			map[string]interface{}{"business_context": "Why", "success_metrics": "How"},
+			[]string{"- **Business Context:** Why", "- **Success Metrics:** How"},
+		},
+		{
+			"object with list field",
+			map[string]interface{}{"target_users": []interface{}{"Parents", "Teachers"}},
+			[]string{"- **Target Users:** Parents; Teachers"},
+		},
+		{
+			"object with unknown keys",
+			map[string]interface{}{"brand_voice": "Warm", "region": "EU ", "seats": 5.0},
+			[]string{"- **Brand Voice:** Warm\n- region: EU\n- seats: 5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := core.ContextToString(tt.context)
+			if len(tt.want) == 0 && got != "" {
+				t.Errorf("ContextToString() = %q, want empty", got)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("ContextToString() = %q, want it to contain %q", got, w)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatContextBlock(t *testing.T) {
+	subtaskCtx := "Reuse the session store"
+	var noCtx *string
+	tests := []struct {
+		name    string
+		context interface{}
+		want    string
+	}{
+		{"nil", nil, ""},
+		{"nil string pointer", noCtx, ""},
+		{"blank string", "  ", ""},
+		{"string", "Plain context", "**Context:** Plain context"},
+		{"string pointer", &subtaskCtx, "**Context:** Reuse the session store"},
+		{
+			"object",
+			map[string]interface{}{"business_context": "Why", "success_metrics": []interface{}{"Fast", "Cheap"}},
+			"**Context:**\n- **Business Context:** Why\n- **Success Metrics:** Fast; Cheap",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := core.FormatContextBlock(tt.context); got != tt.want {
+				t.Errorf("FormatContextBlock() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlexibleContextUnmarshal(t *testing.T) {
+	why, users := "Why", "Parents; Teachers"
+	tests := []struct {
+		name     string
+		json     string
+		raw      string
+		text     string
+		isObject bool
+		block    *core.ContextBlock
+	}{
+		{name: "string", json: `"Plain context"`, raw: "Plain context", text: "Plain context"},
+		{
+			name:     "object",
+			json:     `{"business_context": "Why", "target_users": ["Parents", "Teachers"], "notes": "Not in the block"}`,
+			text:     "- **Business Context:** Why\n- **Target Users:** Parents; Teachers\n- notes: Not in the block",
+			isObject: true,
+			block:    &core.ContextBlock{BusinessContext: &why, TargetUsers: &users},
+		},
+		{name: "null", json: `null`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var task core.Task
+			if err := json.Unmarshal([]byte(`{"temp_id": "1.1", "context": `+tt.json+`}`), &task); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			c := task.Context
+			if c.Raw() != tt.raw || c.String() != tt.text || c.IsObject() != tt.isObject || c.IsZero() != (tt.text == "") {
+				t.Errorf("context = {raw %q, text %q, object %v, zero %v}, want {raw %q, text %q, object %v}",
+					c.Raw(), c.String(), c.IsObject(), c.IsZero(), tt.raw, tt.text, tt.isObject)
+			}
+			if !reflect.DeepEqual(c.Block(), tt.block) {
+				t.Errorf("Block() = %+v, want %+v", c.Block(), tt.block)
+			}
+
+			// Saved plans keep the shape the LLM returned
+			out, err := json.Marshal(c)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			var got, want interface{}
+			json.Unmarshal(out, &got)
+			json.Unmarshal([]byte(tt.json), &want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Marshal() = %s, want %s", out, tt.json)
+			}
+		})
+	}
+}
+
+func TestFormatTesting(t *testing.T) {
+	unit := core.FlexibleString("Hash round-trip")
+	e2e := core.FlexibleString("Login flow")
+	blank := core.FlexibleString("")
+
+	got := core.FormatTesting(&core.TestingRequirements{UnitTests: &unit, TypeTests: &blank, E2ETests: &e2e})
+	want := "**Testing Requirements:**\n- **Unit Tests:** Hash round-trip\n- **E2E Tests:** Login flow"
+	if got != want {
+		t.Errorf("FormatTesting() = %q, want %q", got, want)
+	}
+
+	if got := core.FormatTesting(&core.TestingRequirements{TypeTests: &blank}); got != "" {
+		t.Errorf("FormatTesting() with only empty fields = %q, want empty", got)
+	}
+	if got := core.FormatTesting(nil); got != "" {
+		t.Errorf("FormatTesting(nil) = %q, want empty", got)
+	}
+}
+
+func TestStagePromptsRenderObjectContext(t *testing.T) {
+	config := core.DefaultParseConfig()
+	partial := core.ContextObject(map[string]interface{}{
+		"target_users":    "Busy parents",
+		"success_metrics": []interface{}{"Signup under 1 minute", "NPS 40+"},
+	})
+	epic := core.Epic{TempID: "1", Title: "Onboarding", Context: partial}
+	task := core.Task{TempID: "1.1", Title: "Signup form", Context: partial}
+
+	prompts := map[string]string{
+		"stage 2":       core.BuildStage2Prompt(epic, core.ProjectContext{}, config),
+		"stage 2 (prd)": core.BuildStage2PromptWithPRD(epic, core.ProjectContext{}, config, "# PRD"),
+		"stage 3":       core.BuildStage3Prompt(task, "", core.ProjectContext{}, config),
+		"stage 3 (prd)": core.BuildStage3PromptWithPRD(task, "", core.ProjectContext{}, config, "# PRD"),
+	}
+	for name, prompt := range prompts {
+		if strings.Contains(prompt, "map[") {
+			t.Errorf("%s prompt dumps the context map: %q", name, prompt)
+		}
+		want := "- Context:\n  - **Target Users:** Busy parents\n  - **Success Metrics:** Signup under 1 minute; NPS 40+\n"
+		if !strings.Contains(prompt, want) {
+			t.Errorf("%s prompt should render the context as indented key/value lines, got %q", name, prompt)
+		}
+	}
+
+	// Missing context reads "none" rather than <nil>
+	prompt := core.BuildStage2Prompt(core.Epic{TempID: "2", Title: "Search"}, core.ProjectContext{}, config)
+	if strings.Contains(prompt, "<nil>") || !strings.Contains(prompt, "- Context: none\n") {
+		t.Errorf("nil context should render as none, got %q", prompt)
+	}
+}
+
+func TestPromptVersionsProduceDistinctPrompts(t *testing.T) {
+	epic := core.Epic{TempID: "1", Title: "Onboarding", Context: core.ContextObject(map[string]interface{}{"target_users": "Busy parents"})}
+	task := core.Task{TempID: "1.1", Title: "Signup form"}
+
+	want := map[string]struct{ epicContext, taskContext string }{
+		core.PromptV1: {"- Context: map[target_users:Busy parents]\n", "- Context: <nil>\n"},
+		core.PromptV2: {"- Context: \n  - **Target Users:** Busy parents\n", "- Context: none\n"},
+		core.PromptV3: {"- Context:\n  - **Target Users:** Busy parents\n", "- Context: none\n"},
+	}
+	seen := make(map[string]string)
+	for _, version := range core.PromptVersions {
+		config := core.DefaultParseConfig()
+		config.PromptVersion = version
+		stage2 := core.BuildStage2Prompt(epic, core.ProjectContext{}, config)
+		stage3 := core.BuildStage3PromptWithPRD(task, "", core.ProjectContext{}, config, "# PRD")
+
+		w, ok := want[version]
+		if !ok {
+			t.Fatalf("no expected prompt text for version %s", version)
+		}
+		if !strings.Contains(stage2, w.epicContext) {
+			t.Errorf("%s Stage 2 prompt should contain %q, got %q", version, w.epicContext, stage2)
+		}
+		if !strings.Contains(stage3, w.taskContext) {
+			t.Errorf("%s Stage 3 prompt should contain %q, got %q", version, w.taskContext, stage3)
+		}
+		if other, dup := seen[stage2]; dup {
+			t.Errorf("versions %s and %s produce the same Stage 2 prompt", other, version)
+		}
+		seen[stage2] = version
+	}
+
+	// The default is the current version
+	if got := seen[core.BuildStage2Prompt(epic, core.ProjectContext{}, core.DefaultParseConfig())]; got != core.CurrentPromptVersion {
+		t.Errorf("default prompt is version %q, want %s", got, core.CurrentPromptVersion)
+	}
+}
+
+func TestMultiStageScanOnlyGeneratesEpics(t *testing.T) {
+	days := 3.0
+	gen := newFakeGenerator(
+		core.EpicSummary{TempID: "1", Title: "Foundation", EstimatedDays: &days},
+		core.EpicSummary{TempID: "2", Title: "Feature", DependsOn: []string{"1"}},
+	)
+
+	parser := core.NewMultiStageParser(gen, core.DefaultParseConfig())
+	resp, err := parser.Scan(context.Background(), "# PRD")
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	if len(resp.Epics) != 2 {
+		t.Errorf("Scan() returned %d epics, want 2", len(resp.Epics))
+	}
+	if gen.epicCalls != 1 {
+		t.Errorf("GenerateEpics called %d times, want 1", gen.epicCalls)
+	}
+	if len(gen.taskCalls) != 0 || len(gen.subtaskCalls) != 0 {
+		t.Errorf("Scan() should not generate tasks or subtasks, got %d task calls and %d subtask calls",
+			len(gen.taskCalls), len(gen.subtaskCalls))
+	}
+}
+
+func TestExtractProjectContextSkipsEpics(t *testing.T) {
+	gen := newFakeGenerator(core.EpicSummary{TempID: "1", Title: "Foundation"})
+	gen.epics.Project.TechStack = core.FlexibleStringSlice{"Go", "Postgres"}
+
+	project, err := core.ExtractProjectContext(context.Background(), gen, "# Checkout PRD", core.DefaultParseConfig())
+	if err != nil {
+		t.Fatalf("ExtractProjectContext() error = %v", err)
+	}
+	if project.ProductName != "Test Product" || len(project.TechStack) != 2 {
+		t.Errorf("project = %+v, want the extracted context", project)
+	}
+	if len(gen.projectCalls) != 1 || !strings.Contains(gen.projectCalls[0], "# Checkout PRD") {
+		t.Errorf("ExtractProject calls = %q, want one with the PRD", gen.projectCalls)
+	}
+	if gen.epicCalls != 0 || len(gen.taskCalls) != 0 || len(gen.subtaskCalls) != 0 {
+		t.Errorf("only project extraction should run, got %d epic, %d task, %d subtask calls",
+			gen.epicCalls, len(gen.taskCalls), len(gen.subtaskCalls))
+	}
+}
+
+func TestEstimateRemaining(t *testing.T) {
+	tests := []struct {
+		name              string
+		avgTask           time.Duration
+		avgSubtask        time.Duration
+		remainingTasks    int
+		remainingSubtasks int
+		want              time.Duration
+	}{
+		{"nothing left", 30 * time.Second, 10 * time.Second, 0, 0, 0},
+		// 4 task calls at parallelism 3 = 2 batches; 12 subtask calls at parallelism 5 = 3 batches
+		{"tasks and subtasks", 30 * time.Second, 10 * time.Second, 4, 12, 90 * time.Second},
+		{"subtasks only", 30 * time.Second, 20 * time.Second, 0, 5, 20 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := core.EstimateRemaining(tt.avgTask, tt.avgSubtask, tt.remainingTasks, tt.remainingSubtasks)
+			if got != tt.want {
+				t.Errorf("EstimateRemaining() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatRemaining(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{45 * time.Second, "~45s remaining"},
+		{2*time.Minute + 10*time.Second, "~2m remaining"},
+		{65 * time.Minute, "~1h5m remaining"},
+	}
+
+	for _, tt := range tests {
+		if got := core.FormatRemaining(tt.d); got != tt.want {
+			t.Errorf("FormatRemaining(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestInteractiveRegeneratesSingleEpicTasks(t *testing.T) {
+	gen := newFakeGenerator(
+		core.EpicSummary{TempID: "1", Title: "Foundation"},
+		core.EpicSummary{TempID: "2", Title: "Sync"},
+	)
+
+	parser := core.NewInteractiveParser(gen, core.DefaultParseConfig())
+	// Accept epics, regenerate epic 2's tasks with guidance, then accept tasks
+	parser.SetInput(strings.NewReader("\nr 2\nFocus on Offline Mode\n\n"))
+
+	if _, err := parser.Parse(context.Background(), "# PRD"); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	calls := map[string]int{}
+	for _, id := range gen.taskCalls {
+		calls[id]++
+	}
+	if calls["1"] != 1 {
+		t.Errorf("epic 1 tasks generated %d times, want 1", calls["1"])
+	}
+	if calls["2"] != 2 {
+		t.Errorf("epic 2 tasks generated %d times, want 2 (initial + regenerate)", calls["2"])
+	}
+	if got := gen.taskGuidance["2"]; got != "Focus on Offline Mode" {
+		t.Errorf("regeneration guidance = %q, want %q", got, "Focus on Offline Mode")
+	}
+	if got := gen.taskGuidance["1"]; got != "" {
+		t.Errorf("epic 1 guidance = %q, want none", got)
+	}
+}
+
+func TestStage2PromptIncludesGuidance(t *testing.T) {
+	config := core.DefaultParseConfig()
+	config.Guidance = "Split the API work by endpoint"
+
+	prompt := core.BuildStage2Prompt(core.Epic{TempID: "1", Title: "API"}, core.ProjectContext{}, config)
+	if !strings.Contains(prompt, "Split the API work by endpoint") {
+		t.Error("Stage 2 prompt should include reviewer guidance")
+	}
+}
+
+func TestInstructionsAppearInEveryPrompt(t *testing.T) {
+	config := core.DefaultParseConfig()
+	epic := core.Epic{TempID: "1", Title: "API"}
+	task := core.Task{TempID: "1.1", Title: "Auth endpoint"}
+	project := core.ProjectContext{}
+
+	prompts := func(config core.ParseConfig) map[string]string {
+		return map[string]string{
+			"single-shot":   core.BuildUserPrompt("# PRD", config),
+			"stage 1":       core.BuildStage1Prompt("# PRD", config),
+			"stage 2":       core.BuildStage2Prompt(epic, project, config),
+			"stage 2 (prd)": core.BuildStage2PromptWithPRD(epic, project, config, "# PRD"),
+			"stage 3":       core.BuildStage3Prompt(task, "API", project, config),
+			"stage 3 (prd)": core.BuildStage3PromptWithPRD(task, "API", project, config, "# PRD"),
+		}
+	}
+
+	for name, prompt := range prompts(config) {
+		if strings.Contains(prompt, "ADDITIONAL INSTRUCTIONS") {
+			t.Errorf("%s prompt should not include an instructions section by default", name)
+		}
+	}
+
+	config.Instructions = "  We use pnpm, not npm  "
+	for name, prompt := range prompts(config) {
+		if !strings.Contains(prompt, "ADDITIONAL INSTRUCTIONS") || !strings.Contains(prompt, "We use pnpm, not npm\n---") {
+			t.Errorf("%s prompt should end with the delimited instructions, got tail %q", name, prompt[len(prompt)-80:])
+		}
+	}
+}
+
+func TestGlossaryAppearsInEverySystemPrompt(t *testing.T) {
+	config := core.DefaultParseConfig()
+	systems := map[string]string{
+		"single-shot": core.SystemPrompt,
+		"stage 1":     core.Stage1SystemPrompt,
+		"stage 2":     core.Stage2SystemPrompt,
+		"stage 3":     core.Stage3SystemPrompt,
+	}
+
+	for name, system := range systems {
+		if strings.Contains(core.SystemPromptFor(system, config), "GLOSSARY") {
+			t.Errorf("%s system prompt should not include a glossary by default", name)
+		}
+	}
+
+	config.Glossary = "**Herd**: our voice-first CRM, never a livestock feature\n"
+	for _, noTesting := range []bool{false, true} {
+		config.NoTesting = noTesting
+		for name, system := range systems {
+			prompt := core.SystemPromptFor(system, config)
+			if !strings.Contains(prompt, "GLOSSARY") || !strings.Contains(prompt, "never a livestock feature\n---") {
+				t.Errorf("%s system prompt (no-testing=%v) should end with the glossary, got tail %q", name, noTesting, prompt[len(prompt)-80:])
+			}
+		}
+	}
+}
+
+func TestRetryBudgetSharedAcrossRun(t *testing.T) {
+	for _, ordered := range []bool{false, true} {
+		gen := newFakeGenerator(
+			core.EpicSummary{TempID: "1", Title: "Auth"},
+			core.EpicSummary{TempID: "2", Title: "Search"},
+			core.EpicSummary{TempID: "3", Title: "Billing"},
+		)
+		gen.flakySubtasks = map[string]bool{"1.1": true, "2.1": true}
+
+		config := core.DefaultParseConfig()
+		config.OrderedSubtasks = ordered
+		config.Warnings = core.NewWarningCollector()
+		config.RetryBudget = core.NewRetryBudget(3)
+
+		_, err := core.NewMultiStageParser(gen, config).Parse(context.Background(), "# PRD")
+		var budgetErr *core.RetryBudgetError
+		if !errors.As(err, &budgetErr) {
+			t.Fatalf("ordered=%v: Parse() error = %v, want a RetryBudgetError", ordered, err)
+		}
+		// Each flaky task wants 2 retries; together they exceed the shared 3
+		if gen.retries != 3 || budgetErr.Retries != 3 {
+			t.Errorf("ordered=%v: retries = %d (reported %d), want 3", ordered, gen.retries, budgetErr.Retries)
+		}
+
+		subtasks := make(map[string]int)
+		for _, epic := range budgetErr.Partial.Epics {
+			for _, task := range epic.Tasks {
+				subtasks[task.TempID] = len(task.Subtasks)
+			}
+		}
+		if len(budgetErr.Partial.Epics) != 3 || subtasks["3.1"] != 1 || subtasks["1.1"] != 0 || subtasks["2.1"] != 0 {
+			t.Errorf("ordered=%v: partial plan subtasks = %v, want 3.1 kept and the flaky tasks empty", ordered, subtasks)
+		}
+	}
+}
+
+// taskRateLimitedGenerator rate-limits every Stage 2 call for the epics in limited.
+type taskRateLimitedGenerator struct {
+	*fakeGenerator
+	limited map[string]bool
+}
+
+func (g *taskRateLimitedGenerator) GenerateTasks(ctx context.Context, epic core.Epic, project core.ProjectContext, config core.ParseConfig, prdContent string) ([]core.Task, error) {
+	if g.limited[epic.TempID] {
+		return nil, &llm.Error{Kind: llm.ErrorRateLimit, Message: "429 Too Many Requests"}
+	}
+	return g.fakeGenerator.GenerateTasks(ctx, epic, project, config, prdContent)
+}
+
+func TestRetryBudgetExhaustedInStage2(t *testing.T) {
+	gen := &taskRateLimitedGenerator{
+		fakeGenerator: newFakeGenerator(core.EpicSummary{TempID: "1", Title: "Auth"}, core.EpicSummary{TempID: "2", Title: "Search"}),
+		limited:       map[string]bool{"2": true},
+	}
+	config := core.DefaultParseConfig()
+	config.Warnings = core.NewWarningCollector()
+	config.RetryBudget = core.NewRetryBudget(1)
+
+	_, err := core.NewMultiStageParser(gen, config).Parse(context.Background(), "# PRD")
+	var budgetErr *core.RetryBudgetError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("Parse() error = %v, want a RetryBudgetError", err)
+	}
+	if len(gen.subtaskCalls) != 0 {
+		t.Errorf("Stage 3 ran after the budget ran out: %v", gen.subtaskCalls)
+	}
+
+	// Both epics are kept, the rate-limited one without tasks, for --fill-gaps
+	epics := budgetErr.Partial.Epics
+	if len(epics) != 2 || epics[0].TempID != "1" || len(epics[0].Tasks) != 1 || epics[1].TempID != "2" || len(epics[1].Tasks) != 0 {
+		t.Errorf("partial plan = %+v, want epic 1 with its task and epic 2 empty", epics)
+	}
+	if got := core.EpicsMissingTasks(budgetErr.Partial); strings.Join(got, ",") != "2" {
+		t.Errorf("EpicsMissingTasks() = %v, want [2]", got)
+	}
+}
+
+func TestFillTaskGapsGeneratesTasksForEmptyEpics(t *testing.T) {
+	gen := newFakeGenerator()
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "Auth", Tasks: []core.Task{{TempID: "1.1", Title: "Login"}}},
+		{TempID: "2", Title: "Search"},
+	}}
+	config := core.DefaultParseConfig()
+	config.Warnings = core.NewWarningCollector()
+
+	filled := core.FillTaskGaps(context.Background(), gen, response, config, "")
+	if strings.Join(filled, ",") != "2" || strings.Join(gen.taskCalls, ",") != "2" {
+		t.Errorf("filled = %v, Stage 2 calls = %v; want only epic 2", filled, gen.taskCalls)
+	}
+	if got := core.TasksMissingSubtasks(response); strings.Join(got, ",") != "1.1,2.1" {
+		t.Errorf("TasksMissingSubtasks() = %v, want the new task 2.1 queued for Stage 3", got)
+	}
+}
+
+func TestOrderedSubtasksSkipDependentsOfFailedTask(t *testing.T) {
+	gen := newFakeGenerator(core.EpicSummary{TempID: "1", Title: "Auth"})
+	gen.tasks = map[string][]core.Task{"1": {
+		{TempID: "1.1", Title: "Schema"},
+		{TempID: "1.2", Title: "API", DependsOn: []string{"1.1"}},
+		{TempID: "1.3", Title: "UI", DependsOn: []string{"1.2"}},
+		{TempID: "1.4", Title: "Docs"},
+	}}
+	gen.failSubtasks = map[string]bool{"1.1": true}
+
+	config := core.DefaultParseConfig()
+	config.OrderedSubtasks = true
+	config.Warnings = core.NewWarningCollector()
+
+	response, err := core.NewMultiStageParser(gen, config).Parse(context.Background(), "# PRD")
+	if err != nil {
+		t.Fatalf("Parse() error = %v (ordered mode should not fail the run)", err)
+	}
+
+	called := make(map[string]bool)
+	for _, id := range gen.subtaskCalls {
+		called[id] = true
+	}
+	if called["1.2"] || called["1.3"] {
+		t.Errorf("dependents of failed task 1.1 should be skipped, Stage 3 calls = %v", gen.subtaskCalls)
+	}
+	if !called["1.4"] {
+		t.Errorf("independent task 1.4 should still get subtasks, Stage 3 calls = %v", gen.subtaskCalls)
+	}
+
+	tasks := response.Epics[0].Tasks
+	for i, want := range []int{0, 0, 0, 1} {
+		if got := len(tasks[i].Subtasks); got != want {
+			t.Errorf("task %s has %d subtasks, want %d", tasks[i].TempID, got, want)
+		}
+	}
+
+	codes := make(map[string][]string)
+	for _, w := range config.Warnings.Warnings() {
+		codes[w.Code] = append(codes[w.Code], w.Item)
+	}
+	if got := codes[core.WarnSubtasksFailed]; len(got) != 1 || got[0] != "1.1" {
+		t.Errorf("subtasks_failed warnings = %v, want [1.1]", got)
+	}
+	if got := codes[core.WarnSubtasksSkipped]; len(got) != 2 {
+		t.Errorf("subtasks_skipped warnings = %v, want 1.2 and 1.3", got)
+	}
+}
+
+func TestUnorderedSubtasksFailOnAnyTask(t *testing.T) {
+	gen := newFakeGenerator(core.EpicSummary{TempID: "1", Title: "Auth"})
+	gen.failSubtasks = map[string]bool{"1.1": true}
+
+	if _, err := core.NewMultiStageParser(gen, core.DefaultParseConfig()).Parse(context.Background(), "# PRD"); err == nil {
+		t.Fatal("Parse() should fail Stage 3 without OrderedSubtasks")
+	}
+}
+
+func TestFillSubtaskGapsRegeneratesOnlyEmptyTasks(t *testing.T) {
+	gen := newFakeGenerator()
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Test Product"},
+		Epics: []core.Epic{{
+			TempID: "1",
+			Title:  "Auth",
+			Tasks: []core.Task{
+				{TempID: "1.1", Title: "Login", Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "Existing"}}},
+				{TempID: "1.2", Title: "Logout"},
+			},
+		}},
+	}
+
+	filled := core.FillSubtaskGaps(context.Background(), gen, response, core.ParseConfig{}, "")
+
+	if len(gen.subtaskCalls) != 1 || gen.subtaskCalls[0] != "1.2" {
+		t.Fatalf("subtask calls = %v, want only [1.2]", gen.subtaskCalls)
+	}
+	if len(filled) != 1 || filled[0] != "1.2" {
+		t.Errorf("filled = %v, want [1.2]", filled)
+	}
+	tasks := response.Epics[0].Tasks
+	if len(tasks[0].Subtasks) != 1 || tasks[0].Subtasks[0].Title != "Existing" {
+		t.Errorf("task 1.1 subtasks changed: %+v", tasks[0].Subtasks)
+	}
+	if len(tasks[1].Subtasks) != 1 || tasks[1].Subtasks[0].TempID != "1.2.1" {
+		t.Errorf("task 1.2 subtasks = %+v, want the regenerated subtask", tasks[1].Subtasks)
+	}
+	if missing := core.TasksMissingSubtasks(response); len(missing) != 0 {
+		t.Errorf("still missing subtasks: %v", missing)
+	}
+}
+
+// rateLimitedGenerator rate-limits the first `limited` Stage 3 calls, holding
+// them until all of them are in flight, and records how many calls were in
+// flight as each one started.
+type rateLimitedGenerator struct {
+	*fakeGenerator
+
+	limited  int
+	burst    chan struct{} // closed once the rate-limited calls are all in flight
+	started  int
+	inFlight int
+	atStart  []int // calls in flight when each call started, in start order
+}
+
+func (g *rateLimitedGenerator) GenerateSubtasks(ctx context.Context, task core.Task, epicContext string, project core.ProjectContext, config core.ParseConfig, prdContent string) ([]core.Subtask, error) {
+	g.mu.Lock()
+	call := g.started
+	g.started++
+	g.inFlight++
+	g.atStart = append(g.atStart, g.inFlight)
+	if g.started == g.limited {
+		close(g.burst)
+	}
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		g.inFlight--
+		g.mu.Unlock()
+	}()
+	if call < g.limited {
+		<-g.burst
+		return nil, &llm.Error{Kind: llm.ErrorRateLimit, Message: "429 Too Many Requests"}
+	}
+	time.Sleep(time.Millisecond)
+	return []core.Subtask{{TempID: task.TempID + ".1", Title: "Subtask for " + task.Title}}, nil
+}
+
+func TestStage3ConcurrencyBacksOffOnRateLimits(t *testing.T) {
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Test Product"},
+		Epics:   []core.Epic{{TempID: "1", Title: "Auth"}},
+	}
+	for i := 1; i <= 20; i++ {
+		response.Epics[0].Tasks = append(response.Epics[0].Tasks, core.Task{TempID: fmt.Sprintf("1.%d", i), Title: "Task"})
+	}
+	// Stage 3 runs 5 calls at a time; all of the first 5 are rate-limited
+	gen := &rateLimitedGenerator{fakeGenerator: newFakeGenerator(), limited: 5, burst: make(chan struct{})}
+	config := core.ParseConfig{Warnings: core.NewWarningCollector()}
+
+	filled := core.FillSubtaskGaps(context.Background(), gen, response, config, "")
+	if len(filled) != 20 {
+		t.Fatalf("filled %d tasks, want all 20 with the rate-limited calls retried", len(filled))
+	}
+	if gen.started != 25 {
+		t.Errorf("%d calls, want 25 (20 tasks and 5 retries)", gen.started)
+	}
+	if got := gen.atStart[4]; got != 5 {
+		t.Fatalf("%d calls in flight at the 5th start, want 5", got)
+	}
+
+	// The burst halved the limit down to 1, so the next call runs alone, and
+	// after its success the limit is 2
+	if after := gen.atStart[5:8]; after[0] != 1 || after[1] != 1 || after[2] > 2 {
+		t.Errorf("calls in flight after the rate limits = %v, want [1 1 <=2]", after)
+	}
+}
+
+func TestMultiStageSummarizeFeedsStage1(t *testing.T) {
+	gen := newFakeGenerator(core.EpicSummary{TempID: "1", Title: "Checkout flow"})
+	config := core.DefaultParseConfig()
+	config.FullContext = true
+	config.Summarize = true
+
+	prd := "# Shop\n\nIntro.\n\n## Checkout\n\nPay with card.\n\n## Search\n\nFind products.\n"
+	if _, err := core.NewMultiStageParser(gen, config).Parse(context.Background(), prd); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(gen.summaryCalls) != 1 || !strings.Contains(gen.summaryCalls[0], "Pay with card.") {
+		t.Fatalf("summary calls = %q, want one call with the PRD", gen.summaryCalls)
+	}
+	if gen.epicPRD != "SUMMARY: checkout and search" {
+		t.Errorf("Stage 1 got %q, want the summary", gen.epicPRD)
+	}
+
+	// Stage 2 gets the matching section, not the whole PRD
+	taskPRD := gen.taskPRD["1"]
+	if !strings.Contains(taskPRD, "Pay with card.") || strings.Contains(taskPRD, "Find products.") {
+		t.Errorf("Stage 2 PRD = %q, want only the Checkout section", taskPRD)
+	}
+}
+
+func TestGenerateAllSubtasksLeavesEpicsAndTasksUntouched(t *testing.T) {
+	gen := newFakeGenerator()
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Test Product"},
+		Epics: []core.Epic{
+			{TempID: "1", Title: "Auth", Description: "Hand-written", Tasks: []core.Task{
+				{TempID: "1.1", Title: "Login", Description: "Email login", Labels: []string{"api"}},
+				{TempID: "1.2", Title: "Logout", DependsOn: []string{"1.1"}},
+			}},
+			{TempID: "2", Title: "Billing", DependsOn: []string{"1"}, Tasks: []core.Task{
+				{TempID: "2.1", Title: "Invoices"},
+			}},
+		},
+	}
+	before, _ := json.Marshal(response)
+
+	filled := core.GenerateAllSubtasks(context.Background(), gen, response, core.ParseConfig{}, "")
+
+	if strings.Join(filled, ",") != "1.1,1.2,2.1" {
+		t.Errorf("filled = %v, want every task in plan order", filled)
+	}
+	for _, epic := range response.Epics {
+		for _, task := range epic.Tasks {
+			if len(task.Subtasks) != 1 || task.Subtasks[0].TempID != task.TempID+".1" {
+				t.Errorf("task %s subtasks = %+v, want the generated subtask", task.TempID, task.Subtasks)
+			}
+		}
+	}
+
+	// Dropping the new subtasks gives back exactly the hand-authored plan
+	for ei := range response.Epics {
+		for ti := range response.Epics[ei].Tasks {
+			response.Epics[ei].Tasks[ti].Subtasks = nil
+		}
+	}
+	if after, _ := json.Marshal(response); string(after) != string(before) {
+		t.Errorf("epics or tasks changed:\nbefore %s\nafter  %s", before, after)
+	}
+}
@@ -1,8 +1,16 @@
 package tests
 
 import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/dhabedank/prd-parser/internal/core"
 	"github.com/dhabedank/prd-parser/internal/output"
 )
 
@@ -105,3 +113,569 @@ func TestBeadsAdapterWithConfig(t *testing.T) {
 		t.Errorf("Name() = %s, want beads", adapter.Name())
 	}
 }
+
+func TestRenderPlanDocIncludesExternalIDs(t *testing.T) {
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Test Product"},
+		Epics: []core.Epic{
+			{
+				TempID: "1",
+				Title:  "Foundation",
+				Tasks: []core.Task{
+					{
+						TempID:   "1.1",
+						Title:    "Init project",
+						Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "Run init"}},
+					},
+				},
+			},
+		},
+	}
+	result := &output.CreateResult{
+		Created: []output.CreatedItem{
+			{ExternalID: "proj-e1", TempID: "1", Type: "epic"},
+			{ExternalID: "proj-e1t1", TempID: "1.1", Type: "task"},
+			{ExternalID: "proj-e1t1s1", TempID: "1.1.1", Type: "subtask"},
+		},
+	}
+
+	doc := output.RenderPlanDoc(response, result, "beads")
+
+	for _, id := range []string{"proj-e1", "proj-e1t1", "proj-e1t1s1"} {
+		if !strings.Contains(doc, "`"+id+"`") {
+			t.Errorf("plan doc should contain external ID %s, got:\n%s", id, doc)
+		}
+	}
+	if !strings.Contains(doc, "# Test Product") {
+		t.Error("plan doc should be titled with the product name")
+	}
+}
+
+func TestMarkdownAdapterRendersOutline(t *testing.T) {
+	hours, minutes := 4.0, 30
+	unit := core.FlexibleString("Repo layout check")
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Shop", ElevatorPitch: "Sell things online.", TechStack: core.FlexibleStringSlice{"Go", "Postgres"}},
+		Epics: []core.Epic{
+			{TempID: "1", Title: "Foundation", Context: core.ContextText("Everything builds on this"), AcceptanceCriteria: []string{"Repo builds"}, Tasks: []core.Task{
+				{
+					TempID: "1.1", Title: "Init repo", Description: "Create the module", Priority: core.PriorityHigh,
+					EstimatedHours: &hours, Labels: []string{"setup"}, DependsOn: []string{"1.2"},
+					Context: core.ContextText("Needed by every task"), Testing: core.TestingRequirements{UnitTests: &unit},
+					Subtasks: []core.Subtask{
+						{TempID: "1.1.1", Title: "Run go mod init", EstimatedMinutes: &minutes, DependsOn: []string{"1.1.2"}},
+						{TempID: "1.1.2", Title: "Add .gitignore"},
+					},
+				},
+				{TempID: "1.2", Title: "Pick license"},
+			}},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "plan.md")
+	config := output.Config{IncludeContext: true, IncludeContextTasks: true, IncludeTesting: true}
+	adapter := output.NewMarkdownAdapter(config, path)
+	result, err := adapter.CreateItems(response, config)
+	if err != nil {
+		t.Fatalf("CreateItems() error = %v", err)
+	}
+	if result.Stats.Epics != 1 || result.Stats.Tasks != 2 || result.Stats.Subtasks != 2 {
+		t.Errorf("Stats = %+v, want 1 epic, 2 tasks, 2 subtasks", result.Stats)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := string(data)
+
+	for _, want := range []string{
+		"# Shop\n\nSell things online.",
+		"**Tech Stack:** Go, Postgres",
+		"## Epic 1: Foundation",
+		"**Acceptance Criteria:**\n- Repo builds",
+		"### Task 1.1: Init repo\n\n**Priority:** high · **Estimate:** 4h · **Labels:** `setup` · **Depends on:** 1.2",
+		"Create the module\n\n**Context:** Needed by every task\n\n**Testing Requirements:**\n- **Unit Tests:** Repo layout check",
+		"- [ ] **1.1.1** Run go mod init\n\n  **Estimate:** 30 min · **Depends on:** 1.1.2\n\n- [ ] **1.1.2** Add .gitignore\n",
+		"### Task 1.2: Pick license",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("outline missing %q:\n%s", want, doc)
+		}
+	}
+	// Context is only on the levels --context-levels picked
+	if strings.Contains(doc, "Everything builds on this") {
+		t.Errorf("epic context should be left out with only task context enabled:\n%s", doc)
+	}
+
+	adapter = output.NewMarkdownAdapter(output.Config{}, "")
+	if doc := adapter.Render(response); strings.Contains(doc, "**Context:**") || strings.Contains(doc, "Testing Requirements") {
+		t.Errorf("context and testing should be left out when disabled:\n%s", doc)
+	}
+}
+
+func TestCSVAdapterFlattensPlan(t *testing.T) {
+	days, hours, minutes := 1.5, 2.5, 45
+	response := &core.ParseResponse{Epics: []core.Epic{
+		{TempID: "1", Title: "Foundation", EstimatedDays: &days, Tasks: []core.Task{
+			{
+				TempID: "1.1", Title: "Init repo, module", Priority: core.PriorityCritical, EstimatedHours: &hours,
+				Labels: []string{"setup", "backend"}, DependsOn: []string{"1.2", "2"},
+				Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "Run go mod init", EstimatedMinutes: &minutes}},
+			},
+			{TempID: "1.2", Title: "Pick license"},
+		}},
+	}}
+
+	path := filepath.Join(t.TempDir(), "plan.csv")
+	config := output.Config{EpicPriority: core.PriorityMedium}
+	result, err := output.NewCSVAdapter(config, path).CreateItems(response, config)
+	if err != nil {
+		t.Fatalf("CreateItems() error = %v", err)
+	}
+	if result.Stats.Epics != 1 || result.Stats.Tasks != 2 || result.Stats.Subtasks != 1 {
+		t.Errorf("Stats = %+v, want 1 epic, 2 tasks, 1 subtask", result.Stats)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `temp_id,type,title,parent_temp_id,priority,estimate_minutes,labels,depends_on
+1,epic,Foundation,,medium,720,,
+1.1,task,"Init repo, module",1,critical,150,setup;backend,1.2;2
+1.1.1,subtask,Run go mod init,1.1,medium,45,,
+1.2,task,Pick license,1,,,,
+`
+	if string(data) != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", data, want)
+	}
+
+	tsv, err := output.NewCSVAdapter(output.Config{}, "plan.tsv").Render(response)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if line := strings.Split(tsv, "\n")[1]; line != "1\tepic\tFoundation\t\thigh\t720\t\t" {
+		t.Errorf("TSV epic row = %q, want tab-separated with the default high priority", line)
+	}
+}
+
+func TestRenderPlanDocIncludesSourceHints(t *testing.T) {
+	epicHint := "## Authentication"
+	taskHint := "\"users sign in with email\""
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Test Product"},
+		Epics: []core.Epic{{
+			TempID:     "1",
+			Title:      "Auth",
+			SourceHint: &epicHint,
+			Tasks: []core.Task{
+				{TempID: "1.1", Title: "Email login", SourceHint: &taskHint},
+				{TempID: "1.2", Title: "Logout"},
+			},
+		}},
+	}
+
+	doc := output.RenderPlanDoc(response, nil, "")
+
+	if !strings.Contains(doc, "Source: ## Authentication") {
+		t.Errorf("plan doc should include the epic source hint, got:\n%s", doc)
+	}
+	if !strings.Contains(doc, "Email login _(source: \"users sign in with email\")_") {
+		t.Errorf("plan doc should include the task source hint, got:\n%s", doc)
+	}
+	if strings.Contains(doc, "Logout _(source") {
+		t.Error("plan doc should omit the source for tasks without a hint")
+	}
+}
+
+func TestJSONAdapterWritesGzip(t *testing.T) {
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Archive"},
+		Epics:   []core.Epic{{TempID: "1", Title: "Foundation", Tasks: []core.Task{{TempID: "1.1", Title: "Setup"}}}},
+	}
+
+	tests := []struct {
+		name   string
+		file   string
+		config output.Config
+	}{
+		{"gz extension", "plan.json.gz", output.Config{}},
+		{"gzip flag", "plan.json", output.Config{Gzip: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			adapter := output.NewJSONAdapter(tt.config, path)
+			if _, err := adapter.CreateItems(response, tt.config); err != nil {
+				t.Fatalf("CreateItems() error = %v", err)
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			zr, err := gzip.NewReader(f)
+			if err != nil {
+				t.Fatalf("output is not gzip: %v", err)
+			}
+			data, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatalf("failed to decompress: %v", err)
+			}
+
+			var got core.ParseResponse
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("decompressed output is not JSON: %v", err)
+			}
+			if got.Project.ProductName != "Archive" || len(got.Epics) != 1 || got.Epics[0].Tasks[0].Title != "Setup" {
+				t.Errorf("decompressed plan = %+v, want the original response", got)
+			}
+		})
+	}
+}
+
+func TestJSONAdapterTeeWritesFileAndStdout(t *testing.T) {
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Tee"},
+		Epics:   []core.Epic{{TempID: "1", Title: "Foundation", Tasks: []core.Task{{TempID: "1.1", Title: "Setup"}}}},
+	}
+	config := output.Config{Tee: true}
+	path := filepath.Join(t.TempDir(), "plan.json")
+	adapter := output.NewJSONAdapter(config, path)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	_, createErr := adapter.CreateItems(response, config)
+	os.Stdout = stdout
+	w.Close()
+	echoed, _ := io.ReadAll(r)
+	if createErr != nil {
+		t.Fatalf("CreateItems() error = %v", createErr)
+	}
+
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !json.Valid(written) {
+		t.Fatalf("file is not JSON: %s", written)
+	}
+	if got := strings.TrimSuffix(string(echoed), "\n"); got != string(written) {
+		t.Errorf("stdout and file differ:\nstdout: %s\nfile:   %s", got, written)
+	}
+}
+
+func TestJSONAdapterTeeWithGzipEchoesReadableJSON(t *testing.T) {
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Tee"},
+		Epics:   []core.Epic{{TempID: "1", Title: "Foundation"}},
+	}
+	config := output.Config{Tee: true}
+	path := filepath.Join(t.TempDir(), "plan.json.gz")
+	adapter := output.NewJSONAdapter(config, path)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	_, createErr := adapter.CreateItems(response, config)
+	os.Stdout = stdout
+	w.Close()
+	echoed, _ := io.ReadAll(r)
+	if createErr != nil {
+		t.Fatalf("CreateItems() error = %v", createErr)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("file is not gzip: %v", err)
+	}
+	written, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("failed to decompress: %v", err)
+	}
+	if got := strings.TrimSuffix(string(echoed), "\n"); got != string(written) {
+		t.Errorf("stdout should be the decompressed file:\nstdout: %s\nfile:   %s", got, written)
+	}
+}
+
+// flatAdapter is a JSON adapter that claims no dependency or label support.
+type flatAdapter struct {
+	*output.JSONAdapter
+}
+
+func (a flatAdapter) Name() string { return "csv" }
+
+func (a flatAdapter) Capabilities() output.Capabilities {
+	return output.Capabilities{Hierarchy: true, Estimates: true}
+}
+
+func TestCapabilityGaps(t *testing.T) {
+	response := &core.ParseResponse{
+		Epics: []core.Epic{
+			{TempID: "1", Title: "Auth", Tasks: []core.Task{
+				{TempID: "1.1", Title: "Login"},
+				{TempID: "1.2", Title: "Logout", DependsOn: []string{"1.1"}},
+			}},
+		},
+	}
+
+	limited := flatAdapter{output.NewJSONAdapter(output.DefaultConfig(), "")}
+	gaps := output.CapabilityGaps(limited, response)
+	if len(gaps) != 1 || gaps[0] != "csv adapter flattens dependencies" {
+		t.Errorf("CapabilityGaps() = %v, want only the dependency gap (no labels in plan)", gaps)
+	}
+
+	full := output.NewJSONAdapter(output.DefaultConfig(), "")
+	if gaps := output.CapabilityGaps(full, response); len(gaps) != 0 {
+		t.Errorf("CapabilityGaps() = %v for json adapter, want none", gaps)
+	}
+}
+
+func TestJSONAdapterKeyCase(t *testing.T) {
+	minutes := 30
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Interop"},
+		Epics: []core.Epic{{TempID: "1", Title: "Foundation", Tasks: []core.Task{{TempID: "1.1", Title: "Setup", Subtasks: []core.Subtask{
+			{TempID: "1.1.1", Title: "Init repo", EstimatedMinutes: &minutes, DependsOn: []string{}},
+		}}}}},
+	}
+
+	tests := []struct {
+		keyCase string
+		want    []string
+		notWant []string
+	}{
+		{output.JSONCaseSnake, []string{`"temp_id"`, `"estimated_minutes"`, `"depends_on"`, `"product_name"`}, []string{`"tempId"`}},
+		{output.JSONCaseCamel, []string{`"tempId"`, `"estimatedMinutes"`, `"dependsOn"`, `"productName"`}, []string{`"temp_id"`, `"depends_on"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.keyCase, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "plan.json")
+			config := output.Config{JSONCase: tt.keyCase}
+			if _, err := output.NewJSONAdapter(config, path).CreateItems(response, config); err != nil {
+				t.Fatalf("CreateItems() error = %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range tt.want {
+				if !strings.Contains(string(data), key) {
+					t.Errorf("output missing key %s", key)
+				}
+			}
+			for _, key := range tt.notWant {
+				if strings.Contains(string(data), key) {
+					t.Errorf("output has unexpected key %s", key)
+				}
+			}
+			// Values are untouched and the output is still valid JSON
+			var decoded map[string]interface{}
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("output is not JSON: %v", err)
+			}
+			if !strings.Contains(string(data), `"1.1.1"`) || !strings.Contains(string(data), "30") {
+				t.Errorf("values changed:\n%s", data)
+			}
+		})
+	}
+
+	// snake output is byte-for-byte the struct tag encoding
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if _, err := output.NewJSONAdapter(output.Config{}, path).CreateItems(response, output.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(path)
+	want, _ := json.MarshalIndent(response, "", "  ")
+	if string(got) != string(want) {
+		t.Errorf("default output differs from json.MarshalIndent:\n%s", got)
+	}
+}
+
+func TestJSONAdapterDependenciesFormats(t *testing.T) {
+	response := &core.ParseResponse{Epics: []core.Epic{{TempID: "1", Title: "Auth", Tasks: []core.Task{
+		{TempID: "1.1", Title: "Users table", Subtasks: []core.Subtask{{TempID: "1.1.1", Title: "Migration"}}},
+		{TempID: "1.2", Title: "Login", DependsOn: []string{"1.1"}},
+	}}}}
+
+	// write writes the plan with format, decodes its dependencies section into v, and returns the top-level fields
+	write := func(t *testing.T, format string, v interface{}) map[string]json.RawMessage {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "plan.json")
+		config := output.Config{DepsFormat: format}
+		if _, err := output.NewJSONAdapter(config, path).CreateItems(response, config); err != nil {
+			t.Fatalf("CreateItems() error = %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var plan map[string]json.RawMessage
+		if err := json.Unmarshal(data, &plan); err != nil {
+			t.Fatalf("output is not JSON: %v", err)
+		}
+		if _, ok := plan["epics"]; !ok {
+			t.Errorf("%s output lost the plan itself", format)
+		}
+		if v != nil {
+			if err := json.Unmarshal(plan["dependencies"], v); err != nil {
+				t.Fatalf("%s dependencies: %v\n%s", format, err, plan["dependencies"])
+			}
+		}
+		return plan
+	}
+
+	if plan := write(t, "", nil); plan["dependencies"] != nil {
+		t.Errorf("default output should have no dependencies section, got %s", plan["dependencies"])
+	}
+
+	var list []struct{ From, To, Type string }
+	write(t, output.DepsFormatList, &list)
+	wantList := []struct{ From, To, Type string }{
+		{"epic-1", "task-1.1", "parent-child"},
+		{"task-1.1", "subtask-1.1.1", "parent-child"},
+		{"epic-1", "task-1.2", "parent-child"},
+		{"task-1.1", "task-1.2", "blocks"},
+	}
+	if !reflect.DeepEqual(list, wantList) {
+		t.Errorf("list = %+v, want %+v", list, wantList)
+	}
+
+	var adjacency map[string]map[string][]string
+	write(t, output.DepsFormatAdjacency, &adjacency)
+	wantAdjacency := map[string]map[string][]string{
+		"blocks":       {"task-1.1": {"task-1.2"}},
+		"parent-child": {"epic-1": {"task-1.1", "task-1.2"}, "task-1.1": {"subtask-1.1.1"}},
+	}
+	if !reflect.DeepEqual(adjacency, wantAdjacency) {
+		t.Errorf("adjacency = %v, want %v", adjacency, wantAdjacency)
+	}
+
+	type node struct {
+		ID       string
+		Blocks   []string
+		Children []node
+	}
+	var nested []node
+	write(t, output.DepsFormatNested, &nested)
+	wantNested := []node{{ID: "epic-1", Children: []node{
+		{ID: "task-1.1", Blocks: []string{"task-1.2"}, Children: []node{{ID: "subtask-1.1.1"}}},
+		{ID: "task-1.2"},
+	}}}
+	if !reflect.DeepEqual(nested, wantNested) {
+		t.Errorf("nested = %+v, want %+v", nested, wantNested)
+	}
+}
+
+func TestTraceabilityMatrix(t *testing.T) {
+	response := &core.ParseResponse{
+		Project: core.ProjectContext{ProductName: "Shop"},
+		Epics: []core.Epic{{TempID: "1", Title: "Accounts", RequirementID: "FR-1", Tasks: []core.Task{
+			{TempID: "1.1", Title: "Signup | login", RequirementID: "FR-1, FR-2", Subtasks: []core.Subtask{
+				{TempID: "1.1.1", Title: "Form", RequirementID: "FR-9"},
+				{TempID: "1.1.2", Title: "Styles"},
+			}},
+		}}},
+	}
+
+	rows := output.BuildTraceabilityMatrix(response, []string{"FR-1", "FR-2", "FR-3"})
+	type row struct {
+		req, status string
+		items       []string
+	}
+	want := []row{
+		{"FR-1", output.CoverageCovered, []string{"1", "1.1"}},
+		{"FR-2", output.CoverageCovered, []string{"1.1"}},
+		{"FR-3", output.CoverageUncovered, nil},
+		{"FR-9", output.CoverageNotInPRD, []string{"1.1.1"}},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("rows = %+v, want %d rows", rows, len(want))
+	}
+	for i, w := range want {
+		var items []string
+		for _, item := range rows[i].Items {
+			items = append(items, item.TempID)
+		}
+		if rows[i].Requirement != w.req || rows[i].Status != w.status || strings.Join(items, ",") != strings.Join(w.items, ",") {
+			t.Errorf("row %d = %s %s %v, want %s %s %v", i, rows[i].Requirement, rows[i].Status, items, w.req, w.status, w.items)
+		}
+	}
+
+	md := output.RenderTraceabilityMarkdown("Shop", rows)
+	for _, want := range []string{
+		"| FR-1 | covered | epic 1: Accounts<br>task 1.1: Signup \\| login |",
+		"| FR-3 | **uncovered** |  |",
+		"2 of 3 requirements covered. Uncovered: FR-3",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown missing %q:\n%s", want, md)
+		}
+	}
+
+	csvOutput, err := output.RenderTraceabilityCSV(rows)
+	if err != nil {
+		t.Fatalf("RenderTraceabilityCSV() error = %v", err)
+	}
+	for _, want := range []string{"requirement,status,level,temp_id,title\n", "FR-1,covered,task,1.1,Signup | login\n", "FR-3,uncovered,,,\n"} {
+		if !strings.Contains(csvOutput, want) {
+			t.Errorf("CSV missing %q:\n%s", want, csvOutput)
+		}
+	}
+
+	// Uncovered requirements are reported as warnings when the matrix is written
+	warnings := core.NewWarningCollector()
+	path := filepath.Join(t.TempDir(), "trace.csv")
+	adapter := output.NewTraceabilityAdapter(output.Config{}, path)
+	if _, err := adapter.CreateItems(response, output.Config{Requirements: []string{"FR-1", "FR-3"}, Warnings: warnings}); err != nil {
+		t.Fatalf("CreateItems() error = %v", err)
+	}
+	if got := warnings.Warnings(); len(got) != 1 || got[0].Code != core.WarnUncoveredRequirement || got[0].Item != "FR-3" {
+		t.Errorf("warnings = %v, want one uncovered_requirement for FR-3", got)
+	}
+	if data, err := os.ReadFile(path); err != nil || !strings.HasPrefix(string(data), "requirement,status") {
+		t.Errorf("CSV file not written: %q, %v", data, err)
+	}
+}
+
+func TestLabelColorsByCategory(t *testing.T) {
+	colors := output.NewLabelColors(nil)
+	tests := map[string]string{
+		"frontend":   output.CategoryColors[core.LabelCategoryLayer],
+		"Payments":   output.CategoryColors[core.LabelCategoryDomain],
+		"typescript": output.CategoryColors[core.LabelCategorySkill],
+		"docs":       output.CategoryColors[core.LabelCategoryType],
+		"mobile":     output.DefaultLabelColor,
+	}
+	for label, want := range tests {
+		if got := colors.Color(label); got != want {
+			t.Errorf("Color(%q) = %q, want %q", label, got, want)
+		}
+	}
+
+	colors = output.NewLabelColors(map[string]string{"domain": "#d93f0b", "payments": "b60205"})
+	if got := colors.Color("search"); got != "d93f0b" {
+		t.Errorf("category override: Color(search) = %q, want d93f0b", got)
+	}
+	if got := colors.Color("payments"); got != "b60205" {
+		t.Errorf("label override: Color(payments) = %q, want b60205", got)
+	}
+
+	if err := output.ValidateLabelColors(map[string]string{"layer": "blue"}); err == nil {
+		t.Error("ValidateLabelColors() accepted a non-hex color")
+	}
+}